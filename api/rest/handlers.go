@@ -47,6 +47,7 @@ type Handler struct {
 	logger          *logger.Logger
 	analysisService *services.AnalysisService
 	reviewService   *services.ReviewService
+	driftService    *services.DriftService
 	web3Handler     *Web3Handler
 	nationValidator *web3.NationNFTValidator
 }
@@ -57,6 +58,7 @@ func NewHandler(cfg *config.Config, log *logger.Logger) *Handler {
 	tfAnalyzer := analyzer.NewTerraformAnalyzer()
 	checkovAnalyzer := analyzer.NewCheckovAnalyzer(log)
 	iamAnalyzer := analyzer.NewIAMAnalyzer(log)
+	iamAnalyzer.SetTrustedAccounts(cfg.Analysis.TrustedAccounts)
 	prScorer := scorer.NewPRScorer()
 	costOptimizer := suggester.NewCostOptimizer(log)
 	securityAdvisor := suggester.NewSecurityAdvisor(log)
@@ -82,6 +84,7 @@ func NewHandler(cfg *config.Config, log *logger.Logger) *Handler {
 		logger:          log,
 		analysisService: analysisService,
 		reviewService:   services.NewReviewService(analysisService, log),
+		driftService:    services.NewDriftService(tfAnalyzer, analyzer.NewStateAnalyzer(log), log),
 		nationValidator: nationValidator,
 		// web3Handler será configurado com SetupWeb3Handler
 	}
@@ -102,6 +105,8 @@ func (h *Handler) SetupRoutes() *mux.Router {
 
 	// Analysis endpoints
 	r.HandleFunc("/analyze", h.HandleAnalyze).Methods("POST")
+	r.HandleFunc("/analyze/drift", h.HandleDrift).Methods("POST")
+	r.HandleFunc("/analyze/plan", h.HandlePlan).Methods("POST")
 
 	// Review endpoints
 	r.HandleFunc("/review", h.HandleReview).Methods("POST")
@@ -658,6 +663,8 @@ func (h *Handler) HandleRoot(w http.ResponseWriter, r *http.Request) {
 		"endpoints": map[string]string{
 			"health":  "GET /health",
 			"analyze": "POST /analyze",
+			"drift":   "POST /analyze/drift",
+			"plan":    "POST /analyze/plan",
 			"review":  "POST /review",
 		},
 	})
@@ -693,7 +700,7 @@ func (h *Handler) HandleAnalyze(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Executa análise
-	response, err := h.analysisService.Analyze(&req)
+	response, err := h.analysisService.AnalyzeContext(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("Erro ao executar análise", "error", err)
 		h.respondError(w, "Analysis failed: "+err.Error(), http.StatusInternalServerError)
@@ -709,6 +716,101 @@ func (h *Handler) HandleAnalyze(w http.ResponseWriter, r *http.Request) {
 		"suggestions", len(response.Suggestions))
 }
 
+// HandleDrift processa requisição de detecção de drift
+// @Summary Detectar drift entre configuração e state
+// @Description Compara a configuração Terraform com um terraform.tfstate (v4) ou com a saída de terraform show -json
+// @Tags analysis
+// @Accept json
+// @Produce json
+// @Param request body models.DriftRequest true "Requisição de detecção de drift"
+// @Success 200 {object} models.DriftResponse "Resultado da detecção de drift"
+// @Failure 400 {object} models.ErrorResponse "Requisição inválida"
+// @Failure 500 {object} models.ErrorResponse "Erro interno do servidor"
+// @Router /analyze/drift [post]
+func (h *Handler) HandleDrift(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Requisição de detecção de drift recebida")
+
+	// Parse request
+	var req models.DriftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Erro ao fazer parse da requisição", "error", err)
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Validações básicas
+	if req.Content == "" && req.Path == "" {
+		h.respondError(w, "Either 'content' or 'path' must be provided", http.StatusBadRequest)
+		return
+	}
+	if req.State == "" && req.Path == "" {
+		h.respondError(w, "Either 'state' or 'path' must be provided", http.StatusBadRequest)
+		return
+	}
+
+	// Executa detecção
+	response, err := h.driftService.DetectDrift(&req)
+	if err != nil {
+		h.logger.Error("Erro ao detectar drift", "error", err)
+		h.respondError(w, "Drift detection failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Retorna resultado
+	h.respondJSON(w, http.StatusOK, response)
+
+	h.logger.Info("Detecção de drift concluída",
+		"id", response.ID,
+		"has_drift", response.Drift.HasDrift)
+}
+
+// HandlePlan processa requisição de análise de terraform plan
+// @Summary Analisar terraform plan
+// @Description Analisa a saída de terraform show -json (ou um plan binário com o diretório da configuração), avalia a política de plan e, opcionalmente, a revisão do LLM
+// @Tags analysis
+// @Accept json
+// @Produce json
+// @Param request body models.PlanAnalysisRequest true "Requisição de análise de plan"
+// @Success 200 {object} models.PreviewAnalysisResponse "Resultado da análise do plan"
+// @Failure 400 {object} models.ErrorResponse "Requisição inválida"
+// @Failure 500 {object} models.ErrorResponse "Erro interno do servidor"
+// @Router /analyze/plan [post]
+func (h *Handler) HandlePlan(w http.ResponseWriter, r *http.Request) {
+	h.logger.Info("Requisição de análise de plan recebida")
+
+	// Parse request
+	var req models.PlanAnalysisRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.logger.Error("Erro ao fazer parse da requisição", "error", err)
+		h.respondError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+
+	// Validações básicas
+	if len(req.Plan) == 0 && req.PlanPath == "" {
+		h.respondError(w, "Either 'plan' or 'plan_path' must be provided", http.StatusBadRequest)
+		return
+	}
+
+	// Executa análise
+	response, err := h.analysisService.AnalyzePlan(r.Context(), &req)
+	if err != nil {
+		h.logger.Error("Erro ao analisar plan", "error", err)
+		h.respondError(w, "Plan analysis failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Retorna resultado
+	h.respondJSON(w, http.StatusOK, response)
+
+	h.logger.Info("Análise de plan concluída",
+		"id", response.ID,
+		"risk_level", response.PreviewDetails.RiskLevel,
+		"recommendation", response.ExecutiveSummary.Recommendation)
+}
+
 // HandleReview processa requisição de review
 // @Summary Review de Pull Request
 // @Description Executa uma análise completa de um Pull Request do GitHub
@@ -747,7 +849,7 @@ func (h *Handler) HandleReview(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Executa review
-	response, err := h.reviewService.ReviewPR(&req)
+	response, err := h.reviewService.ReviewPRWithContext(r.Context(), &req)
 	if err != nil {
 		h.logger.Error("Erro ao executar review", "error", err)
 		h.respondError(w, "Review failed: "+err.Error(), http.StatusInternalServerError)
@@ -0,0 +1,12 @@
+# METADATA
+# title: Acesso administrativo via IAM
+# description: Políticas IAM não devem conceder Action e Resource "*"
+# custom:
+#   id: ORG_IAM_ADMIN
+#   severity: HIGH
+#   remediation: Conceda apenas as ações e recursos necessários (least privilege)
+package iac.iam_admin
+
+warn contains "Política IAM concede acesso administrativo (Action e Resource \"*\")" if {
+	input.iam.admin_access_detected
+}
@@ -0,0 +1,28 @@
+# METADATA
+# title: Recursos com tags obrigatórias
+# description: Recursos devem ter as tags Owner e Environment
+# related_resources:
+#   - ref: https://docs.aws.amazon.com/whitepapers/latest/tagging-best-practices/tagging-best-practices.html
+# custom:
+#   id: ORG_REQUIRED_TAGS
+#   severity: MEDIUM
+#   remediation: Adicione as tags Owner e Environment ao recurso
+package iac.required_tags
+
+# Entrada: AnalysisDetails serializado (input.terraform, input.security, input.iam)
+
+required_tags := {"Owner", "Environment"}
+
+tagged_types := {"aws_instance", "aws_s3_bucket", "aws_db_instance", "azurerm_storage_account"}
+
+deny contains result if {
+	some resource in input.terraform.resources
+	resource.type in tagged_types
+	tags := {key | some key, _ in object.get(resource, "tags", {})}
+	missing := required_tags - tags
+	count(missing) > 0
+	result := {
+		"msg": sprintf("%s sem as tags obrigatórias: %s", [resource.address, concat(", ", sort(missing))]),
+		"resource": resource.address,
+	}
+}
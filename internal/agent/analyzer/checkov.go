@@ -1,17 +1,24 @@
 package analyzer
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/govinda777/iac-ai-agent/internal/models"
 	"github.com/govinda777/iac-ai-agent/pkg/logger"
 )
 
+// defaultCheckovTimeout é o tempo máximo de execução do Checkov quando a
+// configuração não define um
+const defaultCheckovTimeout = 5 * time.Minute
+
 // CheckovAnalyzer executa análise de segurança usando Checkov
 type CheckovAnalyzer struct {
 	checkovPath string
@@ -34,6 +41,13 @@ func (ca *CheckovAnalyzer) IsAvailable() bool {
 
 // AnalyzeDirectory executa Checkov em um diretório
 func (ca *CheckovAnalyzer) AnalyzeDirectory(dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
+	return ca.AnalyzeDirectoryContext(context.Background(), dir, config)
+}
+
+// AnalyzeDirectoryContext executa Checkov em um diretório. A execução é
+// interrompida quando o contexto é cancelado ou o timeout da configuração
+// (padrão de 5 minutos) expira.
+func (ca *CheckovAnalyzer) AnalyzeDirectoryContext(ctx context.Context, dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
 	if !ca.IsAvailable() {
 		return nil, fmt.Errorf("checkov não está instalado ou não foi encontrado no PATH")
 	}
@@ -46,6 +60,7 @@ func (ca *CheckovAnalyzer) AnalyzeDirectory(dir string, config *models.CheckovCo
 		"--compact",
 	}
 
+	timeout := defaultCheckovTimeout
 	if config != nil {
 		if config.Framework != "" {
 			args = append(args, "--framework", config.Framework)
@@ -56,38 +71,69 @@ func (ca *CheckovAnalyzer) AnalyzeDirectory(dir string, config *models.CheckovCo
 		if len(config.Checks) > 0 {
 			args = append(args, "--check", strings.Join(config.Checks, ","))
 		}
+		if config.ExternalChecksDir != "" {
+			args = append(args, "--external-checks-dir", config.ExternalChecksDir)
+		}
+		if config.TimeoutSeconds > 0 {
+			timeout = time.Duration(config.TimeoutSeconds) * time.Second
+		}
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
 	// Executa Checkov
-	ca.logger.Info("Executando Checkov", "directory", dir)
-	cmd := exec.Command(ca.checkovPath, args...)
+	ca.logger.Info("Executando Checkov", "directory", dir, "timeout", timeout)
+	cmd := exec.CommandContext(ctx, ca.checkovPath, args...)
+	cmd.WaitDelay = 5 * time.Second // não espera subprocessos que herdaram o stdout
 	output, err := cmd.Output()
 
-	// Checkov retorna exit code != 0 quando há falhas, mas isso é esperado
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		if ctxErr == context.DeadlineExceeded {
+			return nil, fmt.Errorf("checkov excedeu o tempo limite de %s", timeout)
+		}
+		return nil, fmt.Errorf("execução do checkov cancelada: %w", ctxErr)
+	}
+
+	// Checkov retorna exit code != 0 quando há falhas, mas isso é esperado:
+	// o resultado continua no stdout
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			// Se há stderr, algo deu errado
-			if len(exitErr.Stderr) > 0 {
-				return nil, fmt.Errorf("erro ao executar checkov: %s", string(exitErr.Stderr))
-			}
-			// Caso contrário, provavelmente há apenas falhas de check
-			output = exitErr.Stderr
-		} else {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
 			return nil, fmt.Errorf("erro ao executar checkov: %w", err)
 		}
+		if len(strings.TrimSpace(string(output))) == 0 {
+			return nil, fmt.Errorf("erro ao executar checkov: %s", string(exitErr.Stderr))
+		}
 	}
 
 	// Parse resultado
-	var result models.CheckovResult
-	if err := json.Unmarshal(output, &result); err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do resultado checkov: %w", err)
+	result, err := parseCheckovOutput(output)
+	if err != nil {
+		return nil, err
 	}
 
-	return ca.convertToSecurityAnalysis(&result), nil
+	return ca.convertToSecurityAnalysis(result), nil
 }
 
 // AnalyzeFiles executa Checkov em arquivos específicos
 func (ca *CheckovAnalyzer) AnalyzeFiles(files []string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
+	contents := make(map[string]string, len(files))
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		contents[file] = string(content)
+	}
+
+	return ca.AnalyzeContent(context.Background(), contents, config)
+}
+
+// AnalyzeContent executa Checkov sobre conteúdo em memória (nome do arquivo
+// -> conteúdo), gravado em um workspace temporário. Os findings apontam para
+// os nomes de arquivo originais.
+func (ca *CheckovAnalyzer) AnalyzeContent(ctx context.Context, files map[string]string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
 	if !ca.IsAvailable() {
 		return nil, fmt.Errorf("checkov não está instalado")
 	}
@@ -99,20 +145,116 @@ func (ca *CheckovAnalyzer) AnalyzeFiles(files []string, config *models.CheckovCo
 	}
 	defer os.RemoveAll(tmpDir)
 
-	// Copia arquivos para o diretório temporário
-	for _, file := range files {
-		content, err := os.ReadFile(file)
-		if err != nil {
+	// Grava os arquivos no workspace, guardando o nome original de cada um
+	originals := make(map[string]string, len(files))
+	for file, name := range workspaceNames(files) {
+		tmpFile := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(tmpFile), 0755); err != nil {
 			continue
 		}
+		if err := os.WriteFile(tmpFile, []byte(files[file]), 0644); err != nil {
+			continue
+		}
+		originals[filepath.ToSlash(name)] = file
+	}
 
-		tmpFile := filepath.Join(tmpDir, filepath.Base(file))
-		if err := os.WriteFile(tmpFile, content, 0644); err != nil {
+	analysis, err := ca.AnalyzeDirectoryContext(ctx, tmpDir, config)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range analysis.Findings {
+		name := filepath.ToSlash(analysis.Findings[i].File)
+		name = strings.TrimPrefix(strings.TrimPrefix(name, filepath.ToSlash(tmpDir)), "/")
+		if original, ok := originals[name]; ok {
+			analysis.Findings[i].File = original
+		}
+	}
+	return analysis, nil
+}
+
+// workspaceNames retorna o caminho relativo com que cada arquivo é gravado
+// no workspace temporário. Os diretórios abaixo do diretório comum a todos
+// os arquivos são mantidos, de forma que a/main.tf e b/main.tf não colidam.
+func workspaceNames(files map[string]string) map[string]string {
+	paths := make([]string, 0, len(files))
+	for file := range files {
+		paths = append(paths, file)
+	}
+	sort.Strings(paths)
+
+	common := ""
+	for i, file := range paths {
+		dir := filepath.Dir(filepath.Clean(file))
+		if i == 0 {
+			common = dir
 			continue
 		}
+		for !isSubPath(common, dir) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+
+	names := make(map[string]string, len(files))
+	used := make(map[string]bool, len(files))
+	for _, file := range paths {
+		clean := filepath.Clean(file)
+		name, err := filepath.Rel(common, clean)
+		if err != nil || !isSubPath(common, clean) {
+			name = filepath.Base(clean)
+		}
+		if used[name] {
+			// Caminhos que não compartilham um diretório comum (ex.: relativos
+			// e absolutos misturados) recebem um diretório próprio
+			name = filepath.Join(fmt.Sprintf("file-%d", len(used)), filepath.Base(clean))
+		}
+		used[name] = true
+		names[file] = name
+	}
+	return names
+}
+
+// isSubPath indica se path está dentro de dir
+func isSubPath(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// parseCheckovOutput faz o parse da saída JSON do Checkov. Com mais de um
+// framework a saída é uma lista de resultados, que são combinados.
+func parseCheckovOutput(output []byte) (*models.CheckovResult, error) {
+	trimmed := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(trimmed, "[") {
+		var result models.CheckovResult
+		if err := json.Unmarshal(output, &result); err != nil {
+			return nil, fmt.Errorf("erro ao fazer parse do resultado checkov: %w", err)
+		}
+		return &result, nil
 	}
 
-	return ca.AnalyzeDirectory(tmpDir, config)
+	var results []models.CheckovResult
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do resultado checkov: %w", err)
+	}
+
+	merged := &models.CheckovResult{}
+	for _, result := range results {
+		merged.Summary.Passed += result.Summary.Passed
+		merged.Summary.Failed += result.Summary.Failed
+		merged.Summary.Skipped += result.Summary.Skipped
+		merged.Summary.ParsingErrors += result.Summary.ParsingErrors
+		merged.Summary.ResourceCount += result.Summary.ResourceCount
+		merged.Summary.CheckovVersion = result.Summary.CheckovVersion
+		merged.Results.PassedChecks = append(merged.Results.PassedChecks, result.Results.PassedChecks...)
+		merged.Results.FailedChecks = append(merged.Results.FailedChecks, result.Results.FailedChecks...)
+		merged.Results.SkippedChecks = append(merged.Results.SkippedChecks, result.Results.SkippedChecks...)
+		merged.ExecutionTime += result.ExecutionTime
+	}
+	return merged, nil
 }
 
 // convertToSecurityAnalysis converte resultado Checkov para SecurityAnalysis
@@ -135,6 +277,7 @@ func (ca *CheckovAnalyzer) convertToSecurityAnalysis(result *models.CheckovResul
 			File:        check.File,
 			Description: check.Description,
 			Guideline:   check.Guideline,
+			FixedCode:   check.FixedCode,
 		}
 
 		if len(check.FileLineRange) > 0 {
@@ -206,6 +349,10 @@ func (ca *CheckovAnalyzer) GetRecommendations(analysis *models.SecurityAnalysis)
 			Line:           finding.Line,
 			ReferenceLink:  ca.getBridgecrewLink(finding.CheckID),
 		}
+		if finding.FixedCode != "" {
+			suggestion.AutoFixAvailable = true
+			suggestion.Metadata = map[string]interface{}{"fixed_code": finding.FixedCode}
+		}
 
 		suggestions = append(suggestions, suggestion)
 	}
@@ -230,19 +377,19 @@ func (ca *CheckovAnalyzer) GetSecurityAnalysis(result *models.CheckovResult) *mo
 func (ca *CheckovAnalyzer) ValidateAndParseResult(jsonResult []byte) (*models.SecurityAnalysis, error) {
 	ca.logger.Info("Validando resultado Checkov pré-existente")
 
-	// Parse do JSON
-	var result models.CheckovResult
-	if err := json.Unmarshal(jsonResult, &result); err != nil {
-		return nil, fmt.Errorf("erro ao fazer parse do resultado checkov: %w", err)
+	// Parse do JSON (resultado único ou lista por framework)
+	result, err := parseCheckovOutput(jsonResult)
+	if err != nil {
+		return nil, err
 	}
 
 	// Valida estrutura básica
-	if err := ca.validateCheckovResult(&result); err != nil {
+	if err := ca.validateCheckovResult(result); err != nil {
 		return nil, fmt.Errorf("resultado checkov inválido: %w", err)
 	}
 
 	// Converte para SecurityAnalysis
-	analysis := ca.convertToSecurityAnalysis(&result)
+	analysis := ca.convertToSecurityAnalysis(result)
 
 	ca.logger.Info("Resultado Checkov validado com sucesso",
 		"passed", analysis.ChecksPassed,
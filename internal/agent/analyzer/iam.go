@@ -1,7 +1,6 @@
 package analyzer
 
 import (
-	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -11,7 +10,8 @@ import (
 
 // IAMAnalyzer analisa políticas e configurações IAM
 type IAMAnalyzer struct {
-	logger *logger.Logger
+	logger          *logger.Logger
+	trustedAccounts []string
 }
 
 // NewIAMAnalyzer cria uma nova instância do analisador IAM
@@ -21,32 +21,131 @@ func NewIAMAnalyzer(log *logger.Logger) *IAMAnalyzer {
 	}
 }
 
-// AnalyzeTerraform analisa recursos IAM no código Terraform
+// SetTrustedAccounts define as contas AWS da organização. Principais
+// dessas contas em trust policies não são tratados como acesso externo.
+func (ia *IAMAnalyzer) SetTrustedAccounts(accounts []string) {
+	ia.trustedAccounts = nil
+	for _, account := range accounts {
+		if account = strings.TrimSpace(account); account != "" {
+			ia.trustedAccounts = append(ia.trustedAccounts, account)
+		}
+	}
+}
+
+// AnalyzeTerraform analisa recursos IAM no código Terraform. Em análises
+// com vários stacks cada stack é analisado separadamente, já que políticas,
+// anexos e roles só se relacionam dentro do mesmo módulo raiz, e os
+// endereços do resultado são qualificados com o stack (ver StackAddress).
 func (ia *IAMAnalyzer) AnalyzeTerraform(tfAnalysis *models.TerraformAnalysis) (*models.IAMAnalysis, error) {
-	analysis := &models.IAMAnalysis{
+	analysis := newIAMAnalysis()
+
+	stacks := []string{}
+	resourcesByStack := make(map[string][]models.TerraformResource)
+	for _, resource := range tfAnalysis.Resources {
+		if _, seen := resourcesByStack[resource.Stack]; !seen {
+			stacks = append(stacks, resource.Stack)
+		}
+		resourcesByStack[resource.Stack] = append(resourcesByStack[resource.Stack], resource)
+	}
+
+	if len(stacks) <= 1 {
+		ia.analyzeResources(tfAnalysis.Resources, analysis)
+	} else {
+		for _, stack := range stacks {
+			stackAnalysis := newIAMAnalysis()
+			ia.analyzeResources(resourcesByStack[stack], stackAnalysis)
+			mergeIAMAnalysis(analysis, stackAnalysis, stack, resourcesByStack[stack])
+		}
+	}
+
+	// Gera recomendações gerais
+	ia.generateRecommendations(analysis)
+
+	return analysis, nil
+}
+
+// newIAMAnalysis cria uma análise IAM vazia
+func newIAMAnalysis() *models.IAMAnalysis {
+	return &models.IAMAnalysis{
 		WildcardActions: []string{},
 		PublicAccess:    []string{},
 		Recommendations: []string{},
 		PrincipalRisks:  []models.PrincipalRisk{},
 	}
+}
+
+// mergeIAMAnalysis adiciona a análise de um stack à análise agregada,
+// qualificando com o stack os endereços de recursos do próprio stack
+func mergeIAMAnalysis(merged, stackAnalysis *models.IAMAnalysis, stack string, resources []models.TerraformResource) {
+	addresses := make(map[string]bool)
+	for _, resource := range resources {
+		addresses[resourceAddress(resource)] = true
+	}
+	qualify := func(address string) string {
+		if addresses[address] || addresses[stripIndexes(address)] {
+			return StackAddress(stack, address)
+		}
+		return address
+	}
+
+	merged.TotalPolicies += stackAnalysis.TotalPolicies
+	merged.TotalRoles += stackAnalysis.TotalRoles
+	merged.OverlyPermissive = merged.OverlyPermissive || stackAnalysis.OverlyPermissive
+	merged.AdminAccessDetected = merged.AdminAccessDetected || stackAnalysis.AdminAccessDetected
+
+	for _, action := range stackAnalysis.WildcardActions {
+		merged.WildcardActions = append(merged.WildcardActions, fmt.Sprintf("[%s] %s", stack, action))
+	}
+	for _, access := range stackAnalysis.PublicAccess {
+		merged.PublicAccess = append(merged.PublicAccess, fmt.Sprintf("[%s] %s", stack, access))
+	}
+
+	for _, risk := range stackAnalysis.PrincipalRisks {
+		if risk.Resource != "" {
+			risk.Resource = StackAddress(stack, risk.Resource)
+		}
+		risk.Principal = qualify(risk.Principal)
+		merged.PrincipalRisks = append(merged.PrincipalRisks, risk)
+	}
+
+	for _, entry := range stackAnalysis.EffectivePermissions {
+		entry.Principal = qualify(entry.Principal)
+		policies := make([]string, 0, len(entry.Policies))
+		for _, policy := range entry.Policies {
+			policies = append(policies, qualify(policy))
+		}
+		entry.Policies = policies
+		merged.EffectivePermissions = append(merged.EffectivePermissions, entry)
+	}
+}
+
+// analyzeResources aplica as verificações IAM a um conjunto de recursos que
+// pertencem ao mesmo módulo raiz
+func (ia *IAMAnalyzer) analyzeResources(resources []models.TerraformResource, analysis *models.IAMAnalysis) {
+	oidcProviders := openIDConnectProviders(resources)
 
 	// Analisa cada recurso
-	for _, resource := range tfAnalysis.Resources {
+	for _, resource := range resources {
 		switch {
+		case strings.HasPrefix(resource.Type, "azurerm_role_"):
+			ia.analyzeAzureRBAC(resource, analysis)
+		case isGCPIAMResource(resource.Type):
+			ia.analyzeGCPIAM(resource, analysis)
 		case ia.isIAMPolicy(resource.Type):
 			ia.analyzePolicyResource(resource, analysis)
 		case ia.isIAMRole(resource.Type):
-			ia.analyzeRoleResource(resource, analysis)
+			ia.analyzeRoleResource(resource, oidcProviders, analysis)
+			ia.analyzeEmbeddedPolicies(resource, analysis)
 			analysis.TotalRoles++
+		case policyAttachmentTypes[resource.Type] != nil:
+			ia.analyzeEmbeddedPolicies(resource, analysis)
 		case ia.hasPublicAccess(resource):
 			ia.analyzePublicAccess(resource, analysis)
 		}
 	}
 
-	// Gera recomendações gerais
-	ia.generateRecommendations(analysis)
-
-	return analysis, nil
+	// Permissões efetivas por principal e escalada de privilégio
+	ia.analyzeEffectivePermissions(resources, analysis)
 }
 
 // isIAMPolicy verifica se é um recurso de política IAM
@@ -58,6 +157,7 @@ func (ia *IAMAnalyzer) isIAMPolicy(resourceType string) bool {
 		"aws_iam_group_policy",
 		"azurerm_role_definition",
 		"google_project_iam_custom_role",
+		"google_organization_iam_custom_role",
 	}
 
 	for _, t := range policyTypes {
@@ -74,6 +174,11 @@ func (ia *IAMAnalyzer) isIAMRole(resourceType string) bool {
 		"aws_iam_role",
 		"azurerm_role_assignment",
 		"google_project_iam_member",
+		"google_project_iam_binding",
+		"google_folder_iam_member",
+		"google_folder_iam_binding",
+		"google_organization_iam_member",
+		"google_organization_iam_binding",
 	}
 
 	for _, t := range roleTypes {
@@ -88,108 +193,73 @@ func (ia *IAMAnalyzer) isIAMRole(resourceType string) bool {
 func (ia *IAMAnalyzer) analyzePolicyResource(resource models.TerraformResource, analysis *models.IAMAnalysis) {
 	analysis.TotalPolicies++
 
-	// Procura pelo documento de política
-	var policyDoc string
-	if doc, ok := resource.Attributes["policy"].(string); ok {
-		policyDoc = doc
-	} else if doc, ok := resource.Attributes["policy_document"].(string); ok {
-		policyDoc = doc
-	}
-
-	if policyDoc == "" {
+	// Procura pelo documento de política (JSON)
+	doc, err := ia.policyDocumentOf(resource)
+	if err != nil {
+		ia.logger.Warn("Erro ao fazer parse de política IAM", "resource", resource.Name, "error", err)
 		return
 	}
-
-	// Parse policy document (JSON)
-	var policy map[string]interface{}
-	if err := json.Unmarshal([]byte(policyDoc), &policy); err != nil {
-		ia.logger.Warn("Erro ao fazer parse de política IAM", "resource", resource.Name, "error", err)
+	if doc == nil {
 		return
 	}
 
 	// Analisa statements
-	if statements, ok := policy["Statement"].([]interface{}); ok {
-		for _, stmt := range statements {
-			if statement, ok := stmt.(map[string]interface{}); ok {
-				ia.analyzeStatement(statement, resource, analysis)
-			}
+	for _, statement := range doc.Statements {
+		ia.analyzeStatement(statement, resource, analysis)
+	}
+}
+
+// analyzeEmbeddedPolicies aplica as verificações de statement às políticas
+// que não são recursos próprios: blocos inline_policy de roles e políticas
+// gerenciadas pela AWS anexadas (ex.: AdministratorAccess)
+func (ia *IAMAnalyzer) analyzeEmbeddedPolicies(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	inline := ia.inlinePolicies(resource)
+	analysis.TotalPolicies += len(inline)
+
+	for _, policy := range append(inline, managedPolicies(resource)...) {
+		for _, statement := range policy.Document.Statements {
+			ia.analyzeStatement(statement, resource, analysis)
 		}
 	}
 }
 
-// analyzeStatement analisa um statement de política IAM
-func (ia *IAMAnalyzer) analyzeStatement(statement map[string]interface{}, resource models.TerraformResource, analysis *models.IAMAnalysis) {
-	// Verifica Effect
-	effect, ok := statement["Effect"].(string)
-	if !ok || effect != "Allow" {
+// analyzeStatement analisa um statement de política IAM. Acesso
+// administrativo é determinado pelas permissões efetivas, que consideram
+// os Deny (ver analyzeEffectivePermissions).
+func (ia *IAMAnalyzer) analyzeStatement(statement policyStatement, resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	if !statement.isAllow() {
 		return // Só analisa permissões
 	}
 
 	// Analisa Actions
-	actions := ia.extractActions(statement)
-	for _, action := range actions {
+	for _, action := range statement.Actions {
 		if strings.Contains(action, "*") {
 			analysis.WildcardActions = append(analysis.WildcardActions,
 				fmt.Sprintf("%s: %s", resource.Name, action))
 			analysis.OverlyPermissive = true
-
-			// Detecta acesso admin
-			if action == "*" || action == "*:*" {
-				analysis.AdminAccessDetected = true
-			}
-		}
-	}
-
-	// Analisa Resource
-	resources := ia.extractResources(statement)
-	for _, res := range resources {
-		if res == "*" {
-			analysis.OverlyPermissive = true
 		}
 	}
 
-	// Analisa Principal
-	if principal, ok := statement["Principal"].(map[string]interface{}); ok {
-		ia.analyzePrincipal(principal, resource, analysis)
+	// NotAction em um Allow concede tudo exceto as ações listadas
+	for _, action := range statement.NotActions {
+		analysis.WildcardActions = append(analysis.WildcardActions,
+			fmt.Sprintf("%s: NotAction %s", resource.Name, action))
+		analysis.OverlyPermissive = true
 	}
-}
 
-// extractActions extrai actions de um statement
-func (ia *IAMAnalyzer) extractActions(statement map[string]interface{}) []string {
-	actions := []string{}
-
-	if action, ok := statement["Action"].(string); ok {
-		actions = append(actions, action)
-	} else if actionList, ok := statement["Action"].([]interface{}); ok {
-		for _, a := range actionList {
-			if actionStr, ok := a.(string); ok {
-				actions = append(actions, actionStr)
-			}
-		}
+	// Analisa Resource (NotResource concede tudo exceto os recursos listados)
+	if containsString(statement.Resources, "*") || len(statement.NotResources) > 0 {
+		analysis.OverlyPermissive = true
 	}
 
-	return actions
-}
-
-// extractResources extrai resources de um statement
-func (ia *IAMAnalyzer) extractResources(statement map[string]interface{}) []string {
-	resources := []string{}
-
-	if resource, ok := statement["Resource"].(string); ok {
-		resources = append(resources, resource)
-	} else if resourceList, ok := statement["Resource"].([]interface{}); ok {
-		for _, r := range resourceList {
-			if resourceStr, ok := r.(string); ok {
-				resources = append(resources, resourceStr)
-			}
-		}
+	// Analisa Principal
+	if principal, ok := statement.Principal.(map[string]interface{}); ok {
+		ia.analyzePrincipal(principal, statement.Actions, resource, analysis)
 	}
-
-	return resources
 }
 
 // analyzePrincipal analisa principal de uma política
-func (ia *IAMAnalyzer) analyzePrincipal(principal map[string]interface{}, resource models.TerraformResource, analysis *models.IAMAnalysis) {
+func (ia *IAMAnalyzer) analyzePrincipal(principal map[string]interface{}, actions []string, resource models.TerraformResource, analysis *models.IAMAnalysis) {
 	// Verifica se é público
 	if aws, ok := principal["AWS"].(string); ok {
 		if aws == "*" {
@@ -197,10 +267,12 @@ func (ia *IAMAnalyzer) analyzePrincipal(principal map[string]interface{}, resour
 				fmt.Sprintf("Política %s permite acesso público (Principal: *)", resource.Name))
 
 			risk := models.PrincipalRisk{
+				CheckID:     "IAM_PUBLIC_PRINCIPAL",
+				Resource:    resource.Address,
 				Principal:   "*",
 				Type:        "public",
 				RiskLevel:   "critical",
-				Permissions: ia.extractActions(principal),
+				Permissions: actions,
 				Reason:      "Política permite acesso público irrestrito",
 			}
 			analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
@@ -208,34 +280,20 @@ func (ia *IAMAnalyzer) analyzePrincipal(principal map[string]interface{}, resour
 	}
 }
 
-// analyzeRoleResource analisa um recurso de role
-func (ia *IAMAnalyzer) analyzeRoleResource(resource models.TerraformResource, analysis *models.IAMAnalysis) {
-	// Verifica assume role policy
-	if assumePolicy, ok := resource.Attributes["assume_role_policy"].(string); ok {
-		var policy map[string]interface{}
-		if err := json.Unmarshal([]byte(assumePolicy), &policy); err == nil {
-			if statements, ok := policy["Statement"].([]interface{}); ok {
-				for _, stmt := range statements {
-					if statement, ok := stmt.(map[string]interface{}); ok {
-						if principal, ok := statement["Principal"].(map[string]interface{}); ok {
-							if service, ok := principal["Service"].(string); ok {
-								// Verifica serviços de risco
-								if ia.isRiskyService(service) {
-									risk := models.PrincipalRisk{
-										Principal: service,
-										Type:      "service",
-										RiskLevel: "medium",
-										Reason:    fmt.Sprintf("Serviço %s pode assumir esta role", service),
-									}
-									analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
-								}
-							}
-						}
-					}
-				}
-			}
-		}
+// analyzeRoleResource analisa a trust policy (assume_role_policy) de uma
+// role
+func (ia *IAMAnalyzer) analyzeRoleResource(resource models.TerraformResource, oidcProviders map[string]string, analysis *models.IAMAnalysis) {
+	assumePolicy, ok := resource.Attributes["assume_role_policy"].(string)
+	if !ok || assumePolicy == "" {
+		return
+	}
+
+	doc, err := parsePolicyDocument(assumePolicy)
+	if err != nil {
+		ia.logger.Warn("Erro ao fazer parse de trust policy", "resource", resource.Name, "error", err)
+		return
 	}
+	ia.analyzeTrustPolicy(resource, doc, oidcProviders, analysis)
 }
 
 // hasPublicAccess verifica se recurso tem acesso público
@@ -328,6 +386,35 @@ func (ia *IAMAnalyzer) generateRecommendations(analysis *models.IAMAnalysis) {
 			"Recursos com acesso público devem ser revisados cuidadosamente. Considere restringir acesso.")
 	}
 
+	for _, risk := range analysis.PrincipalRisks {
+		if risk.CheckID == "IAM_PRIVILEGE_ESCALATION" {
+			analysis.Recommendations = append(analysis.Recommendations,
+				"Permissões que permitem escalada de privilégio detectadas. Restrinja ações IAM sensíveis (iam:PassRole, iam:CreatePolicyVersion, iam:Attach*/Put*Policy) a recursos específicos e use permission boundaries.")
+			break
+		}
+	}
+
+	for _, risk := range analysis.PrincipalRisks {
+		if strings.HasPrefix(risk.CheckID, "IAM_TRUST_") {
+			analysis.Recommendations = append(analysis.Recommendations,
+				"Trust policies permitem que principais externos ou federados assumam roles. Restrinja com aws:PrincipalOrgID, sts:ExternalId para contas de terceiros e condições em :sub para provedores OIDC.")
+			break
+		}
+	}
+
+	providerRecommendations := map[string]string{
+		"AZURE_": "Papéis amplos no Azure detectados. Evite Owner/Contributor no escopo de subscription e papéis customizados com \"*\"; atribua papéis específicos no menor escopo possível.",
+		"GCP_":   "Permissões amplas no GCP detectadas. Substitua roles/owner e roles/editor por papéis predefinidos, remova allUsers/allAuthenticatedUsers e evite chaves de service account em favor de Workload Identity Federation.",
+	}
+	for _, prefix := range []string{"AZURE_", "GCP_"} {
+		for _, risk := range analysis.PrincipalRisks {
+			if strings.HasPrefix(risk.CheckID, prefix) {
+				analysis.Recommendations = append(analysis.Recommendations, providerRecommendations[prefix])
+				break
+			}
+		}
+	}
+
 	if len(analysis.WildcardActions) > 3 {
 		analysis.Recommendations = append(analysis.Recommendations,
 			"Muitas ações com wildcard detectadas. Revise as permissões e aplique princípio do menor privilégio.")
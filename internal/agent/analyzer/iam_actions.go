@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+)
+
+// awsActionCatalog é o catálogo embarcado de ações AWS usado para expandir
+// wildcards (s3:Get*, iam:*, *) e NotAction. Cobre os serviços mais comuns
+// em políticas e todas as ações dos caminhos de escalada de privilégio;
+// ações fora do catálogo são mantidas literalmente.
+var awsActionCatalog = map[string][]string{
+	"iam": {
+		"AddRoleToInstanceProfile", "AddUserToGroup", "AttachGroupPolicy", "AttachRolePolicy",
+		"AttachUserPolicy", "CreateAccessKey", "CreateGroup", "CreateInstanceProfile",
+		"CreateLoginProfile", "CreatePolicy", "CreatePolicyVersion", "CreateRole",
+		"CreateServiceLinkedRole", "CreateUser", "DeleteAccessKey", "DeleteGroup", "DeletePolicy",
+		"DeletePolicyVersion", "DeleteRole", "DeleteRolePolicy", "DeleteUser", "DeleteUserPolicy",
+		"DetachGroupPolicy", "DetachRolePolicy", "DetachUserPolicy", "GetPolicy", "GetPolicyVersion",
+		"GetRole", "GetRolePolicy", "GetUser", "GetUserPolicy", "ListAttachedRolePolicies",
+		"ListPolicies", "ListRoles", "ListUsers", "PassRole", "PutGroupPolicy", "PutRolePolicy",
+		"PutUserPolicy", "RemoveUserFromGroup", "SetDefaultPolicyVersion", "TagRole",
+		"UpdateAccessKey", "UpdateAssumeRolePolicy", "UpdateLoginProfile", "UpdateRole",
+	},
+	"sts": {
+		"AssumeRole", "AssumeRoleWithSAML", "AssumeRoleWithWebIdentity", "GetCallerIdentity",
+		"GetFederationToken", "GetSessionToken", "TagSession",
+	},
+	"s3": {
+		"AbortMultipartUpload", "CreateBucket", "DeleteBucket", "DeleteBucketPolicy", "DeleteObject",
+		"GetBucketAcl", "GetBucketLocation", "GetBucketPolicy", "GetObject", "GetObjectAcl",
+		"ListAllMyBuckets", "ListBucket", "PutBucketAcl", "PutBucketPolicy",
+		"PutBucketPublicAccessBlock", "PutEncryptionConfiguration", "PutObject", "PutObjectAcl",
+	},
+	"ec2": {
+		"AssociateIamInstanceProfile", "AuthorizeSecurityGroupIngress", "CreateSecurityGroup",
+		"CreateSnapshot", "CreateTags", "CreateVolume", "DeleteSecurityGroup", "DescribeInstances",
+		"DescribeSecurityGroups", "DescribeVolumes", "ModifyInstanceAttribute",
+		"ReplaceIamInstanceProfileAssociation", "RunInstances", "StartInstances", "StopInstances",
+		"TerminateInstances",
+	},
+	"lambda": {
+		"AddPermission", "CreateEventSourceMapping", "CreateFunction", "DeleteFunction", "GetFunction",
+		"InvokeFunction", "ListFunctions", "UpdateFunctionCode", "UpdateFunctionConfiguration",
+	},
+	"kms": {
+		"CreateGrant", "CreateKey", "Decrypt", "DescribeKey", "DisableKey", "Encrypt",
+		"GenerateDataKey", "PutKeyPolicy", "ScheduleKeyDeletion",
+	},
+	"secretsmanager": {
+		"CreateSecret", "DeleteSecret", "DescribeSecret", "GetSecretValue", "ListSecrets",
+		"PutSecretValue", "UpdateSecret",
+	},
+	"ssm": {
+		"GetParameter", "GetParameters", "GetParametersByPath", "PutParameter", "SendCommand",
+		"StartSession",
+	},
+	"cloudformation": {
+		"CreateChangeSet", "CreateStack", "DeleteStack", "DescribeStacks", "ExecuteChangeSet",
+		"SetStackPolicy", "UpdateStack",
+	},
+	"glue": {
+		"CreateDevEndpoint", "CreateJob", "GetDevEndpoint", "StartJobRun", "UpdateDevEndpoint",
+		"UpdateJob",
+	},
+	"datapipeline": {"ActivatePipeline", "CreatePipeline", "PutPipelineDefinition"},
+	"codebuild":    {"CreateProject", "StartBuild", "UpdateProject"},
+	"ecs":          {"CreateService", "RegisterTaskDefinition", "RunTask", "StartTask", "UpdateService"},
+	"sagemaker": {
+		"CreateNotebookInstance", "CreatePresignedNotebookInstanceUrl", "CreateProcessingJob",
+		"CreateTrainingJob",
+	},
+	"dynamodb": {"DeleteItem", "DeleteTable", "GetItem", "PutItem", "Query", "Scan", "UpdateItem"},
+	"rds":      {"CreateDBInstance", "DeleteDBInstance", "DescribeDBInstances", "ModifyDBInstance"},
+	"logs":     {"CreateLogGroup", "CreateLogStream", "DeleteLogGroup", "PutLogEvents"},
+	"sns":      {"Publish", "Subscribe"},
+	"sqs":      {"DeleteMessage", "ReceiveMessage", "SendMessage"},
+}
+
+// catalogActions é a lista ordenada de todas as ações do catálogo no
+// formato servico:Acao
+var catalogActions = func() []string {
+	actions := []string{}
+	for service, names := range awsActionCatalog {
+		for _, name := range names {
+			actions = append(actions, service+":"+name)
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}()
+
+// expandActions expande os padrões de ação (com * e ?) para as ações do
+// catálogo. Padrões sem correspondência no catálogo (serviços ou ações não
+// catalogados) são mantidos como estão.
+func expandActions(patterns []string) []string {
+	seen := make(map[string]bool)
+	expanded := []string{}
+	add := func(action string) {
+		if key := strings.ToLower(action); !seen[key] {
+			seen[key] = true
+			expanded = append(expanded, action)
+		}
+	}
+
+	for _, pattern := range patterns {
+		matched := false
+		for _, action := range catalogActions {
+			if iamWildcardMatch(pattern, action) {
+				add(action)
+				matched = true
+			}
+		}
+		if !matched {
+			add(pattern)
+		}
+	}
+	return expanded
+}
+
+// complementActions retorna as ações do catálogo que não correspondem a
+// nenhum dos padrões (semântica de NotAction)
+func complementActions(patterns []string) []string {
+	actions := []string{}
+	for _, action := range catalogActions {
+		excluded := false
+		for _, pattern := range patterns {
+			if iamWildcardMatch(pattern, action) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
+
+// iamWildcardMatch compara um valor com um padrão IAM (* e ?), sem
+// diferenciar maiúsculas e minúsculas. Diferente de path.Match, * também
+// corresponde a "/" (necessário para ARNs).
+func iamWildcardMatch(pattern, value string) bool {
+	p, v := strings.ToLower(pattern), strings.ToLower(value)
+	pi, vi := 0, 0
+	star, match := -1, 0
+
+	for vi < len(v) {
+		switch {
+		case pi < len(p) && (p[pi] == '?' || p[pi] == v[vi]):
+			pi++
+			vi++
+		case pi < len(p) && p[pi] == '*':
+			star, match = pi, vi
+			pi++
+		case star >= 0:
+			pi = star + 1
+			match++
+			vi = match
+		default:
+			return false
+		}
+	}
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
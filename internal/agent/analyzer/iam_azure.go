@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// azurePrivilegedRole é um papel built-in do Azure com acesso amplo
+type azurePrivilegedRole struct {
+	Name         string
+	DefinitionID string
+	RiskLevel    string
+	Admin        bool // inclui gerenciamento de acesso (Microsoft.Authorization/*)
+	Description  string
+}
+
+// azurePrivilegedRoles são os papéis built-in que não devem ser atribuídos
+// no escopo de subscription ou acima
+var azurePrivilegedRoles = []azurePrivilegedRole{
+	{"Owner", "8e3af657-a8ff-443c-a75c-2fe8c4bcb635", "critical", true,
+		"concede controle total, incluindo atribuir papéis a qualquer principal"},
+	{"User Access Administrator", "18d7d88d-d35e-4fb5-a5c3-7773c20a72d9", "critical", true,
+		"permite atribuir qualquer papel, inclusive Owner, a qualquer principal"},
+	{"Contributor", "b24988ac-6180-42a0-ab88-20f7382dd24c", "high", false,
+		"permite criar, alterar e excluir todos os recursos"},
+}
+
+// azureSubscriptionScope reconhece o escopo de uma subscription inteira
+var azureSubscriptionScope = regexp.MustCompile(`(?i)^/subscriptions/[^/]+/?$`)
+
+// azureRoleAssignmentAction é a ação que permite atribuir papéis
+const azureRoleAssignmentAction = "Microsoft.Authorization/roleAssignments/write"
+
+// analyzeAzureRBAC analisa atribuições e definições de papéis do Azure
+func (ia *IAMAnalyzer) analyzeAzureRBAC(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	switch resource.Type {
+	case "azurerm_role_assignment":
+		analysis.TotalRoles++
+		ia.analyzeAzureRoleAssignment(resource, analysis)
+	case "azurerm_role_definition":
+		analysis.TotalPolicies++
+		ia.analyzeAzureRoleDefinition(resource, analysis)
+	}
+}
+
+// azureAssignmentScope classifica o escopo de uma atribuição: tenant,
+// management group ou subscription. Escopos menores (resource group,
+// recurso) retornam "". Um escopo desconhecido que referencia
+// data.azurerm_subscription é tratado como subscription.
+func azureAssignmentScope(resource models.TerraformResource) string {
+	scope, ok := resource.Attributes["scope"].(string)
+	if !ok {
+		for _, dep := range resource.Dependencies {
+			if strings.HasPrefix(dep, "data.azurerm_subscription.") || strings.Contains(dep, ".data.azurerm_subscription.") {
+				return "subscription"
+			}
+		}
+		return ""
+	}
+
+	switch {
+	case scope == "/":
+		return "tenant"
+	case strings.HasPrefix(strings.ToLower(scope), "/providers/microsoft.management/managementgroups/"):
+		return "management group"
+	case azureSubscriptionScope.MatchString(scope):
+		return "subscription"
+	}
+	return ""
+}
+
+// azureAssignedRole identifica o papel built-in privilegiado atribuído,
+// pelo nome (role_definition_name) ou pelo ID (role_definition_id)
+func azureAssignedRole(resource models.TerraformResource) *azurePrivilegedRole {
+	name, _ := resource.Attributes["role_definition_name"].(string)
+	id, _ := resource.Attributes["role_definition_id"].(string)
+	for i, role := range azurePrivilegedRoles {
+		if strings.EqualFold(name, role.Name) || (id != "" && strings.HasSuffix(strings.ToLower(id), role.DefinitionID)) {
+			return &azurePrivilegedRoles[i]
+		}
+	}
+	return nil
+}
+
+// referencedPrincipal retorna o principal do recurso: o valor literal do
+// atributo ou o primeiro recurso referenciado que não seja escopo ou papel
+func referencedPrincipal(resource models.TerraformResource, attr string, ignoredPrefixes ...string) string {
+	if principal, ok := resource.Attributes[attr].(string); ok && principal != "" {
+		return principal
+	}
+	for _, dep := range resource.Dependencies {
+		ignored := false
+		for _, prefix := range ignoredPrefixes {
+			if strings.HasPrefix(dep, prefix) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			return dep
+		}
+	}
+	return "desconhecido"
+}
+
+// analyzeAzureRoleAssignment reporta Owner, User Access Administrator e
+// Contributor atribuídos no escopo de subscription, management group ou
+// tenant
+func (ia *IAMAnalyzer) analyzeAzureRoleAssignment(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	role := azureAssignedRole(resource)
+	if role == nil {
+		return
+	}
+	scope := azureAssignmentScope(resource)
+	if scope == "" {
+		return
+	}
+
+	principalType := "principal"
+	if t, ok := resource.Attributes["principal_type"].(string); ok && t != "" {
+		principalType = strings.ToLower(t)
+	}
+	if role.Admin {
+		analysis.AdminAccessDetected = true
+	}
+	principal := referencedPrincipal(resource, "principal_id",
+		"data.azurerm_subscription.", "data.azurerm_role_definition.", "azurerm_role_definition.")
+
+	analysis.PrincipalRisks = append(analysis.PrincipalRisks, models.PrincipalRisk{
+		CheckID:     "AZURE_PRIVILEGED_ROLE_ASSIGNMENT",
+		Resource:    resource.Address,
+		Principal:   principal,
+		Type:        principalType,
+		RiskLevel:   role.RiskLevel,
+		Permissions: []string{role.Name},
+		Reason: fmt.Sprintf("Papel %s atribuído no escopo de %s: %s. Atribua papéis específicos no menor escopo possível (resource group ou recurso)",
+			role.Name, scope, role.Description),
+	})
+}
+
+// analyzeAzureRoleDefinition analisa as permissões de um papel customizado:
+// ações com wildcard e papéis que permitem atribuir outros papéis
+func (ia *IAMAnalyzer) analyzeAzureRoleDefinition(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	name, _ := resource.Attributes["name"].(string)
+	if name == "" {
+		name = resource.Name
+	}
+
+	blocks, _ := resource.Attributes["permissions"].([]interface{})
+	actions, notActions := []string{}, []string{}
+	for _, block := range blocks {
+		permissions, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		blockActions := append(stringList(permissions["actions"]), stringList(permissions["data_actions"])...)
+		for _, action := range blockActions {
+			if strings.Contains(action, "*") {
+				analysis.WildcardActions = append(analysis.WildcardActions,
+					fmt.Sprintf("%s: %s", resource.Name, action))
+				analysis.OverlyPermissive = true
+			}
+		}
+		actions = append(actions, stringList(permissions["actions"])...)
+		notActions = append(notActions, stringList(permissions["not_actions"])...)
+	}
+
+	grantsAll := containsString(actions, "*")
+	canAssign := false
+	for _, action := range actions {
+		if iamWildcardMatch(action, azureRoleAssignmentAction) {
+			canAssign = true
+			break
+		}
+	}
+	for _, action := range notActions {
+		if iamWildcardMatch(action, azureRoleAssignmentAction) {
+			canAssign = false
+			break
+		}
+	}
+
+	risk := models.PrincipalRisk{
+		Resource:    resource.Address,
+		Principal:   name,
+		Type:        "custom-role",
+		Permissions: actions,
+	}
+	switch {
+	case grantsAll && canAssign:
+		analysis.AdminAccessDetected = true
+		risk.CheckID = "AZURE_CUSTOM_ROLE_WILDCARD"
+		risk.RiskLevel = "critical"
+		risk.Reason = "Papel customizado concede todas as ações (*), equivalente a Owner"
+	case grantsAll:
+		risk.CheckID = "AZURE_CUSTOM_ROLE_WILDCARD"
+		risk.RiskLevel = "high"
+		risk.Reason = "Papel customizado concede todas as ações (*) exceto gerenciamento de acesso, equivalente a Contributor"
+	case canAssign:
+		risk.CheckID = "AZURE_CUSTOM_ROLE_ROLE_ASSIGNMENT"
+		risk.RiskLevel = "high"
+		risk.Reason = fmt.Sprintf("Papel customizado permite %s, que concede a qualquer principal papéis mais privilegiados",
+			azureRoleAssignmentAction)
+	default:
+		return
+	}
+	analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
+}
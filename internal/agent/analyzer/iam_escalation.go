@@ -0,0 +1,396 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// escalationPath é uma combinação de ações que permite a um principal
+// obter privilégios maiores que os concedidos
+type escalationPath struct {
+	Name        string
+	Actions     []string // todas são necessárias
+	RiskLevel   string
+	Description string
+}
+
+// privilegeEscalationPaths são os caminhos conhecidos de escalada de
+// privilégio na AWS
+var privilegeEscalationPaths = []escalationPath{
+	{"CreatePolicyVersion", []string{"iam:CreatePolicyVersion"}, "critical",
+		"pode criar uma nova versão padrão de uma política gerenciada com permissões administrativas"},
+	{"SetDefaultPolicyVersion", []string{"iam:SetDefaultPolicyVersion"}, "high",
+		"pode ativar uma versão anterior e mais permissiva de uma política gerenciada"},
+	{"AttachUserPolicy", []string{"iam:AttachUserPolicy"}, "critical",
+		"pode anexar qualquer política (ex.: AdministratorAccess) a um usuário"},
+	{"AttachGroupPolicy", []string{"iam:AttachGroupPolicy"}, "critical",
+		"pode anexar qualquer política (ex.: AdministratorAccess) a um grupo"},
+	{"AttachRolePolicy", []string{"iam:AttachRolePolicy"}, "critical",
+		"pode anexar qualquer política (ex.: AdministratorAccess) a uma role"},
+	{"PutUserPolicy", []string{"iam:PutUserPolicy"}, "critical",
+		"pode criar uma política inline arbitrária em um usuário"},
+	{"PutGroupPolicy", []string{"iam:PutGroupPolicy"}, "critical",
+		"pode criar uma política inline arbitrária em um grupo"},
+	{"PutRolePolicy", []string{"iam:PutRolePolicy"}, "critical",
+		"pode criar uma política inline arbitrária em uma role"},
+	{"AddUserToGroup", []string{"iam:AddUserToGroup"}, "high",
+		"pode se adicionar a um grupo com mais privilégios"},
+	{"CreateAccessKey", []string{"iam:CreateAccessKey"}, "high",
+		"pode criar chaves de acesso para outros usuários"},
+	{"CreateLoginProfile", []string{"iam:CreateLoginProfile"}, "high",
+		"pode definir uma senha de console para outros usuários"},
+	{"UpdateLoginProfile", []string{"iam:UpdateLoginProfile"}, "high",
+		"pode alterar a senha de console de outros usuários"},
+	{"UpdateAssumeRolePolicy", []string{"iam:UpdateAssumeRolePolicy", "sts:AssumeRole"}, "critical",
+		"pode alterar a trust policy de uma role privilegiada e assumi-la"},
+	{"PassRole+EC2", []string{"iam:PassRole", "ec2:RunInstances"}, "high",
+		"pode iniciar uma instância EC2 com uma role privilegiada e usar suas credenciais"},
+	{"PassRole+Lambda", []string{"iam:PassRole", "lambda:CreateFunction"}, "high",
+		"pode criar uma função Lambda com uma role privilegiada e executar código com ela"},
+	{"UpdateFunctionCode", []string{"lambda:UpdateFunctionCode"}, "medium",
+		"pode substituir o código de funções Lambda existentes e usar suas roles"},
+	{"PassRole+Glue", []string{"iam:PassRole", "glue:CreateDevEndpoint"}, "high",
+		"pode criar um dev endpoint do Glue com uma role privilegiada"},
+	{"UpdateDevEndpoint", []string{"glue:UpdateDevEndpoint"}, "medium",
+		"pode adicionar sua chave SSH a dev endpoints do Glue existentes"},
+	{"PassRole+CloudFormation", []string{"iam:PassRole", "cloudformation:CreateStack"}, "high",
+		"pode criar stacks CloudFormation que executam com uma role privilegiada"},
+	{"PassRole+DataPipeline", []string{"iam:PassRole", "datapipeline:CreatePipeline", "datapipeline:PutPipelineDefinition"}, "high",
+		"pode criar pipelines que executam comandos com uma role privilegiada"},
+	{"PassRole+CodeBuild", []string{"iam:PassRole", "codebuild:CreateProject", "codebuild:StartBuild"}, "high",
+		"pode criar e executar projetos CodeBuild com uma role privilegiada"},
+	{"PassRole+ECS", []string{"iam:PassRole", "ecs:RegisterTaskDefinition", "ecs:RunTask"}, "high",
+		"pode executar tasks ECS com uma role privilegiada"},
+	{"PassRole+SageMaker", []string{"iam:PassRole", "sagemaker:CreateNotebookInstance", "sagemaker:CreatePresignedNotebookInstanceUrl"}, "high",
+		"pode criar um notebook SageMaker com uma role privilegiada e acessá-lo"},
+}
+
+// principalKinds mapeia os tipos de recurso AWS que são principais
+var principalKinds = map[string]string{
+	"aws_iam_role":  "role",
+	"aws_iam_user":  "user",
+	"aws_iam_group": "group",
+}
+
+// inlinePolicyTypes mapeia políticas inline ao atributo do principal
+var inlinePolicyTypes = map[string]string{
+	"aws_iam_role_policy":  "role",
+	"aws_iam_user_policy":  "user",
+	"aws_iam_group_policy": "group",
+}
+
+// policyAttachmentTypes mapeia anexos de política aos atributos de
+// principais por tipo
+var policyAttachmentTypes = map[string]map[string]string{
+	"aws_iam_role_policy_attachment":  {"role": "role"},
+	"aws_iam_user_policy_attachment":  {"user": "user"},
+	"aws_iam_group_policy_attachment": {"group": "group"},
+	"aws_iam_policy_attachment":       {"roles": "role", "users": "user", "groups": "group"},
+}
+
+// iamPrincipal é uma role, usuário ou grupo com as políticas que recebe
+type iamPrincipal struct {
+	address  string
+	kind     string
+	name     string
+	policies []sourcedPolicy
+	groups   []*iamPrincipal
+}
+
+// resourceAddress retorna o endereço do recurso (tipo.nome se ausente)
+func resourceAddress(r models.TerraformResource) string {
+	if r.Address != "" {
+		return r.Address
+	}
+	return r.Type + "." + r.Name
+}
+
+// policyDocumentOf retorna o documento de política do recurso (atributo
+// policy ou policy_document), ou nil se não houver
+func (ia *IAMAnalyzer) policyDocumentOf(r models.TerraformResource) (*policyDocument, error) {
+	for _, attr := range []string{"policy", "policy_document"} {
+		if doc, ok := r.Attributes[attr].(string); ok && doc != "" {
+			return parsePolicyDocument(doc)
+		}
+	}
+	return nil, nil
+}
+
+// inlinePolicies retorna as políticas dos blocos inline_policy de uma
+// aws_iam_role
+func (ia *IAMAnalyzer) inlinePolicies(r models.TerraformResource) []sourcedPolicy {
+	blocks, _ := r.Attributes["inline_policy"].([]interface{})
+	policies := []sourcedPolicy{}
+	for i, block := range blocks {
+		inline, ok := block.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		doc, ok := inline["policy"].(string)
+		if !ok || doc == "" {
+			continue
+		}
+		document, err := parsePolicyDocument(doc)
+		if err != nil {
+			ia.logger.Warn("Erro ao fazer parse de política inline", "resource", r.Name, "error", err)
+			continue
+		}
+		source := fmt.Sprintf("%s.inline_policy[%d]", resourceAddress(r), i)
+		if name, ok := inline["name"].(string); ok && name != "" {
+			source = fmt.Sprintf("%s.inline_policy[%q]", resourceAddress(r), name)
+		}
+		policies = append(policies, sourcedPolicy{Source: source, Document: document})
+	}
+	return policies
+}
+
+// managedPolicies retorna as políticas gerenciadas pela AWS referenciadas
+// pelo recurso (policy_arn de anexos e managed_policy_arns de roles)
+func managedPolicies(r models.TerraformResource) []sourcedPolicy {
+	arns := stringList(r.Attributes["policy_arn"])
+	arns = append(arns, stringList(r.Attributes["managed_policy_arns"])...)
+
+	policies := []sourcedPolicy{}
+	for _, arn := range arns {
+		if doc := managedPolicyDocument(arn); doc != nil {
+			policies = append(policies, sourcedPolicy{Source: arn, Document: doc})
+		}
+	}
+	return policies
+}
+
+// analyzeEffectivePermissions calcula as permissões efetivas de cada
+// principal (políticas inline, anexadas e herdadas de grupos) e de cada
+// política não anexada, e reporta acesso administrativo e caminhos de
+// escalada de privilégio como PrincipalRisk
+func (ia *IAMAnalyzer) analyzeEffectivePermissions(resources []models.TerraformResource, analysis *models.IAMAnalysis) {
+	principals := []*iamPrincipal{}
+	policies := make(map[string]sourcedPolicy)
+	policyOrder := []string{}
+
+	for _, r := range resources {
+		if kind, ok := principalKinds[r.Type]; ok {
+			name, _ := r.Attributes["name"].(string)
+			if name == "" {
+				name = r.Name
+			}
+			principal := &iamPrincipal{address: resourceAddress(r), kind: kind, name: name}
+			if kind == "role" {
+				principal.policies = append(ia.inlinePolicies(r), managedPolicies(r)...)
+			}
+			principals = append(principals, principal)
+		}
+		if r.Type == "aws_iam_policy" {
+			if doc, err := ia.policyDocumentOf(r); err == nil && doc != nil {
+				address := resourceAddress(r)
+				policies[address] = sourcedPolicy{Source: address, Document: doc}
+				policyOrder = append(policyOrder, address)
+			}
+		}
+	}
+
+	attached := make(map[string]bool)
+	for _, r := range resources {
+		if kind, ok := inlinePolicyTypes[r.Type]; ok {
+			doc, err := ia.policyDocumentOf(r)
+			if err != nil || doc == nil {
+				continue
+			}
+			for _, p := range linkedPrincipals(r, principals, kind, kind) {
+				p.policies = append(p.policies, sourcedPolicy{Source: resourceAddress(r), Document: doc})
+			}
+			continue
+		}
+
+		if attrs, ok := policyAttachmentTypes[r.Type]; ok {
+			linked := append(linkedPolicies(r, policies), managedPolicies(r)...)
+			for attr, kind := range attrs {
+				for _, p := range linkedPrincipals(r, principals, attr, kind) {
+					for _, policy := range linked {
+						p.policies = append(p.policies, policy)
+						attached[policy.Source] = true
+					}
+				}
+			}
+			continue
+		}
+
+		switch r.Type {
+		case "aws_iam_group_membership":
+			for _, group := range linkedPrincipals(r, principals, "group", "group") {
+				for _, user := range linkedPrincipals(r, principals, "users", "user") {
+					user.groups = append(user.groups, group)
+				}
+			}
+		case "aws_iam_user_group_membership":
+			for _, user := range linkedPrincipals(r, principals, "user", "user") {
+				user.groups = append(user.groups, linkedPrincipals(r, principals, "groups", "group")...)
+			}
+		}
+	}
+
+	for _, p := range principals {
+		effective := append([]sourcedPolicy{}, p.policies...)
+		for _, group := range p.groups {
+			effective = append(effective, group.policies...)
+		}
+		if len(effective) == 0 {
+			continue
+		}
+		ia.reportPermissions(p.address, p.kind, effective, analysis)
+	}
+
+	for _, address := range policyOrder {
+		if !attached[address] {
+			ia.reportPermissions(address, "policy", []sourcedPolicy{policies[address]}, analysis)
+		}
+	}
+}
+
+// linkedPrincipals retorna os principais do tipo informado referenciados
+// pelo atributo do recurso (pelo nome ou endereço) ou pelas suas
+// dependências (ex.: role = aws_iam_role.app.name)
+func linkedPrincipals(r models.TerraformResource, principals []*iamPrincipal, attr, kind string) []*iamPrincipal {
+	names := stringList(r.Attributes[attr])
+	linked := []*iamPrincipal{}
+	for _, p := range principals {
+		if p.kind != kind {
+			continue
+		}
+		if containsString(names, p.name) || containsString(names, p.address) || dependsOn(r, p.address) {
+			linked = append(linked, p)
+		}
+	}
+	return linked
+}
+
+// linkedPolicies retorna as políticas aws_iam_policy referenciadas pelo
+// anexo (policy_arn = aws_iam_policy.x.arn)
+func linkedPolicies(r models.TerraformResource, policies map[string]sourcedPolicy) []sourcedPolicy {
+	linked := []sourcedPolicy{}
+	for _, dep := range r.Dependencies {
+		for address, policy := range policies {
+			if stripIndexes(address) == dep {
+				linked = append(linked, policy)
+			}
+		}
+	}
+	return linked
+}
+
+// dependsOn indica se o recurso referencia o endereço informado
+func dependsOn(r models.TerraformResource, address string) bool {
+	target := stripIndexes(address)
+	for _, dep := range r.Dependencies {
+		if dep == target {
+			return true
+		}
+	}
+	return false
+}
+
+// reportPermissions avalia as políticas de um principal, registra as
+// permissões efetivas e adiciona os riscos encontrados
+func (ia *IAMAnalyzer) reportPermissions(principal, kind string, policies []sourcedPolicy, analysis *models.IAMAnalysis) {
+	permissions := evaluatePolicies(policies)
+
+	sources := []string{}
+	for _, policy := range policies {
+		if !containsString(sources, policy.Source) {
+			sources = append(sources, policy.Source)
+		}
+	}
+
+	entry := models.PrincipalPermissions{
+		Principal: principal,
+		Type:      kind,
+		Policies:  sources,
+		Actions:   permissions.actions(),
+	}
+
+	if permissions.fullAccess() {
+		entry.FullAccess = true
+		entry.Actions = []string{"*"}
+		analysis.AdminAccessDetected = true
+		analysis.PrincipalRisks = append(analysis.PrincipalRisks, models.PrincipalRisk{
+			CheckID:     "IAM_ADMIN_ACCESS",
+			Resource:    principal,
+			Principal:   principal,
+			Type:        kind,
+			RiskLevel:   "critical",
+			Permissions: []string{"*"},
+			Reason: fmt.Sprintf("Permissões efetivas equivalem a acesso administrativo (políticas: %s)",
+				strings.Join(sources, ", ")),
+		})
+		analysis.EffectivePermissions = append(analysis.EffectivePermissions, entry)
+		return
+	}
+	analysis.EffectivePermissions = append(analysis.EffectivePermissions, entry)
+
+	for _, path := range privilegeEscalationPaths {
+		if risk, ok := escalationRisk(path, permissions, principal, kind); ok {
+			analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
+		}
+	}
+}
+
+// escalationRisk verifica se as permissões permitem o caminho de escalada.
+// O risco é reduzido em um nível quando alguma ação só é concedida sob
+// condição ou apenas em recursos específicos.
+func escalationRisk(path escalationPath, permissions permissionSet, principal, kind string) (models.PrincipalRisk, bool) {
+	mitigations := []string{}
+	for _, action := range path.Actions {
+		grant := permissions.grant(action)
+		if grant == nil {
+			return models.PrincipalRisk{}, false
+		}
+		if grant.Conditional && !containsString(mitigations, "condição") {
+			mitigations = append(mitigations, "condição")
+		}
+		if !hasWildcardResource(grant.Resources) && !containsString(mitigations, "recursos específicos") {
+			mitigations = append(mitigations, "recursos específicos")
+		}
+	}
+
+	riskLevel := path.RiskLevel
+	reason := fmt.Sprintf("Escalada de privilégio (%s): %s. Concedido por: %s",
+		path.Name, path.Description, strings.Join(permissions.sources(path.Actions...), ", "))
+	if len(mitigations) > 0 {
+		riskLevel = lowerRiskLevel(riskLevel)
+		reason += fmt.Sprintf(" (limitado por %s)", strings.Join(mitigations, " e "))
+	}
+
+	return models.PrincipalRisk{
+		CheckID:     "IAM_PRIVILEGE_ESCALATION",
+		Resource:    principal,
+		Principal:   principal,
+		Type:        kind,
+		RiskLevel:   riskLevel,
+		Permissions: path.Actions,
+		Reason:      reason,
+	}, true
+}
+
+// hasWildcardResource indica se algum dos recursos contém wildcard
+func hasWildcardResource(resources []string) bool {
+	for _, resource := range resources {
+		if strings.Contains(resource, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerRiskLevel reduz o nível de risco em um degrau
+func lowerRiskLevel(level string) string {
+	switch level {
+	case "critical":
+		return "high"
+	case "high":
+		return "medium"
+	default:
+		return "low"
+	}
+}
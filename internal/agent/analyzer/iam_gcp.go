@@ -0,0 +1,236 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// gcpIAMResourcePattern reconhece os recursos IAM do provider google:
+// bindings, members e policies de qualquer recurso e papéis customizados
+var gcpIAMResourcePattern = regexp.MustCompile(`^google_\w+_iam_(binding|member|policy|custom_role)$`)
+
+// gcpHierarchyTypes são os níveis da hierarquia de recursos (papéis
+// primitivos só fazem sentido nesses níveis)
+var gcpHierarchyTypes = []string{"google_project", "google_folder", "google_organization"}
+
+// gcpPublicMemberLevels é o nível de risco de cada membro público (ver
+// gcpPublicMembers)
+var gcpPublicMemberLevels = map[string]string{
+	"allUsers":              "critical",
+	"allAuthenticatedUsers": "high",
+}
+
+// gcpPrimitiveRoles são os papéis básicos, que concedem acesso a todos os
+// serviços do projeto
+var gcpPrimitiveRoles = map[string]string{
+	"roles/owner":  "critical",
+	"roles/editor": "high",
+}
+
+// gcpKeyCreationRole permite criar chaves de qualquer service account
+const gcpKeyCreationRole = "roles/iam.serviceAccountKeyAdmin"
+
+// gcpBinding é um papel concedido a membros
+type gcpBinding struct {
+	Role    string
+	Members []string
+}
+
+// isGCPIAMResource verifica se é um recurso IAM do GCP
+func isGCPIAMResource(resourceType string) bool {
+	return resourceType == "google_service_account_key" || gcpIAMResourcePattern.MatchString(resourceType)
+}
+
+// analyzeGCPIAM analisa bindings, papéis customizados e chaves de service
+// account do GCP
+func (ia *IAMAnalyzer) analyzeGCPIAM(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	switch {
+	case resource.Type == "google_service_account_key":
+		ia.analyzeGCPServiceAccountKey(resource, analysis)
+		return
+	case strings.HasSuffix(resource.Type, "_iam_custom_role"):
+		analysis.TotalPolicies++
+		ia.analyzeGCPCustomRole(resource, analysis)
+		return
+	case strings.HasSuffix(resource.Type, "_iam_policy"):
+		analysis.TotalPolicies++
+	case ia.isIAMRole(resource.Type):
+		analysis.TotalRoles++
+	}
+
+	for _, binding := range gcpBindings(resource) {
+		for _, member := range binding.Members {
+			ia.analyzeGCPMember(resource, binding.Role, member, analysis)
+		}
+	}
+}
+
+// gcpBindings extrai os bindings de um recurso: role + members (binding),
+// role + member (member) ou policy_data (policy)
+func gcpBindings(resource models.TerraformResource) []gcpBinding {
+	role, _ := resource.Attributes["role"].(string)
+	switch {
+	case strings.HasSuffix(resource.Type, "_iam_binding"):
+		return []gcpBinding{{Role: role, Members: stringList(resource.Attributes["members"])}}
+	case strings.HasSuffix(resource.Type, "_iam_member"):
+		return []gcpBinding{{Role: role, Members: stringList(resource.Attributes["member"])}}
+	}
+
+	policyData, ok := resource.Attributes["policy_data"].(string)
+	if !ok {
+		return nil
+	}
+	var policy struct {
+		Bindings []struct {
+			Role    string   `json:"role"`
+			Members []string `json:"members"`
+		} `json:"bindings"`
+	}
+	if err := json.Unmarshal([]byte(policyData), &policy); err != nil {
+		return nil
+	}
+
+	bindings := []gcpBinding{}
+	for _, b := range policy.Bindings {
+		bindings = append(bindings, gcpBinding{Role: b.Role, Members: b.Members})
+	}
+	return bindings
+}
+
+// gcpMemberType retorna o tipo do membro (user, serviceAccount, group,
+// domain) ou "public" para allUsers/allAuthenticatedUsers
+func gcpMemberType(member string) string {
+	if _, ok := gcpPublicMemberLevels[member]; ok {
+		return "public"
+	}
+	if kind, _, ok := strings.Cut(member, ":"); ok {
+		return kind
+	}
+	return "member"
+}
+
+// isGCPHierarchyBinding indica se o binding é de projeto, pasta ou
+// organização
+func isGCPHierarchyBinding(resourceType string) bool {
+	for _, prefix := range gcpHierarchyTypes {
+		if strings.HasPrefix(resourceType, prefix+"_iam_") {
+			return true
+		}
+	}
+	return false
+}
+
+// analyzeGCPMember reporta membros públicos, papéis primitivos na
+// hierarquia e o papel que permite criar chaves de service account
+func (ia *IAMAnalyzer) analyzeGCPMember(resource models.TerraformResource, role, member string, analysis *models.IAMAnalysis) {
+	risk := models.PrincipalRisk{
+		Resource:    resource.Address,
+		Principal:   member,
+		Type:        gcpMemberType(member),
+		Permissions: []string{role},
+	}
+
+	if level, public := gcpPublicMemberLevels[member]; public {
+		analysis.PublicAccess = append(analysis.PublicAccess,
+			fmt.Sprintf("Recurso %s.%s concede %s a %s", resource.Type, resource.Name, role, member))
+
+		risk.CheckID = "GCP_PUBLIC_MEMBER"
+		risk.RiskLevel = level
+		risk.Reason = fmt.Sprintf("Papel %s concedido a %s", role, member)
+		if member == "allAuthenticatedUsers" {
+			risk.Reason += " (qualquer conta Google, não apenas da organização)"
+		}
+		analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
+		return
+	}
+
+	if level, primitive := gcpPrimitiveRoles[role]; primitive && isGCPHierarchyBinding(resource.Type) {
+		if role == "roles/owner" {
+			analysis.AdminAccessDetected = true
+		}
+		analysis.OverlyPermissive = true
+
+		risk.CheckID = "GCP_PRIMITIVE_ROLE"
+		risk.RiskLevel = level
+		risk.Reason = fmt.Sprintf("Papel básico %s concede acesso a todos os serviços; use papéis predefinidos ou customizados", role)
+		analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
+		return
+	}
+
+	if role == gcpKeyCreationRole {
+		risk.CheckID = "GCP_SERVICE_ACCOUNT_KEY_CREATION"
+		risk.RiskLevel = "high"
+		risk.Reason = fmt.Sprintf("Papel %s permite criar chaves de longa duração para service accounts", role)
+		analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
+	}
+}
+
+// analyzeGCPCustomRole reporta papéis customizados que permitem criar
+// chaves de service account ou alterar políticas IAM
+func (ia *IAMAnalyzer) analyzeGCPCustomRole(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	roleID, _ := resource.Attributes["role_id"].(string)
+	if roleID == "" {
+		roleID = resource.Name
+	}
+	permissions := stringList(resource.Attributes["permissions"])
+
+	keyCreation, setIAMPolicy := []string{}, []string{}
+	for _, permission := range permissions {
+		switch {
+		case permission == "iam.serviceAccountKeys.create":
+			keyCreation = append(keyCreation, permission)
+		case strings.HasSuffix(permission, ".setIamPolicy"):
+			setIAMPolicy = append(setIAMPolicy, permission)
+		}
+	}
+
+	if len(keyCreation) > 0 {
+		analysis.PrincipalRisks = append(analysis.PrincipalRisks, models.PrincipalRisk{
+			CheckID:     "GCP_SERVICE_ACCOUNT_KEY_CREATION",
+			Resource:    resource.Address,
+			Principal:   roleID,
+			Type:        "custom-role",
+			RiskLevel:   "high",
+			Permissions: keyCreation,
+			Reason:      "Papel customizado permite criar chaves de longa duração para service accounts",
+		})
+	}
+	if len(setIAMPolicy) > 0 {
+		analysis.PrincipalRisks = append(analysis.PrincipalRisks, models.PrincipalRisk{
+			CheckID:     "GCP_CUSTOM_ROLE_SET_IAM_POLICY",
+			Resource:    resource.Address,
+			Principal:   roleID,
+			Type:        "custom-role",
+			RiskLevel:   "high",
+			Permissions: setIAMPolicy,
+			Reason:      "Papel customizado permite alterar políticas IAM e conceder a si mesmo qualquer papel",
+		})
+	}
+}
+
+// analyzeGCPServiceAccountKey reporta chaves de service account criadas no
+// Terraform. Quando a chave privada é gerada pelo Google ela fica no state;
+// com public_key_data apenas a chave pública é enviada.
+func (ia *IAMAnalyzer) analyzeGCPServiceAccountKey(resource models.TerraformResource, analysis *models.IAMAnalysis) {
+	risk := models.PrincipalRisk{
+		CheckID:     "GCP_SERVICE_ACCOUNT_KEY",
+		Resource:    resource.Address,
+		Principal:   referencedPrincipal(resource, "service_account_id"),
+		Type:        "serviceAccount",
+		RiskLevel:   "high",
+		Permissions: []string{"iam.serviceAccountKeys.create"},
+		Reason:      "Chave de service account de longa duração com a chave privada armazenada no state; prefira Workload Identity Federation",
+	}
+
+	_, hasPublicKey := resource.Attributes["public_key_data"]
+	if hasPublicKey || containsString(resource.UnknownAttributes, "public_key_data") {
+		risk.RiskLevel = "medium"
+		risk.Reason = "Chave de service account de longa duração; prefira Workload Identity Federation"
+	}
+
+	analysis.PrincipalRisks = append(analysis.PrincipalRisks, risk)
+}
@@ -0,0 +1,85 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// managedPolicyARNPattern reconhece ARNs de políticas gerenciadas pela AWS
+// (arn:aws:iam::aws:policy/[caminho/]Nome), em qualquer partição
+var managedPolicyARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::aws:policy/(?:.*/)?([^/]+)$`)
+
+// allowStatement cria um statement Allow em todos os recursos
+func allowStatement(actions ...string) policyStatement {
+	return policyStatement{Effect: "Allow", Actions: actions, Resources: []string{"*"}}
+}
+
+// awsManagedPolicies é o catálogo embarcado das políticas gerenciadas pela
+// AWS mais usadas, resumidas às ações relevantes para a análise de
+// permissões. Políticas fora do catálogo são ignoradas.
+var awsManagedPolicies = map[string]*policyDocument{
+	"AdministratorAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("*"),
+	}},
+	"PowerUserAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		{Effect: "Allow", NotActions: []string{"iam:*", "organizations:*", "account:*"}, Resources: []string{"*"}},
+		allowStatement("iam:CreateServiceLinkedRole", "iam:DeleteServiceLinkedRole", "iam:ListRoles",
+			"organizations:DescribeOrganization", "account:ListRegions"),
+	}},
+	"IAMFullAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("iam:*", "organizations:DescribeAccount", "organizations:DescribeOrganization",
+			"organizations:DescribeOrganizationalUnit", "organizations:DescribePolicy",
+			"organizations:ListChildren", "organizations:ListParents",
+			"organizations:ListPoliciesForTarget", "organizations:ListRoots", "organizations:ListPolicies",
+			"organizations:ListTargetsForPolicy"),
+	}},
+	"ReadOnlyAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("*:Describe*", "*:Get*", "*:List*", "s3:GetObject"),
+	}},
+	"AmazonS3FullAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("s3:*", "s3-object-lambda:*"),
+	}},
+	"AmazonEC2FullAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("ec2:*", "elasticloadbalancing:*", "cloudwatch:*", "autoscaling:*"),
+		{Effect: "Allow", Actions: []string{"iam:CreateServiceLinkedRole"}, Resources: []string{"*"},
+			Condition: map[string]interface{}{"StringEquals": map[string]interface{}{
+				"iam:AWSServiceName": []interface{}{"autoscaling.amazonaws.com", "ec2scheduled.amazonaws.com",
+					"elasticloadbalancing.amazonaws.com", "spot.amazonaws.com"},
+			}}},
+	}},
+	"AWSLambda_FullAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("lambda:*", "cloudformation:DescribeStacks", "cloudwatch:GetMetricData",
+			"ec2:DescribeSecurityGroups", "iam:GetRole", "iam:GetPolicy", "iam:GetPolicyVersion",
+			"iam:GetRolePolicy", "iam:ListAttachedRolePolicies", "iam:ListRoles", "kms:DescribeKey",
+			"logs:DescribeLogGroups", "s3:ListAllMyBuckets"),
+		{Effect: "Allow", Actions: []string{"iam:PassRole"}, Resources: []string{"*"},
+			Condition: map[string]interface{}{"StringEquals": map[string]interface{}{
+				"iam:PassedToService": "lambda.amazonaws.com",
+			}}},
+	}},
+	"AmazonDynamoDBFullAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("dynamodb:*", "dax:*", "application-autoscaling:*", "cloudwatch:*",
+			"kms:DescribeKey", "kms:ListAliases", "sns:ListTopics", "lambda:ListFunctions"),
+	}},
+	"AWSCloudFormationFullAccess": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("cloudformation:*"),
+	}},
+	"SecretsManagerReadWrite": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("secretsmanager:*", "cloudformation:CreateChangeSet",
+			"cloudformation:DescribeStacks", "ec2:DescribeSecurityGroups", "kms:DescribeKey",
+			"kms:ListAliases", "lambda:ListFunctions", "rds:DescribeDBInstances", "tag:GetResources"),
+	}},
+	"AWSLambdaBasicExecutionRole": {Version: "2012-10-17", Statements: []policyStatement{
+		allowStatement("logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"),
+	}},
+}
+
+// managedPolicyDocument retorna o documento de uma política gerenciada pela
+// AWS a partir do ARN, ou nil se o ARN não for de uma política do catálogo
+func managedPolicyDocument(arn string) *policyDocument {
+	match := managedPolicyARNPattern.FindStringSubmatch(strings.TrimSpace(arn))
+	if match == nil {
+		return nil
+	}
+	return awsManagedPolicies[match[1]]
+}
@@ -0,0 +1,276 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// policyDocument é um documento de política IAM normalizado
+type policyDocument struct {
+	Version    string
+	Statements []policyStatement
+}
+
+// policyStatement é um statement com os campos que aceitam string ou lista
+// já convertidos em listas
+type policyStatement struct {
+	Sid          string
+	Effect       string
+	Actions      []string
+	NotActions   []string
+	Resources    []string
+	NotResources []string
+	Principal    interface{}
+	NotPrincipal interface{}
+	Condition    map[string]interface{}
+}
+
+// parsePolicyDocument faz o parse de um documento de política JSON.
+// Statement pode ser um objeto ou uma lista de objetos.
+func parsePolicyDocument(doc string) (*policyDocument, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &raw); err != nil {
+		return nil, err
+	}
+
+	document := &policyDocument{}
+	document.Version, _ = raw["Version"].(string)
+
+	var statements []interface{}
+	switch s := raw["Statement"].(type) {
+	case []interface{}:
+		statements = s
+	case map[string]interface{}:
+		statements = []interface{}{s}
+	case nil:
+	default:
+		return nil, fmt.Errorf("campo Statement inválido")
+	}
+
+	for _, item := range statements {
+		stmt, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		statement := policyStatement{
+			Actions:      stringList(stmt["Action"]),
+			NotActions:   stringList(stmt["NotAction"]),
+			Resources:    stringList(stmt["Resource"]),
+			NotResources: stringList(stmt["NotResource"]),
+			Principal:    stmt["Principal"],
+			NotPrincipal: stmt["NotPrincipal"],
+		}
+		statement.Sid, _ = stmt["Sid"].(string)
+		statement.Effect, _ = stmt["Effect"].(string)
+		statement.Condition, _ = stmt["Condition"].(map[string]interface{})
+		document.Statements = append(document.Statements, statement)
+	}
+
+	return document, nil
+}
+
+// stringList converte um valor string ou lista de strings em lista
+func stringList(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := []string{}
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// isAllow indica se o statement concede permissões
+func (s policyStatement) isAllow() bool {
+	return strings.EqualFold(s.Effect, "Allow")
+}
+
+// isDeny indica se o statement nega permissões
+func (s policyStatement) isDeny() bool {
+	return strings.EqualFold(s.Effect, "Deny")
+}
+
+// matchedActions expande Action, ou o complemento de NotAction, para as
+// ações do catálogo
+func (s policyStatement) matchedActions() []string {
+	if len(s.NotActions) > 0 {
+		return complementActions(s.NotActions)
+	}
+	return expandActions(s.Actions)
+}
+
+// grantedResources retorna os recursos cobertos por um Allow. NotResource
+// concede tudo exceto os recursos listados e por isso é tratado como "*".
+func (s policyStatement) grantedResources() []string {
+	if len(s.NotResources) > 0 || len(s.Resources) == 0 {
+		return []string{"*"}
+	}
+	return s.Resources
+}
+
+// sourcedPolicy é um documento de política e o recurso que o define
+type sourcedPolicy struct {
+	Source   string
+	Document *policyDocument
+}
+
+// actionGrant é uma ação permitida, os recursos em que ela vale e as
+// políticas que a concedem
+type actionGrant struct {
+	Action      string
+	Resources   []string
+	Conditional bool // só concedida por statements com Condition
+	Sources     []string
+}
+
+// permissionSet são as permissões efetivas, indexadas pela ação em
+// minúsculas
+type permissionSet map[string]*actionGrant
+
+// evaluatePolicies calcula as permissões efetivas de um conjunto de
+// políticas: une os Allow (expandindo wildcards e NotAction) e aplica os
+// Deny por cima, já que um Deny explícito sempre prevalece. Deny com
+// Condition pode não se aplicar e por isso não remove permissões.
+func evaluatePolicies(policies []sourcedPolicy) permissionSet {
+	permissions := make(permissionSet)
+
+	for _, policy := range policies {
+		for _, stmt := range policy.Document.Statements {
+			if !stmt.isAllow() {
+				continue
+			}
+			conditional := len(stmt.Condition) > 0
+			for _, action := range stmt.matchedActions() {
+				permissions.allow(action, stmt.grantedResources(), conditional, policy.Source)
+			}
+		}
+	}
+
+	for _, policy := range policies {
+		for _, stmt := range policy.Document.Statements {
+			if !stmt.isDeny() || len(stmt.Condition) > 0 {
+				continue
+			}
+			for _, action := range stmt.matchedActions() {
+				permissions.deny(action, stmt)
+			}
+		}
+	}
+
+	return permissions
+}
+
+// allow adiciona uma ação concedida
+func (ps permissionSet) allow(action string, resources []string, conditional bool, source string) {
+	key := strings.ToLower(action)
+	grant, ok := ps[key]
+	if !ok {
+		grant = &actionGrant{Action: action, Conditional: conditional}
+		ps[key] = grant
+	} else if !conditional {
+		grant.Conditional = false
+	}
+
+	for _, resource := range resources {
+		if !containsString(grant.Resources, resource) {
+			grant.Resources = append(grant.Resources, resource)
+		}
+	}
+	if source != "" && !containsString(grant.Sources, source) {
+		grant.Sources = append(grant.Sources, source)
+	}
+}
+
+// deny remove de uma ação os recursos cobertos pelo statement Deny. Um
+// Allow em "*" só é removido por um Deny em "*"; Deny com NotResource
+// nunca cobre todos os recursos e é ignorado.
+func (ps permissionSet) deny(action string, stmt policyStatement) {
+	key := strings.ToLower(action)
+	grant, ok := ps[key]
+	if !ok || len(stmt.NotResources) > 0 {
+		return
+	}
+
+	denied := stmt.Resources
+	if len(denied) == 0 {
+		denied = []string{"*"}
+	}
+
+	remaining := grant.Resources[:0]
+	for _, resource := range grant.Resources {
+		covered := false
+		for _, pattern := range denied {
+			if iamWildcardMatch(pattern, resource) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			remaining = append(remaining, resource)
+		}
+	}
+	grant.Resources = remaining
+
+	if len(grant.Resources) == 0 {
+		delete(ps, key)
+	}
+}
+
+// has indica se a ação é permitida
+func (ps permissionSet) has(action string) bool {
+	_, ok := ps[strings.ToLower(action)]
+	return ok
+}
+
+// grant retorna a concessão de uma ação
+func (ps permissionSet) grant(action string) *actionGrant {
+	return ps[strings.ToLower(action)]
+}
+
+// fullAccess indica se todas as ações do catálogo são permitidas sem
+// condição em qualquer recurso (equivalente a Action "*")
+func (ps permissionSet) fullAccess() bool {
+	for _, action := range catalogActions {
+		grant := ps.grant(action)
+		if grant == nil || grant.Conditional || !containsString(grant.Resources, "*") {
+			return false
+		}
+	}
+	return true
+}
+
+// actions retorna as ações permitidas em ordem alfabética
+func (ps permissionSet) actions() []string {
+	actions := make([]string, 0, len(ps))
+	for _, grant := range ps {
+		actions = append(actions, grant.Action)
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+// sources retorna as políticas que concedem as ações informadas
+func (ps permissionSet) sources(actions ...string) []string {
+	sources := []string{}
+	for _, action := range actions {
+		if grant := ps.grant(action); grant != nil {
+			for _, source := range grant.Sources {
+				if !containsString(sources, source) {
+					sources = append(sources, source)
+				}
+			}
+		}
+	}
+	sort.Strings(sources)
+	return sources
+}
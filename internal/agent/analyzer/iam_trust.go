@@ -0,0 +1,353 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// accountIDPattern extrai o ID da conta de um principal AWS (ID puro ou ARN)
+var accountIDPattern = regexp.MustCompile(`^(?:arn:aws[a-z-]*:(?:iam|sts)::)?(\d{12})(?::|$)`)
+
+// githubOIDCHost é o provedor OIDC do GitHub Actions
+const githubOIDCHost = "token.actions.githubusercontent.com"
+
+// strongTrustConditionKeys são as chaves de condição que restringem um
+// principal "*" a contas, organizações ou recursos específicos
+var strongTrustConditionKeys = []string{
+	"aws:PrincipalAccount",
+	"aws:PrincipalArn",
+	"aws:PrincipalOrgID",
+	"aws:PrincipalOrgPaths",
+	"aws:SourceAccount",
+	"aws:SourceArn",
+	"aws:SourceOrgID",
+	"aws:SourceOrgPaths",
+}
+
+// trustCondition é uma condição da trust policy: operador, chave e valores
+type trustCondition struct {
+	Operator string
+	Key      string
+	Values   []string
+}
+
+// restrictive indica se a condição de fato restringe quem assume a role:
+// operadores IfExists e ForAllValues passam quando a chave não existe,
+// operadores de negação e o valor "*" não restringem
+func (c trustCondition) restrictive() bool {
+	operator := strings.ToLower(c.Operator)
+	if strings.HasSuffix(operator, "ifexists") || strings.HasPrefix(operator, "forallvalues:") ||
+		strings.Contains(operator, "not") || operator == "null" {
+		return false
+	}
+	if len(c.Values) == 0 {
+		return false
+	}
+	for _, value := range c.Values {
+		if strings.Trim(value, "*") == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// trustConditions converte o bloco Condition de um statement em lista
+func trustConditions(condition map[string]interface{}) []trustCondition {
+	conditions := []trustCondition{}
+	for operator, keys := range condition {
+		entries, ok := keys.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, values := range entries {
+			list := stringList(values)
+			if list == nil {
+				list = []string{fmt.Sprintf("%v", values)}
+			}
+			conditions = append(conditions, trustCondition{Operator: operator, Key: key, Values: list})
+		}
+	}
+	sort.Slice(conditions, func(i, j int) bool {
+		if conditions[i].Key != conditions[j].Key {
+			return conditions[i].Key < conditions[j].Key
+		}
+		return conditions[i].Operator < conditions[j].Operator
+	})
+	return conditions
+}
+
+// findConditions retorna as condições com a chave informada
+func findConditions(conditions []trustCondition, key string) []trustCondition {
+	found := []trustCondition{}
+	for _, c := range conditions {
+		if strings.EqualFold(c.Key, key) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// hasRestrictiveCondition indica se alguma condição restritiva usa a chave
+func hasRestrictiveCondition(conditions []trustCondition, key string) bool {
+	for _, c := range findConditions(conditions, key) {
+		if c.restrictive() {
+			return true
+		}
+	}
+	return false
+}
+
+// trustPrincipals separa o Principal de um statement por tipo (AWS,
+// Service, Federated, CanonicalUser). Principal "*" vira AWS "*".
+func trustPrincipals(principal interface{}) map[string][]string {
+	principals := make(map[string][]string)
+	switch p := principal.(type) {
+	case string:
+		if p == "*" {
+			principals["AWS"] = []string{"*"}
+		}
+	case map[string]interface{}:
+		for kind, value := range p {
+			principals[kind] = stringList(value)
+		}
+	}
+	return principals
+}
+
+// principalAccount retorna o ID da conta de um principal AWS, ou "" se o
+// principal for uma referência a um recurso do próprio código
+func principalAccount(principal string) string {
+	if match := accountIDPattern.FindStringSubmatch(principal); match != nil {
+		return match[1]
+	}
+	return ""
+}
+
+// analyzeTrustPolicy analisa a trust policy (assume_role_policy) de uma
+// role: principais de outras contas, provedores federados, ExternalId e
+// principais "*" protegidos apenas por condições fracas
+func (ia *IAMAnalyzer) analyzeTrustPolicy(resource models.TerraformResource, doc *policyDocument, oidcProviders map[string]string, analysis *models.IAMAnalysis) {
+	for _, statement := range doc.Statements {
+		if !statement.isAllow() {
+			continue
+		}
+
+		conditions := trustConditions(statement.Condition)
+		principals := trustPrincipals(statement.Principal)
+		for _, kind := range []string{"AWS", "Federated", "Service"} {
+			for _, identifier := range principals[kind] {
+				var risk *models.PrincipalRisk
+				switch kind {
+				case "AWS":
+					risk = ia.awsPrincipalRisk(identifier, conditions)
+				case "Federated":
+					risk = federatedPrincipalRisk(identifier, conditions, oidcProviders)
+				case "Service":
+					if ia.isRiskyService(identifier) {
+						risk = &models.PrincipalRisk{
+							CheckID:   "IAM_SERVICE_TRUST",
+							Principal: identifier,
+							Type:      "service",
+							RiskLevel: "medium",
+							Reason:    fmt.Sprintf("Serviço %s pode assumir esta role", identifier),
+						}
+					}
+				}
+				if risk == nil {
+					continue
+				}
+
+				risk.Resource = resource.Address
+				risk.Permissions = statement.Actions
+				analysis.PrincipalRisks = append(analysis.PrincipalRisks, *risk)
+			}
+		}
+	}
+}
+
+// awsPrincipalRisk avalia um principal AWS: "*" exige condições que
+// restrinjam conta, organização ou origem; contas fora da lista de contas
+// confiáveis exigem sts:ExternalId
+func (ia *IAMAnalyzer) awsPrincipalRisk(principal string, conditions []trustCondition) *models.PrincipalRisk {
+	if principal == "*" {
+		for _, key := range strongTrustConditionKeys {
+			if hasRestrictiveCondition(conditions, key) {
+				return nil
+			}
+		}
+
+		if len(conditions) == 0 {
+			return &models.PrincipalRisk{
+				CheckID:   "IAM_TRUST_PUBLIC",
+				Principal: "*",
+				Type:      "public",
+				RiskLevel: "critical",
+				Reason:    "Qualquer principal AWS, de qualquer conta, pode assumir esta role",
+			}
+		}
+
+		keys := []string{}
+		for _, c := range conditions {
+			if !containsString(keys, c.Key) {
+				keys = append(keys, c.Key)
+			}
+		}
+		return &models.PrincipalRisk{
+			CheckID:   "IAM_TRUST_WILDCARD_WEAK_CONDITION",
+			Principal: "*",
+			Type:      "public",
+			RiskLevel: "high",
+			Reason: fmt.Sprintf("Principal * protegido apenas por condições fracas (%s); restrinja com aws:PrincipalOrgID, aws:PrincipalAccount ou aws:SourceArn",
+				strings.Join(keys, ", ")),
+		}
+	}
+
+	account := principalAccount(principal)
+	if account == "" || containsString(ia.trustedAccounts, account) {
+		return nil
+	}
+
+	if !hasRestrictiveCondition(conditions, "sts:ExternalId") {
+		return &models.PrincipalRisk{
+			CheckID:   "IAM_TRUST_MISSING_EXTERNAL_ID",
+			Principal: principal,
+			Type:      "cross-account",
+			RiskLevel: "high",
+			Reason: fmt.Sprintf("Conta externa %s pode assumir esta role sem sts:ExternalId (risco de confused deputy)",
+				account),
+		}
+	}
+
+	return &models.PrincipalRisk{
+		CheckID:   "IAM_TRUST_CROSS_ACCOUNT",
+		Principal: principal,
+		Type:      "cross-account",
+		RiskLevel: "medium",
+		Reason:    fmt.Sprintf("Conta %s não está na lista de contas confiáveis da organização", account),
+	}
+}
+
+// federatedPrincipalRisk avalia um provedor federado. Para OIDC é
+// obrigatória uma condição em <provedor>:sub que limite quais identidades
+// (ex.: repositórios do GitHub Actions) podem assumir a role.
+func federatedPrincipalRisk(principal string, conditions []trustCondition, oidcProviders map[string]string) *models.PrincipalRisk {
+	host := oidcProviderHost(principal, oidcProviders)
+	if host == "" {
+		// Provedor não resolvido (ex.: ARN desconhecido): usa o prefixo das
+		// chaves de condição, como token.actions.githubusercontent.com:sub
+		for _, c := range conditions {
+			if prefix, _, ok := strings.Cut(c.Key, ":"); ok && strings.Contains(prefix, ".") {
+				host = prefix
+				break
+			}
+		}
+	}
+	if host == "" {
+		if strings.Contains(principal, ":saml-provider/") || len(conditions) > 0 {
+			return nil
+		}
+		return &models.PrincipalRisk{
+			CheckID:   "IAM_TRUST_OIDC_NO_SUBJECT",
+			Principal: principal,
+			Type:      "federated",
+			RiskLevel: "high",
+			Reason:    "Provedor federado pode assumir esta role sem nenhuma condição sobre a identidade (sub/aud)",
+		}
+	}
+
+	github := host == githubOIDCHost
+	if host == "cognito-identity.amazonaws.com" {
+		if hasRestrictiveCondition(conditions, host+":aud") {
+			return nil
+		}
+		return &models.PrincipalRisk{
+			CheckID:   "IAM_TRUST_OIDC_NO_AUDIENCE",
+			Principal: principal,
+			Type:      "federated",
+			RiskLevel: "high",
+			Reason:    "Qualquer identity pool do Cognito pode assumir esta role: falta condição em cognito-identity.amazonaws.com:aud",
+		}
+	}
+
+	subs := findConditions(conditions, host+":sub")
+	if !hasRestrictiveCondition(conditions, host+":sub") {
+		level := "high"
+		reason := fmt.Sprintf("Qualquer identidade do provedor OIDC %s pode assumir esta role: falta condição em %s:sub", host, host)
+		if github {
+			level = "critical"
+			reason = fmt.Sprintf("Qualquer repositório do GitHub Actions pode assumir esta role: falta condição em %s:sub", host)
+		}
+		return &models.PrincipalRisk{
+			CheckID:   "IAM_TRUST_OIDC_NO_SUBJECT",
+			Principal: principal,
+			Type:      "federated",
+			RiskLevel: level,
+			Reason:    reason,
+		}
+	}
+
+	if github {
+		for _, c := range subs {
+			for _, value := range c.Values {
+				if broadGitHubSubject(value) {
+					return &models.PrincipalRisk{
+						CheckID:   "IAM_TRUST_OIDC_BROAD_SUBJECT",
+						Principal: principal,
+						Type:      "federated",
+						RiskLevel: "high",
+						Reason: fmt.Sprintf("Condição %s:sub = %q permite repositórios de qualquer organização do GitHub",
+							host, value),
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// broadGitHubSubject indica se o padrão de sub do GitHub Actions não fixa a
+// organização (ex.: "repo:*" ou "*:ref:refs/heads/main")
+func broadGitHubSubject(value string) bool {
+	owner, _, _ := strings.Cut(strings.TrimPrefix(value, "repo:"), "/")
+	return !strings.HasPrefix(value, "repo:") || owner == "" || strings.ContainsAny(owner, "*?")
+}
+
+// oidcProviderHost retorna o host do provedor OIDC referenciado pelo
+// principal Federated, pelo ARN (oidc-provider/<host>) ou pela referência a
+// um aws_iam_openid_connect_provider do próprio código
+func oidcProviderHost(principal string, oidcProviders map[string]string) string {
+	if _, provider, ok := strings.Cut(principal, ":oidc-provider/"); ok {
+		return strings.SplitN(provider, "/", 2)[0]
+	}
+	if strings.HasSuffix(principal, ".amazonaws.com") && strings.HasPrefix(principal, "cognito-identity") {
+		return principal
+	}
+
+	ref := strings.TrimSuffix(strings.TrimPrefix(principal, "${"), "}")
+	for address, url := range oidcProviders {
+		if ref == address+".arn" || ref == address+".id" {
+			return strings.SplitN(strings.TrimPrefix(url, "https://"), "/", 2)[0]
+		}
+	}
+	return ""
+}
+
+// openIDConnectProviders indexa as URLs dos aws_iam_openid_connect_provider
+// pelo endereço sem índices
+func openIDConnectProviders(resources []models.TerraformResource) map[string]string {
+	providers := make(map[string]string)
+	for _, r := range resources {
+		if r.Type != "aws_iam_openid_connect_provider" {
+			continue
+		}
+		if url, ok := r.Attributes["url"].(string); ok {
+			providers[stripIndexes(resourceAddress(r))] = url
+		}
+	}
+	return providers
+}
@@ -0,0 +1,280 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+// taggableTypes são os tipos de recurso que devem ter tags mesmo quando a
+// configuração não define nenhuma
+var taggableTypes = map[string]bool{
+	"aws_instance":            true,
+	"aws_s3_bucket":           true,
+	"aws_vpc":                 true,
+	"aws_subnet":              true,
+	"aws_security_group":      true,
+	"aws_db_instance":         true,
+	"aws_lambda_function":     true,
+	"azurerm_resource_group":  true,
+	"azurerm_virtual_machine": true,
+	"google_compute_instance": true,
+	"google_storage_bucket":   true,
+}
+
+// isTaggable verifica se um recurso aceita tags: tipos conhecidos ou
+// recursos que já declaram tags/labels
+func isTaggable(r *models.TerraformResource) bool {
+	if taggableTypes[r.Type] || len(r.Tags) > 0 {
+		return true
+	}
+	for _, attr := range []string{"tags", "labels"} {
+		if _, ok := r.Attributes[attr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// KnowledgeAnalyzer aplica as regras do conhecimento de um agente
+// (AgentKnowledge): regras regex sobre o conteúdo dos arquivos, tipos de
+// recurso banidos, tags obrigatórias e módulos preferidos
+type KnowledgeAnalyzer struct {
+	logger *logger.Logger
+}
+
+// NewKnowledgeAnalyzer cria um novo analisador de conhecimento do agente
+func NewKnowledgeAnalyzer(log *logger.Logger) *KnowledgeAnalyzer {
+	return &KnowledgeAnalyzer{logger: log}
+}
+
+// Analyze avalia o conhecimento do agente contra a análise e o conteúdo dos
+// arquivos (caminho -> conteúdo). Retorna os findings das regras e as dicas
+// de módulos preferidos.
+func (ka *KnowledgeAnalyzer) Analyze(knowledge *models.AgentKnowledge, tfAnalysis *models.TerraformAnalysis, files map[string]string) (*models.SecurityAnalysis, []string) {
+	result := &models.SecurityAnalysis{
+		Findings: []models.SecurityFinding{},
+	}
+	if knowledge == nil {
+		return result, []string{}
+	}
+
+	ka.checkCustomRules(knowledge.CustomRules, tfAnalysis, files, result)
+	ka.checkBannedResources(knowledge.BannedResources, tfAnalysis, result)
+	ka.checkRequiredTags(knowledge.RequiredTags, tfAnalysis, result)
+	hints := preferredModuleHints(knowledge.PreferredModules, tfAnalysis)
+
+	countSeverities(result)
+
+	ka.logger.Info("Regras do agente aplicadas",
+		"custom_rules", len(knowledge.CustomRules),
+		"findings", len(result.Findings),
+		"module_hints", len(hints))
+
+	return result, hints
+}
+
+// checkCustomRules aplica as regras regex habilitadas a cada arquivo. O
+// finding aponta a linha do match e o recurso declarado nela, se houver.
+func (ka *KnowledgeAnalyzer) checkCustomRules(rules []models.CustomRule, tfAnalysis *models.TerraformAnalysis, files map[string]string, result *models.SecurityAnalysis) {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for i, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			ka.logger.Warn("Regra customizada com padrão inválido", "rule", rule.ID, "error", err)
+			continue
+		}
+
+		checkID := rule.ID
+		if checkID == "" {
+			checkID = fmt.Sprintf("AGENT_CUSTOM_%d", i+1)
+		}
+		name := rule.Name
+		if name == "" {
+			name = rule.Message
+		}
+		message := rule.Message
+		if message == "" {
+			message = rule.Description
+		}
+
+		matched := false
+		for _, filename := range filenames {
+			content := files[filename]
+			for _, loc := range pattern.FindAllStringIndex(content, -1) {
+				matched = true
+				line := strings.Count(content[:loc[0]], "\n") + 1
+				result.Findings = append(result.Findings, models.SecurityFinding{
+					ID:          fmt.Sprintf("%s-%d", checkID, len(result.Findings)+1),
+					CheckID:     checkID,
+					CheckName:   name,
+					Severity:    strings.ToUpper(rule.Severity),
+					Resource:    resourceAtLine(tfAnalysis, filename, line),
+					File:        filename,
+					Line:        line,
+					Description: message,
+					Guideline:   rule.Suggestion,
+				})
+			}
+		}
+
+		if matched {
+			result.ChecksFailed++
+		} else {
+			result.ChecksPassed++
+		}
+	}
+}
+
+// checkBannedResources reporta recursos cujo tipo casa com um dos padrões
+// banidos (ex.: aws_iam_user ou aws_default_*)
+func (ka *KnowledgeAnalyzer) checkBannedResources(banned []string, tfAnalysis *models.TerraformAnalysis, result *models.SecurityAnalysis) {
+	if len(banned) == 0 {
+		return
+	}
+
+	for _, r := range tfAnalysis.Resources {
+		if !matchesAny(banned, r.Type) {
+			result.ChecksPassed++
+			continue
+		}
+		result.ChecksFailed++
+		result.Findings = append(result.Findings, models.SecurityFinding{
+			ID:          fmt.Sprintf("AGENT_BANNED_RESOURCE-%d", len(result.Findings)+1),
+			CheckID:     "AGENT_BANNED_RESOURCE",
+			CheckName:   "Tipo de recurso banido",
+			Severity:    "HIGH",
+			Resource:    StackAddress(r.Stack, r.Address),
+			File:        r.File,
+			Line:        r.LineStart,
+			Description: fmt.Sprintf("O tipo de recurso %s não é permitido por este agente", r.Type),
+			Guideline:   "Remova o recurso ou use a alternativa aprovada pela organização",
+		})
+	}
+}
+
+// checkRequiredTags verifica as tags obrigatórias em cada recurso que aceita
+// tags. Recursos com tags só conhecidas após o apply não são avaliados.
+func (ka *KnowledgeAnalyzer) checkRequiredTags(required []string, tfAnalysis *models.TerraformAnalysis, result *models.SecurityAnalysis) {
+	if len(required) == 0 {
+		return
+	}
+
+	for i := range tfAnalysis.Resources {
+		r := &tfAnalysis.Resources[i]
+		if !isTaggable(r) || hasUnknownAttribute(r, []string{"tags", "labels"}) {
+			continue
+		}
+
+		missing := []string{}
+		for _, key := range required {
+			if _, ok := r.Tags[key]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) == 0 {
+			result.ChecksPassed++
+			continue
+		}
+
+		result.ChecksFailed++
+		result.Findings = append(result.Findings, models.SecurityFinding{
+			ID:          fmt.Sprintf("AGENT_REQUIRED_TAGS-%d", len(result.Findings)+1),
+			CheckID:     "AGENT_REQUIRED_TAGS",
+			CheckName:   "Tags obrigatórias",
+			Severity:    "MEDIUM",
+			Resource:    StackAddress(r.Stack, r.Address),
+			File:        r.File,
+			Line:        r.LineStart,
+			Description: fmt.Sprintf("Recurso %s sem as tags obrigatórias: %s", r.Address, strings.Join(missing, ", ")),
+			Guideline:   fmt.Sprintf("Adicione as tags %s (ou use default_tags no provider)", strings.Join(missing, ", ")),
+		})
+	}
+}
+
+// preferredModuleHints sugere os módulos preferidos do agente. Um módulo do
+// registry namespace/nome/provider cobre o tipo provider_nome (ex.:
+// terraform-aws-modules/vpc/aws cobre aws_vpc); recursos desse tipo
+// declarados diretamente no módulo raiz e módulos de outro namespace com o
+// mesmo nome recebem uma dica.
+func preferredModuleHints(preferred []string, tfAnalysis *models.TerraformAnalysis) []string {
+	hints := []string{}
+	for _, source := range preferred {
+		parts := strings.Split(strings.Split(source, "//")[0], "/")
+		if len(parts) != 3 {
+			continue
+		}
+		name, provider := parts[1], parts[2]
+		resourceType := provider + "_" + strings.ReplaceAll(name, "-", "_")
+
+		for _, r := range tfAnalysis.Resources {
+			if r.Module == "" && r.Type == resourceType {
+				hints = append(hints, fmt.Sprintf("Recurso %s pode usar o módulo preferido %s", r.Address, source))
+			}
+		}
+
+		for _, m := range tfAnalysis.Modules {
+			other := strings.Split(strings.Split(m.Source, "//")[0], "/")
+			if len(other) == 3 && other[1] == name && other[2] == provider && other[0] != parts[0] {
+				hints = append(hints, fmt.Sprintf("Módulo %s (%s) pode ser substituído pelo módulo preferido %s", m.Name, m.Source, source))
+			}
+		}
+	}
+	return hints
+}
+
+// resourceAtLine retorna o endereço do recurso declarado na linha do arquivo,
+// qualificado com o stack quando a análise tem vários
+func resourceAtLine(tfAnalysis *models.TerraformAnalysis, filename string, line int) string {
+	for _, r := range tfAnalysis.Resources {
+		if r.File == filename && line >= r.LineStart && line <= r.LineEnd {
+			return StackAddress(r.Stack, r.Address)
+		}
+	}
+	return ""
+}
+
+// ReadTerraformFiles lê os arquivos .tf e .tf.json de um diretório e seus
+// subdiretórios, ignorando os mesmos diretórios da análise
+func ReadTerraformFiles(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && isSkippedDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isConfigFile(path) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("erro ao ler arquivo %s: %w", path, err)
+		}
+		files[path] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
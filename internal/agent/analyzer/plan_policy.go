@@ -0,0 +1,276 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// planPolicyActions são as ações aceitas nas regras; destroy é sinônimo de delete
+var planPolicyActions = map[string]string{
+	"create":  "create",
+	"update":  "update",
+	"delete":  "delete",
+	"destroy": "delete",
+	"replace": "replace",
+	"read":    "read",
+}
+
+// planPolicyTagAttributes são os atributos onde as tags de um recurso são procuradas
+var planPolicyTagAttributes = []string{"tags", "tags_all", "labels"}
+
+// LoadPlanPolicy lê um arquivo de política de plan em YAML ou JSON
+func LoadPlanPolicy(policyPath string) (*models.PlanPolicy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler política de plan: %w", err)
+	}
+	return ParsePlanPolicy(data)
+}
+
+// ParsePlanPolicy faz o parsing e valida uma política de plan
+func ParsePlanPolicy(data []byte) (*models.PlanPolicy, error) {
+	var policy models.PlanPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse da política de plan: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		if rule.ID == "" {
+			return nil, fmt.Errorf("regra %d da política de plan sem id", i+1)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("regra %s duplicada na política de plan", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if rule.Effect != "deny" && rule.Effect != "warn" {
+			return nil, fmt.Errorf("regra %s: effect %q inválido (use deny ou warn)", rule.ID, rule.Effect)
+		}
+
+		for j, action := range rule.Actions {
+			normalized, ok := planPolicyActions[action]
+			if !ok {
+				return nil, fmt.Errorf("regra %s: ação %q inválida", rule.ID, action)
+			}
+			rule.Actions[j] = normalized
+		}
+
+		patterns := append(append(append([]string{}, rule.ResourceTypes...), rule.Addresses...), rule.Workspaces...)
+		for _, value := range rule.Tags {
+			patterns = append(patterns, value)
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("regra %s: padrão %q inválido", rule.ID, pattern)
+			}
+		}
+	}
+
+	return &policy, nil
+}
+
+// SetPolicy define a política avaliada em cada análise de plan
+func (pa *PreviewAnalyzer) SetPolicy(policy *models.PlanPolicy) {
+	pa.policy = policy
+}
+
+// evaluatePolicy avalia as regras da política contra as mudanças do plan.
+// changes deve estar na mesma ordem de plan.ResourceChanges.
+func (pa *PreviewAnalyzer) evaluatePolicy(plan *models.TerraformPlan, changes []models.PlannedChange, workspace string) *models.PlanPolicyEvaluation {
+	evaluation := &models.PlanPolicyEvaluation{
+		Decision: "pass",
+		Results:  []models.PlanPolicyResult{},
+	}
+
+	for _, rule := range pa.policy.Rules {
+		result := models.PlanPolicyResult{
+			RuleID:      rule.ID,
+			Description: rule.Description,
+			Status:      "pass",
+		}
+
+		// Regras restritas a workspaces não se aplicam a outros workspaces:
+		// são reportadas como ignoradas, não como aprovadas. Sem workspace
+		// informado, regras deny são avaliadas mesmo assim (omitir o
+		// workspace não pode contornar um bloqueio) e regras warn são
+		// ignoradas.
+		unscoped := false
+		if len(rule.Workspaces) > 0 {
+			switch {
+			case workspace == "" && rule.Effect == "deny":
+				unscoped = true
+			case workspace == "":
+				result.Status = "skipped"
+				result.Message = fmt.Sprintf("Regra restrita aos workspaces %s; nenhum workspace informado",
+					strings.Join(rule.Workspaces, ", "))
+			case !matchesAny(rule.Workspaces, workspace):
+				result.Status = "skipped"
+				result.Message = fmt.Sprintf("Regra restrita aos workspaces %s; não se aplica ao workspace %s",
+					strings.Join(rule.Workspaces, ", "), workspace)
+			}
+			if result.Status == "skipped" {
+				evaluation.Results = append(evaluation.Results, result)
+				continue
+			}
+		}
+
+		for i := range plan.ResourceChanges {
+			rc := &plan.ResourceChanges[i]
+			if matchesPlanRule(&rule, rc, changes[i].Changes) {
+				result.Resources = append(result.Resources, rc.Address)
+			}
+		}
+
+		if len(result.Resources) > 0 {
+			result.Status = rule.Effect
+			result.Message = rule.Message
+			if result.Message == "" {
+				result.Message = rule.Description
+			}
+			if unscoped {
+				result.Message = fmt.Sprintf("%s (regra restrita aos workspaces %s avaliada porque nenhum workspace foi informado)",
+					result.Message, strings.Join(rule.Workspaces, ", "))
+			}
+
+			if rule.Effect == "deny" {
+				evaluation.Blocked = true
+				evaluation.Decision = "deny"
+			} else if evaluation.Decision == "pass" {
+				evaluation.Decision = "warn"
+			}
+		}
+
+		evaluation.Results = append(evaluation.Results, result)
+	}
+
+	return evaluation
+}
+
+// policyWarnings converte as violações da política em avisos de risco
+func (pa *PreviewAnalyzer) policyWarnings(evaluation *models.PlanPolicyEvaluation, changes []models.PlannedChange) []models.RiskWarning {
+	actions := make(map[string]string, len(changes))
+	for _, change := range changes {
+		actions[change.Resource] = change.Action
+	}
+
+	warnings := []models.RiskWarning{}
+	for _, result := range evaluation.Results {
+		severity := "medium"
+		if result.Status == "deny" {
+			severity = "critical"
+		}
+		for _, resource := range result.Resources {
+			warnings = append(warnings, models.RiskWarning{
+				Severity: severity,
+				Resource: resource,
+				Message:  fmt.Sprintf("Policy %s (%s): %s", result.RuleID, result.Status, result.Message),
+				Action:   actions[resource],
+			})
+		}
+	}
+	return warnings
+}
+
+// matchesPlanRule verifica se uma mudança satisfaz todos os critérios da regra
+func matchesPlanRule(rule *models.PlanPolicyRule, rc *models.TerraformResourceChange, changes map[string]models.AttributeChange) bool {
+	if rc.Mode == "data" {
+		return false
+	}
+
+	action := planAction(rc.Change.Actions)
+	if action == "no-op" {
+		return false
+	}
+	if len(rule.Actions) > 0 && !containsAction(rule.Actions, action) {
+		return false
+	}
+	if len(rule.ResourceTypes) > 0 && !matchesAny(rule.ResourceTypes, rc.Type) {
+		return false
+	}
+	if len(rule.Addresses) > 0 && !matchesAny(rule.Addresses, rc.Address) {
+		return false
+	}
+	if len(rule.Tags) > 0 && !hasTags(rc.Change.Before, rule.Tags) && !hasTags(rc.Change.After, rule.Tags) {
+		return false
+	}
+	if len(rule.Attributes) > 0 && !changesAttributes(changes, rule.Attributes) {
+		return false
+	}
+	return true
+}
+
+// planAction resume as ações de uma mudança: delete seguido de create (ou o
+// inverso, com create_before_destroy) é uma substituição
+func planAction(actions []string) string {
+	if len(actions) == 2 && containsAction(actions, "delete") && containsAction(actions, "create") {
+		return "replace"
+	}
+	return strings.Join(actions, ",")
+}
+
+// containsAction verifica se uma ação está na lista
+func containsAction(actions []string, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny verifica se value casa com algum dos padrões glob
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTags verifica se os valores de um recurso possuem todas as tags exigidas
+func hasTags(values map[string]interface{}, required map[string]string) bool {
+	if values == nil {
+		return false
+	}
+
+	for key, pattern := range required {
+		found := false
+		for _, attr := range planPolicyTagAttributes {
+			tags, ok := values[attr].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if value, ok := tags[key]; ok && value != nil {
+				if matched, _ := path.Match(pattern, scalarString(value)); matched {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// changesAttributes verifica se algum caminho alterado está em attributes
+func changesAttributes(changes map[string]models.AttributeChange, attributes []string) bool {
+	for changed := range changes {
+		for _, attr := range attributes {
+			if isPathWithin(changed, attr) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -11,6 +11,12 @@ import (
 
 type PreviewAnalyzer struct {
 	logger *logger.Logger
+	policy *models.PlanPolicy
+}
+
+// PreviewOptions são opções da análise de um plan
+type PreviewOptions struct {
+	Workspace string // workspace em que o plan foi gerado, usado pelas políticas
 }
 
 func NewPreviewAnalyzer(log *logger.Logger) *PreviewAnalyzer {
@@ -19,6 +25,12 @@ func NewPreviewAnalyzer(log *logger.Logger) *PreviewAnalyzer {
 
 // AnalyzePreview analisa resultado de terraform plan em formato JSON
 func (pa *PreviewAnalyzer) AnalyzePreview(planJSON []byte) (*models.PreviewAnalysis, error) {
+	return pa.AnalyzePreviewWithOptions(planJSON, PreviewOptions{})
+}
+
+// AnalyzePreviewWithOptions analisa um plan em JSON e, se houver política
+// configurada, avalia suas regras no workspace informado
+func (pa *PreviewAnalyzer) AnalyzePreviewWithOptions(planJSON []byte, opts PreviewOptions) (*models.PreviewAnalysis, error) {
 	var plan models.TerraformPlan
 	if err := json.Unmarshal(planJSON, &plan); err != nil {
 		return nil, fmt.Errorf("invalid terraform plan JSON: %w", err)
@@ -32,23 +44,27 @@ func (pa *PreviewAnalyzer) AnalyzePreview(planJSON []byte) (*models.PreviewAnaly
 		RiskLevel:         "low",
 	}
 
+	// Grafo de dependências para o blast radius
+	graph := newPlanGraph(planJSON)
+
 	// Analisa resource_changes
 	for _, rc := range plan.ResourceChanges {
 		change := pa.analyzeResourceChange(&rc)
+		if action := planAction(rc.Change.Actions); action == "delete" || action == "replace" {
+			change.Dependents = graph.dependentResources(rc.Address)
+		}
 		analysis.PlannedChanges = append(analysis.PlannedChanges, change)
 
-		// Contabiliza ações
-		for _, action := range rc.Change.Actions {
-			switch action {
-			case "create":
-				analysis.CreateCount++
-			case "update":
-				analysis.UpdateCount++
-			case "destroy":
-				analysis.DestroyCount++
-			case "replace":
-				analysis.ReplaceCount++
-			}
+		// Contabiliza ações; delete+create conta apenas como replace
+		switch planAction(rc.Change.Actions) {
+		case "create":
+			analysis.CreateCount++
+		case "update":
+			analysis.UpdateCount++
+		case "delete", "destroy":
+			analysis.DestroyCount++
+		case "replace":
+			analysis.ReplaceCount++
 		}
 	}
 
@@ -58,6 +74,17 @@ func (pa *PreviewAnalyzer) AnalyzePreview(planJSON []byte) (*models.PreviewAnaly
 	// Detecta mudanças arriscadas
 	analysis.RiskWarnings = pa.detectRiskyChanges(analysis.PlannedChanges)
 
+	// Avalia a política de plan
+	if pa.policy != nil {
+		analysis.Policy = pa.evaluatePolicy(&plan, analysis.PlannedChanges, opts.Workspace)
+		analysis.RiskWarnings = append(analysis.RiskWarnings, pa.policyWarnings(analysis.Policy, analysis.PlannedChanges)...)
+		for _, result := range analysis.Policy.Results {
+			if result.Status == "deny" {
+				analysis.Errors = append(analysis.Errors, fmt.Sprintf("Plan bloqueado pela regra %s: %s", result.RuleID, result.Message))
+			}
+		}
+	}
+
 	return analysis, nil
 }
 
@@ -66,16 +93,11 @@ func (pa *PreviewAnalyzer) analyzeResourceChange(rc *models.TerraformResourceCha
 	change := models.PlannedChange{
 		Resource:  rc.Address,
 		Action:    strings.Join(rc.Change.Actions, ","),
-		Changes:   make(map[string]interface{}),
+		Changes:   pa.compareResourceStates(rc),
 		RiskScore: pa.calculateResourceRiskScore(rc),
 		Impact:    pa.determineImpact(rc),
 	}
 
-	// Analisa mudanças específicas
-	if rc.Change.Before != nil && rc.Change.After != nil {
-		change.Changes = pa.compareResourceStates(rc.Change.Before, rc.Change.After)
-	}
-
 	return change
 }
 
@@ -86,7 +108,7 @@ func (pa *PreviewAnalyzer) calculateResourceRiskScore(rc *models.TerraformResour
 	// Ações de alto risco
 	for _, action := range rc.Change.Actions {
 		switch action {
-		case "destroy":
+		case "delete", "destroy":
 			score += 50
 		case "replace":
 			score += 30
@@ -119,7 +141,7 @@ func (pa *PreviewAnalyzer) calculateResourceRiskScore(rc *models.TerraformResour
 func (pa *PreviewAnalyzer) determineImpact(rc *models.TerraformResourceChange) string {
 	for _, action := range rc.Change.Actions {
 		switch action {
-		case "destroy":
+		case "delete", "destroy":
 			if pa.isCriticalResource(rc.Type) {
 				return "critical"
 			}
@@ -141,35 +163,23 @@ func (pa *PreviewAnalyzer) determineImpact(rc *models.TerraformResourceChange) s
 	return "unknown"
 }
 
-// compareResourceStates compara estados antes e depois
-func (pa *PreviewAnalyzer) compareResourceStates(before, after map[string]interface{}) map[string]interface{} {
-	changes := make(map[string]interface{})
-
-	// Compara campos comuns
-	commonFields := []string{"name", "instance_type", "size", "count", "tags"}
-	for _, field := range commonFields {
-		if beforeVal, beforeExists := before[field]; beforeExists {
-			if afterVal, afterExists := after[field]; afterExists {
-				if beforeVal != afterVal {
-					changes[field] = map[string]interface{}{
-						"before": beforeVal,
-						"after":  afterVal,
-					}
-				}
-			}
-		}
-	}
-
-	return changes
+// compareResourceStates compara estados antes e depois, retornando cada
+// caminho alterado. Valores sensíveis são omitidos e valores conhecidos só
+// após o apply são marcados como unknown.
+func (pa *PreviewAnalyzer) compareResourceStates(rc *models.TerraformResourceChange) map[string]models.AttributeChange {
+	return diffResourceChange(rc)
 }
 
 // detectRiskyChanges identifica mudanças de alto risco
 func (pa *PreviewAnalyzer) detectRiskyChanges(changes []models.PlannedChange) []models.RiskWarning {
 	warnings := []models.RiskWarning{}
+	blastRadius := make(map[string][]string)
 
 	for _, change := range changes {
+		action := planAction(strings.Split(change.Action, ","))
+
 		// Destruição de banco de dados
-		if pa.isDatabaseResource(change.Resource) && strings.Contains(change.Action, "destroy") {
+		if pa.isDatabaseResource(change.Resource) && (action == "delete" || action == "destroy") {
 			warnings = append(warnings, models.RiskWarning{
 				Severity: "critical",
 				Resource: change.Resource,
@@ -179,7 +189,7 @@ func (pa *PreviewAnalyzer) detectRiskyChanges(changes []models.PlannedChange) []
 		}
 
 		// Replace de recursos stateful
-		if pa.isStatefulResourceFromAddress(change.Resource) && strings.Contains(change.Action, "replace") {
+		if pa.isStatefulResourceFromAddress(change.Resource) && action == "replace" {
 			warnings = append(warnings, models.RiskWarning{
 				Severity: "high",
 				Resource: change.Resource,
@@ -207,6 +217,26 @@ func (pa *PreviewAnalyzer) detectRiskyChanges(changes []models.PlannedChange) []
 				Action:   change.Action,
 			})
 		}
+
+		// Recursos que dependem de um recurso destruído ou substituído
+		if len(change.Dependents) > 0 {
+			blastRadius[change.Resource] = change.Dependents
+			severity := "high"
+			if len(change.Dependents) >= blastRadiusHigh {
+				severity = "critical"
+			}
+			warnings = append(warnings, models.RiskWarning{
+				Severity: severity,
+				Resource: change.Resource,
+				Message:  fmt.Sprintf("%d dependent resource(s) may be affected by this %s", len(change.Dependents), action),
+				Action:   change.Action,
+			})
+		}
+	}
+
+	// Todo aviso de um recurso com dependentes carrega seu blast radius
+	for i := range warnings {
+		warnings[i].BlastRadius = blastRadius[warnings[i].Resource]
 	}
 
 	return warnings
@@ -216,12 +246,19 @@ func (pa *PreviewAnalyzer) detectRiskyChanges(changes []models.PlannedChange) []
 func (pa *PreviewAnalyzer) calculateRiskLevel(analysis *models.PreviewAnalysis) string {
 	totalRisk := 0
 	criticalCount := 0
+	maxBlastRadius := 0
 
 	for _, change := range analysis.PlannedChanges {
 		totalRisk += change.RiskScore
 		if change.RiskScore > 50 {
 			criticalCount++
 		}
+
+		// Cada dependente de um destroy/replace também está em risco
+		totalRisk += len(change.Dependents) * blastRadiusWeight
+		if len(change.Dependents) > maxBlastRadius {
+			maxBlastRadius = len(change.Dependents)
+		}
 	}
 
 	// Muitas mudanças críticas
@@ -229,6 +266,11 @@ func (pa *PreviewAnalyzer) calculateRiskLevel(analysis *models.PreviewAnalysis)
 		return "critical"
 	}
 
+	// Um único destroy/replace que afeta muitos recursos
+	if maxBlastRadius >= blastRadiusHigh {
+		return "high"
+	}
+
 	// Score total muito alto
 	if totalRisk > 200 {
 		return "high"
@@ -0,0 +1,260 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+const (
+	// blastRadiusWeight é o risco somado por recurso dependente de um destroy/replace
+	blastRadiusWeight = 5
+	// blastRadiusHigh é o número de dependentes a partir do qual o risco é alto
+	blastRadiusHigh = 10
+)
+
+// planGraphInput são as seções do plan usadas para montar o grafo de dependências
+type planGraphInput struct {
+	Configuration struct {
+		RootModule planConfigModule `json:"root_module"`
+	} `json:"configuration"`
+	PriorState *showState `json:"prior_state"`
+}
+
+// planConfigModule é um módulo na seção configuration do plan. Os endereços
+// e referências são relativos ao módulo.
+type planConfigModule struct {
+	Resources []struct {
+		Address           string                 `json:"address"`
+		Expressions       map[string]interface{} `json:"expressions"`
+		CountExpression   interface{}            `json:"count_expression"`
+		ForEachExpression interface{}            `json:"for_each_expression"`
+		DependsOn         []string               `json:"depends_on"`
+	} `json:"resources"`
+	Outputs map[string]struct {
+		Expression interface{} `json:"expression"`
+		DependsOn  []string    `json:"depends_on"`
+	} `json:"outputs"`
+	ModuleCalls map[string]struct {
+		Expressions       map[string]interface{} `json:"expressions"`
+		CountExpression   interface{}            `json:"count_expression"`
+		ForEachExpression interface{}            `json:"for_each_expression"`
+		DependsOn         []string               `json:"depends_on"`
+		Module            planConfigModule       `json:"module"`
+	} `json:"module_calls"`
+}
+
+// planGraph é o grafo de dependências entre os objetos do plan. Os nós são
+// endereços absolutos sem índices de instância: recursos
+// (module.network.aws_vpc.main), variáveis (module.network.var.cidr),
+// outputs (module.network.output.vpc_id) e chamadas de módulo
+// (module.network).
+type planGraph struct {
+	deps       map[string]map[string]bool // nó -> nós dos quais depende
+	dependents map[string][]string        // nó -> nós que dependem dele
+	resources  map[string]bool
+}
+
+// newPlanGraph monta o grafo a partir da configuration e do prior_state do
+// plan. Planos sem essas seções resultam em um grafo vazio.
+func newPlanGraph(planJSON []byte) *planGraph {
+	g := &planGraph{
+		deps:      make(map[string]map[string]bool),
+		resources: make(map[string]bool),
+	}
+
+	var input planGraphInput
+	if err := json.Unmarshal(planJSON, &input); err != nil {
+		return g
+	}
+
+	g.addConfigModule(input.Configuration.RootModule, "", "")
+
+	// O state guarda as dependências efetivas, inclusive as que passam por locals
+	if input.PriorState != nil && input.PriorState.Values != nil {
+		var visit func(m showModule)
+		visit = func(m showModule) {
+			for _, r := range m.Resources {
+				node := stripIndexes(r.Address)
+				g.resources[node] = true
+				for _, dep := range r.DependsOn {
+					g.addEdge(node, stripIndexes(dep))
+				}
+			}
+			for _, child := range m.ChildModules {
+				visit(child)
+			}
+		}
+		visit(input.PriorState.Values.RootModule)
+	}
+
+	g.dependents = make(map[string][]string)
+	for node, deps := range g.deps {
+		for dep := range deps {
+			g.dependents[dep] = append(g.dependents[dep], node)
+		}
+	}
+
+	return g
+}
+
+// addConfigModule adiciona ao grafo os recursos, outputs e chamadas de um
+// módulo. prefix é o endereço do módulo ("" na raiz, "module.a." nos filhos)
+// e call é o nó da chamada que o instanciou.
+func (g *planGraph) addConfigModule(m planConfigModule, prefix, call string) {
+	for _, r := range m.Resources {
+		node := prefix + r.Address
+		g.resources[node] = true
+		g.addReferences(node, prefix, r.Expressions, r.CountExpression, r.ForEachExpression)
+		g.addDependsOn(node, prefix, r.DependsOn)
+		if call != "" {
+			g.addEdge(node, call)
+		}
+
+		// Os nós module.x.* de todos os módulos ancestrais contêm o recurso
+		parts := strings.Split(strings.TrimSuffix(prefix, "."), ".")
+		for i := 2; i <= len(parts) && prefix != ""; i += 2 {
+			g.addEdge(strings.Join(parts[:i], ".")+".*", node)
+		}
+	}
+
+	for name, output := range m.Outputs {
+		node := prefix + "output." + name
+		g.addReferences(node, prefix, output.Expression)
+		g.addDependsOn(node, prefix, output.DependsOn)
+	}
+
+	for name, mc := range m.ModuleCalls {
+		callNode := prefix + "module." + name
+		childPrefix := callNode + "."
+
+		// count, for_each e depends_on da chamada afetam todo o módulo filho
+		g.addReferences(callNode, prefix, mc.CountExpression, mc.ForEachExpression)
+		g.addDependsOn(callNode, prefix, mc.DependsOn)
+		if call != "" {
+			g.addEdge(callNode, call)
+		}
+
+		// Cada argumento da chamada alimenta uma variável do módulo filho
+		for arg, expr := range mc.Expressions {
+			g.addReferences(childPrefix+"var."+arg, prefix, expr)
+		}
+
+		g.addConfigModule(mc.Module, childPrefix, callNode)
+	}
+}
+
+// addReferences adiciona arestas de node para cada referência das expressões
+func (g *planGraph) addReferences(node, prefix string, exprs ...interface{}) {
+	for _, expr := range exprs {
+		for _, ref := range collectReferences(expr) {
+			g.addEdge(node, resolvePlanReference(prefix, ref))
+		}
+	}
+}
+
+// addDependsOn adiciona arestas para os itens de depends_on. Um módulo
+// inteiro (module.x) é representado pelo nó module.x.*, que depende de todos
+// os recursos do módulo.
+func (g *planGraph) addDependsOn(node, prefix string, dependsOn []string) {
+	for _, dep := range dependsOn {
+		dep = stripIndexes(dep)
+		if parts := strings.Split(dep, "."); len(parts) == 2 && parts[0] == "module" {
+			g.addEdge(node, prefix+dep+".*")
+			continue
+		}
+		g.addEdge(node, resolvePlanReference(prefix, dep))
+	}
+}
+
+// addEdge registra que from depende de to
+func (g *planGraph) addEdge(from, to string) {
+	if to == "" || from == to {
+		return
+	}
+	if g.deps[from] == nil {
+		g.deps[from] = make(map[string]bool)
+	}
+	g.deps[from][to] = true
+}
+
+// dependentResources retorna os recursos que dependem, direta ou
+// transitivamente, do recurso no endereço informado
+func (g *planGraph) dependentResources(address string) []string {
+	start := stripIndexes(address)
+	visited := map[string]bool{start: true}
+	queue := []string{start}
+	result := []string{}
+
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, dependent := range g.dependents[node] {
+			if visited[dependent] {
+				continue
+			}
+			visited[dependent] = true
+			queue = append(queue, dependent)
+			if g.resources[dependent] {
+				result = append(result, dependent)
+			}
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// collectReferences percorre uma expressão da configuration do plan e
+// retorna todas as listas "references" encontradas
+func collectReferences(expr interface{}) []string {
+	refs := []string{}
+	switch v := expr.(type) {
+	case map[string]interface{}:
+		if list, ok := v["references"].([]interface{}); ok {
+			for _, ref := range list {
+				if s, ok := ref.(string); ok {
+					refs = append(refs, s)
+				}
+			}
+		}
+		for key, child := range v {
+			if key != "references" {
+				refs = append(refs, collectReferences(child)...)
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			refs = append(refs, collectReferences(child)...)
+		}
+	}
+	return refs
+}
+
+// resolvePlanReference converte uma referência relativa ao módulo, ex.:
+// aws_vpc.main.id, var.cidr ou module.db.endpoint, no nó absoluto do grafo.
+// Referências a locals, count, each, path e self não geram nós.
+func resolvePlanReference(prefix, ref string) string {
+	parts := strings.Split(stripIndexes(ref), ".")
+	switch parts[0] {
+	case "local", "each", "count", "path", "self", "terraform":
+		return ""
+	case "var":
+		if len(parts) >= 2 {
+			return prefix + "var." + parts[1]
+		}
+	case "module":
+		if len(parts) >= 3 {
+			return prefix + "module." + parts[1] + ".output." + parts[2]
+		}
+	case "data":
+		if len(parts) >= 3 {
+			return prefix + "data." + parts[1] + "." + parts[2]
+		}
+	default:
+		if len(parts) >= 2 {
+			return prefix + parts[0] + "." + parts[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// planDiffer calcula a diferença estrutural entre before e after de uma
+// mudança do plan, respeitando after_unknown, before_sensitive,
+// after_sensitive e replace_paths
+type planDiffer struct {
+	changes      map[string]models.AttributeChange
+	replacePaths []string
+}
+
+// diffResourceChange retorna todos os caminhos alterados de uma mudança
+func diffResourceChange(rc *models.TerraformResourceChange) map[string]models.AttributeChange {
+	d := &planDiffer{
+		changes:      make(map[string]models.AttributeChange),
+		replacePaths: planPaths(rc.Change.ReplacePaths),
+	}
+
+	// Mapas nil viram nil de fato para não serem tratados como objetos vazios
+	var before, after interface{}
+	if rc.Change.Before != nil {
+		before = rc.Change.Before
+	}
+	if rc.Change.After != nil {
+		after = rc.Change.After
+	}
+
+	d.diff("", before, after, rc.Change.AfterUnknown, rc.Change.BeforeSensitive, rc.Change.AfterSensitive)
+	return d.changes
+}
+
+// diff compara recursivamente before e after em path. unknown, beforeSens e
+// afterSens são os nós correspondentes de after_unknown, before_sensitive e
+// after_sensitive: true marca o caminho e tudo abaixo dele.
+func (d *planDiffer) diff(path string, before, after, unknown, beforeSens, afterSens interface{}) {
+	if isTrue(unknown) {
+		change := models.AttributeChange{Before: before, Unknown: true}
+		if isTrue(beforeSens) {
+			change.Before = nil
+			change.Sensitive = true
+		}
+		d.add(path, change)
+		return
+	}
+
+	if isTrue(beforeSens) || isTrue(afterSens) {
+		if !reflect.DeepEqual(before, after) {
+			d.add(path, models.AttributeChange{Sensitive: true})
+		}
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+
+	switch {
+	case (beforeIsMap || before == nil) && (afterIsMap || after == nil) && (beforeIsMap || afterIsMap):
+		keys := make(map[string]bool)
+		for k := range beforeMap {
+			keys[k] = true
+		}
+		for k := range afterMap {
+			keys[k] = true
+		}
+		if unknownMap, ok := unknown.(map[string]interface{}); ok {
+			for k := range unknownMap {
+				keys[k] = true
+			}
+		}
+
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		for _, k := range sorted {
+			d.diff(joinPath(path, k), beforeMap[k], afterMap[k],
+				metaChild(unknown, k), metaChild(beforeSens, k), metaChild(afterSens, k))
+		}
+
+	case (beforeIsList || before == nil) && (afterIsList || after == nil) && (beforeIsList || afterIsList):
+		n := len(beforeList)
+		if len(afterList) > n {
+			n = len(afterList)
+		}
+		if unknownList, ok := unknown.([]interface{}); ok && len(unknownList) > n {
+			n = len(unknownList)
+		}
+
+		for i := 0; i < n; i++ {
+			var b, a interface{}
+			if i < len(beforeList) {
+				b = beforeList[i]
+			}
+			if i < len(afterList) {
+				a = afterList[i]
+			}
+			d.diff(fmt.Sprintf("%s[%d]", path, i), b, a,
+				metaChild(unknown, i), metaChild(beforeSens, i), metaChild(afterSens, i))
+		}
+
+	default:
+		if !reflect.DeepEqual(before, after) {
+			d.add(path, models.AttributeChange{Before: before, After: after})
+		}
+	}
+}
+
+// add registra uma mudança, marcando se ela força a substituição do recurso
+func (d *planDiffer) add(path string, change models.AttributeChange) {
+	if path == "" {
+		return
+	}
+	for _, rp := range d.replacePaths {
+		if isPathWithin(path, rp) || isPathWithin(rp, path) {
+			change.ForcesReplacement = true
+			break
+		}
+	}
+	d.changes[path] = change
+}
+
+// metaChild retorna o nó de after_unknown/sensitive correspondente a uma
+// chave ou índice. Um true propaga para todos os descendentes.
+func metaChild(meta interface{}, step interface{}) interface{} {
+	if isTrue(meta) {
+		return true
+	}
+	switch s := step.(type) {
+	case string:
+		if m, ok := meta.(map[string]interface{}); ok {
+			return m[s]
+		}
+	case int:
+		if l, ok := meta.([]interface{}); ok && s < len(l) {
+			return l[s]
+		}
+	}
+	return nil
+}
+
+// isTrue verifica se um nó de metadados é o booleano true
+func isTrue(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+// planPaths converte os replace_paths do plan, ex.: [["ebs_block_device", 0,
+// "volume_size"]], para a mesma notação usada nas mudanças
+func planPaths(paths [][]interface{}) []string {
+	result := []string{}
+	for _, steps := range paths {
+		path := ""
+		for _, step := range steps {
+			switch s := step.(type) {
+			case string:
+				path = joinPath(path, s)
+			case float64:
+				path = fmt.Sprintf("%s[%d]", path, int(s))
+			}
+		}
+		if path != "" {
+			result = append(result, path)
+		}
+	}
+	return result
+}
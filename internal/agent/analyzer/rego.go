@@ -0,0 +1,388 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+// regoEvalTimeout é o tempo máximo de avaliação de cada política Rego
+const regoEvalTimeout = 30 * time.Second
+
+// regoDefaultSeverity é a severidade dos resultados sem severidade definida
+var regoDefaultSeverity = map[string]string{
+	"deny": "HIGH",
+	"warn": "LOW",
+}
+
+// RegoAnalyzer avalia políticas Rego customizadas com o OPA embarcado. Cada
+// pacote que define regras deny ou warn é uma política; a entrada é o
+// AnalysisDetails serializado (input.terraform.resources, input.iam, ...).
+type RegoAnalyzer struct {
+	policies []*regoPolicy
+	logger   *logger.Logger
+}
+
+// regoPolicy é um pacote Rego compilado e seus metadados
+type regoPolicy struct {
+	pkg         string // ex.: data.iac.s3_tags
+	query       rego.PreparedEvalQuery
+	id          string
+	title       string
+	description string
+	severity    string
+	remediation string
+	references  []string
+}
+
+// NewRegoAnalyzer cria um analisador sem políticas carregadas
+func NewRegoAnalyzer(log *logger.Logger) *RegoAnalyzer {
+	return &RegoAnalyzer{
+		logger:   log,
+		policies: []*regoPolicy{},
+	}
+}
+
+// LoadDirectory compila os arquivos .rego do diretório (recursivamente,
+// ignorando testes *_test.rego) e substitui as políticas carregadas.
+//
+// Metadados vêm das anotações METADATA de escopo package: title,
+// description, related_resources e custom (id, severity, remediation).
+func (ra *RegoAnalyzer) LoadDirectory(dir string) error {
+	modules := make(map[string]*ast.Module)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != ".rego" || strings.HasSuffix(path, "_test.rego") {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("erro ao ler política %s: %w", path, err)
+		}
+		module, err := ast.ParseModuleWithOpts(path, string(content), ast.ParserOptions{ProcessAnnotation: true})
+		if err != nil {
+			return fmt.Errorf("erro ao fazer parse da política %s: %w", path, err)
+		}
+		modules[path] = module
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return ra.compile(modules)
+}
+
+// LoadModules compila políticas informadas como arquivo -> conteúdo
+func (ra *RegoAnalyzer) LoadModules(sources map[string]string) error {
+	modules := make(map[string]*ast.Module, len(sources))
+	for filename, content := range sources {
+		module, err := ast.ParseModuleWithOpts(filename, content, ast.ParserOptions{ProcessAnnotation: true})
+		if err != nil {
+			return fmt.Errorf("erro ao fazer parse da política %s: %w", filename, err)
+		}
+		modules[filename] = module
+	}
+	return ra.compile(modules)
+}
+
+// compile compila os módulos juntos (políticas podem importar bibliotecas
+// comuns) e prepara uma consulta por pacote com regras deny ou warn
+func (ra *RegoAnalyzer) compile(modules map[string]*ast.Module) error {
+	compiler := ast.NewCompiler()
+	compiler.Compile(modules)
+	if compiler.Failed() {
+		return fmt.Errorf("erro ao compilar políticas Rego: %v", compiler.Errors)
+	}
+
+	// Agrupa módulos por pacote; o mesmo pacote pode estar em vários arquivos
+	packages := make(map[string]*regoPolicy)
+	filenames := make([]string, 0, len(modules))
+	for filename := range modules {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		module := modules[filename]
+		pkg := module.Package.Path.String()
+
+		evaluated := false
+		for _, rule := range module.Rules {
+			if name := rule.Head.Ref().String(); name == "deny" || name == "warn" {
+				evaluated = true
+				break
+			}
+		}
+
+		policy, ok := packages[pkg]
+		if !ok && !evaluated {
+			continue
+		}
+		if !ok {
+			policy = &regoPolicy{pkg: pkg, id: strings.TrimPrefix(pkg, "data.")}
+			packages[pkg] = policy
+		}
+		policy.applyAnnotations(module)
+	}
+
+	policies := make([]*regoPolicy, 0, len(packages))
+	for _, policy := range packages {
+		query, err := rego.New(
+			rego.Compiler(compiler),
+			rego.Query(policy.pkg),
+		).PrepareForEval(context.Background())
+		if err != nil {
+			return fmt.Errorf("erro ao preparar política %s: %w", policy.id, err)
+		}
+		policy.query = query
+		policies = append(policies, policy)
+	}
+	sort.Slice(policies, func(i, j int) bool { return policies[i].id < policies[j].id })
+
+	ra.policies = policies
+	ra.logger.Info("Políticas Rego carregadas", "policies", len(policies), "modules", len(modules))
+	return nil
+}
+
+// applyAnnotations lê os metadados das anotações de escopo package
+func (p *regoPolicy) applyAnnotations(module *ast.Module) {
+	for _, annotation := range module.Annotations {
+		if annotation.Scope != "package" {
+			continue
+		}
+		if annotation.Title != "" {
+			p.title = annotation.Title
+		}
+		if annotation.Description != "" {
+			p.description = annotation.Description
+		}
+		for _, related := range annotation.RelatedResources {
+			p.references = append(p.references, related.Ref.String())
+		}
+		if id, ok := annotation.Custom["id"].(string); ok && id != "" {
+			p.id = id
+		}
+		if severity, ok := annotation.Custom["severity"].(string); ok {
+			p.severity = strings.ToUpper(severity)
+		}
+		if remediation, ok := annotation.Custom["remediation"].(string); ok {
+			p.remediation = remediation
+		}
+	}
+}
+
+// PolicyCount retorna o número de políticas carregadas
+func (ra *RegoAnalyzer) PolicyCount() int {
+	return len(ra.policies)
+}
+
+// Evaluate avalia as políticas contra a análise sem prazo além do tempo
+// limite de cada política (ver EvaluateContext)
+func (ra *RegoAnalyzer) Evaluate(details *models.AnalysisDetails) ([]models.SecurityFinding, int, error) {
+	return ra.EvaluateContext(context.Background(), details)
+}
+
+// EvaluateContext avalia as políticas contra a análise dentro do contexto da
+// requisição. Cada resultado de deny ou warn vira um finding; o resultado
+// pode ser uma mensagem ou um objeto com msg, resource, stack, severity, file
+// e line. Uma política que falha ou excede o tempo limite vira um finding
+// de erro, para que a falha não passe como aprovação. Retorna também o
+// número de políticas sem violações.
+func (ra *RegoAnalyzer) EvaluateContext(ctx context.Context, details *models.AnalysisDetails) ([]models.SecurityFinding, int, error) {
+	findings := []models.SecurityFinding{}
+	if len(ra.policies) == 0 {
+		return findings, 0, nil
+	}
+
+	input, err := regoInput(details)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Localização dos recursos para resultados que informam apenas o
+	// endereço. Com vários stacks o endereço qualificado (ver StackAddress)
+	// sempre é localizado; o endereço simples só quando existe em um único
+	// stack (nil marca endereços ambíguos).
+	locations := make(map[string]*models.TerraformResource, len(details.Terraform.Resources))
+	for i := range details.Terraform.Resources {
+		r := &details.Terraform.Resources[i]
+		locations[StackAddress(r.Stack, r.Address)] = r
+		if r.Stack == "" {
+			continue
+		}
+		if _, seen := locations[r.Address]; seen {
+			locations[r.Address] = nil
+		} else {
+			locations[r.Address] = r
+		}
+	}
+
+	passed := 0
+	for _, policy := range ra.policies {
+		// Uma requisição cancelada ou expirada não avalia as demais políticas
+		var results rego.ResultSet
+		err := ctx.Err()
+		if err == nil {
+			evalCtx, cancel := context.WithTimeout(ctx, regoEvalTimeout)
+			results, err = policy.query.Eval(evalCtx, rego.EvalInput(input))
+			cancel()
+		}
+		if err != nil {
+			ra.logger.Warn("Erro ao avaliar política Rego", "policy", policy.id, "error", err)
+			findings = append(findings, policy.errorFinding(err, len(findings)+1))
+			continue
+		}
+
+		document := map[string]interface{}{}
+		if len(results) > 0 && len(results[0].Expressions) > 0 {
+			document, _ = results[0].Expressions[0].Value.(map[string]interface{})
+		}
+
+		before := len(findings)
+		for _, kind := range []string{"deny", "warn"} {
+			for _, result := range regoResults(document[kind]) {
+				findings = append(findings, policy.finding(kind, result, locations, len(findings)+1))
+			}
+		}
+		if len(findings) == before {
+			passed++
+		}
+	}
+
+	ra.logger.Info("Políticas Rego avaliadas",
+		"policies", len(ra.policies),
+		"passed", passed,
+		"findings", len(findings))
+
+	return findings, passed, nil
+}
+
+// finding converte um resultado de deny/warn em SecurityFinding
+func (p *regoPolicy) finding(kind string, result map[string]interface{}, locations map[string]*models.TerraformResource, n int) models.SecurityFinding {
+	finding := models.SecurityFinding{
+		ID:          fmt.Sprintf("%s-%d", p.id, n),
+		CheckID:     p.id,
+		CheckName:   p.title,
+		Severity:    p.severity,
+		Description: p.description,
+		Guideline:   p.remediation,
+		References:  p.references,
+	}
+	if finding.CheckName == "" {
+		finding.CheckName = p.id
+	}
+
+	if msg, ok := result["msg"].(string); ok && msg != "" {
+		finding.Description = msg
+	}
+	if severity, ok := result["severity"].(string); ok && severity != "" {
+		finding.Severity = strings.ToUpper(severity)
+	}
+	if finding.Severity == "" {
+		finding.Severity = regoDefaultSeverity[kind]
+	}
+	if resource, ok := result["resource"].(string); ok {
+		if stack, ok := result["stack"].(string); ok && stack != "" {
+			if qualified, _ := splitStackAddress(resource); qualified == "" {
+				resource = StackAddress(stack, resource)
+			}
+		}
+		finding.Resource = resource
+		if r := locations[resource]; r != nil {
+			finding.Resource = StackAddress(r.Stack, r.Address)
+			finding.File = r.File
+			finding.Line = r.LineStart
+		}
+	}
+	if file, ok := result["file"].(string); ok && file != "" {
+		finding.File = file
+	}
+	if line, ok := result["line"].(json.Number); ok {
+		if value, err := line.Int64(); err == nil {
+			finding.Line = int(value)
+		}
+	}
+
+	return finding
+}
+
+// errorFinding registra como finding a falha na avaliação da política
+// (erro de execução ou tempo limite excedido)
+func (p *regoPolicy) errorFinding(err error, n int) models.SecurityFinding {
+	name := p.title
+	if name == "" {
+		name = p.id
+	}
+	return models.SecurityFinding{
+		ID:          fmt.Sprintf("%s-%d", p.id, n),
+		CheckID:     p.id,
+		CheckName:   name,
+		Severity:    regoDefaultSeverity["deny"],
+		Description: fmt.Sprintf("Falha ao avaliar a política %s; o resultado não pôde ser verificado: %v", p.id, err),
+		Guideline:   "Corrija a política ou reduza o custo da avaliação e execute a análise novamente",
+		References:  p.references,
+	}
+}
+
+// regoResults normaliza os resultados de uma regra deny/warn em objetos;
+// mensagens simples viram {"msg": mensagem}
+func regoResults(value interface{}) []map[string]interface{} {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	results := []map[string]interface{}{}
+	for _, item := range items {
+		switch v := item.(type) {
+		case map[string]interface{}:
+			results = append(results, v)
+		case string:
+			results = append(results, map[string]interface{}{"msg": v})
+		default:
+			results = append(results, map[string]interface{}{"msg": fmt.Sprint(v)})
+		}
+	}
+	return results
+}
+
+// regoInput converte a análise na entrada das políticas usando os nomes
+// dos campos JSON
+func regoInput(details *models.AnalysisDetails) (interface{}, error) {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao serializar entrada das políticas: %w", err)
+	}
+
+	var input interface{}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&input); err != nil {
+		return nil, fmt.Errorf("erro ao montar entrada das políticas: %w", err)
+	}
+	return input, nil
+}
+
+// MergeFindings adiciona findings externos (ex.: de políticas Rego) à
+// análise de segurança, recalculando os totais
+func MergeFindings(security *models.SecurityAnalysis, findings []models.SecurityFinding, passed int) {
+	security.Findings = append(security.Findings, findings...)
+	security.ChecksFailed += len(findings)
+	security.ChecksPassed += passed
+	countSeverities(security)
+}
@@ -0,0 +1,731 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+// ScannerParser normaliza a saída JSON de um scanner em findings
+type ScannerParser interface {
+	// Name é o identificador do scanner (ex.: tfsec)
+	Name() string
+	// Detect indica se a saída tem o formato do scanner
+	Detect(data []byte) bool
+	// Parse converte a saída em uma análise de segurança
+	Parse(data []byte) (*models.SecurityAnalysis, error)
+}
+
+// ScannerImporter importa resultados de scanners externos (Checkov, tfsec,
+// Trivy, KICS, Terrascan) e combina os findings das várias ferramentas,
+// removendo os que apontam o mesmo problema no mesmo recurso
+type ScannerImporter struct {
+	parsers []ScannerParser
+	rules   map[string]string // ID do scanner (maiúsculo) -> regra canônica
+	logger  *logger.Logger
+}
+
+// NewScannerImporter cria um importador com os scanners suportados
+func NewScannerImporter(log *logger.Logger) *ScannerImporter {
+	si := &ScannerImporter{
+		rules:  make(map[string]string),
+		logger: log,
+	}
+
+	si.Register(&checkovParser{checkov: &CheckovAnalyzer{logger: log}})
+	si.Register(tfsecParser{})
+	si.Register(trivyParser{})
+	si.Register(kicsParser{})
+	si.Register(terrascanParser{})
+
+	for canonical, ids := range defaultRuleMappings {
+		si.MapRules(canonical, ids...)
+	}
+
+	return si
+}
+
+// Register adiciona um scanner; um scanner com o mesmo nome é substituído
+func (si *ScannerImporter) Register(parser ScannerParser) {
+	for i, existing := range si.parsers {
+		if existing.Name() == parser.Name() {
+			si.parsers[i] = parser
+			return
+		}
+	}
+	si.parsers = append(si.parsers, parser)
+}
+
+// MapRules declara que os IDs de scanners informados verificam o mesmo
+// problema que a regra canônica, para a deduplicação entre ferramentas
+func (si *ScannerImporter) MapRules(canonical string, ids ...string) {
+	canonical = strings.ToUpper(canonical)
+	si.rules[canonical] = canonical
+	for _, id := range ids {
+		si.rules[strings.ToUpper(id)] = canonical
+	}
+}
+
+// CanonicalRule retorna a regra canônica de um ID de scanner, ou o próprio
+// ID quando não há mapeamento
+func (si *ScannerImporter) CanonicalRule(id string) string {
+	if canonical, ok := si.rules[strings.ToUpper(id)]; ok {
+		return canonical
+	}
+	return strings.ToUpper(id)
+}
+
+// Import normaliza a saída de um scanner. Com tool vazio, o scanner é
+// detectado pelo formato da saída.
+func (si *ScannerImporter) Import(tool string, data []byte) (*models.SecurityAnalysis, error) {
+	parser, err := si.parserFor(tool, data)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis, err := parser.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao importar resultado %s: %w", parser.Name(), err)
+	}
+
+	for i := range analysis.Findings {
+		if len(analysis.Findings[i].Sources) == 0 {
+			analysis.Findings[i].Sources = []string{parser.Name()}
+		}
+	}
+
+	si.logger.Info("Resultado de scanner importado",
+		"tool", parser.Name(),
+		"findings", len(analysis.Findings),
+		"passed", analysis.ChecksPassed)
+
+	return analysis, nil
+}
+
+// parserFor localiza o scanner pelo nome ou pelo formato da saída
+func (si *ScannerImporter) parserFor(tool string, data []byte) (ScannerParser, error) {
+	tool = strings.ToLower(strings.TrimSpace(tool))
+	if tool != "" {
+		for _, parser := range si.parsers {
+			if parser.Name() == tool {
+				return parser, nil
+			}
+		}
+		return nil, fmt.Errorf("scanner não suportado: %s (suportados: %s)", tool, strings.Join(si.ScannerNames(), ", "))
+	}
+
+	for _, parser := range si.parsers {
+		if parser.Detect(data) {
+			return parser, nil
+		}
+	}
+	return nil, fmt.Errorf("formato de resultado de scanner não reconhecido")
+}
+
+// Merge combina análises de vários scanners. Findings com a mesma regra
+// canônica no mesmo recurso (e arquivo, quando informado) são unificados:
+// prevalece a maior severidade e as fontes são acumuladas. Instâncias
+// diferentes (b[0], b[1]) não são unificadas; o endereço sem índice só é
+// usado quando um dos scanners não informa a instância. Findings da mesma
+// análise ou da mesma fonte nunca são unificados entre si.
+func (si *ScannerImporter) Merge(analyses ...*models.SecurityAnalysis) *models.SecurityAnalysis {
+	var present []*models.SecurityAnalysis
+	for _, analysis := range analyses {
+		if analysis != nil {
+			present = append(present, analysis)
+		}
+	}
+	if len(present) == 0 {
+		return &models.SecurityAnalysis{Findings: []models.SecurityFinding{}}
+	}
+	if len(present) == 1 {
+		return present[0]
+	}
+
+	merged := &models.SecurityAnalysis{Findings: []models.SecurityFinding{}}
+	byKey := make(map[string][]int)
+	origins := []map[int]bool{} // análises que contribuíram para cada finding
+	duplicates := 0
+
+	for i, analysis := range present {
+		merged.ChecksPassed += analysis.ChecksPassed
+		merged.ChecksFailed += analysis.ChecksFailed
+
+		for _, finding := range analysis.Findings {
+			_, address := splitStackAddress(finding.Resource)
+			key := si.CanonicalRule(finding.CheckID) + "|" + stripIndexes(address)
+			if finding.Resource == "" {
+				key += "|" + fmt.Sprint(finding.Line)
+			}
+
+			candidates := []int{}
+			for _, idx := range byKey[key] {
+				if !origins[idx][i] {
+					candidates = append(candidates, idx)
+				}
+			}
+			if idx, ok := findDuplicate(merged, candidates, finding); ok {
+				mergeFinding(&merged.Findings[idx], finding)
+				origins[idx][i] = true
+				duplicates++
+				continue
+			}
+
+			finding.Sources = append([]string(nil), finding.Sources...)
+			finding.References = append([]string(nil), finding.References...)
+			byKey[key] = append(byKey[key], len(merged.Findings))
+			origins = append(origins, map[int]bool{i: true})
+			merged.Findings = append(merged.Findings, finding)
+		}
+	}
+
+	merged.ChecksFailed -= duplicates
+	if merged.ChecksFailed < len(merged.Findings) {
+		merged.ChecksFailed = len(merged.Findings)
+	}
+	countSeverities(merged)
+
+	if duplicates > 0 {
+		si.logger.Info("Findings duplicados entre scanners unificados", "duplicates", duplicates)
+	}
+	return merged
+}
+
+// findDuplicate procura, entre os candidatos com a mesma chave, um finding
+// da mesma instância, do mesmo stack e do mesmo arquivo (arquivos vazios são
+// compatíveis com qualquer outro) que não compartilhe fontes com o finding
+func findDuplicate(merged *models.SecurityAnalysis, candidates []int, finding models.SecurityFinding) (int, bool) {
+	for _, idx := range candidates {
+		existing := merged.Findings[idx]
+		if !sameStack(existing, finding) || sharesSource(existing, finding) {
+			continue
+		}
+		_, existingAddress := splitStackAddress(existing.Resource)
+		_, address := splitStackAddress(finding.Resource)
+		if !sameInstance(existingAddress, address) {
+			continue
+		}
+		if existing.File == "" || finding.File == "" || sameFile(existing.File, finding.File) {
+			return idx, true
+		}
+	}
+	return 0, false
+}
+
+// sameInstance compara endereços completos; quando um deles não tem índice
+// (o scanner não informa a instância), basta o endereço sem índices coincidir
+func sameInstance(a, b string) bool {
+	if a == b {
+		return true
+	}
+	strippedA, strippedB := stripIndexes(a), stripIndexes(b)
+	return strippedA == strippedB && (a == strippedA || b == strippedB)
+}
+
+// sameStack indica se os findings podem ser do mesmo stack: endereços
+// qualificados (ver StackAddress) precisam do mesmo stack e, quando só um
+// deles é qualificado, o arquivo do outro precisa estar no diretório do stack
+func sameStack(a, b models.SecurityFinding) bool {
+	stackA, _ := splitStackAddress(a.Resource)
+	stackB, _ := splitStackAddress(b.Resource)
+	switch {
+	case stackA != "" && stackB != "":
+		return stackA == stackB
+	case stackA != "":
+		return b.File == "" || fileInStack(b.File, stackA)
+	case stackB != "":
+		return a.File == "" || fileInStack(a.File, stackB)
+	}
+	return true
+}
+
+// sharesSource indica se os findings vieram de uma mesma fonte
+func sharesSource(a, b models.SecurityFinding) bool {
+	for _, source := range b.Sources {
+		if containsString(a.Sources, source) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeFinding unifica um finding duplicado no existente
+func mergeFinding(existing *models.SecurityFinding, duplicate models.SecurityFinding) {
+	if severityRank[strings.ToUpper(duplicate.Severity)] > severityRank[strings.ToUpper(existing.Severity)] {
+		existing.Severity = strings.ToUpper(duplicate.Severity)
+	}
+	for _, source := range duplicate.Sources {
+		if !containsString(existing.Sources, source) {
+			existing.Sources = append(existing.Sources, source)
+		}
+	}
+	for _, ref := range duplicate.References {
+		if !containsString(existing.References, ref) {
+			existing.References = append(existing.References, ref)
+		}
+	}
+	if existing.Guideline == "" {
+		existing.Guideline = duplicate.Guideline
+	}
+	if existing.FixedCode == "" {
+		existing.FixedCode = duplicate.FixedCode
+	}
+	if existing.Description == "" {
+		existing.Description = duplicate.Description
+	}
+	if existing.File == "" {
+		existing.File, existing.Line = duplicate.File, duplicate.Line
+	}
+	if stack, _ := splitStackAddress(existing.Resource); stack == "" {
+		if stack, _ := splitStackAddress(duplicate.Resource); stack != "" {
+			existing.Resource = duplicate.Resource
+		}
+	}
+}
+
+// TagFindingsSource marca com a fonte informada os findings sem fonte
+func TagFindingsSource(analysis *models.SecurityAnalysis, source string) {
+	if analysis == nil {
+		return
+	}
+	for i := range analysis.Findings {
+		if len(analysis.Findings[i].Sources) == 0 {
+			analysis.Findings[i].Sources = []string{source}
+		}
+	}
+}
+
+// severityRank ordena as severidades normalizadas
+var severityRank = map[string]int{
+	"INFO":     0,
+	"LOW":      1,
+	"MEDIUM":   2,
+	"HIGH":     3,
+	"CRITICAL": 4,
+}
+
+// normalizeSeverity converte a severidade de um scanner para o padrão dos
+// findings (CRITICAL, HIGH, MEDIUM, LOW, INFO)
+func normalizeSeverity(severity string) string {
+	severity = strings.ToUpper(strings.TrimSpace(severity))
+	if _, ok := severityRank[severity]; ok {
+		return severity
+	}
+	switch severity {
+	case "ERROR":
+		return "HIGH"
+	case "WARNING":
+		return "MEDIUM"
+	default:
+		return "INFO"
+	}
+}
+
+// containsString indica se o valor está na lista
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonKeys retorna as chaves do objeto JSON de topo (nil para outros tipos)
+func jsonKeys(data []byte) map[string]json.RawMessage {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// jsonKind retorna o primeiro caractere significativo do valor JSON
+func jsonKind(raw json.RawMessage) byte {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" {
+		return 0
+	}
+	return trimmed[0]
+}
+
+// checkovParser importa a saída JSON do Checkov
+type checkovParser struct {
+	checkov *CheckovAnalyzer
+}
+
+func (p *checkovParser) Name() string { return "checkov" }
+
+func (p *checkovParser) Detect(data []byte) bool {
+	if jsonKind(data) == '[' {
+		var list []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &list); err != nil || len(list) == 0 {
+			return false
+		}
+		_, ok := list[0]["check_type"]
+		return ok
+	}
+	keys := jsonKeys(data)
+	if _, ok := keys["check_type"]; ok {
+		return true
+	}
+	_, hasSummary := keys["summary"]
+	return hasSummary && jsonKind(keys["results"]) == '{' && !strings.Contains(string(keys["results"]), `"violations"`)
+}
+
+func (p *checkovParser) Parse(data []byte) (*models.SecurityAnalysis, error) {
+	return p.checkov.ValidateAndParseResult(data)
+}
+
+// tfsecParser importa a saída JSON do tfsec (tfsec --format json)
+type tfsecParser struct{}
+
+type tfsecOutput struct {
+	Results []struct {
+		RuleID          string   `json:"rule_id"`
+		LongID          string   `json:"long_id"`
+		RuleDescription string   `json:"rule_description"`
+		Description     string   `json:"description"`
+		Impact          string   `json:"impact"`
+		Resolution      string   `json:"resolution"`
+		Links           []string `json:"links"`
+		Severity        string   `json:"severity"`
+		Status          int      `json:"status"` // 0 falhou, 1 passou, 2 ignorado
+		Resource        string   `json:"resource"`
+		Location        struct {
+			Filename  string `json:"filename"`
+			StartLine int    `json:"start_line"`
+		} `json:"location"`
+	} `json:"results"`
+}
+
+func (tfsecParser) Name() string { return "tfsec" }
+
+func (tfsecParser) Detect(data []byte) bool {
+	keys := jsonKeys(data)
+	return jsonKind(keys["results"]) == '[' || (keys != nil && string(keys["results"]) == "null")
+}
+
+func (tfsecParser) Parse(data []byte) (*models.SecurityAnalysis, error) {
+	var output tfsecOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do resultado tfsec: %w", err)
+	}
+
+	analysis := &models.SecurityAnalysis{Findings: []models.SecurityFinding{}}
+	for _, result := range output.Results {
+		switch result.Status {
+		case 1:
+			analysis.ChecksPassed++
+			continue
+		case 2:
+			continue
+		}
+
+		id := result.RuleID
+		if id == "" {
+			id = result.LongID
+		}
+		name := result.RuleDescription
+		if name == "" {
+			name = result.Description
+		}
+		analysis.Findings = append(analysis.Findings, models.SecurityFinding{
+			ID:          id,
+			CheckID:     id,
+			CheckName:   name,
+			Severity:    normalizeSeverity(result.Severity),
+			Resource:    result.Resource,
+			File:        result.Location.Filename,
+			Line:        result.Location.StartLine,
+			Description: result.Description,
+			Guideline:   result.Resolution,
+			References:  result.Links,
+		})
+	}
+	analysis.ChecksFailed = len(analysis.Findings)
+	countSeverities(analysis)
+	return analysis, nil
+}
+
+// trivyParser importa a saída JSON do Trivy (trivy config --format json)
+type trivyParser struct{}
+
+type trivyOutput struct {
+	Results []struct {
+		Target         string `json:"Target"`
+		MisconfSummary struct {
+			Successes int `json:"Successes"`
+		} `json:"MisconfSummary"`
+		Misconfigurations []struct {
+			ID            string   `json:"ID"`
+			AVDID         string   `json:"AVDID"`
+			Title         string   `json:"Title"`
+			Description   string   `json:"Description"`
+			Message       string   `json:"Message"`
+			Resolution    string   `json:"Resolution"`
+			Severity      string   `json:"Severity"`
+			PrimaryURL    string   `json:"PrimaryURL"`
+			References    []string `json:"References"`
+			Status        string   `json:"Status"`
+			CauseMetadata struct {
+				Resource  string `json:"Resource"`
+				StartLine int    `json:"StartLine"`
+			} `json:"CauseMetadata"`
+		} `json:"Misconfigurations"`
+	} `json:"Results"`
+}
+
+func (trivyParser) Name() string { return "trivy" }
+
+func (trivyParser) Detect(data []byte) bool {
+	keys := jsonKeys(data)
+	_, ok := keys["Results"]
+	return ok
+}
+
+func (trivyParser) Parse(data []byte) (*models.SecurityAnalysis, error) {
+	var output trivyOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do resultado trivy: %w", err)
+	}
+
+	analysis := &models.SecurityAnalysis{Findings: []models.SecurityFinding{}}
+	for _, result := range output.Results {
+		analysis.ChecksPassed += result.MisconfSummary.Successes
+
+		for _, misconf := range result.Misconfigurations {
+			switch strings.ToUpper(misconf.Status) {
+			case "PASS":
+				analysis.ChecksPassed++
+				continue
+			case "EXCEPTION":
+				continue
+			}
+
+			id := misconf.AVDID
+			if id == "" {
+				id = misconf.ID
+			}
+			description := misconf.Message
+			if description == "" {
+				description = misconf.Description
+			}
+			references := misconf.References
+			if misconf.PrimaryURL != "" && !containsString(references, misconf.PrimaryURL) {
+				references = append([]string{misconf.PrimaryURL}, references...)
+			}
+			analysis.Findings = append(analysis.Findings, models.SecurityFinding{
+				ID:          id,
+				CheckID:     id,
+				CheckName:   misconf.Title,
+				Severity:    normalizeSeverity(misconf.Severity),
+				Resource:    misconf.CauseMetadata.Resource,
+				File:        result.Target,
+				Line:        misconf.CauseMetadata.StartLine,
+				Description: description,
+				Guideline:   misconf.Resolution,
+				References:  references,
+			})
+		}
+	}
+	analysis.ChecksFailed = len(analysis.Findings)
+	countSeverities(analysis)
+	return analysis, nil
+}
+
+// kicsParser importa a saída JSON do KICS (results.json)
+type kicsParser struct{}
+
+type kicsOutput struct {
+	Queries []struct {
+		QueryName   string `json:"query_name"`
+		QueryID     string `json:"query_id"`
+		QueryURL    string `json:"query_url"`
+		Severity    string `json:"severity"`
+		Description string `json:"description"`
+		Files       []struct {
+			FileName     string `json:"file_name"`
+			Line         int    `json:"line"`
+			SearchKey    string `json:"search_key"`
+			ResourceType string `json:"resource_type"`
+			ResourceName string `json:"resource_name"`
+			Remediation  string `json:"remediation"`
+		} `json:"files"`
+	} `json:"queries"`
+}
+
+// kicsSearchKeyPattern extrai tipo e nome do recurso de search_key
+// (ex.: aws_s3_bucket[logs].acl)
+var kicsSearchKeyPattern = regexp.MustCompile(`^(?:resource\.)?([A-Za-z0-9_]+)\[([^\]]+)\]`)
+
+func (kicsParser) Name() string { return "kics" }
+
+func (kicsParser) Detect(data []byte) bool {
+	keys := jsonKeys(data)
+	_, ok := keys["queries"]
+	return ok
+}
+
+func (kicsParser) Parse(data []byte) (*models.SecurityAnalysis, error) {
+	var output kicsOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do resultado kics: %w", err)
+	}
+
+	analysis := &models.SecurityAnalysis{Findings: []models.SecurityFinding{}}
+	for _, query := range output.Queries {
+		var references []string
+		if query.QueryURL != "" {
+			references = []string{query.QueryURL}
+		}
+
+		for _, file := range query.Files {
+			// resource_name é o nome do recurso na nuvem (ex.: o atributo
+			// bucket), não o rótulo do bloco: o endereço vem da search_key
+			resource := ""
+			if match := kicsSearchKeyPattern.FindStringSubmatch(file.SearchKey); match != nil {
+				resource = match[1] + "." + match[2]
+			}
+
+			analysis.Findings = append(analysis.Findings, models.SecurityFinding{
+				ID:          query.QueryID,
+				CheckID:     query.QueryID,
+				CheckName:   query.QueryName,
+				Severity:    normalizeSeverity(query.Severity),
+				Resource:    resource,
+				File:        file.FileName,
+				Line:        file.Line,
+				Description: query.Description,
+				FixedCode:   file.Remediation,
+				References:  references,
+			})
+		}
+	}
+	analysis.ChecksFailed = len(analysis.Findings)
+	countSeverities(analysis)
+	return analysis, nil
+}
+
+// terrascanParser importa a saída JSON do Terrascan (terrascan scan -o json)
+type terrascanParser struct{}
+
+type terrascanOutput struct {
+	Results struct {
+		Violations []struct {
+			RuleName     string `json:"rule_name"`
+			RuleID       string `json:"rule_id"`
+			Description  string `json:"description"`
+			Severity     string `json:"severity"`
+			ResourceName string `json:"resource_name"`
+			ResourceType string `json:"resource_type"`
+			ModuleName   string `json:"module_name"`
+			File         string `json:"file"`
+			Line         int    `json:"line"`
+		} `json:"violations"`
+	} `json:"results"`
+}
+
+func (terrascanParser) Name() string { return "terrascan" }
+
+func (terrascanParser) Detect(data []byte) bool {
+	keys := jsonKeys(data)
+	results := jsonKeys(keys["results"])
+	_, ok := results["violations"]
+	return ok
+}
+
+func (terrascanParser) Parse(data []byte) (*models.SecurityAnalysis, error) {
+	var output terrascanOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do resultado terrascan: %w", err)
+	}
+
+	analysis := &models.SecurityAnalysis{Findings: []models.SecurityFinding{}}
+	for _, violation := range output.Results.Violations {
+		resource := ""
+		if violation.ResourceType != "" && violation.ResourceName != "" {
+			resource = violation.ResourceType + "." + violation.ResourceName
+			if violation.ModuleName != "" && violation.ModuleName != "root" {
+				resource = "module." + violation.ModuleName + "." + resource
+			}
+		}
+
+		name := violation.Description
+		if name == "" {
+			name = violation.RuleName
+		}
+		analysis.Findings = append(analysis.Findings, models.SecurityFinding{
+			ID:          violation.RuleID,
+			CheckID:     violation.RuleID,
+			CheckName:   name,
+			Severity:    normalizeSeverity(violation.Severity),
+			Resource:    resource,
+			File:        violation.File,
+			Line:        violation.Line,
+			Description: violation.Description,
+		})
+	}
+	analysis.ChecksFailed = len(analysis.Findings)
+	countSeverities(analysis)
+	return analysis, nil
+}
+
+// defaultRuleMappings relaciona as regras nativas aos checks equivalentes
+// do Checkov, do tfsec/Trivy (AVD ID e long ID), do KICS (UUID da query) e
+// do Terrascan (AC_*)
+var defaultRuleMappings = map[string][]string{
+	"AWS_S3_ENCRYPTION": {"CKV_AWS_19", "AVD-AWS-0088", "aws-s3-enable-bucket-encryption",
+		"6726dcc0-5ff5-459d-b473-a780bef7665c"},
+	"AWS_S3_VERSIONING": {"CKV_AWS_21", "AVD-AWS-0090", "aws-s3-enable-versioning",
+		"568a4d22-3517-44a6-a7ad-6a7eed88722c", "AC_AWS_0214"},
+	"AWS_S3_LOGGING": {"CKV_AWS_18", "AVD-AWS-0089", "aws-s3-enable-bucket-logging",
+		"f861041c-8c9f-4156-acfc-5e6e524f5884"},
+	"AWS_S3_PUBLIC_ACL": {"CKV_AWS_20", "CKV_AWS_57", "AVD-AWS-0092", "aws-s3-no-public-access-with-acl",
+		"38c5ee0d-7f22-4260-ab72-5073048df100"},
+	"AWS_S3_PUBLIC_ACCESS_BLOCK": {"CKV2_AWS_6", "AVD-AWS-0094", "aws-s3-specify-public-access-block"},
+	"AWS_EC2_IMDSV2":             {"CKV_AWS_79", "AVD-AWS-0028", "aws-ec2-enforce-http-token-imds"},
+	"AWS_EC2_ROOT_ENCRYPTION":    {"CKV_AWS_8", "AVD-AWS-0131", "aws-ec2-enable-at-rest-encryption"},
+	"AWS_EBS_ENCRYPTION": {"CKV_AWS_3", "AVD-AWS-0026", "aws-ec2-enable-volume-encryption",
+		"cc997676-481b-4e93-aa81-d19f8c5e9b12"},
+	"AWS_RDS_ENCRYPTION": {"CKV_AWS_16", "AVD-AWS-0080", "aws-rds-encrypt-instance-storage-data",
+		"3199c26c-7871-4cb3-99c2-10a59244ce7f", "AC_AWS_0058"},
+	"AWS_RDS_PUBLIC": {"CKV_AWS_17", "AVD-AWS-0180", "aws-rds-no-public-db-access",
+		"35113e6f-2c6b-414d-beec-7a9482d3b2d1", "AC_AWS_0054"},
+	"AWS_SG_OPEN_ADMIN_PORTS": {"CKV_AWS_24", "CKV_AWS_25", "AVD-AWS-0107", "aws-ec2-no-public-ingress-sgr",
+		"381c3f2a-ef6f-4eff-99f7-b169cda3422c"},
+	"AWS_CLOUDTRAIL_LOG_VALIDATION": {"CKV_AWS_36", "AVD-AWS-0016", "aws-cloudtrail-enable-log-validation",
+		"52ffcfa6-6c70-4ea6-8376-d828d3961669"},
+	"AWS_KMS_ROTATION": {"CKV_AWS_7", "AVD-AWS-0065", "aws-kms-auto-rotate-keys",
+		"22fbfeac-7b5a-421a-8a27-7a2178bb910b", "AC_AWS_0160"},
+
+	"AZURE_STORAGE_HTTPS_ONLY":        {"CKV_AZURE_3", "AVD-AZU-0008", "azure-storage-enforce-https"},
+	"AZURE_STORAGE_MIN_TLS":           {"CKV_AZURE_44", "AVD-AZU-0011", "azure-storage-use-secure-tls-policy"},
+	"AZURE_STORAGE_PUBLIC_BLOB":       {"AVD-AZU-0007", "azure-storage-no-public-access"},
+	"AZURE_KEYVAULT_PURGE_PROTECTION": {"CKV_AZURE_110", "AVD-AZU-0016", "azure-keyvault-no-purge"},
+	"AZURE_NSG_OPEN_ADMIN_PORTS":      {"CKV_AZURE_9", "CKV_AZURE_10", "AVD-AZU-0047", "azure-network-no-public-ingress"},
+	"AZURE_SQL_PUBLIC_ACCESS":         {"CKV_AZURE_113", "AVD-AZU-0022", "azure-database-no-public-access"},
+
+	"GCP_STORAGE_UNIFORM_ACCESS":    {"CKV_GCP_29", "AVD-GCP-0002", "google-storage-enable-ubla"},
+	"GCP_STORAGE_VERSIONING":        {"CKV_GCP_78"},
+	"GCP_STORAGE_PUBLIC":            {"CKV_GCP_28", "AVD-GCP-0001", "google-storage-no-public-access"},
+	"GCP_COMPUTE_PUBLIC_IP":         {"CKV_GCP_40", "AVD-GCP-0031", "google-compute-no-public-ip"},
+	"GCP_SQL_REQUIRE_SSL":           {"CKV_GCP_6", "AVD-GCP-0015", "google-sql-encrypt-in-transit-data"},
+	"GCP_SQL_PUBLIC_NETWORK":        {"CKV_GCP_11", "AVD-GCP-0017", "google-sql-no-public-access"},
+	"GCP_FIREWALL_OPEN_ADMIN_PORTS": {"CKV_GCP_2", "CKV_GCP_3", "AVD-GCP-0027", "google-compute-no-public-ingress"},
+}
+
+// ScannerNames retorna os scanners registrados, em ordem alfabética
+func (si *ScannerImporter) ScannerNames() []string {
+	names := make([]string, 0, len(si.parsers))
+	for _, parser := range si.parsers {
+		names = append(names, parser.Name())
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,331 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+// adminPorts são as portas de administração remota (SSH e RDP) verificadas
+// pelas regras de exposição à internet
+var adminPorts = []int{22, 3389}
+
+// SecurityRule é uma verificação de segurança nativa aplicada aos recursos
+// de uma análise Terraform
+type SecurityRule struct {
+	ID            string
+	Name          string
+	Provider      string // aws, azure ou gcp
+	Severity      string // CRITICAL, HIGH, MEDIUM ou LOW
+	ResourceTypes []string
+	// Attributes são os caminhos lidos pela verificação; se algum só for
+	// conhecido após o apply, o recurso não é avaliado pela regra
+	Attributes  []string
+	Description string
+	Guideline   string
+	References  []string
+	// Check retorna true quando o recurso está em conformidade
+	Check func(r *models.TerraformResource, ctx *RuleContext) bool
+}
+
+// RuleContext dá às verificações acesso aos demais recursos da análise,
+// usado por regras cuja configuração fica em recursos separados (ex.:
+// aws_s3_bucket_versioning)
+type RuleContext struct {
+	byType map[string][]*models.TerraformResource
+}
+
+// RuleEngine avalia regras de segurança nativas sem depender do Checkov
+type RuleEngine struct {
+	rules  []SecurityRule
+	logger *logger.Logger
+}
+
+// NewRuleEngine cria um motor de regras com o pacote inicial de regras
+// AWS, Azure e GCP
+func NewRuleEngine(log *logger.Logger) *RuleEngine {
+	re := &RuleEngine{
+		logger: log,
+		rules:  []SecurityRule{},
+	}
+	re.loadRules()
+	return re
+}
+
+// loadRules carrega o pacote inicial de regras
+func (re *RuleEngine) loadRules() {
+	re.rules = append(re.rules, awsSecurityRules()...)
+	re.rules = append(re.rules, azureSecurityRules()...)
+	re.rules = append(re.rules, gcpSecurityRules()...)
+
+	re.logger.Info("Regras de segurança carregadas", "count", len(re.rules))
+}
+
+// Rules retorna as regras carregadas
+func (re *RuleEngine) Rules() []SecurityRule {
+	return re.rules
+}
+
+// AddRule adiciona uma regra customizada
+func (re *RuleEngine) AddRule(rule SecurityRule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("regra de segurança sem id")
+	}
+	if rule.Check == nil || len(rule.ResourceTypes) == 0 {
+		return fmt.Errorf("regra %s sem verificação ou tipos de recurso", rule.ID)
+	}
+	for _, existing := range re.rules {
+		if existing.ID == rule.ID {
+			return fmt.Errorf("regra %s já existe", rule.ID)
+		}
+	}
+
+	rule.Severity = strings.ToUpper(rule.Severity)
+	re.rules = append(re.rules, rule)
+	re.logger.Info("Regra de segurança adicionada", "id", rule.ID)
+	return nil
+}
+
+// Analyze avalia as regras contra os recursos da análise. Cada par regra e
+// recurso aplicável conta como um check aprovado ou reprovado.
+func (re *RuleEngine) Analyze(analysis *models.TerraformAnalysis) *models.SecurityAnalysis {
+	result := &models.SecurityAnalysis{
+		Findings: []models.SecurityFinding{},
+	}
+
+	ctx := &RuleContext{byType: make(map[string][]*models.TerraformResource)}
+	for i := range analysis.Resources {
+		r := &analysis.Resources[i]
+		ctx.byType[r.Type] = append(ctx.byType[r.Type], r)
+	}
+
+	for _, rule := range re.rules {
+		for _, resourceType := range rule.ResourceTypes {
+			for _, r := range ctx.byType[resourceType] {
+				if hasUnknownAttribute(r, rule.Attributes) {
+					continue
+				}
+				if rule.Check(r, ctx) {
+					result.ChecksPassed++
+					continue
+				}
+
+				result.ChecksFailed++
+				result.Findings = append(result.Findings, models.SecurityFinding{
+					ID:          fmt.Sprintf("%s-%d", rule.ID, len(result.Findings)+1),
+					CheckID:     rule.ID,
+					CheckName:   rule.Name,
+					Severity:    rule.Severity,
+					Resource:    StackAddress(r.Stack, r.Address),
+					File:        r.File,
+					Line:        r.LineStart,
+					Description: rule.Description,
+					Guideline:   rule.Guideline,
+					References:  rule.References,
+				})
+			}
+		}
+	}
+
+	countSeverities(result)
+
+	re.logger.Info("Análise de segurança nativa concluída",
+		"resources", len(analysis.Resources),
+		"passed", result.ChecksPassed,
+		"failed", result.ChecksFailed)
+
+	return result
+}
+
+// countSeverities recalcula os totais por severidade dos findings
+func countSeverities(analysis *models.SecurityAnalysis) {
+	analysis.Critical, analysis.High, analysis.Medium, analysis.Low, analysis.Info = 0, 0, 0, 0, 0
+	for _, finding := range analysis.Findings {
+		switch strings.ToUpper(finding.Severity) {
+		case "CRITICAL":
+			analysis.Critical++
+		case "HIGH":
+			analysis.High++
+		case "MEDIUM":
+			analysis.Medium++
+		case "LOW":
+			analysis.Low++
+		default:
+			analysis.Info++
+		}
+	}
+	analysis.TotalIssues = len(analysis.Findings)
+}
+
+// Companions retorna os recursos dos tipos informados, do mesmo stack, que
+// configuram r: os que dependem dele ou cujo atributo attr é igual ao
+// atributo targetAttr de r (ex.: o bucket de um aws_s3_bucket_versioning)
+func (ctx *RuleContext) Companions(r *models.TerraformResource, attr, targetAttr string, types ...string) []*models.TerraformResource {
+	node := stripIndexes(r.Address)
+	target := ruleString(r.Attributes, targetAttr, "")
+
+	companions := []*models.TerraformResource{}
+	for _, resourceType := range types {
+		for _, candidate := range ctx.byType[resourceType] {
+			if candidate.Stack != r.Stack {
+				continue
+			}
+			matched := target != "" && ruleString(candidate.Attributes, attr, "") == target
+			for _, dep := range candidate.Dependencies {
+				if stripIndexes(dep) == node {
+					matched = true
+					break
+				}
+			}
+			if matched {
+				companions = append(companions, candidate)
+			}
+		}
+	}
+	return companions
+}
+
+// hasUnknownAttribute verifica se algum dos caminhos (ou um pai/filho
+// deles) só é conhecido após o apply
+func hasUnknownAttribute(r *models.TerraformResource, paths []string) bool {
+	for _, unknown := range r.UnknownAttributes {
+		unknown = stripIndexes(unknown)
+		for _, p := range paths {
+			if isPathWithin(p, unknown) || isPathWithin(unknown, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleValue retorna o valor no caminho a.b.c. Blocos aninhados são listas
+// de mapas; nesse caso é usado o primeiro bloco.
+func ruleValue(values map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = values
+	for _, key := range strings.Split(path, ".") {
+		if list, ok := current.([]interface{}); ok {
+			if len(list) == 0 {
+				return nil, false
+			}
+			current = list[0]
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok || current == nil {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// ruleString retorna o valor textual no caminho ou def quando ausente
+func ruleString(values map[string]interface{}, path, def string) string {
+	v, ok := ruleValue(values, path)
+	if !ok {
+		return def
+	}
+	return scalarString(v)
+}
+
+// ruleBool retorna o valor booleano no caminho ou def quando ausente
+func ruleBool(values map[string]interface{}, path string, def bool) bool {
+	v, ok := ruleValue(values, path)
+	if !ok {
+		return def
+	}
+	if b, err := strconv.ParseBool(scalarString(v)); err == nil {
+		return b
+	}
+	return def
+}
+
+// ruleBlocks retorna os blocos aninhados no caminho
+func ruleBlocks(values map[string]interface{}, path string) []map[string]interface{} {
+	v, ok := ruleValue(values, path)
+	if !ok {
+		return nil
+	}
+	blocks := []map[string]interface{}{}
+	switch val := v.(type) {
+	case []interface{}:
+		for _, item := range val {
+			if m, ok := item.(map[string]interface{}); ok {
+				blocks = append(blocks, m)
+			}
+		}
+	case map[string]interface{}:
+		blocks = append(blocks, val)
+	}
+	return blocks
+}
+
+// ruleStrings retorna a lista de textos no caminho; um escalar vira uma
+// lista de um elemento
+func ruleStrings(values map[string]interface{}, path string) []string {
+	v, ok := ruleValue(values, path)
+	if !ok {
+		return nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return []string{scalarString(v)}
+	}
+	result := []string{}
+	for _, item := range list {
+		if item != nil {
+			result = append(result, scalarString(item))
+		}
+	}
+	return result
+}
+
+// containsFold verifica se algum dos valores é igual (sem diferenciar
+// maiúsculas) a algum dos candidatos
+func containsFold(values []string, candidates ...string) bool {
+	for _, v := range values {
+		for _, c := range candidates {
+			if strings.EqualFold(v, c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// portRangeCovers verifica se o intervalo numérico [from, to] contém alguma
+// das portas
+func portRangeCovers(from, to string, ports []int) bool {
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return false
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil {
+		end = start
+	}
+	for _, port := range ports {
+		if port >= start && port <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// portSpecCovers verifica se uma especificação de portas ("*", "22" ou
+// "20-25") contém alguma das portas
+func portSpecCovers(spec string, ports []int) bool {
+	spec = strings.TrimSpace(spec)
+	if spec == "*" {
+		return true
+	}
+	if from, to, found := strings.Cut(spec, "-"); found {
+		return portRangeCovers(from, to, ports)
+	}
+	return portRangeCovers(spec, spec, ports)
+}
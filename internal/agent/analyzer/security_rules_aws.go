@@ -0,0 +1,258 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// publicCIDRs são os blocos que representam qualquer endereço da internet
+var publicCIDRs = []string{"0.0.0.0/0", "::/0"}
+
+// publicS3ACLs são as ACLs canned que expõem o bucket
+var publicS3ACLs = []string{"public-read", "public-read-write", "authenticated-read"}
+
+// awsSecurityRules retorna o pacote inicial de regras AWS
+func awsSecurityRules() []SecurityRule {
+	return []SecurityRule{
+		{
+			ID:            "AWS_S3_ENCRYPTION",
+			Name:          "Bucket S3 com criptografia configurada",
+			Provider:      "aws",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"aws_s3_bucket"},
+			Attributes:    []string{"server_side_encryption_configuration"},
+			Description:   "Bucket S3 sem criptografia server-side configurada",
+			Guideline:     "Configure aws_s3_bucket_server_side_encryption_configuration com SSE-KMS",
+			References:    []string{"https://docs.aws.amazon.com/AmazonS3/latest/userguide/serv-side-encryption.html"},
+			Check: func(r *models.TerraformResource, ctx *RuleContext) bool {
+				if len(ruleBlocks(r.Attributes, "server_side_encryption_configuration")) > 0 {
+					return true
+				}
+				return len(ctx.Companions(r, "bucket", "bucket", "aws_s3_bucket_server_side_encryption_configuration")) > 0
+			},
+		},
+		{
+			ID:            "AWS_S3_VERSIONING",
+			Name:          "Bucket S3 com versionamento habilitado",
+			Provider:      "aws",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"aws_s3_bucket"},
+			Attributes:    []string{"versioning"},
+			Description:   "Bucket S3 sem versionamento habilitado",
+			Guideline:     "Habilite o versionamento com aws_s3_bucket_versioning (status = \"Enabled\")",
+			References:    []string{"https://docs.aws.amazon.com/AmazonS3/latest/userguide/Versioning.html"},
+			Check: func(r *models.TerraformResource, ctx *RuleContext) bool {
+				if ruleBool(r.Attributes, "versioning.enabled", false) {
+					return true
+				}
+				for _, v := range ctx.Companions(r, "bucket", "bucket", "aws_s3_bucket_versioning") {
+					if ruleString(v.Attributes, "versioning_configuration.status", "") == "Enabled" ||
+						hasUnknownAttribute(v, []string{"versioning_configuration"}) {
+						return true
+					}
+				}
+				return false
+			},
+		},
+		{
+			ID:            "AWS_S3_LOGGING",
+			Name:          "Bucket S3 com access logging",
+			Provider:      "aws",
+			Severity:      "LOW",
+			ResourceTypes: []string{"aws_s3_bucket"},
+			Attributes:    []string{"logging"},
+			Description:   "Bucket S3 sem access logging habilitado",
+			Guideline:     "Configure aws_s3_bucket_logging apontando para um bucket de logs",
+			References:    []string{"https://docs.aws.amazon.com/AmazonS3/latest/userguide/ServerLogs.html"},
+			Check: func(r *models.TerraformResource, ctx *RuleContext) bool {
+				if len(ruleBlocks(r.Attributes, "logging")) > 0 {
+					return true
+				}
+				return len(ctx.Companions(r, "bucket", "bucket", "aws_s3_bucket_logging")) > 0
+			},
+		},
+		{
+			ID:            "AWS_S3_PUBLIC_ACL",
+			Name:          "Bucket S3 sem ACL pública",
+			Provider:      "aws",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"aws_s3_bucket", "aws_s3_bucket_acl"},
+			Attributes:    []string{"acl"},
+			Description:   "Bucket S3 com ACL que concede acesso público",
+			Guideline:     "Use acl = \"private\" e conceda acesso via políticas de bucket específicas",
+			References:    []string{"https://docs.aws.amazon.com/AmazonS3/latest/userguide/acl-overview.html#canned-acl"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return !containsFold([]string{ruleString(r.Attributes, "acl", "private")}, publicS3ACLs...)
+			},
+		},
+		{
+			ID:            "AWS_S3_PUBLIC_ACCESS_BLOCK",
+			Name:          "Bucket S3 com bloqueio de acesso público",
+			Provider:      "aws",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"aws_s3_bucket"},
+			Description:   "Bucket S3 sem aws_s3_bucket_public_access_block bloqueando ACLs e políticas públicas",
+			Guideline:     "Adicione aws_s3_bucket_public_access_block com block_public_acls, block_public_policy, ignore_public_acls e restrict_public_buckets = true",
+			References:    []string{"https://docs.aws.amazon.com/AmazonS3/latest/userguide/access-control-block-public-access.html"},
+			Check: func(r *models.TerraformResource, ctx *RuleContext) bool {
+				for _, b := range ctx.Companions(r, "bucket", "bucket", "aws_s3_bucket_public_access_block") {
+					if ruleBool(b.Attributes, "block_public_acls", false) && ruleBool(b.Attributes, "block_public_policy", false) {
+						return true
+					}
+				}
+				return false
+			},
+		},
+		{
+			ID:            "AWS_EC2_IMDSV2",
+			Name:          "Instância EC2 exige IMDSv2",
+			Provider:      "aws",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"aws_instance", "aws_launch_template"},
+			Attributes:    []string{"metadata_options"},
+			Description:   "Instância permite IMDSv1, expondo credenciais a ataques SSRF",
+			Guideline:     "Defina metadata_options { http_tokens = \"required\" }",
+			References:    []string{"https://docs.aws.amazon.com/AWSEC2/latest/UserGuide/configuring-instance-metadata-service.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleString(r.Attributes, "metadata_options.http_tokens", "optional") == "required" ||
+					ruleString(r.Attributes, "metadata_options.http_endpoint", "enabled") == "disabled"
+			},
+		},
+		{
+			ID:            "AWS_EC2_ROOT_ENCRYPTION",
+			Name:          "Volume raiz da instância EC2 criptografado",
+			Provider:      "aws",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"aws_instance"},
+			Attributes:    []string{"root_block_device"},
+			Description:   "Volume raiz da instância EC2 sem criptografia explícita",
+			Guideline:     "Defina root_block_device { encrypted = true } ou habilite a criptografia EBS por padrão na conta",
+			References:    []string{"https://docs.aws.amazon.com/ebs/latest/userguide/ebs-encryption.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "root_block_device.encrypted", false)
+			},
+		},
+		{
+			ID:            "AWS_EBS_ENCRYPTION",
+			Name:          "Volume EBS criptografado",
+			Provider:      "aws",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"aws_ebs_volume"},
+			Attributes:    []string{"encrypted"},
+			Description:   "Volume EBS sem criptografia",
+			Guideline:     "Defina encrypted = true e, se possível, kms_key_id com uma chave gerenciada pelo cliente",
+			References:    []string{"https://docs.aws.amazon.com/ebs/latest/userguide/ebs-encryption.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "encrypted", false)
+			},
+		},
+		{
+			ID:            "AWS_RDS_ENCRYPTION",
+			Name:          "Banco RDS criptografado",
+			Provider:      "aws",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"aws_db_instance", "aws_rds_cluster"},
+			Attributes:    []string{"storage_encrypted"},
+			Description:   "Armazenamento do banco RDS sem criptografia",
+			Guideline:     "Defina storage_encrypted = true (não pode ser alterado após a criação)",
+			References:    []string{"https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/Overview.Encryption.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "storage_encrypted", false)
+			},
+		},
+		{
+			ID:            "AWS_RDS_PUBLIC",
+			Name:          "Banco RDS não acessível publicamente",
+			Provider:      "aws",
+			Severity:      "CRITICAL",
+			ResourceTypes: []string{"aws_db_instance"},
+			Attributes:    []string{"publicly_accessible"},
+			Description:   "Banco RDS acessível publicamente",
+			Guideline:     "Defina publicly_accessible = false e acesse o banco por subnets privadas",
+			References:    []string{"https://docs.aws.amazon.com/AmazonRDS/latest/UserGuide/USER_VPC.WorkingWithRDSInstanceinaVPC.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return !ruleBool(r.Attributes, "publicly_accessible", false)
+			},
+		},
+		{
+			ID:            "AWS_SG_OPEN_ADMIN_PORTS",
+			Name:          "Security group sem SSH/RDP aberto para a internet",
+			Provider:      "aws",
+			Severity:      "CRITICAL",
+			ResourceTypes: []string{"aws_security_group", "aws_security_group_rule", "aws_vpc_security_group_ingress_rule"},
+			Attributes:    []string{"ingress", "type", "cidr_blocks", "ipv6_cidr_blocks", "cidr_ipv4", "cidr_ipv6", "from_port", "to_port"},
+			Description:   "Security group permite SSH (22) ou RDP (3389) a partir de 0.0.0.0/0",
+			Guideline:     "Restrinja o ingress a faixas conhecidas ou use SSM Session Manager em vez de portas abertas",
+			References:    []string{"https://docs.aws.amazon.com/vpc/latest/userguide/security-group-rules.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				switch r.Type {
+				case "aws_security_group":
+					for _, ingress := range ruleBlocks(r.Attributes, "ingress") {
+						if awsIngressOpen(ingress, "protocol", "cidr_blocks", "ipv6_cidr_blocks") {
+							return false
+						}
+					}
+					return true
+				case "aws_security_group_rule":
+					return ruleString(r.Attributes, "type", "") != "ingress" ||
+						!awsIngressOpen(r.Attributes, "protocol", "cidr_blocks", "ipv6_cidr_blocks")
+				default:
+					return !awsIngressOpen(r.Attributes, "ip_protocol", "cidr_ipv4", "cidr_ipv6")
+				}
+			},
+		},
+		{
+			ID:            "AWS_CLOUDTRAIL_LOG_VALIDATION",
+			Name:          "CloudTrail com validação de integridade dos logs",
+			Provider:      "aws",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"aws_cloudtrail"},
+			Attributes:    []string{"enable_log_file_validation"},
+			Description:   "CloudTrail sem validação de integridade dos arquivos de log",
+			Guideline:     "Defina enable_log_file_validation = true",
+			References:    []string{"https://docs.aws.amazon.com/awscloudtrail/latest/userguide/cloudtrail-log-file-validation-intro.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "enable_log_file_validation", false)
+			},
+		},
+		{
+			ID:            "AWS_KMS_ROTATION",
+			Name:          "Chave KMS com rotação automática",
+			Provider:      "aws",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"aws_kms_key"},
+			Attributes:    []string{"enable_key_rotation", "customer_master_key_spec"},
+			Description:   "Chave KMS simétrica sem rotação automática",
+			Guideline:     "Defina enable_key_rotation = true",
+			References:    []string{"https://docs.aws.amazon.com/kms/latest/developerguide/rotate-keys.html"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				// Rotação automática só existe para chaves simétricas
+				if spec := ruleString(r.Attributes, "customer_master_key_spec", "SYMMETRIC_DEFAULT"); spec != "SYMMETRIC_DEFAULT" {
+					return true
+				}
+				return ruleBool(r.Attributes, "enable_key_rotation", false)
+			},
+		},
+	}
+}
+
+// awsIngressOpen verifica se uma regra de ingress libera portas de
+// administração para a internet. Protocolo -1 libera todas as portas.
+func awsIngressOpen(values map[string]interface{}, protocolAttr string, cidrAttrs ...string) bool {
+	public := false
+	for _, attr := range cidrAttrs {
+		if containsFold(ruleStrings(values, attr), publicCIDRs...) {
+			public = true
+		}
+	}
+	if !public {
+		return false
+	}
+
+	protocol := strings.ToLower(ruleString(values, protocolAttr, "tcp"))
+	if protocol == "-1" || protocol == "all" {
+		return true
+	}
+	return portRangeCovers(ruleString(values, "from_port", ""), ruleString(values, "to_port", ""), adminPorts)
+}
@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// azureInternetSources são os prefixos de origem que representam a internet
+// em regras de NSG
+var azureInternetSources = []string{"*", "0.0.0.0/0", "0.0.0.0", "Internet", "Any"}
+
+// azureSecurityRules retorna o pacote inicial de regras Azure
+func azureSecurityRules() []SecurityRule {
+	return []SecurityRule{
+		{
+			ID:            "AZURE_STORAGE_HTTPS_ONLY",
+			Name:          "Storage account aceita apenas HTTPS",
+			Provider:      "azure",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"azurerm_storage_account"},
+			Attributes:    []string{"enable_https_traffic_only", "https_traffic_only_enabled"},
+			Description:   "Storage account aceita tráfego HTTP sem criptografia",
+			Guideline:     "Defina https_traffic_only_enabled = true (enable_https_traffic_only no provider 3.x)",
+			References:    []string{"https://learn.microsoft.com/azure/storage/common/storage-require-secure-transfer"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "https_traffic_only_enabled", true) &&
+					ruleBool(r.Attributes, "enable_https_traffic_only", true)
+			},
+		},
+		{
+			ID:            "AZURE_STORAGE_MIN_TLS",
+			Name:          "Storage account exige TLS 1.2",
+			Provider:      "azure",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"azurerm_storage_account"},
+			Attributes:    []string{"min_tls_version"},
+			Description:   "Storage account aceita versões de TLS anteriores à 1.2",
+			Guideline:     "Defina min_tls_version = \"TLS1_2\"",
+			References:    []string{"https://learn.microsoft.com/azure/storage/common/transport-layer-security-configure-minimum-version"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				version := ruleString(r.Attributes, "min_tls_version", "TLS1_2")
+				return version != "TLS1_0" && version != "TLS1_1"
+			},
+		},
+		{
+			ID:            "AZURE_STORAGE_PUBLIC_BLOB",
+			Name:          "Storage account sem acesso público a blobs",
+			Provider:      "azure",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"azurerm_storage_account"},
+			Attributes:    []string{"allow_nested_items_to_be_public", "allow_blob_public_access"},
+			Description:   "Storage account permite containers com acesso público anônimo",
+			Guideline:     "Defina allow_nested_items_to_be_public = false (o padrão do provider é true)",
+			References:    []string{"https://learn.microsoft.com/azure/storage/blobs/anonymous-read-access-prevent"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				if _, ok := ruleValue(r.Attributes, "allow_blob_public_access"); ok {
+					return !ruleBool(r.Attributes, "allow_blob_public_access", false)
+				}
+				return !ruleBool(r.Attributes, "allow_nested_items_to_be_public", true)
+			},
+		},
+		{
+			ID:            "AZURE_KEYVAULT_PURGE_PROTECTION",
+			Name:          "Key Vault com proteção contra expurgo",
+			Provider:      "azure",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"azurerm_key_vault"},
+			Attributes:    []string{"purge_protection_enabled"},
+			Description:   "Key Vault sem purge protection; segredos excluídos podem ser expurgados permanentemente",
+			Guideline:     "Defina purge_protection_enabled = true",
+			References:    []string{"https://learn.microsoft.com/azure/key-vault/general/soft-delete-overview"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "purge_protection_enabled", false)
+			},
+		},
+		{
+			ID:            "AZURE_NSG_OPEN_ADMIN_PORTS",
+			Name:          "NSG sem SSH/RDP aberto para a internet",
+			Provider:      "azure",
+			Severity:      "CRITICAL",
+			ResourceTypes: []string{"azurerm_network_security_group", "azurerm_network_security_rule"},
+			Attributes: []string{"security_rule", "direction", "access", "source_address_prefix",
+				"source_address_prefixes", "destination_port_range", "destination_port_ranges"},
+			Description: "Regra de NSG permite SSH (22) ou RDP (3389) a partir da internet",
+			Guideline:   "Restrinja source_address_prefix a faixas conhecidas ou use Azure Bastion",
+			References:  []string{"https://learn.microsoft.com/azure/virtual-network/network-security-groups-overview"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				if r.Type == "azurerm_network_security_rule" {
+					return !azureRuleOpen(r.Attributes)
+				}
+				for _, rule := range ruleBlocks(r.Attributes, "security_rule") {
+					if azureRuleOpen(rule) {
+						return false
+					}
+				}
+				return true
+			},
+		},
+		{
+			ID:            "AZURE_SQL_PUBLIC_ACCESS",
+			Name:          "SQL Server sem acesso por rede pública",
+			Provider:      "azure",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"azurerm_mssql_server"},
+			Attributes:    []string{"public_network_access_enabled"},
+			Description:   "SQL Server acessível pela rede pública",
+			Guideline:     "Defina public_network_access_enabled = false e use private endpoints",
+			References:    []string{"https://learn.microsoft.com/azure/azure-sql/database/connectivity-settings"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return !ruleBool(r.Attributes, "public_network_access_enabled", true)
+			},
+		},
+	}
+}
+
+// azureRuleOpen verifica se uma regra de NSG de entrada permite portas de
+// administração a partir da internet
+func azureRuleOpen(values map[string]interface{}) bool {
+	if !strings.EqualFold(ruleString(values, "direction", ""), "Inbound") ||
+		!strings.EqualFold(ruleString(values, "access", ""), "Allow") {
+		return false
+	}
+
+	sources := append(ruleStrings(values, "source_address_prefix"), ruleStrings(values, "source_address_prefixes")...)
+	if !containsFold(sources, azureInternetSources...) {
+		return false
+	}
+
+	ports := append(ruleStrings(values, "destination_port_range"), ruleStrings(values, "destination_port_ranges")...)
+	for _, spec := range ports {
+		if portSpecCovers(spec, adminPorts) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// gcpPublicMembers são os membros IAM que representam acesso público
+var gcpPublicMembers = []string{"allUsers", "allAuthenticatedUsers"}
+
+// gcpSecurityRules retorna o pacote inicial de regras GCP
+func gcpSecurityRules() []SecurityRule {
+	return []SecurityRule{
+		{
+			ID:            "GCP_STORAGE_UNIFORM_ACCESS",
+			Name:          "Bucket com uniform bucket-level access",
+			Provider:      "gcp",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"google_storage_bucket"},
+			Attributes:    []string{"uniform_bucket_level_access"},
+			Description:   "Bucket usa ACLs por objeto em vez de uniform bucket-level access",
+			Guideline:     "Defina uniform_bucket_level_access = true",
+			References:    []string{"https://cloud.google.com/storage/docs/uniform-bucket-level-access"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "uniform_bucket_level_access", false)
+			},
+		},
+		{
+			ID:            "GCP_STORAGE_VERSIONING",
+			Name:          "Bucket com versionamento habilitado",
+			Provider:      "gcp",
+			Severity:      "LOW",
+			ResourceTypes: []string{"google_storage_bucket"},
+			Attributes:    []string{"versioning"},
+			Description:   "Bucket sem versionamento de objetos",
+			Guideline:     "Defina versioning { enabled = true }",
+			References:    []string{"https://cloud.google.com/storage/docs/object-versioning"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				return ruleBool(r.Attributes, "versioning.enabled", false)
+			},
+		},
+		{
+			ID:            "GCP_STORAGE_PUBLIC",
+			Name:          "Bucket sem acesso público via IAM",
+			Provider:      "gcp",
+			Severity:      "CRITICAL",
+			ResourceTypes: []string{"google_storage_bucket_iam_member", "google_storage_bucket_iam_binding"},
+			Attributes:    []string{"member", "members"},
+			Description:   "Bucket concede acesso a allUsers ou allAuthenticatedUsers",
+			Guideline:     "Remova allUsers/allAuthenticatedUsers e conceda acesso a identidades específicas",
+			References:    []string{"https://cloud.google.com/storage/docs/access-control/making-data-public"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				members := append(ruleStrings(r.Attributes, "member"), ruleStrings(r.Attributes, "members")...)
+				return !containsFold(members, gcpPublicMembers...)
+			},
+		},
+		{
+			ID:            "GCP_COMPUTE_PUBLIC_IP",
+			Name:          "Instância sem IP público",
+			Provider:      "gcp",
+			Severity:      "MEDIUM",
+			ResourceTypes: []string{"google_compute_instance"},
+			Attributes:    []string{"network_interface"},
+			Description:   "Instância com IP externo (access_config) exposta à internet",
+			Guideline:     "Remova access_config e use Cloud NAT ou IAP para acesso",
+			References:    []string{"https://cloud.google.com/compute/docs/ip-addresses/reserve-static-external-ip-address"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				for _, nic := range ruleBlocks(r.Attributes, "network_interface") {
+					if len(ruleBlocks(nic, "access_config")) > 0 {
+						return false
+					}
+				}
+				return true
+			},
+		},
+		{
+			ID:            "GCP_SQL_REQUIRE_SSL",
+			Name:          "Cloud SQL exige SSL",
+			Provider:      "gcp",
+			Severity:      "HIGH",
+			ResourceTypes: []string{"google_sql_database_instance"},
+			Attributes:    []string{"settings"},
+			Description:   "Instância Cloud SQL aceita conexões sem SSL",
+			Guideline:     "Defina settings.ip_configuration.ssl_mode = \"ENCRYPTED_ONLY\"",
+			References:    []string{"https://cloud.google.com/sql/docs/mysql/configure-ssl-instance"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				mode := ruleString(r.Attributes, "settings.ip_configuration.ssl_mode", "")
+				return mode == "ENCRYPTED_ONLY" || mode == "TRUSTED_CLIENT_CERTIFICATE_REQUIRED" ||
+					ruleBool(r.Attributes, "settings.ip_configuration.require_ssl", false)
+			},
+		},
+		{
+			ID:            "GCP_SQL_PUBLIC_NETWORK",
+			Name:          "Cloud SQL sem rede autorizada pública",
+			Provider:      "gcp",
+			Severity:      "CRITICAL",
+			ResourceTypes: []string{"google_sql_database_instance"},
+			Attributes:    []string{"settings"},
+			Description:   "Instância Cloud SQL autoriza conexões de 0.0.0.0/0",
+			Guideline:     "Remova 0.0.0.0/0 de authorized_networks e use private IP ou o Cloud SQL Auth Proxy",
+			References:    []string{"https://cloud.google.com/sql/docs/mysql/authorize-networks"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				for _, ipConfig := range ruleBlocks(r.Attributes, "settings.ip_configuration") {
+					for _, network := range ruleBlocks(ipConfig, "authorized_networks") {
+						if containsFold(ruleStrings(network, "value"), publicCIDRs...) {
+							return false
+						}
+					}
+				}
+				return true
+			},
+		},
+		{
+			ID:            "GCP_FIREWALL_OPEN_ADMIN_PORTS",
+			Name:          "Firewall sem SSH/RDP aberto para a internet",
+			Provider:      "gcp",
+			Severity:      "CRITICAL",
+			ResourceTypes: []string{"google_compute_firewall"},
+			Attributes:    []string{"direction", "source_ranges", "allow"},
+			Description:   "Regra de firewall permite SSH (22) ou RDP (3389) a partir de 0.0.0.0/0",
+			Guideline:     "Restrinja source_ranges a faixas conhecidas ou use Identity-Aware Proxy (35.235.240.0/20)",
+			References:    []string{"https://cloud.google.com/firewall/docs/firewalls"},
+			Check: func(r *models.TerraformResource, _ *RuleContext) bool {
+				if !strings.EqualFold(ruleString(r.Attributes, "direction", "INGRESS"), "INGRESS") ||
+					!containsFold(ruleStrings(r.Attributes, "source_ranges"), publicCIDRs...) {
+					return true
+				}
+				for _, allow := range ruleBlocks(r.Attributes, "allow") {
+					protocol := strings.ToLower(ruleString(allow, "protocol", ""))
+					if protocol != "all" && protocol != "tcp" {
+						continue
+					}
+					ports := ruleStrings(allow, "ports")
+					if protocol == "all" || len(ports) == 0 {
+						return false
+					}
+					for _, spec := range ports {
+						if portSpecCovers(spec, adminPorts) {
+							return false
+						}
+					}
+				}
+				return true
+			},
+		},
+	}
+}
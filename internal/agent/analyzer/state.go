@@ -0,0 +1,643 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+// StateAnalyzer lê states do Terraform e os compara com a configuração
+type StateAnalyzer struct {
+	logger *logger.Logger
+}
+
+// NewStateAnalyzer cria uma nova instância do analisador de state
+func NewStateAnalyzer(log *logger.Logger) *StateAnalyzer {
+	return &StateAnalyzer{logger: log}
+}
+
+// rawStateV4 é o formato do terraform.tfstate (version 4)
+type rawStateV4 struct {
+	Version          int    `json:"version"`
+	TerraformVersion string `json:"terraform_version"`
+	Serial           int64  `json:"serial"`
+	Lineage          string `json:"lineage"`
+	Resources        []struct {
+		Module    string `json:"module"`
+		Mode      string `json:"mode"`
+		Type      string `json:"type"`
+		Name      string `json:"name"`
+		Provider  string `json:"provider"`
+		Instances []struct {
+			IndexKey            interface{}            `json:"index_key"`
+			Attributes          map[string]interface{} `json:"attributes"`
+			SensitiveAttributes []json.RawMessage      `json:"sensitive_attributes"`
+		} `json:"instances"`
+	} `json:"resources"`
+}
+
+// showState é o formato de terraform show -json para um state
+type showState struct {
+	FormatVersion    string `json:"format_version"`
+	TerraformVersion string `json:"terraform_version"`
+	Values           *struct {
+		RootModule showModule `json:"root_module"`
+	} `json:"values"`
+}
+
+// showModule é um módulo em terraform show -json
+type showModule struct {
+	Address   string `json:"address"`
+	Resources []struct {
+		Address         string                 `json:"address"`
+		Mode            string                 `json:"mode"`
+		Type            string                 `json:"type"`
+		Name            string                 `json:"name"`
+		Index           interface{}            `json:"index"`
+		ProviderName    string                 `json:"provider_name"`
+		Values          map[string]interface{} `json:"values"`
+		SensitiveValues map[string]interface{} `json:"sensitive_values"`
+		DependsOn       []string               `json:"depends_on"`
+	} `json:"resources"`
+	ChildModules []showModule `json:"child_modules"`
+}
+
+// sensitivePathStep é um passo de caminho em sensitive_attributes do tfstate
+type sensitivePathStep struct {
+	Type  string `json:"type"` // get_attr, index
+	Value json.RawMessage
+}
+
+// ParseState lê um terraform.tfstate (v4) ou a saída de terraform show -json
+func (sa *StateAnalyzer) ParseState(data []byte) (*models.TerraformState, error) {
+	var header struct {
+		Version       *int            `json:"version"`
+		FormatVersion string          `json:"format_version"`
+		Values        json.RawMessage `json:"values"`
+		PlannedValues json.RawMessage `json:"planned_values"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid terraform state JSON: %w", err)
+	}
+
+	switch {
+	case header.FormatVersion != "":
+		if header.PlannedValues != nil {
+			return nil, fmt.Errorf("o JSON informado é um plan, não um state")
+		}
+		return sa.parseShowState(data)
+	case header.Version != nil && *header.Version == 4:
+		return sa.parseStateV4(data)
+	case header.Version != nil:
+		return nil, fmt.Errorf("versão de state não suportada: %d", *header.Version)
+	default:
+		return nil, fmt.Errorf("formato de state não reconhecido")
+	}
+}
+
+// parseStateV4 normaliza um terraform.tfstate
+func (sa *StateAnalyzer) parseStateV4(data []byte) (*models.TerraformState, error) {
+	var raw rawStateV4
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid terraform state JSON: %w", err)
+	}
+
+	state := &models.TerraformState{
+		Format:           "tfstate",
+		Version:          raw.Version,
+		TerraformVersion: raw.TerraformVersion,
+		Serial:           raw.Serial,
+		Lineage:          raw.Lineage,
+		Resources:        []models.StateResource{},
+	}
+
+	for _, r := range raw.Resources {
+		for _, instance := range r.Instances {
+			resource := models.StateResource{
+				Module:     r.Module,
+				Mode:       r.Mode,
+				Type:       r.Type,
+				Name:       r.Name,
+				Index:      instance.IndexKey,
+				Provider:   r.Provider,
+				Attributes: instance.Attributes,
+			}
+			resource.Address = stateAddress(r.Module, r.Mode, r.Type, r.Name, instance.IndexKey)
+			if resource.Attributes == nil {
+				resource.Attributes = map[string]interface{}{}
+			}
+			for _, path := range instance.SensitiveAttributes {
+				if p, ok := sensitiveAttributePath(path); ok {
+					resource.SensitivePaths = append(resource.SensitivePaths, p)
+				}
+			}
+			state.Resources = append(state.Resources, resource)
+		}
+	}
+
+	return state, nil
+}
+
+// parseShowState normaliza a saída de terraform show -json
+func (sa *StateAnalyzer) parseShowState(data []byte) (*models.TerraformState, error) {
+	var show showState
+	if err := json.Unmarshal(data, &show); err != nil {
+		return nil, fmt.Errorf("invalid terraform state JSON: %w", err)
+	}
+
+	state := &models.TerraformState{
+		Format:           "show-json",
+		TerraformVersion: show.TerraformVersion,
+		Resources:        []models.StateResource{},
+	}
+	if show.Values == nil {
+		// State vazio
+		return state, nil
+	}
+
+	var visit func(m showModule)
+	visit = func(m showModule) {
+		for _, r := range m.Resources {
+			resource := models.StateResource{
+				Address:    r.Address,
+				Module:     m.Address,
+				Mode:       r.Mode,
+				Type:       r.Type,
+				Name:       r.Name,
+				Index:      r.Index,
+				Provider:   r.ProviderName,
+				Attributes: r.Values,
+			}
+			if resource.Attributes == nil {
+				resource.Attributes = map[string]interface{}{}
+			}
+			resource.SensitivePaths = sensitiveValuePaths(r.SensitiveValues, "")
+			state.Resources = append(state.Resources, resource)
+		}
+		for _, child := range m.ChildModules {
+			visit(child)
+		}
+	}
+	visit(show.Values.RootModule)
+
+	return state, nil
+}
+
+// stateAddress monta o endereço de uma instância, ex.:
+// module.network.aws_subnet.private["a"] ou data.aws_ami.ubuntu
+func stateAddress(module, mode, resourceType, name string, index interface{}) string {
+	address := resourceType + "." + name
+	if mode == "data" {
+		address = "data." + address
+	}
+	switch key := index.(type) {
+	case float64:
+		address += "[" + strconv.FormatFloat(key, 'f', -1, 64) + "]"
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	}
+	if module != "" {
+		address = module + "." + address
+	}
+	return address
+}
+
+// sensitiveAttributePath converte um caminho de sensitive_attributes do
+// tfstate, ex.: [{"type":"get_attr","value":"password"}], em texto
+func sensitiveAttributePath(raw json.RawMessage) (string, bool) {
+	var steps []sensitivePathStep
+	if err := json.Unmarshal(raw, &steps); err != nil || len(steps) == 0 {
+		return "", false
+	}
+
+	path := ""
+	for _, step := range steps {
+		switch step.Type {
+		case "get_attr":
+			var name string
+			if err := json.Unmarshal(step.Value, &name); err != nil {
+				return "", false
+			}
+			path = joinPath(path, name)
+		case "index":
+			var key struct {
+				Value interface{} `json:"value"`
+			}
+			if err := json.Unmarshal(step.Value, &key); err != nil {
+				return "", false
+			}
+			switch k := key.Value.(type) {
+			case float64:
+				path += "[" + strconv.FormatFloat(k, 'f', -1, 64) + "]"
+			case string:
+				path = joinPath(path, k)
+			default:
+				return path, path != ""
+			}
+		default:
+			return path, path != ""
+		}
+	}
+	return path, true
+}
+
+// sensitiveValuePaths lista os caminhos marcados como true em sensitive_values
+func sensitiveValuePaths(values interface{}, prefix string) []string {
+	paths := []string{}
+	switch v := values.(type) {
+	case bool:
+		if v && prefix != "" {
+			paths = append(paths, prefix)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			paths = append(paths, sensitiveValuePaths(v[k], joinPath(prefix, k))...)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			paths = append(paths, sensitiveValuePaths(elem, fmt.Sprintf("%s[%d]", prefix, i))...)
+		}
+	}
+	return paths
+}
+
+// indexPattern encontra os índices de instância em endereços
+var indexPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// stripIndexes remove os índices de instância de um endereço, ex.:
+// module.app["a"].aws_instance.web[0] -> module.app.aws_instance.web
+func stripIndexes(address string) string {
+	return indexPattern.ReplaceAllString(address, "")
+}
+
+// DetectDrift compara a configuração analisada com o state. Reporta
+// atributos configurados cujo valor aplicado difere, recursos no state que
+// não estão mais declarados e recursos declarados que nunca foram aplicados.
+// Recursos de módulos que o analisador não segue (sources remotos) são
+// listados como não verificados.
+func (sa *StateAnalyzer) DetectDrift(config *models.TerraformAnalysis, state *models.TerraformState) *models.DriftAnalysis {
+	drift := &models.DriftAnalysis{
+		StateFormat:      state.Format,
+		TerraformVersion: state.TerraformVersion,
+		Serial:           state.Serial,
+		Drifted:          []models.DriftedResource{},
+		Orphaned:         []models.OrphanedResource{},
+		Unapplied:        []string{},
+	}
+
+	managed := make(map[string]*models.StateResource)
+	byPattern := make(map[string][]string)
+	for i := range state.Resources {
+		r := &state.Resources[i]
+		if r.Mode != "managed" {
+			continue
+		}
+		managed[r.Address] = r
+		pattern := stripIndexes(r.Address)
+		byPattern[pattern] = append(byPattern[pattern], r.Address)
+	}
+
+	// Módulos locais analisados; recursos de outros módulos não podem ser
+	// comparados com a configuração
+	localModules := make(map[string]bool)
+	for _, m := range config.Modules {
+		if isLocalModuleSource(m.Source) {
+			parent := stripIndexes(m.Module)
+			if parent != "" {
+				parent += "."
+			}
+			localModules[parent+"module."+m.Name] = true
+		}
+	}
+
+	matched := make(map[string]bool)
+	for _, r := range config.Resources {
+		// Com count/for_each desconhecido qualquer instância do state corresponde
+		if r.UnknownCardinality {
+			instances := byPattern[stripIndexes(r.Address)]
+			if len(instances) == 0 {
+				drift.Unapplied = append(drift.Unapplied, r.Address)
+			}
+			for _, address := range instances {
+				matched[address] = true
+			}
+			continue
+		}
+
+		s, ok := managed[r.Address]
+		if !ok {
+			drift.Unapplied = append(drift.Unapplied, r.Address)
+			continue
+		}
+		matched[r.Address] = true
+
+		comparer := &driftComparer{resourceType: r.Type, unknown: r.UnknownAttributes, sensitive: s.SensitivePaths}
+		comparer.compare("", r.Attributes, s.Attributes)
+		if len(comparer.drifts) == 0 {
+			drift.InSync++
+			continue
+		}
+		drift.Drifted = append(drift.Drifted, models.DriftedResource{
+			Address:    r.Address,
+			Type:       r.Type,
+			File:       r.File,
+			Line:       r.LineStart,
+			Attributes: comparer.drifts,
+		})
+	}
+
+	for _, r := range state.Resources {
+		if r.Mode != "managed" || matched[r.Address] {
+			continue
+		}
+		if r.Module != "" && !localModules[stripIndexes(r.Module)] {
+			drift.Unverified = append(drift.Unverified, r.Address)
+			continue
+		}
+		drift.Orphaned = append(drift.Orphaned, models.OrphanedResource{
+			Address: r.Address,
+			Module:  r.Module,
+			Mode:    r.Mode,
+			Type:    r.Type,
+		})
+	}
+
+	drift.HasDrift = len(drift.Drifted) > 0 || len(drift.Orphaned) > 0 || len(drift.Unapplied) > 0
+
+	sa.logger.Info("Detecção de drift concluída",
+		"drifted", len(drift.Drifted),
+		"orphaned", len(drift.Orphaned),
+		"unapplied", len(drift.Unapplied),
+		"in_sync", drift.InSync)
+
+	return drift
+}
+
+// driftIgnoredAttributes são meta-argumentos que não existem no state
+var driftIgnoredAttributes = map[string]bool{
+	"depends_on":  true,
+	"provider":    true,
+	"lifecycle":   true,
+	"provisioner": true,
+	"connection":  true,
+	"timeouts":    true,
+	"dynamic":     true,
+}
+
+// driftExactMaps são atributos de tipo mapa em que chaves adicionadas fora
+// do Terraform também são drift
+var driftExactMaps = map[string]bool{
+	"tags":   true,
+	"labels": true,
+}
+
+// driftSensitiveAttributes são atributos marcados como sensitive nos schemas
+// dos providers. States antigos não os listam em sensitive_attributes, então
+// são mascarados pelo nome em qualquer nível.
+var driftSensitiveAttributes = map[string]bool{
+	"password":                     true,
+	"master_password":              true,
+	"admin_password":               true,
+	"administrator_login_password": true,
+	"secret":                       true,
+	"client_secret":                true,
+	"secret_string":                true,
+	"secret_binary":                true,
+	"secret_access_key":            true,
+	"private_key":                  true,
+	"private_key_pem":              true,
+	"auth_token":                   true,
+	"token":                        true,
+	"sas_token":                    true,
+	"primary_access_key":           true,
+	"secondary_access_key":         true,
+	"connection_string":            true,
+	"primary_connection_string":    true,
+	"secondary_connection_string":  true,
+}
+
+// driftSensitiveTypeAttributes são atributos de nome genérico que o schema
+// do provider marca como sensitive apenas em alguns tipos de recurso
+var driftSensitiveTypeAttributes = map[string][]string{
+	"aws_ssm_parameter":                    {"value"},
+	"kubernetes_secret":                    {"data", "binary_data"},
+	"kubernetes_secret_v1":                 {"data", "binary_data"},
+	"random_password":                      {"result", "bcrypt_hash"},
+	"azurerm_key_vault_secret":             {"value"},
+	"google_secret_manager_secret_version": {"secret_data"},
+}
+
+// driftComparer compara os atributos configurados de um recurso com o state.
+// Só os atributos presentes na configuração são comparados, já que o state
+// também guarda atributos computados.
+type driftComparer struct {
+	resourceType string   // tipo do recurso, para os atributos sensíveis do schema
+	unknown      []string // caminhos desconhecidos na configuração
+	sensitive    []string // caminhos sensíveis no state
+	drifts       []models.AttributeDrift
+}
+
+// compare registra as divergências entre configured e actual em path
+func (c *driftComparer) compare(path string, configured, actual interface{}) {
+	switch cv := configured.(type) {
+	case map[string]interface{}:
+		am, ok := actual.(map[string]interface{})
+		if !ok {
+			c.add(path, configured, actual)
+			return
+		}
+
+		keys := make([]string, 0, len(cv))
+		for k := range cv {
+			if k == "_labels" || (path == "" && driftIgnoredAttributes[k]) {
+				continue
+			}
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			c.compare(joinPath(path, k), cv[k], am[k])
+		}
+
+		if driftExactMaps[path] {
+			extra := []string{}
+			for k := range am {
+				if _, ok := cv[k]; !ok {
+					extra = append(extra, k)
+				}
+			}
+			sort.Strings(extra)
+			for _, k := range extra {
+				c.add(joinPath(path, k), nil, am[k])
+			}
+		}
+
+	case []interface{}:
+		// Elementos desconhecidos foram omitidos da lista configurada
+		if c.unknownBelow(path) {
+			return
+		}
+		al, ok := actual.([]interface{})
+		if !ok || len(al) != len(cv) {
+			c.add(path, configured, actual)
+			return
+		}
+
+		ordered := &driftComparer{resourceType: c.resourceType, unknown: c.unknown, sensitive: c.sensitive}
+		for i := range cv {
+			ordered.compare(fmt.Sprintf("%s[%d]", path, i), cv[i], al[i])
+		}
+		if len(ordered.drifts) == 0 || c.matchesUnordered(path, cv, al) {
+			// Sets não têm ordem garantida no state
+			return
+		}
+		c.drifts = append(c.drifts, ordered.drifts...)
+
+	default:
+		if !scalarEqual(configured, actual) {
+			c.add(path, configured, actual)
+		}
+	}
+}
+
+// matchesUnordered verifica se cada elemento configurado corresponde a um
+// elemento distinto do state, independente da ordem
+func (c *driftComparer) matchesUnordered(path string, configured, actual []interface{}) bool {
+	used := make([]bool, len(actual))
+	for _, cv := range configured {
+		found := false
+		for j, av := range actual {
+			if used[j] {
+				continue
+			}
+			probe := &driftComparer{resourceType: c.resourceType, unknown: c.unknown, sensitive: c.sensitive}
+			probe.compare(path, cv, av)
+			if len(probe.drifts) == 0 {
+				used[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// add registra uma divergência, omitindo valores sensíveis
+func (c *driftComparer) add(path string, configured, actual interface{}) {
+	drift := models.AttributeDrift{Path: path, Configured: configured, Actual: actual}
+	if c.isSensitive(path) || containsRedacted(configured) {
+		drift.Configured = nil
+		drift.Actual = nil
+		drift.Sensitive = true
+	}
+	c.drifts = append(c.drifts, drift)
+}
+
+// isSensitive verifica se path é sensível no state ou no schema do provider
+func (c *driftComparer) isSensitive(path string) bool {
+	for _, s := range c.sensitive {
+		if isPathWithin(path, s) || isPathWithin(s, path) {
+			return true
+		}
+	}
+
+	steps := strings.Split(path, ".")
+	for i, step := range steps {
+		name := step
+		if idx := strings.Index(name, "["); idx >= 0 {
+			name = name[:idx]
+		}
+		if driftSensitiveAttributes[name] {
+			return true
+		}
+		if i == 0 {
+			for _, attr := range driftSensitiveTypeAttributes[c.resourceType] {
+				if name == attr {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// containsRedacted verifica se o valor configurado veio de uma variável ou
+// output sensível, em qualquer nível
+func containsRedacted(v interface{}) bool {
+	switch val := v.(type) {
+	case string:
+		return val == redactedValue
+	case map[string]interface{}:
+		for _, elem := range val {
+			if containsRedacted(elem) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, elem := range val {
+			if containsRedacted(elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unknownBelow verifica se algum caminho desconhecido está em path ou abaixo
+func (c *driftComparer) unknownBelow(path string) bool {
+	for _, u := range c.unknown {
+		if isPathWithin(u, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPathWithin verifica se path é igual a parent ou está abaixo dele
+func isPathWithin(path, parent string) bool {
+	if parent == "" || path == parent {
+		return true
+	}
+	return strings.HasPrefix(path, parent+".") || strings.HasPrefix(path, parent+"[")
+}
+
+// scalarEqual compara valores escalares como o Terraform os converte: o state
+// pode guardar como string um número configurado e vice-versa
+func scalarEqual(configured, actual interface{}) bool {
+	if configured == nil || actual == nil {
+		return configured == nil && actual == nil
+	}
+	return scalarString(configured) == scalarString(actual)
+}
+
+// scalarString converte um escalar em sua forma textual
+func scalarString(v interface{}) string {
+	switch val := v.(type) {
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case string:
+		// Números configurados como string ("10") e lidos como número
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+		return val
+	default:
+		return fmt.Sprint(val)
+	}
+}
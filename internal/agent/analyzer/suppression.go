@@ -0,0 +1,307 @@
+package analyzer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// ExceptionsFileName é o arquivo de exceções procurado na raiz do diretório
+// analisado
+const ExceptionsFileName = ".iac-agent-exceptions.yaml"
+
+// suppressionDateLayout é o formato das datas de expiração
+const suppressionDateLayout = "2006-01-02"
+
+var (
+	// suppressionComment casa comentários "# iac-agent:ignore ID[,ID] k=v ..."
+	// (também com // ou /* */)
+	suppressionComment = regexp.MustCompile(`(?:#|//|/\*)\s*iac-agent:ignore\s+([^\s=]+)(.*)$`)
+
+	// suppressionParam casa os parâmetros chave=valor ou chave="valor"
+	suppressionParam = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|(\S+))`)
+)
+
+// LoadExceptionsFile carrega o arquivo de exceções do repositório
+func LoadExceptionsFile(exceptionsPath string) ([]models.Suppression, error) {
+	data, err := os.ReadFile(exceptionsPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo de exceções: %w", err)
+	}
+	return ParseExceptionsFile(data)
+}
+
+// ParseExceptionsFile faz o parsing do arquivo de exceções. Entradas sem
+// justificativa ou com data inválida não são aplicadas e aparecem como
+// inválidas no relatório.
+func ParseExceptionsFile(data []byte) ([]models.Suppression, error) {
+	var file models.ExceptionsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("erro ao fazer parse do arquivo de exceções: %w", err)
+	}
+
+	for i := range file.Exceptions {
+		file.Exceptions[i].Source = "exceptions_file"
+	}
+	return file.Exceptions, nil
+}
+
+// ParseInlineSuppressions extrai os comentários iac-agent:ignore dos arquivos
+// (caminho -> conteúdo). O comentário vale para o recurso em que está ou para
+// o recurso declarado logo abaixo dele; fora de um recurso, vale para o
+// arquivo inteiro.
+func ParseInlineSuppressions(files map[string]string, tfAnalysis *models.TerraformAnalysis) []models.Suppression {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	suppressions := []models.Suppression{}
+	for _, filename := range filenames {
+		lines := []string{}
+		scanner := bufio.NewScanner(strings.NewReader(files[filename]))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+
+		for i, text := range lines {
+			match := suppressionComment.FindStringSubmatch(text)
+			if match == nil {
+				continue
+			}
+
+			params := map[string]string{}
+			for _, param := range suppressionParam.FindAllStringSubmatch(match[2], -1) {
+				value := param[2]
+				if value == "" {
+					value = strings.TrimSuffix(param[3], "*/")
+				}
+				params[param[1]] = value
+			}
+
+			resource, stack := suppressedResource(tfAnalysis, filename, lines, i+1)
+			for _, checkID := range strings.Split(match[1], ",") {
+				if checkID = strings.TrimSpace(checkID); checkID == "" {
+					continue
+				}
+				suppressions = append(suppressions, models.Suppression{
+					CheckID:  checkID,
+					Resource: resource,
+					File:     filename,
+					Stack:    stack,
+					Reason:   params["reason"],
+					Until:    params["until"],
+					Line:     i + 1,
+					Source:   "inline",
+				})
+			}
+		}
+	}
+	return suppressions
+}
+
+// suppressedResource retorna o endereço (sem índices) e o stack do recurso ao
+// qual um comentário se aplica: o recurso que contém a linha ou o primeiro
+// recurso abaixo dela, separados apenas por linhas em branco ou comentários
+func suppressedResource(tfAnalysis *models.TerraformAnalysis, filename string, lines []string, line int) (string, string) {
+	var next *models.TerraformResource
+	for i := range tfAnalysis.Resources {
+		r := &tfAnalysis.Resources[i]
+		if r.File != filename {
+			continue
+		}
+		if line >= r.LineStart && line <= r.LineEnd {
+			return stripIndexes(r.Address), r.Stack
+		}
+		if r.LineStart > line && (next == nil || r.LineStart < next.LineStart) {
+			next = r
+		}
+	}
+	if next == nil {
+		return "", ""
+	}
+
+	for i := line; i < next.LineStart-1 && i < len(lines); i++ {
+		text := strings.TrimSpace(lines[i])
+		if text != "" && !strings.HasPrefix(text, "#") && !strings.HasPrefix(text, "//") {
+			return "", ""
+		}
+	}
+	return stripIndexes(next.Address), next.Stack
+}
+
+// ApplySuppressions remove da análise de segurança e dos riscos IAM os
+// findings cobertos por supressões válidas e não expiradas, recalculando os
+// totais. Supressões expiradas deixam de valer e o finding volta a contar.
+func ApplySuppressions(suppressions []models.Suppression, security *models.SecurityAnalysis, iam *models.IAMAnalysis, now time.Time) *models.SuppressionReport {
+	report := &models.SuppressionReport{
+		Suppressed: []models.SuppressedFinding{},
+		Active:     []models.Suppression{},
+		Expired:    []models.Suppression{},
+		Invalid:    []models.Suppression{},
+	}
+
+	today := now.Format(suppressionDateLayout)
+	active := []*models.Suppression{}
+	for i := range suppressions {
+		s := &suppressions[i]
+		switch {
+		case s.CheckID == "":
+			s.Error = "check_id não informado"
+		case s.Source == "inline" && strings.ContainsAny(s.CheckID, "*?["):
+			s.Error = fmt.Sprintf("check_id %q inválido: padrões glob só são aceitos no arquivo de exceções", s.CheckID)
+		case strings.TrimSpace(s.Reason) == "":
+			s.Error = "justificativa (reason) obrigatória"
+		case s.Until != "":
+			if _, err := time.Parse(suppressionDateLayout, s.Until); err != nil {
+				s.Error = fmt.Sprintf("data de expiração %q inválida (use YYYY-MM-DD)", s.Until)
+			}
+		}
+
+		switch {
+		case s.Error != "":
+			report.Invalid = append(report.Invalid, *s)
+		case s.Until != "" && s.Until < today:
+			report.Expired = append(report.Expired, *s)
+		default:
+			active = append(active, s)
+		}
+	}
+
+	if security != nil {
+		findings := []models.SecurityFinding{}
+		for _, finding := range security.Findings {
+			if s := findSuppression(active, finding.CheckID, finding.Resource, finding.File); s != nil {
+				report.Suppressed = append(report.Suppressed, suppressedFinding(finding, s))
+				continue
+			}
+			findings = append(findings, finding)
+		}
+
+		suppressed := len(security.Findings) - len(findings)
+		security.Findings = findings
+		security.ChecksFailed -= suppressed
+		if security.ChecksFailed < 0 {
+			security.ChecksFailed = 0
+		}
+		countSeverities(security)
+	}
+
+	if iam != nil {
+		risks := []models.PrincipalRisk{}
+		for _, risk := range iam.PrincipalRisks {
+			if s := findSuppression(active, risk.CheckID, risk.Resource, ""); s != nil {
+				report.Suppressed = append(report.Suppressed, suppressedFinding(models.SecurityFinding{
+					CheckID:     risk.CheckID,
+					Severity:    strings.ToUpper(risk.RiskLevel),
+					Resource:    risk.Resource,
+					Description: risk.Reason,
+				}, s))
+				continue
+			}
+			risks = append(risks, risk)
+		}
+		iam.PrincipalRisks = risks
+	}
+
+	for _, s := range active {
+		report.Active = append(report.Active, *s)
+	}
+	return report
+}
+
+// findSuppression retorna a primeira supressão ativa que cobre o finding
+func findSuppression(active []*models.Suppression, checkID, resource, file string) *models.Suppression {
+	if checkID == "" {
+		return nil
+	}
+	for _, s := range active {
+		if suppressionMatches(s, checkID, resource, file) {
+			s.Matched++
+			return s
+		}
+	}
+	return nil
+}
+
+// suppressedFinding registra um finding suprimido com a justificativa
+func suppressedFinding(finding models.SecurityFinding, s *models.Suppression) models.SuppressedFinding {
+	return models.SuppressedFinding{
+		Finding: finding,
+		Reason:  s.Reason,
+		Until:   s.Until,
+		Source:  s.Source,
+	}
+}
+
+// suppressionMatches verifica se a supressão cobre o check, o stack, o
+// recurso e o arquivo de um finding. Comentários inline exigem o id exato do
+// check; padrões glob valem apenas no arquivo de exceções. Em análises com
+// vários stacks o endereço do finding vem qualificado com o stack (ver
+// StackAddress) ou o stack é deduzido do arquivo. Recursos de módulos podem
+// ser reportados sem o prefixo module.x (ex.: pelo Checkov), então, quando a
+// supressão está presa ao arquivo do finding, o endereço do finding também
+// casa com o final do endereço da supressão.
+func suppressionMatches(s *models.Suppression, checkID, resource, file string) bool {
+	if s.CheckID != checkID && (s.Source == "inline" || !matchesAny([]string{s.CheckID}, checkID)) {
+		return false
+	}
+
+	stack, resource := splitStackAddress(resource)
+	suppressionStack, suppressionResource := splitStackAddress(s.Resource)
+	if suppressionStack == "" {
+		suppressionStack = s.Stack
+	}
+	if suppressionStack != "" {
+		if stack != "" && stack != suppressionStack ||
+			stack == "" && (file == "" || !fileInStack(file, suppressionStack)) {
+			return false
+		}
+	}
+
+	if s.File != "" {
+		// Riscos IAM não têm arquivo: só supressões de recurso se aplicam
+		if file == "" && s.Resource == "" || file != "" && !sameFile(s.File, file) {
+			return false
+		}
+	}
+	if suppressionResource == "" {
+		return true
+	}
+
+	base := stripIndexes(resource)
+	if suppressionResource == resource || suppressionResource == base ||
+		matchesAny([]string{suppressionResource}, resource) {
+		return true
+	}
+
+	// Endereço sem o prefixo do módulo: só casa quando o finding está no
+	// arquivo da supressão, senão um recurso homônimo do módulo raiz também
+	// seria suprimido
+	return s.File != "" && file != "" && isModuleAddress(suppressionResource) &&
+		strings.HasSuffix(suppressionResource, "."+base)
+}
+
+// isModuleAddress indica se o endereço pertence a um módulo (module.x...)
+func isModuleAddress(address string) bool {
+	return strings.HasPrefix(address, "module.")
+}
+
+// sameFile compara caminhos de arquivo relativos ou absolutos: o Checkov
+// reporta caminhos relativos ao diretório analisado (ex.: /main.tf)
+func sameFile(a, b string) bool {
+	a = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(a)), "/")
+	b = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(b)), "/")
+	return a == b || strings.HasSuffix(a, "/"+b) || strings.HasSuffix(b, "/"+a)
+}
@@ -2,107 +2,81 @@ package analyzer
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/govinda777/iac-ai-agent/internal/models"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/zclconf/go-cty/cty"
-	"github.com/zclconf/go-cty/cty/function"
-	"github.com/zclconf/go-cty/cty/function/stdlib"
 )
 
-var terraformFunctions = map[string]function.Function{
-	"jsonencode": function.New(&function.Spec{
-		Params: []function.Parameter{
-			{
-				Name:             "val",
-				Type:             cty.DynamicPseudoType,
-				AllowNull:        true,
-				AllowUnknown:     true,
-				AllowDynamicType: true,
-			},
-		},
-		Type: function.StaticReturnType(cty.String),
-		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
-			return stdlib.JSONEncode(args[0])
-		},
-	}),
-}
-
 // TerraformAnalyzer realiza análise de código Terraform
 type TerraformAnalyzer struct {
-	parser *hclparse.Parser
+	workers int         // arquivos parseados em paralelo
+	cache   *parseCache // arquivos parseados, reutilizados entre análises
 }
 
 // NewTerraformAnalyzer cria uma nova instância do analisador
 func NewTerraformAnalyzer() *TerraformAnalyzer {
 	return &TerraformAnalyzer{
-		parser: hclparse.NewParser(),
+		workers: runtime.NumCPU(),
+		cache:   newParseCache(DefaultParseCacheSize),
 	}
 }
 
-// AnalyzeDirectory analisa todos os arquivos Terraform em um diretório
-func (ta *TerraformAnalyzer) AnalyzeDirectory(dir string) (*models.TerraformAnalysis, error) {
-	analysis := &models.TerraformAnalysis{
-		Valid:                true,
-		Resources:            []models.TerraformResource{},
-		Modules:              []models.TerraformModule{},
-		Variables:            []models.TerraformVariable{},
-		Outputs:              []models.TerraformOutput{},
-		Providers:            []string{},
-		SyntaxErrors:         []models.SyntaxError{},
-		BestPracticeWarnings: []string{},
-	}
-
-	// Busca arquivos .tf no diretório
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
+// moduleFile representa um arquivo de configuração já parseado
+type moduleFile struct {
+	path    string
+	file    *hcl.File
+	content *hcl.BodyContent
+}
 
-		if info.IsDir() || !strings.HasSuffix(path, ".tf") {
-			return nil
-		}
+// AnalyzeDirectory analisa todos os arquivos Terraform em um diretório.
+// Os módulos raiz (stacks) são descobertos e analisados separadamente por
+// AnalyzeStacks. Com um único módulo raiz sua análise é retornada
+// diretamente; com vários, o resultado agrega os stacks e guarda a análise de
+// cada um em Stacks.
+func (ta *TerraformAnalyzer) AnalyzeDirectory(dir string, varFiles ...string) (*models.TerraformAnalysis, error) {
+	stacks, err := ta.AnalyzeStacks(dir, varFiles...)
+	if err != nil {
+		return nil, err
+	}
 
-		// Analisa o arquivo
-		fileAnalysis, err := ta.analyzeFile(path)
-		if err != nil {
-			// Adiciona erro de sintaxe
-			analysis.Valid = false
-			analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
-				File:    path,
-				Message: err.Error(),
-			})
-			return nil // Continua processando outros arquivos
-		}
+	if len(stacks) == 1 {
+		return stacks[0], nil
+	}
+	return mergeStacks(stacks), nil
+}
 
-		// Merge resultados
-		ta.mergeAnalysis(analysis, fileAnalysis)
-		return nil
-	})
+// AnalyzeContent analisa conteúdo Terraform direto. Conteúdo com filename
+// terminado em .json é interpretado como sintaxe JSON do Terraform.
+func (ta *TerraformAnalyzer) AnalyzeContent(content string, filename string) (*models.TerraformAnalysis, error) {
+	analysis := newTerraformAnalysis()
 
-	if err != nil {
-		return nil, fmt.Errorf("erro ao percorrer diretório: %w", err)
+	file, diags := parseConfigFile([]byte(content), filename)
+	if diags.HasErrors() {
+		ta.appendDiagnostics(analysis, filename, diags)
+		return analysis, nil
 	}
 
-	// Calcula totais
-	analysis.TotalResources = len(analysis.Resources)
-	analysis.TotalModules = len(analysis.Modules)
-	analysis.TotalVariables = len(analysis.Variables)
-	analysis.TotalOutputs = len(analysis.Outputs)
+	// Parse resources, modules, etc
+	// Conteúdo inline não tem acesso ao sistema de arquivos
+	f := &moduleFile{path: filename, file: file}
+	f.content = ta.decodeFile(f, analysis)
+	loader := newModuleLoader(hclparse.NewParser(), "", nil, nil)
+	mod := newModuleInstance("", "", newEvalScope("", "", newTerraformFunctions("", "")), []*moduleFile{f})
+	ta.analyzeModule(loader, mod, nil, analysis)
 
-	// Verifica best practices
-	ta.checkBestPractices(analysis)
+	ta.finalizeAnalysis(analysis, false)
 
 	return analysis, nil
 }
 
-// AnalyzeContent analisa conteúdo Terraform direto
-func (ta *TerraformAnalyzer) AnalyzeContent(content string, filename string) (*models.TerraformAnalysis, error) {
-	analysis := &models.TerraformAnalysis{
+// newTerraformAnalysis cria uma análise vazia e válida
+func newTerraformAnalysis() *models.TerraformAnalysis {
+	return &models.TerraformAnalysis{
 		Valid:                true,
 		Resources:            []models.TerraformResource{},
 		Modules:              []models.TerraformModule{},
@@ -112,50 +86,142 @@ func (ta *TerraformAnalyzer) AnalyzeContent(content string, filename string) (*m
 		SyntaxErrors:         []models.SyntaxError{},
 		BestPracticeWarnings: []string{},
 	}
+}
 
-	file, diags := ta.parser.ParseHCL([]byte(content), filename)
-	if diags.HasErrors() {
-		analysis.Valid = false
-		for _, diag := range diags {
-			analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
-				File:    filename,
-				Line:    diag.Subject.Start.Line,
-				Column:  diag.Subject.Start.Column,
-				Message: diag.Summary,
-				Snippet: diag.Detail,
-			})
-		}
-		return analysis, nil
-	}
-
-	// Parse resources, modules, etc
-	ta.parseFile(file, filename, analysis)
-
-	// Calcula totais
+// finalizeAnalysis calcula totais e verifica best practices. lockExpected
+// indica se a análise cobre um diretório, onde o lock file deveria existir.
+func (ta *TerraformAnalyzer) finalizeAnalysis(analysis *models.TerraformAnalysis, lockExpected bool) {
 	analysis.TotalResources = len(analysis.Resources)
 	analysis.TotalModules = len(analysis.Modules)
 	analysis.TotalVariables = len(analysis.Variables)
 	analysis.TotalOutputs = len(analysis.Outputs)
 
+	// Providers únicos
+	seen := make(map[string]bool)
+	providers := []string{}
+	for _, p := range analysis.Providers {
+		if !seen[p] {
+			seen[p] = true
+			providers = append(providers, p)
+		}
+	}
+	analysis.Providers = providers
+
+	ta.checkCycles(analysis)
+	ta.checkSettings(analysis, lockExpected)
 	ta.checkBestPractices(analysis)
+}
 
-	return analysis, nil
+// appendDiagnostics registra diagnósticos de erro como erros de sintaxe
+func (ta *TerraformAnalyzer) appendDiagnostics(analysis *models.TerraformAnalysis, filename string, diags hcl.Diagnostics) {
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+
+		analysis.Valid = false
+		syntaxErr := models.SyntaxError{
+			File:    filename,
+			Message: diag.Summary,
+			Snippet: diag.Detail,
+		}
+		if diag.Subject != nil {
+			syntaxErr.Line = diag.Subject.Start.Line
+			syntaxErr.Column = diag.Subject.Start.Column
+		}
+		analysis.SyntaxErrors = append(analysis.SyntaxErrors, syntaxErr)
+	}
 }
 
-// analyzeFile analisa um arquivo individual
-func (ta *TerraformAnalyzer) analyzeFile(path string) (*models.TerraformAnalysis, error) {
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao ler arquivo: %w", err)
+// analyzeModule avalia os arquivos de um mesmo módulo em conjunto, construindo
+// o contexto de avaliação antes de extrair recursos, módulos e outputs.
+// Retorna os valores dos outputs do módulo.
+func (ta *TerraformAnalyzer) analyzeModule(
+	loader *moduleLoader,
+	mod *moduleInstance,
+	varFiles []string,
+	analysis *models.TerraformAnalysis,
+) map[string]cty.Value {
+	loader.visited[mod.dir] = true
+	scope := mod.scope
+
+	// 1. Variáveis e seus defaults
+	for _, f := range mod.files {
+		for _, block := range f.content.Blocks {
+			if block.Type == "variable" {
+				ta.parseVariable(block, f, mod, analysis)
+			}
+		}
+	}
+
+	// 2. Valores de terraform.tfvars, *.auto.tfvars e -var-file
+	for _, path := range varFiles {
+		values, diags := loadTfvars(loader.parser, path)
+		ta.appendDiagnostics(analysis, path, diags)
+		for name, val := range values {
+			scope.setVariable(name, val)
+		}
 	}
 
-	return ta.AnalyzeContent(string(content), path)
+	// 3. Inputs recebidos do módulo chamador
+	for name, val := range mod.inputs {
+		if !scope.setVariable(name, val) {
+			analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
+				fmt.Sprintf("Módulo %s recebe o input %s, que não é declarado como variável", mod.address, name))
+		}
+	}
+
+	// 4. Locals
+	locals := []*hcl.Attribute{}
+	for _, f := range mod.files {
+		for _, block := range f.content.Blocks {
+			if block.Type != "locals" {
+				continue
+			}
+			attrs, diags := block.Body.JustAttributes()
+			ta.appendDiagnostics(analysis, f.path, diags)
+			for _, attr := range attrs {
+				locals = append(locals, attr)
+			}
+		}
+	}
+	scope.evaluateLocals(locals)
+
+	// 4.1. Documentos aws_iam_policy_document, que só dependem de variáveis,
+	// locals e outros documentos. Os locals são reavaliados para enxergar o
+	// JSON gerado.
+	if ta.evaluatePolicyDocuments(mod) > 0 {
+		scope.evaluateLocals(locals)
+	}
+
+	// 5. Chamadas de módulos. Os locals são reavaliados para enxergar os
+	// outputs dos módulos analisados.
+	for _, f := range mod.files {
+		for _, block := range f.content.Blocks {
+			if block.Type == "module" {
+				ta.parseModule(loader, block, f.path, mod, analysis)
+			}
+		}
+	}
+	if len(scope.modules) > 0 {
+		scope.evaluateLocals(locals)
+	}
+
+	// 6. Demais blocos
+	firstResource := len(analysis.Resources)
+	for _, f := range mod.files {
+		ta.parseFile(f, mod, analysis)
+	}
+
+	// 7. Grafo de dependências
+	ta.buildGraph(mod, firstResource, analysis)
+
+	return mod.outputs
 }
 
-// parseFile faz parsing de um arquivo HCL
-func (ta *TerraformAnalyzer) parseFile(file *hcl.File, filename string, analysis *models.TerraformAnalysis) {
-	// Extrai o conteúdo do body
-	body, diags := file.Body.Content(&hcl.BodySchema{
+// decodeFile extrai os blocos de nível superior de um arquivo
+func (ta *TerraformAnalyzer) decodeFile(f *moduleFile, analysis *models.TerraformAnalysis) *hcl.BodyContent {
+	body, diags := f.file.Body.Content(&hcl.BodySchema{
 		Blocks: []hcl.BlockHeaderSchema{
 			{Type: "resource", LabelNames: []string{"type", "name"}},
 			{Type: "module", LabelNames: []string{"name"}},
@@ -163,45 +229,40 @@ func (ta *TerraformAnalyzer) parseFile(file *hcl.File, filename string, analysis
 			{Type: "output", LabelNames: []string{"name"}},
 			{Type: "provider", LabelNames: []string{"name"}},
 			{Type: "data", LabelNames: []string{"type", "name"}},
+			{Type: "locals"},
+			{Type: "terraform"},
 		},
 	})
 
-	if diags.HasErrors() {
-		analysis.Valid = false
-		for _, diag := range diags {
-			analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
-				File:    filename,
-				Line:    diag.Subject.Start.Line,
-				Column:  diag.Subject.Start.Column,
-				Message: diag.Summary,
-				Snippet: diag.Detail,
-			})
-		}
-	}
+	ta.appendDiagnostics(analysis, f.path, diags)
+
+	return body
+}
 
+// parseFile extrai recursos, outputs, providers e configurações do bloco
+// terraform de um arquivo
+func (ta *TerraformAnalyzer) parseFile(f *moduleFile, mod *moduleInstance, analysis *models.TerraformAnalysis) {
 	// Parse blocks
-	for _, block := range body.Blocks {
+	for _, block := range f.content.Blocks {
 		switch block.Type {
 		case "resource":
-			ta.parseResource(block, filename, analysis)
-		case "module":
-			ta.parseModule(block, filename, analysis)
-		case "variable":
-			ta.parseVariable(block, filename, analysis)
+			ta.parseResource(block, f.path, mod, analysis)
 		case "output":
-			ta.parseOutput(block, filename, analysis)
+			ta.parseOutput(block, f.path, mod, analysis)
 		case "provider":
 			if len(block.Labels) > 0 {
 				analysis.Providers = append(analysis.Providers, block.Labels[0])
 			}
 		case "data":
-			analysis.TotalDataSources++
+			ta.parseDataSource(block, f.path, mod, analysis)
+		case "terraform":
+			ta.parseTerraformBlock(block, f.path, mod, analysis)
 		}
 	}
 }
 
 // parseResource extrai informações de um resource block
-func (ta *TerraformAnalyzer) parseResource(block *hcl.Block, filename string, analysis *models.TerraformAnalysis) {
+func (ta *TerraformAnalyzer) parseResource(block *hcl.Block, filename string, mod *moduleInstance, analysis *models.TerraformAnalysis) {
 	if len(block.Labels) < 2 {
 		analysis.Valid = false
 		analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
@@ -212,124 +273,152 @@ func (ta *TerraformAnalyzer) parseResource(block *hcl.Block, filename string, an
 		return
 	}
 
-	resource := models.TerraformResource{
-		Type:       block.Labels[0],
-		Name:       block.Labels[1],
-		Provider:   strings.Split(block.Labels[0], "_")[0],
-		File:       filename,
-		LineStart:  block.DefRange.Start.Line,
-		LineEnd:    block.DefRange.End.Line,
-		Attributes: make(map[string]interface{}),
-	}
-
-	// Avalia atributos
-	evalCtx := &hcl.EvalContext{
-		Functions: terraformFunctions,
-	}
-	attrs, diags := block.Body.JustAttributes()
-	if diags.HasErrors() {
-		analysis.Valid = false
-		for _, diag := range diags {
-			analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
-				File:    filename,
-				Line:    diag.Subject.Start.Line,
-				Column:  diag.Subject.Start.Column,
-				Message: diag.Summary,
-				Snippet: diag.Detail,
-			})
-		}
-	}
-	for name, attr := range attrs {
-		val, valDiags := attr.Expr.Value(evalCtx)
-		if valDiags.HasErrors() {
-			continue
+	// Uma instância para cada elemento de count/for_each conhecido
+	instances, known, diags := expandInstances(block.Body, mod.scope)
+	ta.appendDiagnostics(analysis, filename, diags)
+	for _, instance := range instances {
+		resource := models.TerraformResource{
+			Address:            mod.qualify(block.Labels[0] + "." + block.Labels[1] + instance.suffix()),
+			Module:             mod.address,
+			Type:               block.Labels[0],
+			Name:               block.Labels[1],
+			Index:              instance.index(),
+			UnknownCardinality: !known || mod.unknownCardinality,
+			Provider:           strings.Split(block.Labels[0], "_")[0],
+			File:               filename,
+			LineStart:          block.DefRange.Start.Line,
+			LineEnd:            blockEndLine(block),
 		}
 
-		// Tenta converter para tipos Go nativos
-		if val.IsKnown() && !val.IsNull() {
-			switch val.Type() {
-			case cty.String:
-				resource.Attributes[name] = val.AsString()
-			case cty.Bool:
-				resource.Attributes[name] = val.True()
-			case cty.Number:
-				bf := val.AsBigFloat()
-				f64, _ := bf.Float64()
-				resource.Attributes[name] = f64
-			}
-		}
-	}
+		// Avalia atributos e blocos aninhados
+		decoder := newBodyDecoder(instance.scope)
+		resource.Attributes = decoder.decode(block.Body, "")
+		resource.UnknownAttributes = decoder.unknownPaths()
+		resource.Tags = extractTags(resource.Attributes)
+		ta.appendDiagnostics(analysis, filename, decoder.diags)
 
-	analysis.Resources = append(analysis.Resources, resource)
+		analysis.Resources = append(analysis.Resources, resource)
+	}
 }
 
-// parseModule extrai informações de um module block
-func (ta *TerraformAnalyzer) parseModule(block *hcl.Block, filename string, analysis *models.TerraformAnalysis) {
+// parseVariable extrai informações de um variable block e registra seu
+// default no escopo de avaliação
+func (ta *TerraformAnalyzer) parseVariable(block *hcl.Block, f *moduleFile, mod *moduleInstance, analysis *models.TerraformAnalysis) {
 	if len(block.Labels) < 1 {
 		return
 	}
 
-	module := models.TerraformModule{
-		Name:      block.Labels[0],
-		File:      filename,
-		LineStart: block.DefRange.Start.Line,
-		Inputs:    make(map[string]interface{}),
+	variable := models.TerraformVariable{
+		Name:     block.Labels[0],
+		Module:   mod.address,
+		File:     f.path,
+		Required: true,
 	}
 
-	analysis.Modules = append(analysis.Modules, module)
-}
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "type"},
+			{Name: "description"},
+			{Name: "default"},
+			{Name: "sensitive"},
+		},
+	})
+	ta.appendDiagnostics(analysis, f.path, diags)
 
-// parseVariable extrai informações de um variable block
-func (ta *TerraformAnalyzer) parseVariable(block *hcl.Block, filename string, analysis *models.TerraformAnalysis) {
-	if len(block.Labels) < 1 {
-		return
+	vt := variableType{ty: cty.DynamicPseudoType}
+	if attr, ok := content.Attributes["type"]; ok {
+		vt = decodeVariableType(attr.Expr)
+		variable.Type = typeexpr.TypeString(vt.ty)
 	}
 
-	variable := models.TerraformVariable{
-		Name:     block.Labels[0],
-		File:     filename,
-		Required: true,
+	if attr, ok := content.Attributes["description"]; ok {
+		if val, valDiags := attr.Expr.Value(nil); !valDiags.HasErrors() && val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+			variable.Description = val.AsString()
+		}
+	}
+
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		if val, valDiags := attr.Expr.Value(nil); !valDiags.HasErrors() && val.Type() == cty.Bool && val.IsKnown() && !val.IsNull() {
+			variable.Sensitive = val.True()
+		}
 	}
 
+	def := cty.NilVal
+	if attr, ok := content.Attributes["default"]; ok {
+		val, valDiags := attr.Expr.Value(nil)
+		if !valDiags.HasErrors() {
+			def = val
+			variable.Required = false
+			// O default de uma variável sensível nunca é exposto
+			if goVal, ok := ctyToGo(vt.coerce(val)); ok && !variable.Sensitive {
+				variable.Default = goVal
+			}
+		}
+	}
+	mod.scope.declareVariable(variable.Name, vt, def, variable.Sensitive)
+
 	analysis.Variables = append(analysis.Variables, variable)
 }
 
-// parseOutput extrai informações de um output block
-func (ta *TerraformAnalyzer) parseOutput(block *hcl.Block, filename string, analysis *models.TerraformAnalysis) {
+// parseOutput extrai informações de um output block e avalia seu valor, que
+// fica disponível para o módulo chamador
+func (ta *TerraformAnalyzer) parseOutput(block *hcl.Block, filename string, mod *moduleInstance, analysis *models.TerraformAnalysis) {
 	if len(block.Labels) < 1 {
 		return
 	}
 
 	output := models.TerraformOutput{
-		Name: block.Labels[0],
-		File: filename,
+		Name:   block.Labels[0],
+		Module: mod.address,
+		File:   filename,
 	}
 
-	analysis.Outputs = append(analysis.Outputs, output)
-}
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "value"},
+			{Name: "description"},
+			{Name: "sensitive"},
+		},
+	})
+	ta.appendDiagnostics(analysis, filename, diags)
 
-// mergeAnalysis combina resultados de análise de múltiplos arquivos
-func (ta *TerraformAnalyzer) mergeAnalysis(dest, src *models.TerraformAnalysis) {
-	dest.Resources = append(dest.Resources, src.Resources...)
-	dest.Modules = append(dest.Modules, src.Modules...)
-	dest.Variables = append(dest.Variables, src.Variables...)
-	dest.Outputs = append(dest.Outputs, src.Outputs...)
-	dest.SyntaxErrors = append(dest.SyntaxErrors, src.SyntaxErrors...)
+	if attr, ok := content.Attributes["description"]; ok {
+		if val, valDiags := attr.Expr.Value(nil); !valDiags.HasErrors() && val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+			output.Description = val.AsString()
+		}
+	}
 
-	// Merge providers (unique)
-	providerMap := make(map[string]bool)
-	for _, p := range dest.Providers {
-		providerMap[p] = true
+	if attr, ok := content.Attributes["sensitive"]; ok {
+		if val, valDiags := attr.Expr.Value(nil); !valDiags.HasErrors() && val.Type() == cty.Bool && val.IsKnown() && !val.IsNull() {
+			output.Sensitive = val.True()
+		}
 	}
-	for _, p := range src.Providers {
-		providerMap[p] = true
+
+	val := cty.DynamicVal
+	if attr, ok := content.Attributes["value"]; ok {
+		if v, valDiags := attr.Expr.Value(mod.scope.exprContext(attr.Expr)); !valDiags.HasErrors() {
+			val = v
+		}
 	}
-	dest.Providers = []string{}
-	for p := range providerMap {
-		dest.Providers = append(dest.Providers, p)
+	if output.Sensitive {
+		val = val.Mark(sensitiveMark)
+	}
+	mod.outputs[output.Name] = val
+	mod.sensitiveOutputs[output.Name] = output.Sensitive
+	// Outputs derivados de valores sensíveis também ficam marcados
+	if val.Type() == cty.String && val.IsKnown() && !val.IsNull() && !val.IsMarked() {
+		output.Value = val.AsString()
 	}
 
-	dest.Valid = dest.Valid && src.Valid
+	analysis.Outputs = append(analysis.Outputs, output)
+}
+
+// ctyToGo converte um valor cty conhecido em um tipo Go nativo
+func ctyToGo(val cty.Value) (interface{}, bool) {
+	if !val.IsWhollyKnown() {
+		return nil, false
+	}
+	return convertValue(val, "", nil)
 }
 
 // checkBestPractices verifica best practices
@@ -338,12 +427,18 @@ func (ta *TerraformAnalyzer) checkBestPractices(analysis *models.TerraformAnalys
 	for _, resource := range analysis.Resources {
 		if len(resource.Tags) == 0 && ta.shouldHaveTags(resource.Type) {
 			analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
-				fmt.Sprintf("Recurso %s.%s não possui tags", resource.Type, resource.Name))
+				fmt.Sprintf("Recurso %s não possui tags", resource.Address))
 		}
 	}
 
-	// Verifica se há outputs
-	if len(analysis.Outputs) == 0 && len(analysis.Resources) > 0 {
+	// Verifica se há outputs no módulo raiz
+	rootOutputs := 0
+	for _, output := range analysis.Outputs {
+		if output.Module == "" {
+			rootOutputs++
+		}
+	}
+	if rootOutputs == 0 && len(analysis.Resources) > 0 {
 		analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
 			"Considere adicionar outputs para facilitar integração com outros módulos")
 	}
@@ -351,25 +446,17 @@ func (ta *TerraformAnalyzer) checkBestPractices(analysis *models.TerraformAnalys
 	// Verifica se variáveis têm descrição
 	for _, variable := range analysis.Variables {
 		if variable.Description == "" {
+			name := variable.Name
+			if variable.Module != "" {
+				name = variable.Module + ".var." + variable.Name
+			}
 			analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
-				fmt.Sprintf("Variável %s não possui descrição", variable.Name))
+				fmt.Sprintf("Variável %s não possui descrição", name))
 		}
 	}
 }
 
 // shouldHaveTags verifica se um tipo de recurso deveria ter tags
 func (ta *TerraformAnalyzer) shouldHaveTags(resourceType string) bool {
-	taggableTypes := []string{
-		"aws_instance", "aws_s3_bucket", "aws_vpc", "aws_subnet",
-		"aws_security_group", "aws_rds_instance", "aws_lambda_function",
-		"azurerm_resource_group", "azurerm_virtual_machine",
-		"google_compute_instance", "google_storage_bucket",
-	}
-
-	for _, t := range taggableTypes {
-		if t == resourceType {
-			return true
-		}
-	}
-	return false
+	return taggableTypes[resourceType]
 }
@@ -0,0 +1,304 @@
+package analyzer
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// identifierPattern reconhece chaves que podem ser usadas com a notação a.b
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// bodyDecoder converte um hcl.Body sem schema em uma árvore de valores Go:
+// blocos aninhados viram listas de mapas (como no JSON do terraform plan),
+// listas/sets/tuplas viram []interface{} e mapas/objetos viram
+// map[string]interface{}. Folhas desconhecidas são omitidas e seus caminhos
+// registrados em unknown.
+type bodyDecoder struct {
+	scope   *evalScope
+	unknown []string
+	diags   hcl.Diagnostics
+}
+
+// newBodyDecoder cria um decoder que avalia expressões no escopo informado
+func newBodyDecoder(scope *evalScope) *bodyDecoder {
+	return &bodyDecoder{scope: scope}
+}
+
+// keywordAttributes são meta-argumentos cujas expressões são referências
+// (e não valores) e por isso são guardados como texto
+var keywordAttributes = map[string]bool{
+	"depends_on":           true,
+	"provider":             true,
+	"ignore_changes":       true,
+	"replace_triggered_by": true,
+}
+
+// decode percorre o body retornando seus atributos e blocos
+func (d *bodyDecoder) decode(body hcl.Body, prefix string) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	attrs, blocks, diags := bodyItems(body)
+	d.diags = append(d.diags, diags...)
+
+	for name, attr := range attrs {
+		if prefix == "" && instanceMetaArguments[name] {
+			// count/for_each já foram expandidos em instâncias
+			continue
+		}
+		if name == "dynamic" {
+			// Blocos dynamic em JSON não são expandidos
+			d.unknown = append(d.unknown, joinPath(prefix, name))
+			continue
+		}
+		d.decodeAttribute(result, name, attr.Expr, prefix)
+	}
+
+	for _, block := range blocks {
+		if block.Type == "dynamic" {
+			d.decodeDynamic(block, result, prefix)
+			continue
+		}
+
+		labels := make([]interface{}, len(block.Labels))
+		for i, label := range block.Labels {
+			labels[i] = label
+		}
+		d.appendBlock(result, block.Type, labels, block.Body, prefix)
+	}
+
+	return result
+}
+
+// appendBlock decodifica um bloco aninhado e o adiciona à lista do seu tipo
+func (d *bodyDecoder) appendBlock(result map[string]interface{}, blockType string, labels []interface{}, body hcl.Body, prefix string) {
+	list, _ := result[blockType].([]interface{})
+
+	nested := d.decode(body, fmt.Sprintf("%s[%d]", joinPath(prefix, blockType), len(list)))
+	if len(labels) > 0 {
+		nested["_labels"] = labels
+	}
+
+	result[blockType] = append(list, nested)
+}
+
+// decodeDynamic expande um bloco dynamic "<tipo>" { for_each, content {} }
+// em blocos <tipo> comuns (ver expandDynamic). Se a coleção for desconhecida
+// o caminho do bloco é registrado como desconhecido.
+func (d *bodyDecoder) decodeDynamic(block *hcl.Block, result map[string]interface{}, prefix string) {
+	if len(block.Labels) != 1 {
+		return
+	}
+	blockType := block.Labels[0]
+
+	instances, known := expandDynamic(block, d.scope)
+	if !known {
+		d.unknown = append(d.unknown, joinPath(prefix, blockType))
+		return
+	}
+
+	scope := d.scope
+	defer func() { d.scope = scope }()
+
+	for _, instance := range instances {
+		d.scope = instance.scope
+		d.appendBlock(result, blockType, instance.labels, instance.body, prefix)
+	}
+}
+
+// decodeAttribute avalia um atributo e grava o valor convertido
+func (d *bodyDecoder) decodeAttribute(result map[string]interface{}, name string, expr hcl.Expression, prefix string) {
+	path := joinPath(prefix, name)
+
+	if keywordAttributes[name] {
+		if refs := traversalStrings(expr); refs != nil {
+			if name == "provider" && len(refs) == 1 {
+				result[name] = refs[0]
+			} else {
+				result[name] = refs
+			}
+			return
+		}
+	}
+
+	val, diags := expr.Value(d.scope.exprContext(expr))
+	if diags.HasErrors() {
+		// Valor só é conhecido após o apply (ou não pôde ser avaliado)
+		d.unknown = append(d.unknown, path)
+		return
+	}
+
+	if goVal, ok := convertValue(val, path, &d.unknown); ok {
+		result[name] = goVal
+	}
+}
+
+// convertValue transforma um valor cty em Go. Folhas desconhecidas são
+// omitidas e, se unknown não for nil, seus caminhos são registrados nele.
+// Valores sensíveis viram redactedValue.
+func convertValue(val cty.Value, path string, unknown *[]string) (interface{}, bool) {
+	if !val.IsKnown() {
+		if unknown != nil {
+			*unknown = append(*unknown, path)
+		}
+		return nil, false
+	}
+	if val.IsNull() {
+		return nil, false
+	}
+	if val.IsMarked() {
+		if val.HasMark(sensitiveMark) {
+			return redactedValue, true
+		}
+		val, _ = val.Unmark()
+	}
+
+	ty := val.Type()
+	switch {
+	case ty == cty.String:
+		return val.AsString(), true
+	case ty == cty.Bool:
+		return val.True(), true
+	case ty == cty.Number:
+		f64, _ := val.AsBigFloat().Float64()
+		return f64, true
+	case ty.IsListType() || ty.IsSetType() || ty.IsTupleType():
+		list := make([]interface{}, 0, val.LengthInt())
+		i := 0
+		for it := val.ElementIterator(); it.Next(); i++ {
+			_, elem := it.Element()
+			goVal, ok := convertValue(elem, fmt.Sprintf("%s[%d]", path, i), unknown)
+			if !ok && !elem.IsKnown() {
+				continue
+			}
+			list = append(list, goVal)
+		}
+		return list, true
+	case ty.IsMapType() || ty.IsObjectType():
+		obj := make(map[string]interface{}, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			k := key.AsString()
+			if goVal, ok := convertValue(elem, joinPath(path, k), unknown); ok {
+				obj[k] = goVal
+			}
+		}
+		return obj, true
+	}
+
+	return nil, false
+}
+
+// unknownPaths retorna os caminhos desconhecidos ordenados
+func (d *bodyDecoder) unknownPaths() []string {
+	sort.Strings(d.unknown)
+	return d.unknown
+}
+
+// joinPath concatena um caminho de atributo usando a.b ou a["b"]
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	if identifierPattern.MatchString(key) {
+		return prefix + "." + key
+	}
+	return fmt.Sprintf("%s[%q]", prefix, key)
+}
+
+// traversalStrings converte uma expressão de referência (ou lista de
+// referências) em texto, ex.: [aws_vpc.main, module.db]. Retorna nil se a
+// expressão não for composta apenas por referências.
+func traversalStrings(expr hcl.Expression) []string {
+	if keyword := hcl.ExprAsKeyword(expr); keyword != "" {
+		return []string{keyword}
+	}
+
+	exprs, diags := hcl.ExprList(expr)
+	if diags.HasErrors() {
+		exprs = []hcl.Expression{expr}
+	}
+
+	refs := []string{}
+	for _, e := range exprs {
+		traversal, diags := hcl.AbsTraversalForExpr(e)
+		if diags.HasErrors() {
+			return nil
+		}
+		refs = append(refs, traversalString(traversal))
+	}
+	return refs
+}
+
+// traversalString formata uma traversal HCL como texto
+func traversalString(traversal hcl.Traversal) string {
+	var sb strings.Builder
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			sb.WriteString(s.Name)
+		case hcl.TraverseAttr:
+			sb.WriteString(".")
+			sb.WriteString(s.Name)
+		case hcl.TraverseIndex:
+			switch {
+			case s.Key.Type() == cty.String:
+				sb.WriteString(fmt.Sprintf("[%q]", s.Key.AsString()))
+			case s.Key.Type() == cty.Number:
+				sb.WriteString(fmt.Sprintf("[%s]", s.Key.AsBigFloat().Text('f', -1)))
+			default:
+				sb.WriteString("[*]")
+			}
+		case hcl.TraverseSplat:
+			sb.WriteString("[*]")
+		}
+	}
+	return sb.String()
+}
+
+// extractTags lê as tags de um recurso a partir de tags (AWS/Azure),
+// labels (GCP) ou blocos tag { key, value } (ex.: aws_autoscaling_group)
+func extractTags(attrs map[string]interface{}) map[string]string {
+	tags := make(map[string]string)
+
+	for _, key := range []string{"tags", "labels"} {
+		if m, ok := attrs[key].(map[string]interface{}); ok {
+			for k, v := range m {
+				tags[k] = tagValueString(v)
+			}
+		}
+	}
+
+	if blocks, ok := attrs["tag"].([]interface{}); ok {
+		for _, b := range blocks {
+			if m, ok := b.(map[string]interface{}); ok {
+				if k, ok := m["key"].(string); ok {
+					tags[k] = tagValueString(m["value"])
+				}
+			}
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// tagValueString converte o valor de uma tag em string
+func tagValueString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return big.NewFloat(t).Text('f', -1)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
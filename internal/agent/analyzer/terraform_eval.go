@@ -0,0 +1,432 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// valueMark é o tipo das marcas cty aplicadas pelo analisador
+type valueMark string
+
+// sensitiveMark marca valores de variáveis sensíveis e outputs sensíveis. O
+// cty propaga a marca por expressões e funções, então qualquer valor
+// derivado também fica marcado.
+const sensitiveMark = valueMark("sensitive")
+
+// redactedValue substitui valores sensíveis nos atributos, inputs e outputs
+// expostos pela análise, como o Terraform faz no plan
+const redactedValue = "(sensitive value)"
+
+// evalScope agrupa os valores conhecidos durante a avaliação de um módulo
+type evalScope struct {
+	root      string
+	dir       string
+	functions map[string]function.Function
+	variables map[string]cty.Value
+	varTypes  map[string]variableType
+	sensitive map[string]bool // variáveis declaradas com sensitive = true
+	locals    map[string]cty.Value
+	modules   map[string]cty.Value
+	data      map[string]map[string]cty.Value // data sources avaliados (tipo -> nome)
+	extra     map[string]cty.Value            // count.*, each.* e iteradores de blocos dynamic
+
+	// O hcl.EvalContext é montado uma vez e reutilizado até que variáveis,
+	// locals ou módulos mudem. version é compartilhado com as cópias criadas
+	// por withVariables, que enxergam os mesmos mapas.
+	version    *int
+	ctx        *hcl.EvalContext
+	ctxVersion int
+}
+
+// variableType guarda a restrição de tipo declarada em um bloco variable
+type variableType struct {
+	ty       cty.Type
+	defaults *typeexpr.Defaults
+}
+
+// newEvalScope cria um escopo de avaliação vazio para o módulo em dir.
+// root é o diretório do módulo raiz (o stack): é a base de path.module.
+// Com root vazio path.module vale ".".
+func newEvalScope(root, dir string, functions map[string]function.Function) *evalScope {
+	return &evalScope{
+		root:      root,
+		dir:       dir,
+		functions: functions,
+		variables: make(map[string]cty.Value),
+		varTypes:  make(map[string]variableType),
+		sensitive: make(map[string]bool),
+		locals:    make(map[string]cty.Value),
+		modules:   make(map[string]cty.Value),
+		data:      make(map[string]map[string]cty.Value),
+		version:   new(int),
+	}
+}
+
+// context monta o hcl.EvalContext com var.*, local.*, module.*, path.* e
+// terraform.*. O contexto retornado é compartilhado e não deve ser alterado.
+func (s *evalScope) context() *hcl.EvalContext {
+	if s.ctx != nil && s.ctxVersion == *s.version {
+		return s.ctx
+	}
+
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var":   objectOrEmpty(s.variables),
+			"local": objectOrEmpty(s.locals),
+			// path.cwd é relativo para não expor o diretório do servidor
+			"path": cty.ObjectVal(map[string]cty.Value{
+				"module": cty.StringVal(s.modulePath()),
+				"root":   cty.StringVal("."),
+				"cwd":    cty.StringVal("."),
+			}),
+			"terraform": cty.ObjectVal(map[string]cty.Value{
+				"workspace": cty.StringVal("default"),
+			}),
+		},
+		Functions: s.functions,
+	}
+	if len(s.modules) > 0 {
+		ctx.Variables["module"] = cty.ObjectVal(s.modules)
+	}
+	for name, val := range s.extra {
+		ctx.Variables[name] = val
+	}
+
+	s.ctx = ctx
+	s.ctxVersion = *s.version
+	return ctx
+}
+
+// changed invalida os contextos montados a partir dos valores do escopo
+func (s *evalScope) changed() {
+	*s.version++
+}
+
+// withVariables retorna uma cópia do escopo com variáveis adicionais, usada
+// para ligar count.index, each.key/each.value e iteradores de blocos dynamic
+func (s *evalScope) withVariables(vars map[string]cty.Value) *evalScope {
+	child := *s
+	child.ctx = nil
+	child.extra = make(map[string]cty.Value, len(s.extra)+len(vars))
+	for name, val := range s.extra {
+		child.extra[name] = val
+	}
+	for name, val := range vars {
+		child.extra[name] = val
+	}
+	return &child
+}
+
+// modulePath retorna path.module: o diretório do módulo relativo à raiz
+func (s *evalScope) modulePath() string {
+	if s.root == "" {
+		return "."
+	}
+	rel, err := filepath.Rel(s.root, s.dir)
+	if err != nil {
+		return "."
+	}
+	return filepath.ToSlash(rel)
+}
+
+// exprContext retorna um contexto onde referências que o analisador não
+// consegue resolver estaticamente (recursos, data sources, módulos, self,
+// count, each) são tratadas como valores desconhecidos em vez de erros.
+// Data sources avaliados pelo analisador (ex.: aws_iam_policy_document)
+// são expostos com seus valores.
+func (s *evalScope) exprContext(expr hcl.Expression) *hcl.EvalContext {
+	ctx := s.context()
+
+	var unresolved map[string]cty.Value
+	var dataRefs []hcl.Traversal
+	for _, traversal := range expr.Variables() {
+		root := traversal.RootName()
+		if _, ok := ctx.Variables[root]; ok {
+			continue
+		}
+		if root == "data" && len(s.data) > 0 {
+			dataRefs = append(dataRefs, traversal)
+			continue
+		}
+		if unresolved == nil {
+			unresolved = make(map[string]cty.Value)
+		}
+		unresolved[root] = cty.DynamicVal
+	}
+
+	if dataRefs != nil {
+		if unresolved == nil {
+			unresolved = make(map[string]cty.Value)
+		}
+		unresolved["data"] = s.dataObject(dataRefs)
+	}
+
+	if unresolved == nil {
+		return ctx
+	}
+
+	child := ctx.NewChild()
+	child.Variables = unresolved
+	return child
+}
+
+// declareVariable registra uma variável com seu valor default. Variáveis sem
+// default ficam desconhecidas até que um arquivo tfvars forneça um valor.
+// Valores de variáveis sensíveis recebem sensitiveMark.
+func (s *evalScope) declareVariable(name string, vt variableType, def cty.Value, sensitive bool) {
+	s.varTypes[name] = vt
+	s.sensitive[name] = sensitive
+	s.changed()
+	if def == cty.NilVal {
+		s.variables[name] = s.markSensitive(name, cty.UnknownVal(vt.ty))
+		return
+	}
+	s.variables[name] = s.markSensitive(name, vt.coerce(def))
+}
+
+// setVariable sobrescreve o valor de uma variável declarada com um valor vindo
+// de um arquivo tfvars. Valores para variáveis não declaradas são ignorados,
+// assim como o Terraform faz.
+func (s *evalScope) setVariable(name string, val cty.Value) bool {
+	vt, declared := s.varTypes[name]
+	if !declared {
+		return false
+	}
+	s.variables[name] = s.markSensitive(name, vt.coerce(val))
+	s.changed()
+	return true
+}
+
+// markSensitive marca o valor se a variável foi declarada como sensível
+func (s *evalScope) markSensitive(name string, val cty.Value) cty.Value {
+	if s.sensitive[name] {
+		return val.Mark(sensitiveMark)
+	}
+	return val
+}
+
+// coerce aplica defaults de atributos opcionais e converte o valor para o tipo
+// declarado; se a conversão falhar o valor original é mantido. Marcas do
+// valor recebido são preservadas no resultado.
+func (vt variableType) coerce(val cty.Value) cty.Value {
+	val, marks := val.UnmarkDeep()
+	return vt.convert(val).WithMarks(marks)
+}
+
+// convert faz a conversão de coerce para um valor sem marcas
+func (vt variableType) convert(val cty.Value) cty.Value {
+	if vt.defaults != nil {
+		val = vt.defaults.Apply(val)
+	}
+	if vt.ty == cty.NilType || vt.ty == cty.DynamicPseudoType {
+		return val
+	}
+	if converted, err := convert.Convert(val, vt.ty); err == nil {
+		return converted
+	}
+	return val
+}
+
+// setDataSource registra o valor de um data source avaliado
+// (data.<tipo>.<nome>)
+func (s *evalScope) setDataSource(dataType, name string, val cty.Value) {
+	if s.data[dataType] == nil {
+		s.data[dataType] = make(map[string]cty.Value)
+	}
+	s.data[dataType][name] = val
+	s.changed()
+}
+
+// dataObject monta o objeto data com os data sources referenciados: os
+// avaliados com seus valores e os demais desconhecidos
+func (s *evalScope) dataObject(refs []hcl.Traversal) cty.Value {
+	types := make(map[string]map[string]cty.Value)
+	for _, traversal := range refs {
+		if len(traversal) < 3 {
+			return cty.DynamicVal
+		}
+		dataType, okType := traversal[1].(hcl.TraverseAttr)
+		name, okName := traversal[2].(hcl.TraverseAttr)
+		if !okType || !okName {
+			return cty.DynamicVal
+		}
+
+		if types[dataType.Name] == nil {
+			types[dataType.Name] = make(map[string]cty.Value)
+		}
+		val, ok := s.data[dataType.Name][name.Name]
+		if !ok {
+			val = cty.DynamicVal
+		}
+		types[dataType.Name][name.Name] = val
+	}
+
+	obj := make(map[string]cty.Value, len(types))
+	for dataType, names := range types {
+		obj[dataType] = cty.ObjectVal(names)
+	}
+	return cty.ObjectVal(obj)
+}
+
+// setModule registra os outputs de uma chamada de módulo (module.<nome>)
+func (s *evalScope) setModule(name string, outputs cty.Value) {
+	s.modules[name] = outputs
+	s.changed()
+}
+
+// evaluateLocals avalia os blocos locals resolvendo dependências entre eles.
+// Locals que participam de ciclos ou que não podem ser avaliados ficam
+// desconhecidos.
+func (s *evalScope) evaluateLocals(attrs []*hcl.Attribute) {
+	pending := make(map[string]*hcl.Attribute, len(attrs))
+	for _, attr := range attrs {
+		pending[attr.Name] = attr
+	}
+
+	for len(pending) > 0 {
+		progress := false
+
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			attr := pending[name]
+			if !s.localDepsResolved(attr.Expr, pending) {
+				continue
+			}
+
+			val, valDiags := attr.Expr.Value(s.exprContext(attr.Expr))
+			if valDiags.HasErrors() {
+				val = cty.DynamicVal
+			}
+			s.locals[name] = val
+			s.changed()
+			delete(pending, name)
+			progress = true
+		}
+
+		if !progress {
+			// Ciclo entre locals: marca os restantes como desconhecidos
+			for name := range pending {
+				s.locals[name] = cty.DynamicVal
+			}
+			s.changed()
+			break
+		}
+	}
+}
+
+// localDepsResolved verifica se todos os local.* referenciados já foram avaliados
+func (s *evalScope) localDepsResolved(expr hcl.Expression, pending map[string]*hcl.Attribute) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "local" || len(traversal) < 2 {
+			continue
+		}
+		if attr, ok := traversal[1].(hcl.TraverseAttr); ok {
+			if _, isPending := pending[attr.Name]; isPending {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// decodeVariableType interpreta a expressão type de um bloco variable
+func decodeVariableType(expr hcl.Expression) variableType {
+	ty, defaults, diags := typeexpr.TypeConstraintWithDefaults(expr)
+	if diags.HasErrors() {
+		return variableType{ty: cty.DynamicPseudoType}
+	}
+	return variableType{ty: ty, defaults: defaults}
+}
+
+// tfvarsFiles lista os arquivos de variáveis carregados automaticamente pelo
+// Terraform, na ordem de precedência (o último vence)
+func tfvarsFiles(dir string) []string {
+	files := []string{}
+
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return files
+	}
+
+	auto := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		if strings.HasSuffix(name, ".auto.tfvars") || strings.HasSuffix(name, ".auto.tfvars.json") {
+			auto = append(auto, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(auto)
+
+	return append(files, auto...)
+}
+
+// loadTfvars faz parsing de um arquivo .tfvars (HCL ou JSON) e retorna os valores
+func loadTfvars(parser *hclparse.Parser, path string) (map[string]cty.Value, hcl.Diagnostics) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "Erro ao ler arquivo de variáveis",
+			Detail:   fmt.Sprintf("%s: %s", path, err),
+		}}
+	}
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		file, diags = parser.ParseJSON(content, path)
+	} else {
+		file, diags = parser.ParseHCL(content, path)
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	attrs, attrDiags := file.Body.JustAttributes()
+	diags = append(diags, attrDiags...)
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		val, valDiags := attr.Expr.Value(nil)
+		if valDiags.HasErrors() {
+			diags = append(diags, valDiags...)
+			continue
+		}
+		values[name] = val
+	}
+
+	return values, diags
+}
+
+// objectOrEmpty converte um mapa de valores em objeto cty
+func objectOrEmpty(values map[string]cty.Value) cty.Value {
+	if len(values) == 0 {
+		return cty.EmptyObjectVal
+	}
+	return cty.ObjectVal(values)
+}
@@ -0,0 +1,296 @@
+package analyzer
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// blockInstance é uma instância criada por count ou for_each
+type blockInstance struct {
+	key   cty.Value  // número (count), string (for_each) ou cty.NilVal sem expansão
+	scope *evalScope // escopo com count.index ou each.key/each.value
+}
+
+// suffix retorna o sufixo de endereço da instância, ex.: [0] ou ["logs"]
+func (i blockInstance) suffix() string {
+	switch {
+	case i.key == cty.NilVal:
+		return ""
+	case i.key.Type() == cty.Number:
+		return fmt.Sprintf("[%s]", i.key.AsBigFloat().Text('f', -1))
+	default:
+		return fmt.Sprintf("[%q]", i.key.AsString())
+	}
+}
+
+// index retorna a chave da instância como valor Go (float64 ou string)
+func (i blockInstance) index() interface{} {
+	if i.key == cty.NilVal {
+		return nil
+	}
+	goVal, _ := ctyToGo(i.key)
+	return goVal
+}
+
+// maxCountInstances é o maior count expandido. Valores acima são tratados
+// como cardinalidade desconhecida para não alocar milhões de instâncias.
+const maxCountInstances = 1000
+
+// instanceMetaArguments são os meta-argumentos que definem a cardinalidade
+var instanceMetaArguments = map[string]bool{
+	"count":    true,
+	"for_each": true,
+}
+
+// expandInstances avalia count/for_each de um bloco resource, data ou module.
+// Sem esses argumentos há uma única instância. Retorna known = false quando a
+// cardinalidade só é conhecida após o apply (ou count excede
+// maxCountInstances); nesse caso a única instância retornada tem
+// count.*/each.* desconhecidos. Um count negativo gera um diagnóstico e
+// nenhuma instância, assim como count ou for_each derivados de valores
+// sensíveis.
+func expandInstances(body hcl.Body, scope *evalScope) (instances []blockInstance, known bool, diags hcl.Diagnostics) {
+	content, _, _ := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "count"},
+			{Name: "for_each"},
+		},
+	})
+
+	single := []blockInstance{{key: cty.NilVal, scope: scope}}
+	if content == nil {
+		return single, true, nil
+	}
+
+	if attr, ok := content.Attributes["count"]; ok {
+		return expandCount(attr.Expr, scope)
+	}
+	if attr, ok := content.Attributes["for_each"]; ok {
+		return expandForEach(attr.Expr, scope)
+	}
+	return single, true, nil
+}
+
+// expandCount cria uma instância para cada valor de count.index
+func expandCount(expr hcl.Expression, scope *evalScope) ([]blockInstance, bool, hcl.Diagnostics) {
+	unknown := unknownInstance(scope, "count", cty.ObjectVal(map[string]cty.Value{
+		"index": cty.UnknownVal(cty.Number),
+	}))
+
+	val, diags := expr.Value(scope.exprContext(expr))
+	if !diags.HasErrors() && val.HasMark(sensitiveMark) {
+		return nil, true, sensitiveInstanceDiags("count", expr)
+	}
+	if diags.HasErrors() || !val.IsKnown() {
+		return unknown, false, nil
+	}
+	if val.IsNull() {
+		return nil, true, invalidInstanceDiags("count", expr, "count não pode ser nulo")
+	}
+
+	// Como no Terraform, strings numéricas são aceitas (ex.: "2")
+	val, err := convert.Convert(val, cty.Number)
+	if err != nil {
+		return nil, true, invalidInstanceDiags("count", expr, "count deve ser um número inteiro")
+	}
+	if !val.IsKnown() {
+		return unknown, false, nil
+	}
+
+	number := val.AsBigFloat()
+	if !number.IsInt() {
+		return nil, true, invalidInstanceDiags("count", expr,
+			fmt.Sprintf("count deve ser um número inteiro (%s)", number.Text('f', -1)))
+	}
+	if number.Sign() < 0 {
+		return nil, true, invalidInstanceDiags("count", expr,
+			fmt.Sprintf("count não pode ser negativo (%s)", number.Text('f', -1)))
+	}
+	count, accuracy := number.Int64()
+	if count > maxCountInstances || accuracy == big.Above {
+		return unknown, false, nil
+	}
+
+	instances := make([]blockInstance, 0, count)
+	for i := int64(0); i < count; i++ {
+		key := cty.NumberIntVal(i)
+		instances = append(instances, blockInstance{
+			key: key,
+			scope: scope.withVariables(map[string]cty.Value{
+				"count": cty.ObjectVal(map[string]cty.Value{"index": key}),
+			}),
+		})
+	}
+	return instances, true, nil
+}
+
+// expandForEach cria uma instância para cada chave de for_each (mapa, objeto
+// ou set de strings). Como no Terraform, listas, tuplas e sets de outros
+// tipos são rejeitados.
+func expandForEach(expr hcl.Expression, scope *evalScope) ([]blockInstance, bool, hcl.Diagnostics) {
+	val, diags := expr.Value(scope.exprContext(expr))
+	unknown := func() ([]blockInstance, bool, hcl.Diagnostics) {
+		return unknownInstance(scope, "each", cty.ObjectVal(map[string]cty.Value{
+			"key":   cty.UnknownVal(cty.String),
+			"value": cty.DynamicVal,
+		})), false, nil
+	}
+
+	if !diags.HasErrors() && val.HasMark(sensitiveMark) {
+		return nil, true, sensitiveInstanceDiags("for_each", expr)
+	}
+	if diags.HasErrors() || val.Type() == cty.DynamicPseudoType {
+		return unknown()
+	}
+
+	ty := val.Type()
+	isMap := ty.IsMapType() || ty.IsObjectType()
+	switch {
+	case ty.IsListType() || ty.IsTupleType():
+		return nil, true, invalidInstanceDiags("for_each", expr,
+			"for_each deve ser um mapa ou um set de strings; converta a lista com toset()")
+	case !isMap && !ty.IsSetType():
+		return nil, true, invalidInstanceDiags("for_each", expr,
+			fmt.Sprintf("for_each deve ser um mapa ou um set de strings, não %s", ty.FriendlyName()))
+	case ty.IsSetType() && ty.ElementType() != cty.String && ty.ElementType() != cty.DynamicPseudoType:
+		return nil, true, invalidInstanceDiags("for_each", expr,
+			fmt.Sprintf("for_each deve ser um set de strings, não %s", ty.FriendlyName()))
+	}
+	if !val.IsKnown() {
+		return unknown()
+	}
+	if val.IsNull() {
+		return nil, true, invalidInstanceDiags("for_each", expr, "for_each não pode ser nulo")
+	}
+	if !isMap && !val.IsWhollyKnown() {
+		// Sets com elementos desconhecidos não têm chaves conhecidas
+		return unknown()
+	}
+
+	instances := []blockInstance{}
+	seen := make(map[string]bool)
+	for it := val.ElementIterator(); it.Next(); {
+		k, v := it.Element()
+		if !isMap {
+			if v.Type() != cty.String || v.IsNull() {
+				return nil, true, invalidInstanceDiags("for_each", expr,
+					"for_each deve ser um set de strings sem elementos nulos")
+			}
+			k = v
+		}
+		if seen[k.AsString()] {
+			continue
+		}
+		seen[k.AsString()] = true
+
+		instances = append(instances, blockInstance{
+			key: k,
+			scope: scope.withVariables(map[string]cty.Value{
+				"each": cty.ObjectVal(map[string]cty.Value{"key": k, "value": v}),
+			}),
+		})
+	}
+	return instances, true, nil
+}
+
+// sensitiveInstanceDiags rejeita count/for_each derivados de valores
+// sensíveis: as chaves das instâncias apareceriam nos endereços
+func sensitiveInstanceDiags(name string, expr hcl.Expression) hcl.Diagnostics {
+	return invalidInstanceDiags(name, expr, fmt.Sprintf("%s não pode derivar de valores sensíveis", name))
+}
+
+// invalidInstanceDiags retorna o erro de um count/for_each inválido
+func invalidInstanceDiags(name string, expr hcl.Expression, detail string) hcl.Diagnostics {
+	rng := expr.Range()
+	return hcl.Diagnostics{{
+		Severity: hcl.DiagError,
+		Summary:  "Valor inválido para " + name,
+		Detail:   detail,
+		Subject:  &rng,
+	}}
+}
+
+// dynamicInstance é um bloco gerado por um bloco dynamic: o body de content
+// com o iterador ligado no escopo
+type dynamicInstance struct {
+	body   hcl.Body
+	labels []interface{}
+	scope  *evalScope
+}
+
+// dynamicSchema é o schema do body de um bloco dynamic
+var dynamicSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "for_each"},
+		{Name: "iterator"},
+		{Name: "labels"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{{Type: "content"}},
+}
+
+// expandDynamic expande um bloco dynamic "<tipo>" { for_each, content {} }
+// em uma instância por elemento de for_each, ligando o iterador
+// (<tipo>.key/<tipo>.value ou o nome definido em iterator). Funciona com
+// sintaxe nativa e JSON. Retorna known = false se a coleção for desconhecida
+// ou o bloco estiver incompleto.
+func expandDynamic(block *hcl.Block, scope *evalScope) ([]dynamicInstance, bool) {
+	if len(block.Labels) != 1 {
+		return nil, false
+	}
+
+	content, _, diags := block.Body.PartialContent(dynamicSchema)
+	forEach, ok := content.Attributes["for_each"]
+	if diags.HasErrors() || !ok || len(content.Blocks) != 1 {
+		return nil, false
+	}
+
+	iterator := block.Labels[0]
+	if attr, ok := content.Attributes["iterator"]; ok {
+		if keyword := hcl.ExprAsKeyword(attr.Expr); keyword != "" {
+			iterator = keyword
+		}
+	}
+
+	val, diags := forEach.Expr.Value(scope.exprContext(forEach.Expr))
+	// Uma coleção sensível gera blocos com o conteúdo sensível
+	val, marks := val.Unmark()
+	if diags.HasErrors() || !val.IsKnown() || val.IsNull() || !val.CanIterateElements() {
+		return nil, false
+	}
+
+	instances := []dynamicInstance{}
+	for it := val.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+		instance := dynamicInstance{
+			body:   content.Blocks[0].Body,
+			labels: []interface{}{},
+			scope: scope.withVariables(map[string]cty.Value{
+				iterator: cty.ObjectVal(map[string]cty.Value{"key": key.WithMarks(marks), "value": value.WithMarks(marks)}),
+			}),
+		}
+
+		if attr, ok := content.Attributes["labels"]; ok {
+			if labelsVal, diags := attr.Expr.Value(instance.scope.exprContext(attr.Expr)); !diags.HasErrors() {
+				if goVal, ok := ctyToGo(labelsVal); ok {
+					instance.labels, _ = goVal.([]interface{})
+				}
+			}
+		}
+
+		instances = append(instances, instance)
+	}
+	return instances, true
+}
+
+// unknownInstance retorna uma única instância com o símbolo de iteração
+// (count ou each) desconhecido
+func unknownInstance(scope *evalScope, name string, val cty.Value) []blockInstance {
+	return []blockInstance{{
+		key:   cty.NilVal,
+		scope: scope.withVariables(map[string]cty.Value{name: val}),
+	}}
+}
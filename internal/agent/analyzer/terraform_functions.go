@@ -0,0 +1,1132 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	"gopkg.in/yaml.v3"
+)
+
+// fsSandbox restringe as funções de arquivo (file, templatefile, fileset...)
+// ao diretório analisado. Caminhos relativos partem de base, o diretório do
+// módulo raiz (o diretório de trabalho do Terraform). Um sandbox sem raiz
+// bloqueia qualquer acesso ao sistema de arquivos, o que é usado na análise
+// de conteúdo inline.
+type fsSandbox struct {
+	root string
+	base string
+}
+
+// newFSSandbox cria um sandbox com raiz no diretório informado e caminhos
+// relativos a base (root se vazio)
+func newFSSandbox(root, base string) fsSandbox {
+	if root == "" {
+		return fsSandbox{}
+	}
+	if base == "" {
+		base = root
+	}
+	return fsSandbox{root: resolvedDir(root), base: resolvedDir(base)}
+}
+
+// resolvedDir retorna o caminho absoluto do diretório, sem links simbólicos
+func resolvedDir(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+	return dir
+}
+
+// resolve converte um caminho em caminho absoluto dentro do sandbox
+func (fs fsSandbox) resolve(path string) (string, error) {
+	if fs.root == "" {
+		return "", fmt.Errorf("funções de arquivo não estão disponíveis na análise de conteúdo")
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(fs.base, path)
+	}
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	rel, err := filepath.Rel(fs.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("caminho %q está fora do diretório analisado", path)
+	}
+	return path, nil
+}
+
+// readFile lê um arquivo dentro do sandbox
+func (fs fsSandbox) readFile(path string) ([]byte, error) {
+	resolved, err := fs.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler arquivo %q: %w", path, err)
+	}
+	return content, nil
+}
+
+// newTerraformFunctions monta a biblioteca de funções do Terraform usada na
+// avaliação das expressões. Funções de arquivo ficam restritas a root e
+// resolvem caminhos relativos a base, o diretório do módulo raiz.
+func newTerraformFunctions(root, base string) map[string]function.Function {
+	fs := newFSSandbox(root, base)
+
+	funcs := map[string]function.Function{
+		// Numéricas
+		"abs":      stdlib.AbsoluteFunc,
+		"ceil":     stdlib.CeilFunc,
+		"floor":    stdlib.FloorFunc,
+		"log":      stdlib.LogFunc,
+		"max":      stdlib.MaxFunc,
+		"min":      stdlib.MinFunc,
+		"parseint": stdlib.ParseIntFunc,
+		"pow":      stdlib.PowFunc,
+		"signum":   stdlib.SignumFunc,
+
+		// Strings
+		"chomp":       stdlib.ChompFunc,
+		"endswith":    stringPredicateFunc(strings.HasSuffix),
+		"format":      stdlib.FormatFunc,
+		"formatlist":  stdlib.FormatListFunc,
+		"indent":      stdlib.IndentFunc,
+		"join":        stdlib.JoinFunc,
+		"lower":       stdlib.LowerFunc,
+		"regex":       stdlib.RegexFunc,
+		"regexall":    stdlib.RegexAllFunc,
+		"replace":     replaceFunc,
+		"split":       stdlib.SplitFunc,
+		"startswith":  stringPredicateFunc(strings.HasPrefix),
+		"strcontains": stringPredicateFunc(strings.Contains),
+		"strrev":      stdlib.ReverseFunc,
+		"substr":      stdlib.SubstrFunc,
+		"title":       stdlib.TitleFunc,
+		"trim":        stdlib.TrimFunc,
+		"trimprefix":  stdlib.TrimPrefixFunc,
+		"trimspace":   stdlib.TrimSpaceFunc,
+		"trimsuffix":  stdlib.TrimSuffixFunc,
+		"upper":       stdlib.UpperFunc,
+
+		// Coleções
+		"alltrue":         allTrueFunc,
+		"anytrue":         anyTrueFunc,
+		"chunklist":       stdlib.ChunklistFunc,
+		"coalesce":        stdlib.CoalesceFunc,
+		"coalescelist":    stdlib.CoalesceListFunc,
+		"compact":         stdlib.CompactFunc,
+		"concat":          stdlib.ConcatFunc,
+		"contains":        stdlib.ContainsFunc,
+		"distinct":        stdlib.DistinctFunc,
+		"element":         stdlib.ElementFunc,
+		"flatten":         stdlib.FlattenFunc,
+		"index":           indexFunc,
+		"keys":            stdlib.KeysFunc,
+		"length":          lengthFunc,
+		"lookup":          stdlib.LookupFunc,
+		"matchkeys":       matchKeysFunc,
+		"merge":           stdlib.MergeFunc,
+		"one":             oneFunc,
+		"range":           stdlib.RangeFunc,
+		"reverse":         stdlib.ReverseListFunc,
+		"setintersection": stdlib.SetIntersectionFunc,
+		"setproduct":      stdlib.SetProductFunc,
+		"setsubtract":     stdlib.SetSubtractFunc,
+		"setunion":        stdlib.SetUnionFunc,
+		"slice":           stdlib.SliceFunc,
+		"sort":            stdlib.SortFunc,
+		"sum":             sumFunc,
+		"transpose":       transposeFunc,
+		"values":          stdlib.ValuesFunc,
+		"zipmap":          stdlib.ZipmapFunc,
+
+		// Codificação
+		"base64decode":     stringFunc(base64Decode),
+		"base64encode":     stringFunc(func(s string) (string, error) { return base64.StdEncoding.EncodeToString([]byte(s)), nil }),
+		"base64gzip":       stringFunc(base64Gzip),
+		"csvdecode":        stdlib.CSVDecodeFunc,
+		"jsondecode":       stdlib.JSONDecodeFunc,
+		"jsonencode":       stdlib.JSONEncodeFunc,
+		"textdecodebase64": textDecodeBase64Func,
+		"textencodebase64": textEncodeBase64Func,
+		"urlencode":        stringFunc(func(s string) (string, error) { return url.QueryEscape(s), nil }),
+		"yamldecode":       yamlDecodeFunc,
+		"yamlencode":       yamlEncodeFunc,
+
+		// Sistema de arquivos (restrito ao diretório analisado)
+		"abspath":    stringFunc(fs.absPath),
+		"basename":   stringFunc(func(s string) (string, error) { return filepath.Base(s), nil }),
+		"dirname":    stringFunc(func(s string) (string, error) { return filepath.Dir(s), nil }),
+		"file":       fileFunc(fs, false),
+		"filebase64": fileFunc(fs, true),
+		"fileexists": fileExistsFunc(fs),
+		"fileset":    fileSetFunc(fs),
+		"pathexpand": stringFunc(func(s string) (string, error) { return s, nil }), // não expõe o home do servidor
+
+		// Data e hora
+		"formatdate":    stdlib.FormatDateFunc,
+		"plantimestamp": impureFunc(cty.String, false),
+		"timeadd":       stdlib.TimeAddFunc,
+		"timecmp":       timeCmpFunc,
+		"timestamp":     impureFunc(cty.String, false),
+
+		// Hash e criptografia
+		"base64sha256":     hashFunc(sha256.New, base64.StdEncoding.EncodeToString),
+		"base64sha512":     hashFunc(sha512.New, base64.StdEncoding.EncodeToString),
+		"bcrypt":           impureFunc(cty.String, true),
+		"filebase64sha256": fileHashFunc(fs, sha256.New, base64.StdEncoding.EncodeToString),
+		"filebase64sha512": fileHashFunc(fs, sha512.New, base64.StdEncoding.EncodeToString),
+		"filemd5":          fileHashFunc(fs, md5.New, hex.EncodeToString),
+		"filesha1":         fileHashFunc(fs, sha1.New, hex.EncodeToString),
+		"filesha256":       fileHashFunc(fs, sha256.New, hex.EncodeToString),
+		"filesha512":       fileHashFunc(fs, sha512.New, hex.EncodeToString),
+		"md5":              hashFunc(md5.New, hex.EncodeToString),
+		"rsadecrypt":       rsaDecryptFunc,
+		"sha1":             hashFunc(sha1.New, hex.EncodeToString),
+		"sha256":           hashFunc(sha256.New, hex.EncodeToString),
+		"sha512":           hashFunc(sha512.New, hex.EncodeToString),
+		"uuid":             impureFunc(cty.String, false),
+		"uuidv5":           uuidV5Func,
+
+		// Rede
+		"cidrhost":    cidrHostFunc,
+		"cidrnetmask": cidrNetmaskFunc,
+		"cidrsubnet":  cidrSubnetFunc,
+		"cidrsubnets": cidrSubnetsFunc,
+
+		// Conversão de tipos
+		"can":          tryfunc.CanFunc,
+		"issensitive":  isSensitiveFunc,
+		"nonsensitive": nonSensitiveFunc,
+		"sensitive":    sensitiveFunc,
+		"tobool":       stdlib.MakeToFunc(cty.Bool),
+		"tolist":       stdlib.MakeToFunc(cty.List(cty.DynamicPseudoType)),
+		"tomap":        stdlib.MakeToFunc(cty.Map(cty.DynamicPseudoType)),
+		"tonumber":     stdlib.MakeToFunc(cty.Number),
+		"toset":        stdlib.MakeToFunc(cty.Set(cty.DynamicPseudoType)),
+		"tostring":     stdlib.MakeToFunc(cty.String),
+		"try":          tryfunc.TryFunc,
+	}
+
+	// templatefile não pode chamar a si mesma, como no Terraform
+	templateFuncs := make(map[string]function.Function, len(funcs))
+	for name, fn := range funcs {
+		templateFuncs[name] = fn
+	}
+	funcs["templatefile"] = templateFileFunc(fs, templateFuncs)
+
+	return funcs
+}
+
+// stringFunc cria uma função string -> string
+func stringFunc(impl func(string) (string, error)) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "str", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			out, err := impl(args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.String), err
+			}
+			return cty.StringVal(out), nil
+		},
+	})
+}
+
+// stringPredicateFunc cria uma função (string, string) -> bool
+func stringPredicateFunc(pred func(s, substr string) bool) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "str", Type: cty.String},
+			{Name: "substr", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.BoolVal(pred(args[0].AsString(), args[1].AsString())), nil
+		},
+	})
+}
+
+// hashFunc cria uma função de hash sobre uma string
+func hashFunc(newHash func() hash.Hash, encode func([]byte) string) function.Function {
+	return stringFunc(func(s string) (string, error) {
+		h := newHash()
+		h.Write([]byte(s))
+		return encode(h.Sum(nil)), nil
+	})
+}
+
+// fileHashFunc cria uma função de hash sobre o conteúdo de um arquivo
+func fileHashFunc(fs fsSandbox, newHash func() hash.Hash, encode func([]byte) string) function.Function {
+	return stringFunc(func(path string) (string, error) {
+		content, err := fs.readFile(path)
+		if err != nil {
+			return "", err
+		}
+		h := newHash()
+		h.Write(content)
+		return encode(h.Sum(nil)), nil
+	})
+}
+
+// impureFunc representa funções cujo resultado só é conhecido no apply
+// (timestamp, uuid, bcrypt): o analisador devolve sempre um valor desconhecido
+func impureFunc(retType cty.Type, takesString bool) function.Function {
+	spec := &function.Spec{
+		Type: function.StaticReturnType(retType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.UnknownVal(retType), nil
+		},
+	}
+	if takesString {
+		spec.Params = []function.Parameter{{Name: "str", Type: cty.String, AllowUnknown: true}}
+		spec.VarParam = &function.Parameter{Name: "cost", Type: cty.Number, AllowUnknown: true}
+	}
+	return function.New(spec)
+}
+
+// markParam é o parâmetro de sensitive/nonsensitive/issensitive, que
+// recebem o valor com suas marcas
+var markParam = function.Parameter{
+	Name:             "value",
+	Type:             cty.DynamicPseudoType,
+	AllowNull:        true,
+	AllowUnknown:     true,
+	AllowDynamicType: true,
+	AllowMarked:      true,
+}
+
+// sensitiveFunc marca o valor como sensível
+var sensitiveFunc = function.New(&function.Spec{
+	Params: []function.Parameter{markParam},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return args[0].Mark(sensitiveMark), nil
+	},
+})
+
+// nonSensitiveFunc remove a marca de sensível do valor
+var nonSensitiveFunc = function.New(&function.Spec{
+	Params: []function.Parameter{markParam},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		val, marks := args[0].Unmark()
+		delete(marks, sensitiveMark)
+		return val.WithMarks(marks), nil
+	},
+})
+
+// isSensitiveFunc indica se o valor é sensível
+var isSensitiveFunc = function.New(&function.Spec{
+	Params: []function.Parameter{markParam},
+	Type:   function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		return cty.BoolVal(args[0].HasMark(sensitiveMark)), nil
+	},
+})
+
+// replaceFunc implementa replace, tratando substr entre barras como regex
+var replaceFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "str", Type: cty.String},
+		{Name: "substr", Type: cty.String},
+		{Name: "replace", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		substr := args[1].AsString()
+		if len(substr) > 1 && substr[0] == '/' && substr[len(substr)-1] == '/' {
+			return stdlib.RegexReplace(args[0], cty.StringVal(substr[1:len(substr)-1]), args[2])
+		}
+		return stdlib.Replace(args[0], args[1], args[2])
+	},
+})
+
+// lengthFunc aceita coleções e strings
+var lengthFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name:             "value",
+		Type:             cty.DynamicPseudoType,
+		AllowDynamicType: true,
+		AllowUnknown:     true,
+	}},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if args[0].Type() == cty.String {
+			return stdlib.Strlen(args[0])
+		}
+		return stdlib.Length(args[0])
+	},
+})
+
+// allTrueFunc retorna true se todos os elementos forem true (ou a lista vazia)
+var allTrueFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "list", Type: cty.List(cty.Bool)}},
+	Type:   function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		result := cty.True
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if !v.IsKnown() {
+				result = cty.UnknownVal(cty.Bool)
+				continue
+			}
+			if v.IsNull() || v.False() {
+				return cty.False, nil
+			}
+		}
+		return result, nil
+	},
+})
+
+// anyTrueFunc retorna true se algum elemento for true
+var anyTrueFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "list", Type: cty.List(cty.Bool)}},
+	Type:   function.StaticReturnType(cty.Bool),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		result := cty.False
+		for it := args[0].ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if !v.IsKnown() {
+				result = cty.UnknownVal(cty.Bool)
+				continue
+			}
+			if !v.IsNull() && v.True() {
+				return cty.True, nil
+			}
+		}
+		return result, nil
+	},
+})
+
+// indexFunc retorna o índice do primeiro elemento igual ao valor procurado
+var indexFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "list", Type: cty.DynamicPseudoType},
+		{Name: "value", Type: cty.DynamicPseudoType},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		list := args[0]
+		if !(list.Type().IsListType() || list.Type().IsTupleType()) {
+			return cty.NilVal, fmt.Errorf("argument must be a list or tuple")
+		}
+		if !list.IsWhollyKnown() {
+			return cty.UnknownVal(cty.Number), nil
+		}
+		for it := list.ElementIterator(); it.Next(); {
+			i, v := it.Element()
+			eq, err := stdlib.Equal(v, args[1])
+			if err != nil {
+				return cty.NilVal, err
+			}
+			if !eq.IsKnown() {
+				return cty.UnknownVal(cty.Number), nil
+			}
+			if eq.True() {
+				return i, nil
+			}
+		}
+		return cty.NilVal, fmt.Errorf("item not found")
+	},
+})
+
+// oneFunc retorna o único elemento de uma coleção, ou null se estiver vazia
+var oneFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "list", Type: cty.DynamicPseudoType}},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		ty := args[0].Type()
+		switch {
+		case ty.IsListType() || ty.IsSetType():
+			return ty.ElementType(), nil
+		case ty.IsTupleType():
+			etys := ty.TupleElementTypes()
+			switch len(etys) {
+			case 0:
+				return cty.DynamicPseudoType, nil
+			case 1:
+				return etys[0], nil
+			}
+			return cty.NilType, fmt.Errorf("must be a list, set, or tuple value with either zero or one elements")
+		}
+		return cty.NilType, fmt.Errorf("must be a list, set, or tuple value with either zero or one elements")
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		val := args[0]
+		if !val.IsKnown() {
+			return cty.UnknownVal(retType), nil
+		}
+		switch val.LengthInt() {
+		case 0:
+			return cty.NullVal(retType), nil
+		case 1:
+			var result cty.Value
+			for it := val.ElementIterator(); it.Next(); {
+				_, result = it.Element()
+			}
+			return result, nil
+		}
+		return cty.NilVal, fmt.Errorf("must be a list, set, or tuple value with either zero or one elements")
+	},
+})
+
+// sumFunc soma uma coleção de números
+var sumFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "list", Type: cty.DynamicPseudoType}},
+	Type:   function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		list := args[0]
+		if !list.CanIterateElements() || list.LengthInt() == 0 {
+			return cty.NilVal, fmt.Errorf("cannot sum an empty list")
+		}
+		total := cty.NumberIntVal(0)
+		for it := list.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if !v.IsKnown() {
+				return cty.UnknownVal(cty.Number), nil
+			}
+			num, err := stdlib.MakeToFunc(cty.Number).Call([]cty.Value{v})
+			if err != nil {
+				return cty.NilVal, err
+			}
+			total = total.Add(num)
+		}
+		return total, nil
+	},
+})
+
+// transposeFunc troca chaves e valores de um map(list(string))
+var transposeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "values", Type: cty.Map(cty.List(cty.String))}},
+	Type:   function.StaticReturnType(cty.Map(cty.List(cty.String))),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		tmp := make(map[string][]string)
+		for it := args[0].ElementIterator(); it.Next(); {
+			k, list := it.Element()
+			for lit := list.ElementIterator(); lit.Next(); {
+				_, v := lit.Element()
+				if !v.IsKnown() {
+					return cty.UnknownVal(retType), nil
+				}
+				tmp[v.AsString()] = append(tmp[v.AsString()], k.AsString())
+			}
+		}
+
+		out := make(map[string]cty.Value, len(tmp))
+		for k, vals := range tmp {
+			sort.Strings(vals)
+			list := make([]cty.Value, len(vals))
+			for i, v := range vals {
+				list[i] = cty.StringVal(v)
+			}
+			out[k] = cty.ListVal(list)
+		}
+		if len(out) == 0 {
+			return cty.MapValEmpty(cty.List(cty.String)), nil
+		}
+		return cty.MapVal(out), nil
+	},
+})
+
+// matchKeysFunc seleciona elementos de values cujas keys estão em searchset
+var matchKeysFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "values", Type: cty.List(cty.DynamicPseudoType)},
+		{Name: "keys", Type: cty.List(cty.DynamicPseudoType)},
+		{Name: "searchset", Type: cty.List(cty.DynamicPseudoType)},
+	},
+	Type: func(args []cty.Value) (cty.Type, error) {
+		return args[0].Type(), nil
+	},
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		values, keys, search := args[0], args[1], args[2]
+		if values.LengthInt() != keys.LengthInt() {
+			return cty.NilVal, fmt.Errorf("length of keys and values should be equal")
+		}
+		if !keys.IsWhollyKnown() || !search.IsWhollyKnown() {
+			return cty.UnknownVal(retType), nil
+		}
+
+		out := []cty.Value{}
+		valueList := values.AsValueSlice()
+		i := 0
+		for it := keys.ElementIterator(); it.Next(); i++ {
+			_, key := it.Element()
+			for sit := search.ElementIterator(); sit.Next(); {
+				_, candidate := sit.Element()
+				if key.Equals(candidate).True() {
+					out = append(out, valueList[i])
+					break
+				}
+			}
+		}
+		if len(out) == 0 {
+			return cty.ListValEmpty(retType.ElementType()), nil
+		}
+		return cty.ListVal(out), nil
+	},
+})
+
+// base64Decode decodifica base64 exigindo UTF-8 válido, como o Terraform
+func base64Decode(s string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 data %q", s)
+	}
+	if !utf8.Valid(decoded) {
+		return "", fmt.Errorf("the result of decoding the provided string is not valid UTF-8")
+	}
+	return string(decoded), nil
+}
+
+// base64Gzip comprime a string com gzip e codifica em base64
+func base64Gzip(s string) (string, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// isUTF8Encoding verifica se o nome de encoding é UTF-8, o único suportado
+// pelas funções textencodebase64/textdecodebase64 do analisador
+func isUTF8Encoding(name string) bool {
+	switch strings.ToLower(strings.ReplaceAll(name, "-", "")) {
+	case "utf8":
+		return true
+	}
+	return false
+}
+
+var textEncodeBase64Func = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "string", Type: cty.String},
+		{Name: "encoding", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if !isUTF8Encoding(args[1].AsString()) {
+			return cty.UnknownVal(cty.String), fmt.Errorf("encoding %q não suportado", args[1].AsString())
+		}
+		return cty.StringVal(base64.StdEncoding.EncodeToString([]byte(args[0].AsString()))), nil
+	},
+})
+
+var textDecodeBase64Func = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "source", Type: cty.String},
+		{Name: "encoding", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if !isUTF8Encoding(args[1].AsString()) {
+			return cty.UnknownVal(cty.String), fmt.Errorf("encoding %q não suportado", args[1].AsString())
+		}
+		decoded, err := base64Decode(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		return cty.StringVal(decoded), nil
+	},
+})
+
+// yamlDecodeFunc decodifica YAML passando por JSON para reaproveitar jsondecode
+var yamlDecodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "src", Type: cty.String}},
+	Type:   function.StaticReturnType(cty.DynamicPseudoType),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		jsonVal, err := yamlToJSON(args[0].AsString())
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return stdlib.JSONDecode(cty.StringVal(jsonVal))
+	},
+})
+
+// yamlToJSON converte um documento YAML em JSON
+func yamlToJSON(src string) (string, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return "", fmt.Errorf("yaml inválido: %w", err)
+	}
+	normalized, err := normalizeYAML(doc)
+	if err != nil {
+		return "", err
+	}
+	out, err := json.Marshal(normalized)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// normalizeYAML garante que mapas tenham chaves string para serialização JSON
+func normalizeYAML(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, item := range t {
+			n, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			t[k] = n
+		}
+		return t, nil
+	case map[interface{}]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("chaves de mapas YAML devem ser strings")
+			}
+			n, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = n
+		}
+		return out, nil
+	case []interface{}:
+		for i, item := range t {
+			n, err := normalizeYAML(item)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = n
+		}
+		return t, nil
+	}
+	return v, nil
+}
+
+// yamlEncodeFunc serializa um valor em YAML
+var yamlEncodeFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{
+		Name:             "value",
+		Type:             cty.DynamicPseudoType,
+		AllowNull:        true,
+		AllowDynamicType: true,
+	}},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if !args[0].IsWhollyKnown() {
+			return cty.UnknownVal(cty.String), nil
+		}
+		encoded, err := stdlib.JSONEncode(args[0])
+		if err != nil {
+			return cty.NilVal, err
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(encoded.AsString()), &doc); err != nil {
+			return cty.NilVal, err
+		}
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		return cty.StringVal(string(out)), nil
+	},
+})
+
+// rsaDecryptFunc decifra um texto cifrado em base64 com uma chave RSA PEM
+var rsaDecryptFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "ciphertext", Type: cty.String},
+		{Name: "privatekey", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		ciphertext, err := base64.StdEncoding.DecodeString(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to decode input %q: %w", args[0].AsString(), err)
+		}
+		block, _ := pem.Decode([]byte(args[1].AsString()))
+		if block == nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to parse key: no PEM block found")
+		}
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to parse key: %w", err)
+		}
+		out, err := rsa.DecryptPKCS1v15(rand.Reader, key, ciphertext)
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("failed to decrypt: %w", err)
+		}
+		return cty.StringVal(string(out)), nil
+	},
+})
+
+// uuidV5Func gera um UUID v5 determinístico
+var uuidV5Func = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "namespace", Type: cty.String},
+		{Name: "name", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		var namespace uuid.UUID
+		switch ns := args[0].AsString(); ns {
+		case "dns":
+			namespace = uuid.NameSpaceDNS
+		case "url":
+			namespace = uuid.NameSpaceURL
+		case "oid":
+			namespace = uuid.NameSpaceOID
+		case "x500":
+			namespace = uuid.NameSpaceX500
+		default:
+			parsed, err := uuid.Parse(ns)
+			if err != nil {
+				return cty.UnknownVal(cty.String), fmt.Errorf("uuidv5() doesn't support namespace %s (%w)", ns, err)
+			}
+			namespace = parsed
+		}
+		return cty.StringVal(uuid.NewSHA1(namespace, []byte(args[1].AsString())).String()), nil
+	},
+})
+
+// timeCmpFunc compara dois timestamps RFC3339
+var timeCmpFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "timestamp_a", Type: cty.String},
+		{Name: "timestamp_b", Type: cty.String},
+	},
+	Type: function.StaticReturnType(cty.Number),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		a, err := time.Parse(time.RFC3339, args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.Number), err
+		}
+		b, err := time.Parse(time.RFC3339, args[1].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.Number), err
+		}
+		switch {
+		case a.Before(b):
+			return cty.NumberIntVal(-1), nil
+		case a.After(b):
+			return cty.NumberIntVal(1), nil
+		}
+		return cty.NumberIntVal(0), nil
+	},
+})
+
+// parseCIDR interpreta um prefixo CIDR devolvendo endereço base, tamanho do
+// prefixo e quantidade de bits do endereço
+func parseCIDR(prefix string) (*big.Int, int, int, error) {
+	_, network, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("invalid CIDR expression: %w", err)
+	}
+	ones, bits := network.Mask.Size()
+	ip := network.IP
+	if bits == 32 {
+		ip = ip.To4()
+	}
+	return new(big.Int).SetBytes(ip), ones, bits, nil
+}
+
+// formatIP converte um inteiro de volta em endereço IP
+func formatIP(n *big.Int, bits int) string {
+	buf := make([]byte, bits/8)
+	n.FillBytes(buf)
+	return net.IP(buf).String()
+}
+
+var cidrHostFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "prefix", Type: cty.String},
+		{Name: "hostnum", Type: cty.Number},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		base, ones, bits, err := parseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		hostnum, _ := args[1].AsBigFloat().Int(nil)
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+		if hostnum.Sign() < 0 {
+			hostnum.Add(hostnum, size)
+		}
+		if hostnum.Sign() < 0 || hostnum.Cmp(size) >= 0 {
+			return cty.UnknownVal(cty.String), fmt.Errorf("prefix of %d bits cannot accommodate host number %s", ones, args[1].AsBigFloat().String())
+		}
+		return cty.StringVal(formatIP(new(big.Int).Add(base, hostnum), bits)), nil
+	},
+})
+
+var cidrNetmaskFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "prefix", Type: cty.String}},
+	Type:   function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		_, network, err := net.ParseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("invalid CIDR expression: %w", err)
+		}
+		if network.IP.To4() == nil {
+			return cty.UnknownVal(cty.String), fmt.Errorf("IPv6 addresses cannot have a netmask: %s", args[0].AsString())
+		}
+		return cty.StringVal(net.IP(network.Mask).String()), nil
+	},
+})
+
+var cidrSubnetFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "prefix", Type: cty.String},
+		{Name: "newbits", Type: cty.Number},
+		{Name: "netnum", Type: cty.Number},
+	},
+	Type: function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		base, ones, bits, err := parseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(cty.String), err
+		}
+		newbits, _ := args[1].AsBigFloat().Int64()
+		netnum, _ := args[2].AsBigFloat().Int(nil)
+		newLen := ones + int(newbits)
+		if newbits < 0 || newLen > bits {
+			return cty.UnknownVal(cty.String), fmt.Errorf("insufficient address space to extend prefix of %d by %d", ones, newbits)
+		}
+		if netnum.Sign() < 0 || netnum.Cmp(new(big.Int).Lsh(big.NewInt(1), uint(newbits))) >= 0 {
+			return cty.UnknownVal(cty.String), fmt.Errorf("prefix extension of %d does not accommodate a subnet numbered %s", newbits, netnum)
+		}
+		subnet := new(big.Int).Add(base, new(big.Int).Lsh(netnum, uint(bits-newLen)))
+		return cty.StringVal(fmt.Sprintf("%s/%d", formatIP(subnet, bits), newLen)), nil
+	},
+})
+
+var cidrSubnetsFunc = function.New(&function.Spec{
+	Params: []function.Parameter{{Name: "prefix", Type: cty.String}},
+	VarParam: &function.Parameter{
+		Name: "newbits",
+		Type: cty.Number,
+	},
+	Type: function.StaticReturnType(cty.List(cty.String)),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		base, ones, bits, err := parseCIDR(args[0].AsString())
+		if err != nil {
+			return cty.UnknownVal(retType), err
+		}
+		if len(args) == 1 {
+			return cty.ListValEmpty(cty.String), nil
+		}
+
+		limit := new(big.Int).Add(base, new(big.Int).Lsh(big.NewInt(1), uint(bits-ones)))
+		current := new(big.Int).Set(base)
+		out := make([]cty.Value, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			newbits, _ := arg.AsBigFloat().Int64()
+			newLen := ones + int(newbits)
+			if newbits < 1 || newLen > bits {
+				return cty.UnknownVal(retType), fmt.Errorf("would extend prefix to %d bits, which is too long for an address of %d bits", newLen, bits)
+			}
+
+			size := new(big.Int).Lsh(big.NewInt(1), uint(bits-newLen))
+			// Alinha o início da sub-rede ao seu tamanho
+			rem := new(big.Int).Mod(current, size)
+			if rem.Sign() != 0 {
+				current.Add(current, new(big.Int).Sub(size, rem))
+			}
+			next := new(big.Int).Add(current, size)
+			if next.Cmp(limit) > 0 {
+				return cty.UnknownVal(retType), fmt.Errorf("not enough remaining address space for a subnet with a prefix of %d bits", newLen)
+			}
+			out = append(out, cty.StringVal(fmt.Sprintf("%s/%d", formatIP(current, bits), newLen)))
+			current = next
+		}
+		return cty.ListVal(out), nil
+	},
+})
+
+// absPath resolve um caminho relativo ao diretório do módulo raiz
+func (fs fsSandbox) absPath(path string) (string, error) {
+	if filepath.IsAbs(path) || fs.root == "" {
+		return filepath.ToSlash(filepath.Clean(path)), nil
+	}
+	return filepath.ToSlash(filepath.Join(fs.base, path)), nil
+}
+
+// fileFunc implementa file e filebase64
+func fileFunc(fs fsSandbox, encodeBase64 bool) function.Function {
+	return stringFunc(func(path string) (string, error) {
+		content, err := fs.readFile(path)
+		if err != nil {
+			return "", err
+		}
+		if encodeBase64 {
+			return base64.StdEncoding.EncodeToString(content), nil
+		}
+		if !utf8.Valid(content) {
+			return "", fmt.Errorf("contents of %s are not valid UTF-8; use the filebase64 function to obtain the Base64 encoded contents", path)
+		}
+		return string(content), nil
+	})
+}
+
+// fileExistsFunc implementa fileexists
+func fileExistsFunc(fs fsSandbox) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "path", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.Bool),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			resolved, err := fs.resolve(args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(cty.Bool), err
+			}
+			info, err := os.Stat(resolved)
+			if err != nil {
+				return cty.False, nil
+			}
+			return cty.BoolVal(info.Mode().IsRegular()), nil
+		},
+	})
+}
+
+// fileSetFunc implementa fileset com suporte a ** nos padrões
+func fileSetFunc(fs fsSandbox) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+			{Name: "pattern", Type: cty.String},
+		},
+		Type: function.StaticReturnType(cty.Set(cty.String)),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			base, err := fs.resolve(args[0].AsString())
+			if err != nil {
+				return cty.UnknownVal(retType), err
+			}
+			pattern := strings.Split(filepath.ToSlash(args[1].AsString()), "/")
+
+			matches := []cty.Value{}
+			err = filepath.Walk(base, func(path string, info os.FileInfo, err error) error {
+				if err != nil || !info.Mode().IsRegular() {
+					return nil
+				}
+				rel, err := filepath.Rel(base, path)
+				if err != nil {
+					return nil
+				}
+				rel = filepath.ToSlash(rel)
+				if globMatch(pattern, strings.Split(rel, "/")) {
+					matches = append(matches, cty.StringVal(rel))
+				}
+				return nil
+			})
+			if err != nil {
+				return cty.UnknownVal(retType), err
+			}
+			if len(matches) == 0 {
+				return cty.SetValEmpty(cty.String), nil
+			}
+			return cty.SetVal(matches), nil
+		},
+	})
+}
+
+// globMatch compara segmentos de caminho com um padrão que aceita **
+func globMatch(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if globMatch(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, err := filepath.Match(pattern[0], parts[0]); err != nil || !ok {
+		return false
+	}
+	return globMatch(pattern[1:], parts[1:])
+}
+
+// templateFileFunc implementa templatefile usando a sintaxe de templates HCL
+func templateFileFunc(fs fsSandbox, funcs map[string]function.Function) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+			{Name: "vars", Type: cty.DynamicPseudoType},
+		},
+		Type: function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := args[0].AsString()
+			vars := args[1]
+			if !(vars.Type().IsObjectType() || vars.Type().IsMapType()) {
+				return cty.DynamicVal, fmt.Errorf("invalid vars value: must be a map")
+			}
+			if !vars.IsWhollyKnown() {
+				return cty.DynamicVal, nil
+			}
+
+			content, err := fs.readFile(path)
+			if err != nil {
+				return cty.DynamicVal, err
+			}
+			expr, diags := hclsyntax.ParseTemplate(content, path, hcl.Pos{Line: 1, Column: 1})
+			if diags.HasErrors() {
+				return cty.DynamicVal, diags
+			}
+
+			ctx := &hcl.EvalContext{
+				Variables: vars.AsValueMap(),
+				Functions: funcs,
+			}
+			val, diags := expr.Value(ctx)
+			if diags.HasErrors() {
+				return cty.DynamicVal, diags
+			}
+			return val, nil
+		},
+	})
+}
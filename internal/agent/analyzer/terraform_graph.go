@@ -0,0 +1,307 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Tipos de aresta do grafo de recursos
+const (
+	EdgeExplicit = "explicit" // depends_on
+	EdgeImplicit = "implicit" // referência em expressão
+)
+
+// graphNodeRefs guarda as referências de um nó ainda não resolvidas
+type graphNodeRefs struct {
+	node     models.GraphNode
+	implicit []hcl.Traversal
+	explicit []hcl.Traversal
+}
+
+// buildGraph monta o grafo de dependências de um módulo a partir das
+// referências em expressões (aws_vpc.main.id), de depends_on, das entradas de
+// módulos e de data sources. Referências via local.* são seguidas até os nós
+// que os locals referenciam. Os IDs dos nós recebem o endereço do módulo e
+// resources a partir de firstResource recebem o campo Dependencies preenchido.
+func (ta *TerraformAnalyzer) buildGraph(mod *moduleInstance, firstResource int, analysis *models.TerraformAnalysis) {
+	nodes := []*graphNodeRefs{}
+	known := make(map[string]bool)
+	localRefs := make(map[string][]hcl.Traversal)
+
+	for _, f := range mod.files {
+		for _, block := range f.content.Blocks {
+			var node models.GraphNode
+			switch {
+			case block.Type == "resource" && len(block.Labels) >= 2:
+				node = models.GraphNode{
+					ID:       block.Labels[0] + "." + block.Labels[1],
+					Type:     "resource",
+					Name:     block.Labels[1],
+					Resource: block.Labels[0],
+				}
+			case block.Type == "data" && len(block.Labels) >= 2:
+				node = models.GraphNode{
+					ID:       "data." + block.Labels[0] + "." + block.Labels[1],
+					Type:     "data",
+					Name:     block.Labels[1],
+					Resource: block.Labels[0],
+				}
+			case block.Type == "module" && len(block.Labels) >= 1:
+				node = models.GraphNode{
+					ID:   "module." + block.Labels[0],
+					Type: "module",
+					Name: block.Labels[0],
+				}
+			case block.Type == "locals":
+				attrs, _ := block.Body.JustAttributes()
+				for name, attr := range attrs {
+					localRefs["local."+name] = attr.Expr.Variables()
+				}
+				continue
+			default:
+				continue
+			}
+
+			implicit, explicit := blockReferences(block.Body)
+			nodes = append(nodes, &graphNodeRefs{node: node, implicit: implicit, explicit: explicit})
+			known[node.ID] = true
+		}
+	}
+
+	if len(nodes) == 0 {
+		return
+	}
+
+	if analysis.ResourceGraph == nil {
+		analysis.ResourceGraph = &models.ResourceGraph{
+			Nodes: []models.GraphNode{},
+			Edges: []models.GraphEdge{},
+		}
+	}
+	graph := analysis.ResourceGraph
+
+	dependencies := make(map[string][]string)
+	for _, n := range nodes {
+		id := n.node.ID
+		n.node.ID = mod.qualify(id)
+		graph.Nodes = append(graph.Nodes, n.node)
+
+		edges := make(map[string]string)
+		for _, target := range resolveReferences(n.implicit, known, localRefs) {
+			edges[target] = EdgeImplicit
+		}
+		for _, target := range resolveReferences(n.explicit, known, localRefs) {
+			edges[target] = EdgeExplicit
+		}
+		delete(edges, id)
+
+		targets := make([]string, 0, len(edges))
+		for target := range edges {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+
+		for i, target := range targets {
+			targets[i] = mod.qualify(target)
+			graph.Edges = append(graph.Edges, models.GraphEdge{
+				From: n.node.ID,
+				To:   targets[i],
+				Type: edges[target],
+			})
+		}
+		dependencies[n.node.ID] = targets
+	}
+
+	for i := firstResource; i < len(analysis.Resources); i++ {
+		resource := &analysis.Resources[i]
+		if deps := dependencies[mod.qualify(resource.Type+"."+resource.Name)]; len(deps) > 0 {
+			resource.Dependencies = deps
+		}
+	}
+}
+
+// blockReferences coleta as traversals de um body sem schema, separando as
+// referências de depends_on (explícitas) das demais (implícitas)
+func blockReferences(body hcl.Body) (implicit, explicit []hcl.Traversal) {
+	attrs, blocks, _ := bodyItems(body)
+
+	for name, attr := range attrs {
+		switch name {
+		case "depends_on":
+			explicit = append(explicit, exprTraversals(attr.Expr)...)
+		case "provider", "ignore_changes":
+			// Referem-se a configurações/atributos, não a outros blocos
+		default:
+			implicit = append(implicit, attr.Expr.Variables()...)
+		}
+	}
+
+	for _, block := range blocks {
+		nestedImplicit, nestedExplicit := blockReferences(block.Body)
+		implicit = append(implicit, nestedImplicit...)
+		explicit = append(explicit, nestedExplicit...)
+	}
+
+	return implicit, explicit
+}
+
+// exprTraversals retorna as traversals de uma lista de referências como a de
+// depends_on
+func exprTraversals(expr hcl.Expression) []hcl.Traversal {
+	exprs, diags := hcl.ExprList(expr)
+	if diags.HasErrors() {
+		return expr.Variables()
+	}
+
+	traversals := []hcl.Traversal{}
+	for _, e := range exprs {
+		if traversal, diags := hcl.AbsTraversalForExpr(e); !diags.HasErrors() {
+			traversals = append(traversals, traversal)
+		}
+	}
+	return traversals
+}
+
+// resolveReferences converte traversals em IDs de nós conhecidos, seguindo
+// referências a locals de forma transitiva
+func resolveReferences(traversals []hcl.Traversal, known map[string]bool, localRefs map[string][]hcl.Traversal) []string {
+	targets := []string{}
+	seen := make(map[string]bool)
+
+	var visit func(traversals []hcl.Traversal)
+	visit = func(traversals []hcl.Traversal) {
+		for _, traversal := range traversals {
+			id := referenceID(traversal)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			if strings.HasPrefix(id, "local.") {
+				visit(localRefs[id])
+				continue
+			}
+			if known[id] {
+				targets = append(targets, id)
+			}
+		}
+	}
+	visit(traversals)
+
+	return targets
+}
+
+// referenceID retorna o endereço do bloco referenciado por uma traversal, ex.:
+// aws_vpc.main.id -> aws_vpc.main, data.aws_ami.ubuntu.id -> data.aws_ami.ubuntu,
+// module.network.vpc_id -> module.network
+func referenceID(traversal hcl.Traversal) string {
+	names := []string{traversal.RootName()}
+	for _, step := range traversal[1:] {
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			break
+		}
+		names = append(names, attr.Name)
+	}
+
+	switch names[0] {
+	case "var", "path", "terraform", "self", "count", "each":
+		return ""
+	case "data":
+		if len(names) < 3 {
+			return ""
+		}
+		return strings.Join(names[:3], ".")
+	default:
+		if len(names) < 2 {
+			return ""
+		}
+		return strings.Join(names[:2], ".")
+	}
+}
+
+// DetectCycles retorna os ciclos do grafo de dependências (componentes
+// fortemente conexos com mais de um nó), cada um com os IDs ordenados
+func DetectCycles(graph *models.ResourceGraph) [][]string {
+	if graph == nil {
+		return nil
+	}
+
+	adjacency := make(map[string][]string)
+	for _, edge := range graph.Edges {
+		adjacency[edge.From] = append(adjacency[edge.From], edge.To)
+	}
+
+	// Algoritmo de Tarjan
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := []string{}
+	cycles := [][]string{}
+
+	var strongConnect func(id string)
+	strongConnect = func(id string) {
+		indices[id] = index
+		lowlink[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range adjacency[id] {
+			if _, visited := indices[next]; !visited {
+				strongConnect(next)
+				if lowlink[next] < lowlink[id] {
+					lowlink[id] = lowlink[next]
+				}
+			} else if onStack[next] && indices[next] < lowlink[id] {
+				lowlink[id] = indices[next]
+			}
+		}
+
+		if lowlink[id] != indices[id] {
+			return
+		}
+
+		component := []string{}
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == id {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if _, visited := indices[node.ID]; !visited {
+			strongConnect(node.ID)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+// checkCycles registra os ciclos de dependência encontrados no grafo
+func (ta *TerraformAnalyzer) checkCycles(analysis *models.TerraformAnalysis) {
+	if analysis.ResourceGraph == nil {
+		return
+	}
+
+	analysis.ResourceGraph.Cycles = DetectCycles(analysis.ResourceGraph)
+	for _, cycle := range analysis.ResourceGraph.Cycles {
+		analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
+			fmt.Sprintf("Ciclo de dependências detectado: %s", strings.Join(cycle, ", ")))
+	}
+}
@@ -0,0 +1,332 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// moduleInstance é uma instância de módulo em análise: o módulo raiz de um
+// diretório ou uma chamada module "x" { source = "./modules/x" }
+type moduleInstance struct {
+	address string // "" no módulo raiz, "module.x" (ou "module.x.module.y") em chamadas
+	dir     string
+	scope   *evalScope
+	files   []*moduleFile
+	inputs  map[string]cty.Value
+	outputs map[string]cty.Value
+	stack   []string // diretórios na pilha de chamadas, evita recursão infinita
+
+	// sensitiveOutputs são os outputs declarados com sensitive = true
+	sensitiveOutputs map[string]bool
+
+	// unknownCardinality indica que count/for_each da chamada do módulo é
+	// desconhecido e a instância representa um número incerto de cópias
+	unknownCardinality bool
+}
+
+// newModuleInstance cria uma instância de módulo com escopo próprio
+func newModuleInstance(address, dir string, scope *evalScope, files []*moduleFile) *moduleInstance {
+	return &moduleInstance{
+		address: address,
+		dir:     dir,
+		scope:   scope,
+		files:   files,
+		inputs:  make(map[string]cty.Value),
+		outputs: make(map[string]cty.Value),
+		stack:   []string{dir},
+
+		sensitiveOutputs: make(map[string]bool),
+	}
+}
+
+// qualify prefixa um endereço com o endereço do módulo, ex.:
+// aws_vpc.main -> module.network.aws_vpc.main
+func (m *moduleInstance) qualify(address string) string {
+	if m.address == "" {
+		return address
+	}
+	return m.address + "." + address
+}
+
+// moduleLoader carrega os arquivos de cada diretório de módulo, decodificando
+// uma única vez mesmo quando o módulo é chamado várias vezes
+type moduleLoader struct {
+	parser  *hclparse.Parser // tfvars e lock files
+	root    string
+	paths   map[string][]string    // diretório absoluto -> arquivos .tf e .tf.json
+	parsed  map[string]*parsedFile // arquivo -> resultado do parsing paralelo
+	loaded  map[string][]*moduleFile
+	visited map[string]bool
+}
+
+// newModuleLoader cria um loader para os arquivos encontrados sob root, já
+// parseados em parsed
+func newModuleLoader(parser *hclparse.Parser, root string, paths map[string][]string, parsed map[string]*parsedFile) *moduleLoader {
+	return &moduleLoader{
+		parser:  parser,
+		root:    root,
+		paths:   paths,
+		parsed:  parsed,
+		loaded:  make(map[string][]*moduleFile),
+		visited: make(map[string]bool),
+	}
+}
+
+// load faz o parsing dos arquivos de um diretório. Erros de leitura e de
+// sintaxe são registrados na análise apenas na primeira carga.
+func (l *moduleLoader) load(ta *TerraformAnalyzer, dir string, analysis *models.TerraformAnalysis) ([]*moduleFile, bool) {
+	if files, ok := l.loaded[dir]; ok {
+		return files, true
+	}
+
+	paths, ok := l.paths[dir]
+	if !ok {
+		return nil, false
+	}
+
+	files := []*moduleFile{}
+	for _, path := range paths {
+		parsed, ok := l.parsed[path]
+		if !ok {
+			parsed = ta.readConfigFile(path)
+		}
+		if parsed.err != nil {
+			analysis.Valid = false
+			analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
+				File:    path,
+				Message: fmt.Sprintf("erro ao ler arquivo: %s", parsed.err),
+			})
+			continue
+		}
+
+		if parsed.diags.HasErrors() {
+			// Continua processando outros arquivos
+			ta.appendDiagnostics(analysis, path, parsed.diags)
+			continue
+		}
+
+		f := &moduleFile{path: path, file: parsed.file}
+		f.content = ta.decodeFile(f, analysis)
+		files = append(files, f)
+	}
+
+	files = ta.applyOverrides(files, analysis)
+	l.loaded[dir] = files
+	return files, true
+}
+
+// localModuleDir resolve o diretório de um source local (./ ou ../). Sources
+// remotos (registry, git, s3...) e diretórios fora da raiz analisada não são
+// seguidos.
+func (l *moduleLoader) localModuleDir(callerDir, source string) (string, bool) {
+	if l.root == "" || !isLocalModuleSource(source) {
+		return "", false
+	}
+
+	dir := filepath.Clean(filepath.Join(callerDir, filepath.FromSlash(source)))
+	rel, err := filepath.Rel(l.root, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return dir, true
+}
+
+// calledDirs retorna os diretórios chamados como módulos locais por algum
+// outro diretório. Eles são analisados a partir de quem os chama, com os
+// inputs ligados às variáveis, em vez de como módulos raiz.
+func (l *moduleLoader) calledDirs(ta *TerraformAnalyzer, dirs []string, analysis *models.TerraformAnalysis) map[string]bool {
+	called := make(map[string]bool)
+	for _, dir := range dirs {
+		files, _ := l.load(ta, dir, analysis)
+		for _, f := range files {
+			for _, block := range f.content.Blocks {
+				if block.Type != "module" {
+					continue
+				}
+				if target, ok := l.localModuleDir(dir, moduleSource(block)); ok && target != dir {
+					called[target] = true
+				}
+			}
+		}
+	}
+	return called
+}
+
+// isLocalModuleSource verifica se o source aponta para um caminho local
+func isLocalModuleSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+// moduleSource lê o source literal de um bloco module
+func moduleSource(block *hcl.Block) string {
+	attrs, _ := block.Body.JustAttributes()
+	attr, ok := attrs["source"]
+	if !ok {
+		return ""
+	}
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || val.Type() != cty.String || !val.IsKnown() || val.IsNull() {
+		return ""
+	}
+	return val.AsString()
+}
+
+// moduleMetaArguments são argumentos de um bloco module que não são inputs
+var moduleMetaArguments = map[string]bool{
+	"source":     true,
+	"version":    true,
+	"count":      true,
+	"for_each":   true,
+	"providers":  true,
+	"depends_on": true,
+}
+
+// parseModule extrai informações de um module block. Para sources locais o
+// analisador desce no módulo: os inputs são ligados às variáveis do módulo,
+// seus recursos são analisados com o endereço module.<nome> e seus outputs
+// ficam disponíveis como module.<nome>.<output> para o chamador.
+func (ta *TerraformAnalyzer) parseModule(
+	loader *moduleLoader,
+	block *hcl.Block,
+	filename string,
+	parent *moduleInstance,
+	analysis *models.TerraformAnalysis,
+) {
+	if len(block.Labels) < 1 {
+		return
+	}
+
+	module := models.TerraformModule{
+		Name:      block.Labels[0],
+		Module:    parent.address,
+		File:      filename,
+		LineStart: block.DefRange.Start.Line,
+		Inputs:    make(map[string]interface{}),
+	}
+
+	attrs, diags := block.Body.JustAttributes()
+	ta.appendDiagnostics(analysis, filename, diags)
+
+	module.Source = moduleSource(block)
+	if attr, ok := attrs["version"]; ok {
+		if val, valDiags := attr.Expr.Value(nil); !valDiags.HasErrors() && val.Type() == cty.String && val.IsKnown() && !val.IsNull() {
+			module.Version = val.AsString()
+		}
+	}
+
+	// Inputs no nível da chamada: valores que dependem de count.index ou
+	// each.* variam por instância e ficam de fora
+	configScope := parent.scope.withVariables(map[string]cty.Value{
+		"count": cty.DynamicVal,
+		"each":  cty.DynamicVal,
+	})
+	for name, val := range moduleInputs(attrs, configScope) {
+		if goVal, ok := convertValue(val, name, nil); ok {
+			module.Inputs[name] = goVal
+		}
+	}
+
+	// Outputs de módulos não analisados são desconhecidos
+	parent.scope.setModule(module.Name, cty.DynamicVal)
+
+	if dir, ok := loader.localModuleDir(parent.dir, module.Source); ok {
+		ta.callModule(loader, dir, block, attrs, &module, parent, analysis)
+	}
+
+	analysis.Modules = append(analysis.Modules, module)
+}
+
+// moduleInputs avalia os argumentos de um bloco module que são inputs
+func moduleInputs(attrs hcl.Attributes, scope *evalScope) map[string]cty.Value {
+	inputs := make(map[string]cty.Value)
+	for name, attr := range attrs {
+		if moduleMetaArguments[name] {
+			continue
+		}
+
+		val, diags := attr.Expr.Value(scope.exprContext(attr.Expr))
+		if diags.HasErrors() {
+			val = cty.DynamicVal
+		}
+		inputs[name] = val
+	}
+	return inputs
+}
+
+// callModule analisa um módulo local chamado por parent, uma vez para cada
+// instância de count/for_each
+func (ta *TerraformAnalyzer) callModule(
+	loader *moduleLoader,
+	dir string,
+	block *hcl.Block,
+	attrs hcl.Attributes,
+	module *models.TerraformModule,
+	parent *moduleInstance,
+	analysis *models.TerraformAnalysis,
+) {
+	address := parent.qualify("module." + module.Name)
+
+	for _, d := range parent.stack {
+		if d == dir {
+			analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
+				fmt.Sprintf("Módulo %s chama a si mesmo recursivamente (%s)", address, module.Source))
+			return
+		}
+	}
+
+	files, ok := loader.load(ta, dir, analysis)
+	if !ok {
+		analysis.BestPracticeWarnings = append(analysis.BestPracticeWarnings,
+			fmt.Sprintf("Módulo %s: source local %s não contém arquivos Terraform", address, module.Source))
+		return
+	}
+
+	instances, known, diags := expandInstances(block.Body, parent.scope)
+	ta.appendDiagnostics(analysis, module.File, diags)
+	byIndex := []cty.Value{}
+	byKey := make(map[string]cty.Value)
+	for _, instance := range instances {
+		child := newModuleInstance(address+instance.suffix(), dir, newEvalScope(parent.scope.root, dir, parent.scope.functions), files)
+		child.inputs = moduleInputs(attrs, instance.scope)
+		child.stack = append(append([]string{}, parent.stack...), dir)
+		child.unknownCardinality = !known || parent.unknownCardinality
+
+		outputs := objectOrEmpty(ta.analyzeModule(loader, child, nil, analysis))
+
+		switch {
+		case instance.key == cty.NilVal:
+			module.Outputs = make(map[string]interface{})
+			for name, val := range child.outputs {
+				if child.sensitiveOutputs[name] {
+					module.SensitiveOutputs = append(module.SensitiveOutputs, name)
+					continue
+				}
+				if goVal, ok := convertValue(val, name, nil); ok {
+					module.Outputs[name] = goVal
+				}
+			}
+			sort.Strings(module.SensitiveOutputs)
+			if known {
+				parent.scope.setModule(module.Name, outputs)
+			}
+		case instance.key.Type() == cty.Number:
+			byIndex = append(byIndex, outputs)
+		default:
+			byKey[instance.key.AsString()] = outputs
+		}
+	}
+
+	// module.<nome>[i].<output> para count e module.<nome>["k"].<output> para for_each
+	if _, hasCount := attrs["count"]; hasCount && known {
+		parent.scope.setModule(module.Name, cty.TupleVal(byIndex))
+	} else if _, hasForEach := attrs["for_each"]; hasForEach && known {
+		parent.scope.setModule(module.Name, cty.ObjectVal(byKey))
+	}
+}
@@ -0,0 +1,286 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// isOverrideFile verifica se o arquivo é um override do Terraform
+// (override.tf, override.tf.json, *_override.tf, *_override.tf.json)
+func isOverrideFile(path string) bool {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".json"), ".tf")
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
+
+// isConfigFile verifica se o arquivo é configuração Terraform (nativa ou JSON)
+func isConfigFile(path string) bool {
+	return strings.HasSuffix(path, ".tf") || strings.HasSuffix(path, ".tf.json")
+}
+
+// parseConfigFile faz o parsing de um arquivo de configuração usando o parser
+// JSON para arquivos .json e o parser HCL nativo para os demais. Não usa um
+// hclparse.Parser compartilhado e pode ser chamada de várias goroutines.
+func parseConfigFile(content []byte, path string) (*hcl.File, hcl.Diagnostics) {
+	if strings.HasSuffix(path, ".json") {
+		return hcljson.Parse(content, path)
+	}
+	return hclsyntax.ParseConfig(content, path, hcl.InitialPos)
+}
+
+// overrideBody combina o body de um bloco original com o de um bloco de
+// override seguindo a semântica do Terraform: atributos do override
+// substituem os originais e blocos aninhados do override substituem todos os
+// blocos do mesmo tipo. O bloco lifecycle é combinado argumento a argumento.
+type overrideBody struct {
+	base     hcl.Body
+	override hcl.Body
+}
+
+// Content implementa hcl.Body
+func (b *overrideBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	baseContent, diags := b.base.Content(schema)
+	overrideContent, overrideDiags := b.override.Content(schema)
+	return mergeBodyContent(baseContent, overrideContent), append(diags, overrideDiags...)
+}
+
+// PartialContent implementa hcl.Body
+func (b *overrideBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+	baseContent, baseRemain, diags := b.base.PartialContent(schema)
+	overrideContent, overrideRemain, overrideDiags := b.override.PartialContent(schema)
+	remain := &overrideBody{base: baseRemain, override: overrideRemain}
+	return mergeBodyContent(baseContent, overrideContent), remain, append(diags, overrideDiags...)
+}
+
+// JustAttributes implementa hcl.Body
+func (b *overrideBody) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
+	attrs, diags := b.base.JustAttributes()
+	overrideAttrs, overrideDiags := b.override.JustAttributes()
+	merged := make(hcl.Attributes, len(attrs)+len(overrideAttrs))
+	for name, attr := range attrs {
+		merged[name] = attr
+	}
+	for name, attr := range overrideAttrs {
+		merged[name] = attr
+	}
+	return merged, append(diags, overrideDiags...)
+}
+
+// MissingItemRange implementa hcl.Body
+func (b *overrideBody) MissingItemRange() hcl.Range {
+	return b.base.MissingItemRange()
+}
+
+// mergeBodyContent aplica o conteúdo do override sobre o original
+func mergeBodyContent(base, override *hcl.BodyContent) *hcl.BodyContent {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+
+	merged := &hcl.BodyContent{
+		Attributes:       make(hcl.Attributes, len(base.Attributes)+len(override.Attributes)),
+		MissingItemRange: base.MissingItemRange,
+	}
+	for name, attr := range base.Attributes {
+		merged.Attributes[name] = attr
+	}
+	for name, attr := range override.Attributes {
+		merged.Attributes[name] = attr
+	}
+	merged.Blocks = mergeBlocks(base.Blocks, override.Blocks)
+	return merged
+}
+
+// mergeBlocks substitui os blocos originais pelos blocos do override de
+// mesmo tipo, combinando lifecycle argumento a argumento
+func mergeBlocks(base, override hcl.Blocks) hcl.Blocks {
+	overridden := make(map[string]bool)
+	for _, block := range override {
+		overridden[block.Type] = true
+	}
+
+	merged := hcl.Blocks{}
+	var baseLifecycle *hcl.Block
+	for _, block := range base {
+		if block.Type == "lifecycle" && baseLifecycle == nil {
+			baseLifecycle = block
+		}
+		if !overridden[block.Type] {
+			merged = append(merged, block)
+		}
+	}
+
+	for _, block := range override {
+		if block.Type == "lifecycle" && baseLifecycle != nil {
+			block = overrideBlock(baseLifecycle, block)
+		}
+		merged = append(merged, block)
+	}
+	return merged
+}
+
+// overrideBlock cria uma cópia do bloco original com o body do override aplicado
+func overrideBlock(base, override *hcl.Block) *hcl.Block {
+	merged := *base
+	merged.Body = &overrideBody{base: base.Body, override: override.Body}
+	return &merged
+}
+
+// bodyItems retorna uma visão sem schema de um body: seus atributos e blocos
+// aninhados. Bodies JSON não distinguem blocos de atributos, então seus blocos
+// aninhados aparecem como atributos de tipo objeto.
+func bodyItems(body hcl.Body) (hcl.Attributes, hcl.Blocks, hcl.Diagnostics) {
+	switch b := body.(type) {
+	case *hclsyntax.Body:
+		attrs := make(hcl.Attributes, len(b.Attributes))
+		for name, attr := range b.Attributes {
+			attrs[name] = attr.AsHCLAttribute()
+		}
+		blocks := make(hcl.Blocks, 0, len(b.Blocks))
+		for _, block := range b.Blocks {
+			blocks = append(blocks, block.AsHCLBlock())
+		}
+		return attrs, blocks, nil
+	case *overrideBody:
+		attrs, blocks, diags := bodyItems(b.base)
+		overrideAttrs, overrideBlocks, overrideDiags := bodyItems(b.override)
+		merged := mergeBodyContent(
+			&hcl.BodyContent{Attributes: attrs, Blocks: blocks},
+			&hcl.BodyContent{Attributes: overrideAttrs, Blocks: overrideBlocks},
+		)
+		return merged.Attributes, merged.Blocks, append(diags, overrideDiags...)
+	default:
+		attrs, diags := body.JustAttributes()
+		return attrs, nil, diags
+	}
+}
+
+// blockEndLine retorna a última linha de um bloco (o fechamento do body).
+// Bodies sem posição de fim, como os de arquivos JSON, usam o cabeçalho.
+func blockEndLine(block *hcl.Block) int {
+	body := block.Body
+	if b, ok := body.(*overrideBody); ok {
+		body = b.base
+	}
+	if b, ok := body.(*hclsyntax.Body); ok {
+		return b.SrcRange.End.Line
+	}
+	return block.DefRange.End.Line
+}
+
+// applyOverrides aplica os arquivos de override sobre os arquivos primários de
+// um módulo, na ordem em que aparecem. Blocos de override sem um bloco
+// original correspondente são erros, como no Terraform.
+func (ta *TerraformAnalyzer) applyOverrides(files []*moduleFile, analysis *models.TerraformAnalysis) []*moduleFile {
+	primary := []*moduleFile{}
+	overrides := []*moduleFile{}
+	for _, f := range files {
+		if isOverrideFile(f.path) {
+			overrides = append(overrides, f)
+		} else {
+			primary = append(primary, f)
+		}
+	}
+
+	for _, of := range overrides {
+		for _, block := range of.content.Blocks {
+			if !ta.overrideBlockIn(primary, block) {
+				analysis.Valid = false
+				analysis.SyntaxErrors = append(analysis.SyntaxErrors, models.SyntaxError{
+					File:    of.path,
+					Line:    block.DefRange.Start.Line,
+					Column:  block.DefRange.Start.Column,
+					Message: fmt.Sprintf("Override de %s sem definição original correspondente", blockKey(block)),
+				})
+			}
+		}
+	}
+
+	return primary
+}
+
+// overrideBlockIn aplica um bloco de override ao bloco original
+// correspondente, retornando false se não houver original
+func (ta *TerraformAnalyzer) overrideBlockIn(primary []*moduleFile, override *hcl.Block) bool {
+	switch override.Type {
+	case "locals":
+		// Cada local é sobrescrito individualmente
+		overrideAttrs, _ := override.Body.JustAttributes()
+		found := make(map[string]bool)
+		for _, f := range primary {
+			for i, block := range f.content.Blocks {
+				if block.Type != "locals" {
+					continue
+				}
+				attrs, _ := block.Body.JustAttributes()
+				shared := false
+				for name := range overrideAttrs {
+					if _, ok := attrs[name]; ok {
+						found[name] = true
+						shared = true
+					}
+				}
+				if shared {
+					f.content.Blocks[i] = overrideBlock(block, override)
+				}
+			}
+		}
+		return len(found) == len(overrideAttrs)
+
+	case "terraform":
+		// Configurações do bloco terraform são combinadas com o primeiro original
+		for _, f := range primary {
+			for i, block := range f.content.Blocks {
+				if block.Type == "terraform" {
+					f.content.Blocks[i] = overrideBlock(block, override)
+					return true
+				}
+			}
+		}
+		if len(primary) > 0 {
+			primary[0].content.Blocks = append(primary[0].content.Blocks, override)
+		}
+		return true
+	}
+
+	key := blockKey(override)
+	for _, f := range primary {
+		for i, block := range f.content.Blocks {
+			if blockKey(block) == key {
+				f.content.Blocks[i] = overrideBlock(block, override)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// blockKey identifica um bloco de nível superior: tipo, labels e, para
+// providers, o alias
+func blockKey(block *hcl.Block) string {
+	key := strings.Join(append([]string{block.Type}, block.Labels...), ".")
+	if block.Type != "provider" {
+		return key
+	}
+
+	content, _, _ := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "alias"}},
+	})
+	if content != nil {
+		if attr, ok := content.Attributes["alias"]; ok {
+			if val, diags := attr.Expr.Value(nil); !diags.HasErrors() && val.IsKnown() && !val.IsNull() && val.Type() == cty.String {
+				key += "." + val.AsString()
+			}
+		}
+	}
+	return key
+}
@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// DefaultParseCacheSize é o número máximo de arquivos mantidos no cache de
+// parsing entre análises
+const DefaultParseCacheSize = 20000
+
+// skipDirs são diretórios ignorados ao percorrer um repositório: cópias de
+// módulos baixadas pelo terraform init, metadados do git e código vendorizado
+var skipDirs = map[string]bool{
+	".terraform":        true,
+	".terragrunt-cache": true,
+	".git":              true,
+	"vendor":            true,
+	"node_modules":      true,
+}
+
+// isSkippedDir verifica se um diretório deve ser ignorado na varredura
+func isSkippedDir(name string) bool {
+	return skipDirs[name]
+}
+
+// parsedFile é o resultado do parsing de um arquivo de configuração
+type parsedFile struct {
+	file  *hcl.File
+	diags hcl.Diagnostics
+	err   error // erro de leitura
+}
+
+// parseCacheKey identifica um arquivo pelo caminho e pelo hash do conteúdo.
+// O caminho faz parte da chave porque os ranges do hcl.File o referenciam.
+type parseCacheKey struct {
+	path string
+	hash [sha256.Size]byte
+}
+
+// parseCacheEntry é uma entrada da lista LRU do cache
+type parseCacheEntry struct {
+	key    parseCacheKey
+	parsed *parsedFile
+}
+
+// ParseCacheStats resume o uso do cache de parsing
+type ParseCacheStats struct {
+	Entries int
+	Hits    int
+	Misses  int
+}
+
+// parseCache guarda arquivos já parseados, indexados pelo hash do conteúdo,
+// para que análises repetidas do mesmo repositório só façam o parsing dos
+// arquivos alterados. Os hcl.File armazenados são apenas lidos pelas
+// análises, podendo ser compartilhados entre goroutines.
+type parseCache struct {
+	mu      sync.Mutex
+	maxSize int
+	entries map[parseCacheKey]*list.Element
+	order   *list.List // mais recentes na frente
+	hits    int
+	misses  int
+}
+
+// newParseCache cria um cache LRU com até maxSize arquivos
+func newParseCache(maxSize int) *parseCache {
+	return &parseCache{
+		maxSize: maxSize,
+		entries: make(map[parseCacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// get retorna o arquivo parseado para a chave, se presente
+func (c *parseCache) get(key parseCacheKey) (*parsedFile, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*parseCacheEntry).parsed, true
+}
+
+// put armazena um arquivo parseado, descartando o menos usado se necessário
+func (c *parseCache) put(key parseCacheKey, parsed *parsedFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&parseCacheEntry{key: key, parsed: parsed})
+	for c.maxSize > 0 && c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*parseCacheEntry).key)
+	}
+}
+
+// stats retorna as estatísticas do cache
+func (c *parseCache) stats() ParseCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return ParseCacheStats{Entries: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+// SetConcurrency define quantos arquivos são lidos e parseados em paralelo.
+// Valores menores que 1 usam o número de CPUs.
+func (ta *TerraformAnalyzer) SetConcurrency(workers int) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	ta.workers = workers
+}
+
+// ParseCacheStats retorna as estatísticas do cache de parsing
+func (ta *TerraformAnalyzer) ParseCacheStats() ParseCacheStats {
+	return ta.cache.stats()
+}
+
+// parseFiles lê e faz o parsing dos arquivos com um pool de até ta.workers
+// goroutines, reutilizando o cache para arquivos com conteúdo inalterado
+func (ta *TerraformAnalyzer) parseFiles(paths []string) map[string]*parsedFile {
+	results := make([]*parsedFile, len(paths))
+
+	workers := ta.workers
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = ta.readConfigFile(paths[i])
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	parsed := make(map[string]*parsedFile, len(paths))
+	for i, path := range paths {
+		parsed[path] = results[i]
+	}
+	return parsed
+}
+
+// readConfigFile lê um arquivo e faz seu parsing, consultando o cache pelo
+// hash do conteúdo
+func (ta *TerraformAnalyzer) readConfigFile(path string) *parsedFile {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return &parsedFile{err: err}
+	}
+
+	key := parseCacheKey{path: filepath.Clean(path), hash: sha256.Sum256(content)}
+	if parsed, ok := ta.cache.get(key); ok {
+		return parsed
+	}
+
+	file, diags := parseConfigFile(content, path)
+	parsed := &parsedFile{file: file, diags: diags}
+	ta.cache.put(key, parsed)
+	return parsed
+}
@@ -0,0 +1,485 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// policyDocumentDataType é o data source que gera documentos de política
+const policyDocumentDataType = "aws_iam_policy_document"
+
+// evaluatePolicyDocuments gera o JSON dos data sources aws_iam_policy_document
+// do módulo e os registra no escopo, de forma que referências como
+// data.aws_iam_policy_document.x.json sejam resolvidas. Documentos que
+// dependem de outros (source_policy_documents, override_policy_documents)
+// são avaliados depois deles; data sources com count/for_each ficam
+// desconhecidos.
+func (ta *TerraformAnalyzer) evaluatePolicyDocuments(mod *moduleInstance) int {
+	pending := make(map[string]*hcl.Block)
+	for _, f := range mod.files {
+		for _, block := range f.content.Blocks {
+			if block.Type != "data" || len(block.Labels) < 2 || block.Labels[0] != policyDocumentDataType {
+				continue
+			}
+			attrs, _, _ := bodyItems(block.Body)
+			if attrs["count"] != nil || attrs["for_each"] != nil {
+				continue
+			}
+			pending[block.Labels[1]] = block
+		}
+	}
+
+	evaluated := 0
+	for len(pending) > 0 {
+		names := make([]string, 0, len(pending))
+		for name := range pending {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		progress := false
+		for _, name := range names {
+			block := pending[name]
+			if !policyDocumentDepsResolved(block.Body, pending) {
+				continue
+			}
+
+			doc := cty.UnknownVal(cty.String)
+			if rendered, ok := renderPolicyDocument(block.Body, mod.scope); ok {
+				doc = cty.StringVal(rendered)
+			}
+			mod.scope.setDataSource(policyDocumentDataType, name, cty.ObjectVal(map[string]cty.Value{
+				"id":            cty.UnknownVal(cty.String),
+				"json":          doc,
+				"minified_json": doc,
+			}))
+			delete(pending, name)
+			progress = true
+			evaluated++
+		}
+
+		if !progress {
+			// Ciclo entre documentos: os restantes ficam desconhecidos
+			break
+		}
+	}
+
+	return evaluated
+}
+
+// policyDocumentDepsResolved verifica se os documentos referenciados pelo
+// bloco já foram avaliados
+func policyDocumentDepsResolved(body hcl.Body, pending map[string]*hcl.Block) bool {
+	implicit, _ := blockReferences(body)
+	for _, traversal := range implicit {
+		if traversal.RootName() != "data" || len(traversal) < 3 {
+			continue
+		}
+		dataType, okType := traversal[1].(hcl.TraverseAttr)
+		name, okName := traversal[2].(hcl.TraverseAttr)
+		if okType && okName && dataType.Name == policyDocumentDataType {
+			if _, isPending := pending[name.Name]; isPending {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// policyDocumentSchema, policyStatementSchema, policyPrincipalsSchema e
+// policyConditionSchema descrevem o aws_iam_policy_document. O schema é
+// necessário para ler blocos de bodies JSON.
+var (
+	policyDocumentSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "version"},
+			{Name: "policy_id"},
+			{Name: "source_policy_documents"},
+			{Name: "override_policy_documents"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "statement"},
+			{Type: "dynamic", LabelNames: []string{"type"}},
+		},
+	}
+	policyStatementSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "sid"},
+			{Name: "effect"},
+			{Name: "actions"},
+			{Name: "not_actions"},
+			{Name: "resources"},
+			{Name: "not_resources"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "principals"},
+			{Type: "not_principals"},
+			{Type: "condition"},
+			{Type: "dynamic", LabelNames: []string{"type"}},
+		},
+	}
+	policyPrincipalsSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "type"}, {Name: "identifiers"}},
+	}
+	policyConditionSchema = &hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "test"}, {Name: "variable"}, {Name: "values"}},
+	}
+)
+
+// policyBlock é um bloco aninhado do documento, literal ou gerado por um
+// bloco dynamic, com o escopo em que deve ser avaliado
+type policyBlock struct {
+	blockType string
+	body      hcl.Body
+	scope     *evalScope
+}
+
+// expandPolicyBlocks lista os blocos na ordem em que aparecem, expandindo os
+// blocos dynamic. Retorna false se algum dynamic não puder ser expandido.
+func expandPolicyBlocks(blocks hcl.Blocks, scope *evalScope) ([]policyBlock, bool) {
+	expanded := []policyBlock{}
+	for _, block := range blocks {
+		if block.Type != "dynamic" {
+			expanded = append(expanded, policyBlock{blockType: block.Type, body: block.Body, scope: scope})
+			continue
+		}
+		instances, known := expandDynamic(block, scope)
+		if !known {
+			return nil, false
+		}
+		for _, instance := range instances {
+			expanded = append(expanded, policyBlock{blockType: block.Labels[0], body: instance.body, scope: instance.scope})
+		}
+	}
+	return expanded, true
+}
+
+// renderPolicyDocument converte o body de um aws_iam_policy_document no
+// JSON que o provider AWS gera. Valores só conhecidos após o apply (ex.:
+// ARNs de recursos) aparecem como ${referência}. Retorna false quando algum
+// statement não pode ser decodificado (ex.: dynamic com for_each
+// desconhecido): o documento é desconhecido, não vazio.
+func renderPolicyDocument(body hcl.Body, scope *evalScope) (string, bool) {
+	content, _, diags := body.PartialContent(policyDocumentSchema)
+	if diags.HasErrors() {
+		return "", false
+	}
+	attrs := content.Attributes
+
+	version := "2012-10-17"
+	if values := policyValues(attrs["version"], scope); len(values) == 1 {
+		version = values[0]
+	}
+	doc := map[string]interface{}{"Version": version}
+	if values := policyValues(attrs["policy_id"], scope); len(values) == 1 {
+		doc["Id"] = values[0]
+	}
+
+	// Statements dos documentos de origem são sobrescritos pelos do
+	// documento com o mesmo Sid, que por sua vez são sobrescritos pelos
+	// documentos de override
+	statements := []map[string]interface{}{}
+	for _, source := range policyValues(attrs["source_policy_documents"], scope) {
+		statements = mergePolicyStatements(statements, rawPolicyStatements(source))
+	}
+
+	blocks, ok := expandPolicyBlocks(content.Blocks, scope)
+	if !ok {
+		return "", false
+	}
+	own := []map[string]interface{}{}
+	for _, block := range blocks {
+		if block.blockType != "statement" {
+			continue
+		}
+		statement, ok := renderPolicyStatement(block.body, block.scope)
+		if !ok {
+			return "", false
+		}
+		own = append(own, statement)
+	}
+	statements = mergePolicyStatements(statements, own)
+
+	for _, override := range policyValues(attrs["override_policy_documents"], scope) {
+		statements = mergePolicyStatements(statements, rawPolicyStatements(override))
+	}
+
+	doc["Statement"] = statements
+	data, _ := json.Marshal(doc)
+	return string(data), true
+}
+
+// renderPolicyStatement converte um bloco statement
+func renderPolicyStatement(body hcl.Body, scope *evalScope) (map[string]interface{}, bool) {
+	content, _, diags := body.PartialContent(policyStatementSchema)
+	if diags.HasErrors() {
+		return nil, false
+	}
+	attrs := content.Attributes
+
+	statement := map[string]interface{}{"Effect": "Allow"}
+	if values := policyValues(attrs["sid"], scope); len(values) == 1 && values[0] != "" {
+		statement["Sid"] = values[0]
+	}
+	if values := policyValues(attrs["effect"], scope); len(values) == 1 {
+		statement["Effect"] = values[0]
+	}
+
+	for attr, key := range map[string]string{
+		"actions":       "Action",
+		"not_actions":   "NotAction",
+		"resources":     "Resource",
+		"not_resources": "NotResource",
+	} {
+		if values := policyValues(attrs[attr], scope); len(values) > 0 {
+			statement[key] = singleOrList(values)
+		}
+	}
+
+	blocks, ok := expandPolicyBlocks(content.Blocks, scope)
+	if !ok {
+		return nil, false
+	}
+	for _, block := range blocks {
+		switch block.blockType {
+		case "principals", "not_principals":
+			nested, _, diags := block.body.PartialContent(policyPrincipalsSchema)
+			if diags.HasErrors() {
+				return nil, false
+			}
+			key := "Principal"
+			if block.blockType == "not_principals" {
+				key = "NotPrincipal"
+			}
+			principalType := policyValues(nested.Attributes["type"], block.scope)
+			identifiers := policyValues(nested.Attributes["identifiers"], block.scope)
+			if len(principalType) != 1 {
+				continue
+			}
+			if principalType[0] == "*" {
+				statement[key] = "*"
+				continue
+			}
+			principals, _ := statement[key].(map[string]interface{})
+			if principals == nil {
+				principals = map[string]interface{}{}
+			}
+			principals[principalType[0]] = mergeIdentifiers(principals[principalType[0]], identifiers)
+			statement[key] = principals
+		case "condition":
+			nested, _, diags := block.body.PartialContent(policyConditionSchema)
+			if diags.HasErrors() {
+				return nil, false
+			}
+			test := policyValues(nested.Attributes["test"], block.scope)
+			variable := policyValues(nested.Attributes["variable"], block.scope)
+			if len(test) != 1 || len(variable) != 1 {
+				continue
+			}
+			conditions, _ := statement["Condition"].(map[string]interface{})
+			if conditions == nil {
+				conditions = map[string]interface{}{}
+			}
+			operator, _ := conditions[test[0]].(map[string]interface{})
+			if operator == nil {
+				operator = map[string]interface{}{}
+			}
+			operator[variable[0]] = singleOrList(policyValues(nested.Attributes["values"], block.scope))
+			conditions[test[0]] = operator
+			statement["Condition"] = conditions
+		}
+	}
+
+	return statement, true
+}
+
+// policyValues avalia um atributo como lista de strings. Elementos
+// desconhecidos viram ${referência} para que o statement continue restrito
+// ao recurso referenciado em vez de ser descartado.
+func policyValues(attr *hcl.Attribute, scope *evalScope) []string {
+	if attr == nil {
+		return nil
+	}
+
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		exprs = []hcl.Expression{attr.Expr}
+	}
+
+	values := []string{}
+	for _, expr := range exprs {
+		val, diags := expr.Value(scope.exprContext(expr))
+		if diags.HasErrors() || !val.IsWhollyKnown() {
+			values = append(values, unknownPolicyValue(expr, scope))
+			continue
+		}
+		goVal, ok := convertValue(val, "", nil)
+		if !ok {
+			continue
+		}
+		switch v := goVal.(type) {
+		case []interface{}:
+			for _, item := range v {
+				values = append(values, policyValueString(item))
+			}
+		default:
+			values = append(values, policyValueString(v))
+		}
+	}
+	return values
+}
+
+// unknownPolicyValue representa um valor desconhecido pela referência. Em
+// templates, as partes conhecidas são mantidas (ex.: ${aws_s3_bucket.x.arn}/*).
+func unknownPolicyValue(expr hcl.Expression, scope *evalScope) string {
+	switch e := expr.(type) {
+	case *hclsyntax.TemplateWrapExpr:
+		return unknownPolicyValue(e.Wrapped, scope)
+	case *hclsyntax.TemplateExpr:
+		var sb strings.Builder
+		for _, part := range e.Parts {
+			val, diags := part.Value(scope.exprContext(part))
+			if !diags.HasErrors() && val.IsWhollyKnown() && !val.IsNull() && val.Type() == cty.String {
+				if val.IsMarked() {
+					sb.WriteString(redactedValue)
+					continue
+				}
+				sb.WriteString(val.AsString())
+				continue
+			}
+			sb.WriteString(unknownPolicyValue(part, scope))
+		}
+		return sb.String()
+	}
+
+	if refs := traversalStrings(expr); len(refs) == 1 {
+		return "${" + refs[0] + "}"
+	}
+	return "${unknown}"
+}
+
+// policyValueString converte um valor de política em string
+func policyValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		return tagValueString(v)
+	}
+}
+
+// singleOrList retorna o valor único como string, como o provider faz
+func singleOrList(values []string) interface{} {
+	if len(values) == 1 {
+		return values[0]
+	}
+	list := make([]interface{}, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return list
+}
+
+// mergeIdentifiers acrescenta identificadores a um principal existente
+func mergeIdentifiers(existing interface{}, identifiers []string) interface{} {
+	merged := stringList(existing)
+	for _, id := range identifiers {
+		if !containsString(merged, id) {
+			merged = append(merged, id)
+		}
+	}
+	return singleOrList(merged)
+}
+
+// rawPolicyStatements extrai os statements de um documento JSON
+func rawPolicyStatements(doc string) []map[string]interface{} {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(doc), &raw); err != nil {
+		return nil
+	}
+
+	statements := []map[string]interface{}{}
+	switch s := raw["Statement"].(type) {
+	case map[string]interface{}:
+		statements = append(statements, s)
+	case []interface{}:
+		for _, item := range s {
+			if stmt, ok := item.(map[string]interface{}); ok {
+				statements = append(statements, stmt)
+			}
+		}
+	}
+	return statements
+}
+
+// mergePolicyStatements acrescenta statements, substituindo os que têm o
+// mesmo Sid
+func mergePolicyStatements(base, statements []map[string]interface{}) []map[string]interface{} {
+	for _, stmt := range statements {
+		sid, _ := stmt["Sid"].(string)
+		replaced := false
+		if sid != "" {
+			for i, existing := range base {
+				if existingSid, _ := existing["Sid"].(string); existingSid == sid {
+					base[i] = stmt
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			base = append(base, stmt)
+		}
+	}
+	return base
+}
+
+// parseDataSource extrai um bloco data com seus atributos avaliados. Para
+// aws_iam_policy_document o atributo json recebe o documento gerado.
+func (ta *TerraformAnalyzer) parseDataSource(block *hcl.Block, filename string, mod *moduleInstance, analysis *models.TerraformAnalysis) {
+	analysis.TotalDataSources++
+	if len(block.Labels) < 2 {
+		return
+	}
+
+	instances, _, diags := expandInstances(block.Body, mod.scope)
+	ta.appendDiagnostics(analysis, filename, diags)
+	for _, instance := range instances {
+		dataSource := models.TerraformDataSource{
+			Address:   mod.qualify("data." + block.Labels[0] + "." + block.Labels[1] + instance.suffix()),
+			Module:    mod.address,
+			Type:      block.Labels[0],
+			Name:      block.Labels[1],
+			Index:     instance.index(),
+			File:      filename,
+			LineStart: block.DefRange.Start.Line,
+			LineEnd:   blockEndLine(block),
+		}
+
+		decoder := newBodyDecoder(instance.scope)
+		dataSource.Attributes = decoder.decode(block.Body, "")
+		dataSource.UnknownAttributes = decoder.unknownPaths()
+		ta.appendDiagnostics(analysis, filename, decoder.diags)
+
+		if val, ok := mod.scope.data[block.Labels[0]][block.Labels[1]]; ok && block.Labels[0] == policyDocumentDataType {
+			if doc := val.GetAttr("json"); doc.IsKnown() {
+				dataSource.Attributes["json"] = doc.AsString()
+			} else {
+				dataSource.UnknownAttributes = append(dataSource.UnknownAttributes, "json")
+			}
+		}
+
+		analysis.DataSources = append(analysis.DataSources, dataSource)
+	}
+}
@@ -0,0 +1,376 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// lockFileName é o nome do lock file de dependências do Terraform/OpenTofu
+const lockFileName = ".terraform.lock.hcl"
+
+// Registries padrão usados para normalizar endereços de providers
+const (
+	terraformRegistry = "registry.terraform.io"
+	opentofuRegistry  = "registry.opentofu.org"
+)
+
+// settings retorna as configurações da análise, criando-as se necessário
+func settings(analysis *models.TerraformAnalysis) *models.TerraformSettings {
+	if analysis.Settings == nil {
+		analysis.Settings = &models.TerraformSettings{Engine: "terraform"}
+	}
+	return analysis.Settings
+}
+
+// parseTerraformBlock extrai required_version, required_providers e o
+// backend (apenas no módulo raiz) de um bloco terraform {}
+func (ta *TerraformAnalyzer) parseTerraformBlock(block *hcl.Block, filename string, mod *moduleInstance, analysis *models.TerraformAnalysis) {
+	s := settings(analysis)
+
+	content, _, diags := block.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{
+			{Name: "required_version"},
+		},
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "required_providers"},
+			{Type: "backend", LabelNames: []string{"type"}},
+			{Type: "cloud"},
+		},
+	})
+	ta.appendDiagnostics(analysis, filename, diags)
+
+	if attr, ok := content.Attributes["required_version"]; ok {
+		if constraint, ok := literalString(attr.Expr); ok {
+			s.RequiredVersion = joinConstraints(s.RequiredVersion, constraint)
+		}
+	}
+
+	for _, nested := range content.Blocks {
+		switch nested.Type {
+		case "required_providers":
+			attrs, attrDiags := nested.Body.JustAttributes()
+			ta.appendDiagnostics(analysis, filename, attrDiags)
+			names := make([]string, 0, len(attrs))
+			for name := range attrs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				s.RequiredProviders = append(s.RequiredProviders, providerRequirement(name, attrs[name], filename, mod.address))
+			}
+		case "backend", "cloud":
+			if mod.address != "" {
+				// O Terraform ignora backends declarados em módulos filhos
+				continue
+			}
+			backendType := "cloud"
+			if nested.Type == "backend" {
+				backendType = nested.Labels[0]
+			}
+			decoder := newBodyDecoder(mod.scope)
+			s.Backend = &models.BackendConfig{
+				Type:   backendType,
+				Config: decoder.decode(nested.Body, ""),
+				File:   filename,
+				Line:   nested.DefRange.Start.Line,
+			}
+		}
+	}
+}
+
+// providerRequirement interpreta uma entrada de required_providers, tanto na
+// forma de objeto { source, version } quanto na forma legada "~> 5.0"
+func providerRequirement(name string, attr *hcl.Attribute, filename, module string) models.ProviderRequirement {
+	req := models.ProviderRequirement{
+		Name:   name,
+		Source: normalizeProviderSource("hashicorp/" + name),
+		Module: module,
+		File:   filename,
+	}
+
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+		return req
+	}
+
+	if val.Type() == cty.String {
+		req.Version = val.AsString()
+		return req
+	}
+
+	if val.Type().IsObjectType() || val.Type().IsMapType() {
+		obj := val.AsValueMap()
+		if source, ok := obj["source"]; ok && source.Type() == cty.String {
+			req.Source = normalizeProviderSource(source.AsString())
+		}
+		if v, ok := obj["version"]; ok && v.Type() == cty.String {
+			req.Version = v.AsString()
+		}
+	}
+	return req
+}
+
+// normalizeProviderSource completa o endereço de um provider com o registry
+// padrão, ex.: hashicorp/aws -> registry.terraform.io/hashicorp/aws
+func normalizeProviderSource(source string) string {
+	source = strings.ToLower(source)
+	if strings.Count(source, "/") == 1 {
+		return terraformRegistry + "/" + source
+	}
+	return source
+}
+
+// providerSourceKey remove o registry do endereço para comparar endereços
+// do Terraform e do OpenTofu, ex.: registry.opentofu.org/hashicorp/aws -> hashicorp/aws
+func providerSourceKey(source string) string {
+	parts := strings.Split(normalizeProviderSource(source), "/")
+	if len(parts) == 3 && (parts[0] == terraformRegistry || parts[0] == opentofuRegistry) {
+		return parts[1] + "/" + parts[2]
+	}
+	return strings.Join(parts, "/")
+}
+
+// joinConstraints combina restrições de versão de vários módulos
+func joinConstraints(existing, constraint string) string {
+	if existing == "" {
+		return constraint
+	}
+	for _, c := range strings.Split(existing, ", ") {
+		if c == constraint {
+			return existing
+		}
+	}
+	return existing + ", " + constraint
+}
+
+// literalString avalia uma expressão sem contexto e retorna seu valor string
+func literalString(expr hcl.Expression) (string, bool) {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() || val.Type() != cty.String || !val.IsKnown() || val.IsNull() {
+		return "", false
+	}
+	return val.AsString(), true
+}
+
+// loadLockFile faz o parsing do .terraform.lock.hcl de um módulo raiz
+func (ta *TerraformAnalyzer) loadLockFile(parser *hclparse.Parser, dir string, analysis *models.TerraformAnalysis) {
+	path := filepath.Join(dir, lockFileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	file, diags := parser.ParseHCL(content, path)
+	ta.appendDiagnostics(analysis, path, diags)
+	if diags.HasErrors() {
+		return
+	}
+
+	body, contentDiags := file.Body.Content(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: "provider", LabelNames: []string{"source"}},
+		},
+	})
+	ta.appendDiagnostics(analysis, path, contentDiags)
+
+	s := settings(analysis)
+	if s.LockFile == "" {
+		s.LockFile = path
+	}
+
+	for _, block := range body.Blocks {
+		attrs, _ := block.Body.JustAttributes()
+		locked := models.LockedProvider{Source: strings.ToLower(block.Labels[0])}
+		if attr, ok := attrs["version"]; ok {
+			locked.Version, _ = literalString(attr.Expr)
+		}
+		if attr, ok := attrs["constraints"]; ok {
+			locked.Constraints, _ = literalString(attr.Expr)
+		}
+
+		if strings.HasPrefix(locked.Source, opentofuRegistry+"/") {
+			s.Engine = "opentofu"
+		}
+		s.LockedProviders = append(s.LockedProviders, locked)
+	}
+}
+
+// checkSettings gera findings sobre versões, providers, lock file e backend.
+// lockExpected indica se a ausência do lock file deve ser reportada (análise
+// de diretório).
+func (ta *TerraformAnalyzer) checkSettings(analysis *models.TerraformAnalysis, lockExpected bool) {
+	if analysis.Settings == nil && (!lockExpected || len(analysis.Resources) == 0) {
+		return
+	}
+	s := settings(analysis)
+	s.Findings = []models.SecurityFinding{}
+
+	addFinding := func(checkID, severity, resource, file, description, guideline string) {
+		s.Findings = append(s.Findings, models.SecurityFinding{
+			ID:          fmt.Sprintf("%s-%d", checkID, len(s.Findings)+1),
+			CheckID:     checkID,
+			CheckName:   checkNames[checkID],
+			Severity:    severity,
+			Resource:    resource,
+			File:        file,
+			Description: description,
+			Guideline:   guideline,
+		})
+	}
+
+	if s.RequiredVersion == "" {
+		addFinding("TF_VERSION_REQUIRED", "LOW", "terraform", "",
+			"Nenhum módulo define required_version",
+			"Defina required_version no bloco terraform {} para garantir uma versão suportada")
+	}
+
+	// Providers usados precisam ter versão fixada em required_providers
+	pinned := make(map[string]bool)
+	for _, req := range s.RequiredProviders {
+		if req.Version != "" {
+			pinned[req.Name] = true
+		}
+	}
+	for _, name := range usedProviders(analysis) {
+		if !pinned[name] {
+			addFinding("TF_PROVIDER_UNPINNED", "MEDIUM", "provider."+name, "",
+				fmt.Sprintf("Provider %s não possui versão fixada em required_providers", name),
+				fmt.Sprintf(`Adicione %s = { source = "...", version = "~> X.Y" } em required_providers`, name))
+		}
+	}
+
+	ta.checkLockFile(s, lockExpected, addFinding)
+	ta.checkBackend(analysis, addFinding)
+}
+
+// checkNames descreve os checks de configuração do Terraform
+var checkNames = map[string]string{
+	"TF_VERSION_REQUIRED":         "required_version não definido",
+	"TF_PROVIDER_UNPINNED":        "Provider sem versão fixada",
+	"TF_LOCK_MISSING":             "Lock file ausente",
+	"TF_LOCK_ENTRY_MISSING":       "Provider ausente do lock file",
+	"TF_LOCK_CONSTRAINT_MISMATCH": "Versão do lock file fora da restrição",
+	"TF_BACKEND_LOCAL":            "State armazenado localmente",
+	"TF_BACKEND_UNENCRYPTED":      "Backend sem criptografia",
+}
+
+// checkLockFile verifica se os providers exigidos estão no lock file com
+// versões que satisfazem as restrições
+func (ta *TerraformAnalyzer) checkLockFile(
+	s *models.TerraformSettings,
+	lockExpected bool,
+	addFinding func(checkID, severity, resource, file, description, guideline string),
+) {
+	if s.LockFile == "" {
+		if lockExpected && len(s.RequiredProviders) > 0 {
+			addFinding("TF_LOCK_MISSING", "LOW", "terraform", "",
+				fmt.Sprintf("%s não encontrado: as versões dos providers não estão travadas", lockFileName),
+				fmt.Sprintf("Execute terraform init e versione o arquivo %s", lockFileName))
+		}
+		return
+	}
+
+	locked := make(map[string]models.LockedProvider)
+	for _, l := range s.LockedProviders {
+		locked[providerSourceKey(l.Source)] = l
+	}
+
+	for _, req := range s.RequiredProviders {
+		l, ok := locked[providerSourceKey(req.Source)]
+		if !ok {
+			addFinding("TF_LOCK_ENTRY_MISSING", "MEDIUM", "provider."+req.Name, s.LockFile,
+				fmt.Sprintf("Provider %s (%s) não está registrado em %s", req.Name, req.Source, lockFileName),
+				"Execute terraform init -upgrade para atualizar o lock file")
+			continue
+		}
+
+		if req.Version == "" || l.Version == "" {
+			continue
+		}
+		constraints, err := version.NewConstraint(req.Version)
+		if err != nil {
+			continue
+		}
+		v, err := version.NewVersion(l.Version)
+		if err != nil {
+			continue
+		}
+		if !constraints.Check(v) {
+			addFinding("TF_LOCK_CONSTRAINT_MISMATCH", "MEDIUM", "provider."+req.Name, s.LockFile,
+				fmt.Sprintf("Versão %s do provider %s no lock file não satisfaz %q", l.Version, req.Name, req.Version),
+				"Execute terraform init -upgrade para atualizar o lock file")
+		}
+	}
+}
+
+// checkBackend verifica se o state é remoto e criptografado
+func (ta *TerraformAnalyzer) checkBackend(
+	analysis *models.TerraformAnalysis,
+	addFinding func(checkID, severity, resource, file, description, guideline string),
+) {
+	backend := analysis.Settings.Backend
+	if backend == nil || backend.Type == "local" {
+		if len(analysis.Resources) == 0 {
+			return
+		}
+		file := ""
+		if backend != nil {
+			file = backend.File
+		}
+		addFinding("TF_BACKEND_LOCAL", "MEDIUM", "backend.local", file,
+			"O state é armazenado localmente, sem locking nem backup",
+			"Configure um backend remoto (s3, gcs, azurerm, cloud) com locking e criptografia")
+		return
+	}
+
+	unencrypted := ""
+	switch backend.Type {
+	case "s3":
+		encrypt, _ := backend.Config["encrypt"].(bool)
+		_, hasKMS := backend.Config["kms_key_id"]
+		if !encrypt && !hasKMS {
+			unencrypted = "Backend s3 sem encrypt = true: o state é gravado sem criptografia no bucket"
+		}
+	case "http":
+		if address, ok := backend.Config["address"].(string); ok && strings.HasPrefix(address, "http://") {
+			unencrypted = "Backend http usa um endereço sem TLS: o state trafega sem criptografia"
+		}
+	}
+
+	if unencrypted != "" {
+		addFinding("TF_BACKEND_UNENCRYPTED", "HIGH", "backend."+backend.Type, backend.File,
+			unencrypted,
+			"Habilite a criptografia do state (encrypt = true / kms_key_id ou endereço https)")
+	}
+}
+
+// usedProviders retorna os providers declarados em blocos provider ou usados
+// por recursos, ordenados
+func usedProviders(analysis *models.TerraformAnalysis) []string {
+	seen := make(map[string]bool)
+	for _, p := range analysis.Providers {
+		seen[p] = true
+	}
+	for _, r := range analysis.Resources {
+		// terraform_data e similares pertencem ao provider embutido
+		if r.Provider != "" && r.Provider != "terraform" {
+			seen[r.Provider] = true
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
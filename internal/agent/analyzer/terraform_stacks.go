@@ -0,0 +1,231 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+// AnalyzeStacks descobre os módulos raiz de um diretório e retorna uma
+// análise para cada um. Um diretório é módulo raiz quando declara backend,
+// bloco cloud ou provider, ou quando não é usado como source de um módulo
+// local. Diretórios como .terraform, .git e vendor são ignorados e os
+// arquivos são parseados em paralelo, com cache por conteúdo entre chamadas.
+// Em cada stack os arquivos de override (override.tf, *_override.tf) são
+// aplicados sobre os demais e variáveis, locals, terraform.tfvars,
+// *.auto.tfvars e os varFiles informados (equivalentes a -var-file) são
+// usados para resolver os atributos.
+func (ta *TerraformAnalyzer) AnalyzeStacks(dir string, varFiles ...string) ([]*models.TerraformAnalysis, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao resolver diretório: %w", err)
+	}
+
+	// Agrupa arquivos .tf/.tf.json por diretório (cada diretório é um módulo)
+	moduleDirs := []string{}
+	filesByDir := make(map[string][]string)
+	paths := []string{}
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != dir && isSkippedDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isConfigFile(path) {
+			return nil
+		}
+
+		moduleDir, _ := filepath.Abs(filepath.Dir(path))
+		if _, seen := filesByDir[moduleDir]; !seen {
+			moduleDirs = append(moduleDirs, moduleDir)
+		}
+		filesByDir[moduleDir] = append(filesByDir[moduleDir], path)
+		paths = append(paths, path)
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("erro ao percorrer diretório: %w", err)
+	}
+
+	parsed := ta.parseFiles(paths)
+
+	// Descoberta dos módulos raiz. Erros encontrados aqui são reportados
+	// novamente na análise de cada stack.
+	discovery := newModuleLoader(hclparse.NewParser(), root, filesByDir, parsed)
+	called := discovery.calledDirs(ta, moduleDirs, newTerraformAnalysis())
+
+	stacks := []*models.TerraformAnalysis{}
+	reached := make(map[string]bool)
+	analyzeRoot := func(moduleDir string) {
+		analysis := newTerraformAnalysis()
+		analysis.Root = relativePath(root, moduleDir)
+
+		loader := newModuleLoader(hclparse.NewParser(), root, filesByDir, parsed)
+		files, _ := loader.load(ta, moduleDir, analysis)
+
+		// O diretório do stack é o diretório de trabalho do Terraform:
+		// path.root e path.module valem "." no módulo raiz e caminhos
+		// relativos de file() partem dele. As leituras continuam limitadas
+		// ao diretório analisado.
+		functions := newTerraformFunctions(root, moduleDir)
+		mod := newModuleInstance("", moduleDir, newEvalScope(moduleDir, moduleDir, functions), files)
+		ta.analyzeModule(loader, mod, append(tfvarsFiles(moduleDir), varFiles...), analysis)
+		ta.loadLockFile(loader.parser, moduleDir, analysis)
+		ta.finalizeAnalysis(analysis, true)
+
+		for d := range loader.visited {
+			reached[d] = true
+		}
+		stacks = append(stacks, analysis)
+	}
+
+	for _, moduleDir := range moduleDirs {
+		if !called[moduleDir] || discovery.declaresRoot(ta, moduleDir) {
+			analyzeRoot(moduleDir)
+		}
+	}
+
+	// Módulos nunca alcançados a partir de um módulo raiz (ex.: chamadas
+	// circulares) são analisados como stacks próprios
+	for _, moduleDir := range moduleDirs {
+		if !reached[moduleDir] {
+			analyzeRoot(moduleDir)
+		}
+	}
+
+	return stacks, nil
+}
+
+// declaresRoot verifica se o diretório declara backend, bloco cloud ou
+// provider, o que indica um módulo raiz mesmo quando ele também é chamado
+// como módulo por outro diretório
+func (l *moduleLoader) declaresRoot(ta *TerraformAnalyzer, dir string) bool {
+	files, _ := l.load(ta, dir, newTerraformAnalysis())
+	for _, f := range files {
+		for _, block := range f.content.Blocks {
+			switch block.Type {
+			case "provider":
+				return true
+			case "terraform":
+				content, _, _ := block.Body.PartialContent(&hcl.BodySchema{
+					Blocks: []hcl.BlockHeaderSchema{
+						{Type: "backend", LabelNames: []string{"type"}},
+						{Type: "cloud"},
+					},
+				})
+				if content != nil && len(content.Blocks) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// relativePath retorna dir relativo a root com separadores /
+func relativePath(root, dir string) string {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return dir
+	}
+	return filepath.ToSlash(rel)
+}
+
+// StackAddress qualifica um endereço com o stack de origem, ex.:
+// envs/prod:aws_iam_role.app. Sem stack o endereço não muda.
+func StackAddress(stack, address string) string {
+	if stack == "" {
+		return address
+	}
+	return stack + ":" + address
+}
+
+// splitStackAddress separa o stack de um endereço qualificado por
+// StackAddress. Dois-pontos dentro de índices (ex.: ["a:b"]) não contam.
+func splitStackAddress(address string) (string, string) {
+	sep := strings.Index(address, ":")
+	if sep <= 0 {
+		return "", address
+	}
+	if index := strings.IndexAny(address, `["`); index >= 0 && index < sep {
+		return "", address
+	}
+	return address[:sep], address[sep+1:]
+}
+
+// fileInStack verifica se um arquivo (relativo ao diretório analisado, como
+// o Checkov reporta, ou absoluto) pertence ao diretório do stack
+func fileInStack(file, stack string) bool {
+	if stack == "." {
+		return true
+	}
+	file = "/" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(file)), "/")
+	return strings.Contains(file, "/"+stack+"/")
+}
+
+// mergeStacks agrega as análises de vários stacks: recursos, módulos,
+// variáveis e outputs são concatenados, os totais somados e os avisos
+// prefixados com o stack de origem. Recursos e data sources guardam o stack
+// de origem em Stack. O grafo de dependências e as configurações do bloco
+// terraform ficam apenas em cada stack.
+func mergeStacks(stacks []*models.TerraformAnalysis) *models.TerraformAnalysis {
+	merged := newTerraformAnalysis()
+	merged.Stacks = []models.TerraformAnalysis{}
+
+	seenProviders := make(map[string]bool)
+	seenErrors := make(map[models.SyntaxError]bool)
+	for _, stack := range stacks {
+		merged.Valid = merged.Valid && stack.Valid
+		for _, resource := range stack.Resources {
+			resource.Stack = stack.Root
+			merged.Resources = append(merged.Resources, resource)
+		}
+		for _, dataSource := range stack.DataSources {
+			dataSource.Stack = stack.Root
+			merged.DataSources = append(merged.DataSources, dataSource)
+		}
+		merged.Modules = append(merged.Modules, stack.Modules...)
+		merged.Variables = append(merged.Variables, stack.Variables...)
+		merged.Outputs = append(merged.Outputs, stack.Outputs...)
+		merged.TotalDataSources += stack.TotalDataSources
+
+		for _, p := range stack.Providers {
+			if !seenProviders[p] {
+				seenProviders[p] = true
+				merged.Providers = append(merged.Providers, p)
+			}
+		}
+
+		// Módulos compartilhados reportam o mesmo erro em cada stack
+		for _, syntaxErr := range stack.SyntaxErrors {
+			if !seenErrors[syntaxErr] {
+				seenErrors[syntaxErr] = true
+				merged.SyntaxErrors = append(merged.SyntaxErrors, syntaxErr)
+			}
+		}
+
+		for _, warning := range stack.BestPracticeWarnings {
+			merged.BestPracticeWarnings = append(merged.BestPracticeWarnings,
+				fmt.Sprintf("[%s] %s", stack.Root, warning))
+		}
+
+		merged.Stacks = append(merged.Stacks, *stack)
+	}
+
+	merged.TotalResources = len(merged.Resources)
+	merged.TotalModules = len(merged.Modules)
+	merged.TotalVariables = len(merged.Variables)
+	merged.TotalOutputs = len(merged.Outputs)
+	return merged
+}
@@ -28,7 +28,7 @@ func (sa *SecurityAdvisor) GenerateSuggestions(
 
 	// Sugestões baseadas em security findings
 	for _, finding := range securityAnalysis.Findings {
-		suggestions = append(suggestions, models.Suggestion{
+		suggestion := models.Suggestion{
 			Type:           "security",
 			Severity:       mapSeverity(finding.Severity),
 			Message:        finding.CheckName,
@@ -36,7 +36,18 @@ func (sa *SecurityAdvisor) GenerateSuggestions(
 			File:           finding.File,
 			Line:           finding.Line,
 			ReferenceLink:  fmt.Sprintf("https://docs.bridgecrew.io/docs/%s", finding.CheckID),
-		})
+		}
+
+		// Correção automática sugerida pelo scanner (ex.: fixed_code do Checkov)
+		if finding.FixedCode != "" {
+			suggestion.AutoFixAvailable = true
+			suggestion.Resource = finding.Resource
+			suggestion.Metadata = map[string]interface{}{
+				"fixed_code": finding.FixedCode,
+				"check_id":   finding.CheckID,
+			}
+		}
+		suggestions = append(suggestions, suggestion)
 	}
 
 	// Sugestões baseadas em IAM analysis
@@ -64,6 +75,7 @@ func (sa *SecurityAdvisor) GenerateSuggestions(
 			Severity:       risk.RiskLevel,
 			Message:        fmt.Sprintf("Risco de principal: %s", risk.Principal),
 			Recommendation: risk.Reason,
+			Resource:       risk.Resource,
 		})
 	}
 
@@ -7,6 +7,9 @@ import (
 	"regexp"
 	"strings"
 	"time"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
 )
 
 // WhatsAppAgent representa um agente WhatsApp
@@ -18,6 +21,7 @@ type WhatsAppAgent struct {
 	APIKey         string
 	VerifyToken    string
 	Service        *MockAgentService
+	PlanService    PlanService
 	LLMService     *MockLLMService
 	AuthService    *MockAuthService
 	BillingService *MockBillingService
@@ -141,6 +145,17 @@ type CostAnalysis struct {
 	Optimizations        []Optimization `json:"optimizations"`
 }
 
+// PlanAnalysis resultado da análise de terraform plan
+type PlanAnalysis struct {
+	RiskLevel string   `json:"risk_level"`
+	Create    int      `json:"create"`
+	Update    int      `json:"update"`
+	Replace   int      `json:"replace"`
+	Destroy   int      `json:"destroy"`
+	Blocked   bool     `json:"blocked"` // bloqueado pela política de plan
+	Warnings  []string `json:"warnings"`
+}
+
 // Optimization otimização sugerida
 type Optimization struct {
 	Description    string  `json:"description"`
@@ -161,29 +176,40 @@ func NewWhatsAppAgent(config *WhatsAppAgentConfig) (*WhatsAppAgent, error) {
 		return nil, fmt.Errorf("failed to recover API key: %w", err)
 	}
 
+	// Política de plan avaliada pelo comando /plan
+	previewAnalyzer := analyzer.NewPreviewAnalyzer(logger.New("info", "json"))
+	if config.PlanPolicyFile != "" {
+		policy, err := analyzer.LoadPlanPolicy(config.PlanPolicyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load plan policy: %w", err)
+		}
+		previewAnalyzer.SetPolicy(policy)
+	}
+
 	// Criar serviços
 	agentService := &MockAgentService{}
 	llmService := &MockLLMService{}
 	billingService := &MockBillingService{}
 
 	// Criar logger
-	logger := &WhatsAppLogger{
+	agentLogger := &WhatsAppLogger{
 		AgentID: generateAgentID(),
 	}
 
 	// Criar agente
 	agent := &WhatsAppAgent{
-		ID:             logger.AgentID,
+		ID:             agentLogger.AgentID,
 		Name:           config.Name,
 		Description:    config.Description,
 		WalletAddr:     config.WalletAddr,
 		APIKey:         apiKey,
 		VerifyToken:    config.VerifyToken,
 		Service:        agentService,
+		PlanService:    NewPlanService(previewAnalyzer),
 		LLMService:     llmService,
 		AuthService:    authService,
 		BillingService: billingService,
-		Logger:         logger,
+		Logger:         agentLogger,
 		Commands:       AvailableCommands(),
 	}
 
@@ -257,14 +283,15 @@ func (a *WhatsAppAgent) executeCommand(ctx context.Context, cmd *Command, msg *W
 	// Extrair argumentos e código
 	args, codeBlock := a.extractCommandArgs(msg.Text, cmd.Pattern)
 
-	context := &CommandContext{
+	cmdCtx := &CommandContext{
+		Context:   ctx,
 		Message:   msg,
 		Agent:     a,
 		Args:      args,
 		CodeBlock: codeBlock,
 	}
 
-	return cmd.Handler(a, context)
+	return cmd.Handler(a, cmdCtx)
 }
 
 // extractCommandArgs extrai argumentos e blocos de código do comando
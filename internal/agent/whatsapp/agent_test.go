@@ -5,9 +5,20 @@ import (
 	"testing"
 	"time"
 
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
 	"github.com/stretchr/testify/assert"
 )
 
+// MockPlanService mock para PlanService
+type MockPlanService struct {
+	AnalyzePlanFunc func(ctx context.Context, planJSON, workspace string) (*PlanAnalysis, error)
+}
+
+func (m *MockPlanService) AnalyzePlan(ctx context.Context, planJSON, workspace string) (*PlanAnalysis, error) {
+	return m.AnalyzePlanFunc(ctx, planJSON, workspace)
+}
+
 func TestWhatsAppAgent_ProcessMessage(t *testing.T) {
 	// Setup
 	config := &WhatsAppAgentConfig{
@@ -76,6 +87,23 @@ func TestWhatsAppAgent_ProcessMessage(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "plan command blocked by policy",
+			message: &WhatsAppMessage{
+				From: "test_user",
+				Text: "/plan prod\n```json\n{\"resource_changes\": []}\n```",
+			},
+			expected: "Plan bloqueado",
+			setupMocks: func() {
+				agent.PlanService = &MockPlanService{
+					AnalyzePlanFunc: func(ctx context.Context, planJSON, workspace string) (*PlanAnalysis, error) {
+						assert.NotNil(t, ctx)
+						assert.Equal(t, "prod", workspace)
+						return &PlanAnalysis{RiskLevel: "critical", Replace: 1, Blocked: true}, nil
+					},
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -208,6 +236,39 @@ func TestWhatsAppAgent_HandleError(t *testing.T) {
 	assert.Equal(t, "text", response.Type)
 }
 
+func TestPlanService_AnalyzePlan(t *testing.T) {
+	service := NewPlanService(analyzer.NewPreviewAnalyzer(logger.New("error", "text")))
+	planJSON := `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_db_instance.main",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": { "engine_version": "13.4" },
+        "after": { "engine_version": "15.2" },
+        "replace_paths": [["engine_version"]]
+      }
+    }
+  ]
+}`
+
+	analysis, err := service.AnalyzePlan(context.Background(), planJSON, "prod")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, analysis.Replace)
+	assert.NotEmpty(t, analysis.Warnings)
+	assert.NotContains(t, analysis.Warnings, "Test warning")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = service.AnalyzePlan(ctx, planJSON, "prod")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestWhatsAppAgent_GetUsageStats(t *testing.T) {
 	mockBillingService := &MockBillingService{}
 	agent := &WhatsAppAgent{
@@ -300,6 +361,7 @@ func TestAvailableCommands(t *testing.T) {
 	assert.Contains(t, commands, "analyze")
 	assert.Contains(t, commands, "security")
 	assert.Contains(t, commands, "cost")
+	assert.Contains(t, commands, "plan")
 	assert.Contains(t, commands, "status")
 	assert.Contains(t, commands, "balance")
 	assert.Contains(t, commands, "usage")
@@ -309,6 +371,10 @@ func TestAvailableCommands(t *testing.T) {
 	assert.False(t, commands["help"].RequiresPayment)
 	assert.Equal(t, 0, commands["help"].TokenCost)
 
+	assert.Equal(t, "plan", commands["plan"].Name)
+	assert.True(t, commands["plan"].RequiresPayment)
+	assert.Equal(t, 1, commands["plan"].TokenCost)
+
 	assert.Equal(t, "analyze", commands["analyze"].Name)
 	assert.True(t, commands["analyze"].RequiresPayment)
 	assert.Equal(t, 1, commands["analyze"].TokenCost)
@@ -32,6 +32,14 @@ func AvailableCommands() map[string]*Command {
 			RequiresPayment: true,
 			TokenCost:       1,
 		},
+		"plan": {
+			Name:            "plan",
+			Description:     "Analisa terraform plan (saída de terraform show -json)",
+			Pattern:         `(?s)^/plan\s*(.*)`,
+			Handler:         handlePlanCommand,
+			RequiresPayment: true,
+			TokenCost:       1,
+		},
 		"help": {
 			Name:            "help",
 			Description:     "Lista comandos disponíveis",
@@ -187,6 +195,55 @@ func handleCostCommand(agent *WhatsAppAgent, ctx *CommandContext) (*WhatsAppResp
 	}, nil
 }
 
+// handlePlanCommand processa comando de análise de plan. O primeiro
+// argumento, se informado, é o workspace usado pela política de plan.
+func handlePlanCommand(agent *WhatsAppAgent, ctx *CommandContext) (*WhatsAppResponse, error) {
+	if ctx.CodeBlock == "" {
+		return &WhatsAppResponse{
+			Text: "❌ Por favor, forneça o plan em JSON (terraform show -json plan.out).\n\nExemplo:\n/plan prod\n```json\n{\"format_version\": \"1.2\", \"resource_changes\": []}\n```",
+			Type: "text",
+		}, nil
+	}
+
+	workspace := ""
+	if len(ctx.Args) > 0 {
+		workspace = ctx.Args[0]
+	}
+
+	// Executar análise do plan
+	planAnalysis, err := agent.PlanService.AnalyzePlan(ctx.Context, ctx.CodeBlock, workspace)
+	if err != nil {
+		return &WhatsAppResponse{
+			Text: fmt.Sprintf("❌ Erro na análise do plan: %v", err),
+			Type: "text",
+		}, nil
+	}
+
+	// Gerar resposta
+	response := "📋 Análise de Plan Concluída!\n\n"
+	response += fmt.Sprintf("➕ Create: %d | ✏️ Update: %d | 🔁 Replace: %d | 🗑️ Destroy: %d\n",
+		planAnalysis.Create, planAnalysis.Update, planAnalysis.Replace, planAnalysis.Destroy)
+	response += fmt.Sprintf("⚠️ Nível de risco: %s\n", planAnalysis.RiskLevel)
+
+	if planAnalysis.Blocked {
+		response += "\n⛔ Plan bloqueado pela política de plan\n"
+	}
+
+	if len(planAnalysis.Warnings) > 0 {
+		response += "\n🚨 Avisos:\n"
+		for _, warning := range planAnalysis.Warnings {
+			response += fmt.Sprintf("• %s\n", warning)
+		}
+	}
+
+	response += fmt.Sprintf("\n💰 Custo: %d token(s) IACAI", 1)
+
+	return &WhatsAppResponse{
+		Text: response,
+		Type: "text",
+	}, nil
+}
+
 // handleHelpCommand processa comando de ajuda
 func handleHelpCommand(agent *WhatsAppAgent, ctx *CommandContext) (*WhatsAppResponse, error) {
 	response := ResponseTemplates["welcome"]
@@ -36,6 +36,9 @@ type WhatsAppAgentConfig struct {
 	WalletAddr  string `json:"wallet_address" yaml:"wallet_address"`
 	WebhookURL  string `json:"webhook_url" yaml:"webhook_url"`
 	VerifyToken string `json:"verify_token" yaml:"verify_token"`
+
+	// PlanPolicyFile é a política de plan avaliada pelo comando /plan
+	PlanPolicyFile string `json:"plan_policy_file,omitempty" yaml:"plan_policy_file,omitempty"`
 }
 
 // Command representa um comando disponível no agente
@@ -50,6 +53,7 @@ type Command struct {
 
 // CommandContext contexto para execução de comandos
 type CommandContext struct {
+	Context   context.Context
 	Message   *WhatsAppMessage
 	Agent     *WhatsAppAgent
 	Args      []string
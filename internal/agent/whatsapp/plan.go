@@ -0,0 +1,62 @@
+package whatsapp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+)
+
+// PlanService analisa planos do Terraform para o comando /plan
+type PlanService interface {
+	AnalyzePlan(ctx context.Context, planJSON, workspace string) (*PlanAnalysis, error)
+}
+
+// previewPlanService implementa PlanService com o PreviewAnalyzer, o mesmo
+// usado por /analyze/plan
+type previewPlanService struct {
+	previewAnalyzer *analyzer.PreviewAnalyzer
+}
+
+// NewPlanService cria o serviço de análise de plan do agente
+func NewPlanService(previewAnalyzer *analyzer.PreviewAnalyzer) PlanService {
+	return &previewPlanService{previewAnalyzer: previewAnalyzer}
+}
+
+// AnalyzePlan analisa o JSON de terraform show -json e avalia a política de
+// plan no workspace informado. Avisos são as mudanças de risco alto ou
+// crítico e as regras deny da política.
+func (s *previewPlanService) AnalyzePlan(ctx context.Context, planJSON, workspace string) (*PlanAnalysis, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	preview, err := s.previewAnalyzer.AnalyzePreviewWithOptions([]byte(planJSON), analyzer.PreviewOptions{Workspace: workspace})
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &PlanAnalysis{
+		RiskLevel: preview.RiskLevel,
+		Create:    preview.CreateCount,
+		Update:    preview.UpdateCount,
+		Replace:   preview.ReplaceCount,
+		Destroy:   preview.DestroyCount,
+		Warnings:  []string{},
+	}
+	for _, warning := range preview.RiskWarnings {
+		if warning.Severity == "critical" || warning.Severity == "high" {
+			analysis.Warnings = append(analysis.Warnings, fmt.Sprintf("%s: %s", warning.Resource, warning.Message))
+		}
+	}
+	if preview.Policy != nil {
+		analysis.Blocked = preview.Policy.Blocked
+		for _, result := range preview.Policy.Results {
+			if result.Status == "deny" {
+				analysis.Warnings = append(analysis.Warnings, fmt.Sprintf("%s: %s", result.RuleID, result.Description))
+			}
+		}
+	}
+
+	return analysis, nil
+}
@@ -50,4 +50,5 @@ type CheckovConfig struct {
 	CompactOutput     bool     `json:"compact_output"`
 	Quiet             bool     `json:"quiet"`
 	ExternalChecksDir string   `json:"external_checks_dir,omitempty"`
+	TimeoutSeconds    int      `json:"timeout_seconds,omitempty"` // 0 usa o padrão de 5 minutos
 }
@@ -9,6 +9,15 @@ type AnalysisRequest struct {
 	Content    string `json:"content"`
 	Branch     string `json:"branch,omitempty"`
 	CommitSHA  string `json:"commit_sha,omitempty"`
+	VarFile    string `json:"var_file,omitempty"` // Arquivo .tfvars adicional (equivalente a -var-file)
+
+	// Knowledge são as regras do agente para esta análise; quando ausente é
+	// usado o conhecimento configurado no serviço
+	Knowledge *AgentKnowledge `json:"knowledge,omitempty"`
+
+	// ScannerReports são resultados de outros scanners (tfsec, Trivy, KICS,
+	// Terrascan, Checkov) combinados aos findings da análise
+	ScannerReports []ScannerReport `json:"scanner_reports,omitempty"`
 }
 
 // AnalysisResponse representa o resultado de uma análise
@@ -19,6 +28,28 @@ type AnalysisResponse struct {
 	Suggestions []Suggestion           `json:"suggestions"`
 	Metadata    map[string]interface{} `json:"metadata"`
 	Timestamp   time.Time              `json:"timestamp"`
+	Stacks      []StackSummary         `json:"stacks,omitempty"` // um item por módulo raiz
+
+	// Suppressions relata os findings suprimidos e as exceções expiradas ou
+	// inválidas; ausente quando não há supressões
+	Suppressions *SuppressionReport `json:"suppressions,omitempty"`
+}
+
+// StackSummary resume a análise de um módulo raiz (stack) de um diretório
+type StackSummary struct {
+	Root           string   `json:"root"`
+	Valid          bool     `json:"valid"`
+	TotalResources int      `json:"total_resources"`
+	TotalModules   int      `json:"total_modules"`
+	Providers      []string `json:"providers"`
+	Backend        string   `json:"backend,omitempty"`
+	Errors         int      `json:"errors"`
+	Warnings       int      `json:"warnings"`
+	Findings       int      `json:"findings"`
+
+	// ResourceGraph é o grafo de dependências do stack; a análise agregada
+	// não tem grafo porque os endereços se repetem entre stacks
+	ResourceGraph *ResourceGraph `json:"resource_graph,omitempty"`
 }
 
 // AnalysisDetails contém detalhes de todas as análises
@@ -70,6 +101,8 @@ type SecurityFinding struct {
 	Description string   `json:"description"`
 	Guideline   string   `json:"guideline"`
 	References  []string `json:"references"`
+	FixedCode   string   `json:"fixed_code,omitempty"` // código corrigido sugerido pelo scanner
+	Sources     []string `json:"sources,omitempty"`    // scanners que reportaram o finding
 }
 
 // IAMAnalysis contém análise de políticas IAM
@@ -82,10 +115,25 @@ type IAMAnalysis struct {
 	PublicAccess        []string        `json:"public_access"`
 	Recommendations     []string        `json:"recommendations"`
 	PrincipalRisks      []PrincipalRisk `json:"principal_risks"`
+
+	// EffectivePermissions são as permissões efetivas de cada role, usuário
+	// e grupo (e de políticas não anexadas), após aplicar os Deny
+	EffectivePermissions []PrincipalPermissions `json:"effective_permissions,omitempty"`
+}
+
+// PrincipalPermissions são as permissões efetivas de um principal IAM
+type PrincipalPermissions struct {
+	Principal  string   `json:"principal"` // endereço do recurso (ex.: aws_iam_role.deploy)
+	Type       string   `json:"type"`      // role, user, group, policy
+	Policies   []string `json:"policies"`  // políticas consideradas
+	FullAccess bool     `json:"full_access"`
+	Actions    []string `json:"actions"` // ["*"] quando FullAccess
 }
 
 // PrincipalRisk representa um risco relacionado a principal IAM
 type PrincipalRisk struct {
+	CheckID     string   `json:"check_id,omitempty"`
+	Resource    string   `json:"resource,omitempty"` // recurso que concede o acesso
 	Principal   string   `json:"principal"`
 	Type        string   `json:"type"` // user, role, service
 	RiskLevel   string   `json:"risk_level"`
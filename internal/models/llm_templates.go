@@ -197,16 +197,47 @@ type DangerousOperation struct {
 
 // PreviewDetails contém detalhes do preview analisado
 type PreviewDetails struct {
-	PlannedChanges       []PlannedChange      `json:"planned_changes"`
-	ResourcesAffected    int                  `json:"resources_affected"`
-	CreateCount          int                  `json:"create_count"`
-	UpdateCount          int                  `json:"update_count"`
-	DestroyCount         int                  `json:"destroy_count"`
-	ReplaceCount         int                  `json:"replace_count"`
-	RiskLevel            string               `json:"risk_level"` // low, medium, high, critical
-	DangerousOperations  []DangerousOperation `json:"dangerous_operations"`
-	EstimatedApplyTime   string               `json:"estimated_apply_time"`
-	RecommendedApprovals []string             `json:"recommended_approvals,omitempty"`
+	PlannedChanges       []PlannedChange       `json:"planned_changes"`
+	ResourcesAffected    int                   `json:"resources_affected"`
+	CreateCount          int                   `json:"create_count"`
+	UpdateCount          int                   `json:"update_count"`
+	DestroyCount         int                   `json:"destroy_count"`
+	ReplaceCount         int                   `json:"replace_count"`
+	RiskLevel            string                `json:"risk_level"` // low, medium, high, critical
+	DangerousOperations  []DangerousOperation  `json:"dangerous_operations"`
+	EstimatedApplyTime   string                `json:"estimated_apply_time"`
+	RecommendedApprovals []string              `json:"recommended_approvals,omitempty"`
+	Workspace            string                `json:"workspace,omitempty"`
+	Policy               *PlanPolicyEvaluation `json:"policy,omitempty"`
+}
+
+// PreviewLLMReview é o formato de resposta pedido ao LLM pelo prompt de
+// análise de preview
+type PreviewLLMReview struct {
+	RiskAssessment struct {
+		OverallRisk     string `json:"overall_risk"`
+		HighRiskChanges []struct {
+			Resource    string `json:"resource"`
+			Action      string `json:"action"`
+			RiskDetails string `json:"risk_details"`
+			Mitigation  string `json:"mitigation"`
+		} `json:"high_risk_changes"`
+		BlastRadius string `json:"blast_radius"`
+	} `json:"risk_assessment"`
+	ImpactAnalysis struct {
+		EstimatedDowntime    string   `json:"estimated_downtime"`
+		AffectedDependencies []string `json:"affected_dependencies"`
+		PerformanceImpact    string   `json:"performance_impact"`
+	} `json:"impact_analysis"`
+	Recommendations struct {
+		ApplyStrategy   string `json:"apply_strategy"`
+		TestingStrategy string `json:"testing_strategy"`
+		RollbackPlan    string `json:"rollback_plan"`
+	} `json:"recommendations"`
+	BestPractices []struct {
+		Issue          string `json:"issue"`
+		Recommendation string `json:"recommendation"`
+	} `json:"best_practices"`
 }
 
 // SecurityAuditResponse é a resposta específica para auditoria de segurança
@@ -0,0 +1,38 @@
+package models
+
+// PlanPolicy representa um arquivo de políticas declarativas avaliadas
+// contra um terraform plan
+type PlanPolicy struct {
+	Rules []PlanPolicyRule `json:"rules" yaml:"rules"`
+}
+
+// PlanPolicyRule representa uma regra da política. Todos os critérios
+// informados precisam casar com a mudança para que a regra seja violada.
+type PlanPolicyRule struct {
+	ID            string            `json:"id" yaml:"id"`
+	Description   string            `json:"description,omitempty" yaml:"description"`
+	Effect        string            `json:"effect" yaml:"effect"`                           // deny, warn
+	Actions       []string          `json:"actions,omitempty" yaml:"actions"`               // create, update, delete, replace, read
+	ResourceTypes []string          `json:"resource_types,omitempty" yaml:"resource_types"` // padrões glob, ex.: aws_iam_*
+	Addresses     []string          `json:"addresses,omitempty" yaml:"addresses"`           // padrões glob, ex.: module.db.*
+	Workspaces    []string          `json:"workspaces,omitempty" yaml:"workspaces"`         // padrões glob, ex.: prod*
+	Tags          map[string]string `json:"tags,omitempty" yaml:"tags"`                     // tags ou labels antes ou depois da mudança
+	Attributes    []string          `json:"attributes,omitempty" yaml:"attributes"`         // caminhos alterados, ex.: engine_version
+	Message       string            `json:"message,omitempty" yaml:"message"`               // mensagem exibida quando violada
+}
+
+// PlanPolicyEvaluation representa o resultado da avaliação de uma política
+type PlanPolicyEvaluation struct {
+	Decision string             `json:"decision"` // pass, warn, deny
+	Blocked  bool               `json:"blocked"`  // true quando alguma regra deny foi violada
+	Results  []PlanPolicyResult `json:"results"`
+}
+
+// PlanPolicyResult representa o resultado de uma regra
+type PlanPolicyResult struct {
+	RuleID      string   `json:"rule_id"`
+	Description string   `json:"description,omitempty"`
+	Status      string   `json:"status"` // pass, warn, deny, skipped (workspace fora da regra warn/deny, ou regra warn sem workspace)
+	Message     string   `json:"message,omitempty"`
+	Resources   []string `json:"resources,omitempty"` // recursos que violaram a regra
+}
@@ -1,5 +1,16 @@
 package models
 
+import "encoding/json"
+
+// PlanAnalysisRequest representa uma requisição de análise de terraform plan
+type PlanAnalysisRequest struct {
+	Plan      json.RawMessage `json:"plan,omitempty"`       // saída de terraform show -json (objeto ou string)
+	PlanPath  string          `json:"plan_path,omitempty"`  // plan binário (terraform plan -out) ou JSON
+	Path      string          `json:"path,omitempty"`       // diretório da configuração do plan binário
+	Workspace string          `json:"workspace,omitempty"`  // usado pelas regras da política de plan
+	LLMReview bool            `json:"llm_review,omitempty"` // enriquece a análise com revisão do LLM
+}
+
 // PreviewAnalysis representa análise de um terraform plan
 type PreviewAnalysis struct {
 	PlannedChanges    []PlannedChange `json:"planned_changes"`
@@ -12,15 +23,31 @@ type PreviewAnalysis struct {
 	ReplaceCount      int             `json:"replace_count"`
 	RiskLevel         string          `json:"risk_level"` // low, medium, high, critical
 	RiskWarnings      []RiskWarning   `json:"risk_warnings"`
+
+	// Policy é o resultado da política de plan, quando configurada
+	Policy *PlanPolicyEvaluation `json:"policy,omitempty"`
 }
 
 // PlannedChange representa uma mudança planejada em um recurso
 type PlannedChange struct {
-	Resource  string                 `json:"resource"`
-	Action    string                 `json:"action"` // create, update, destroy, replace
-	Changes   map[string]interface{} `json:"changes"`
-	RiskScore int                    `json:"risk_score"`
-	Impact    string                 `json:"impact"`
+	Resource  string                     `json:"resource"`
+	Action    string                     `json:"action"`  // create, update, destroy, replace
+	Changes   map[string]AttributeChange `json:"changes"` // indexado pelo caminho, ex.: tags.Env, ingress[0].cidr_blocks
+	RiskScore int                        `json:"risk_score"`
+	Impact    string                     `json:"impact"`
+
+	// Dependents são os recursos que dependem, direta ou transitivamente,
+	// de um recurso destruído ou substituído (blast radius)
+	Dependents []string `json:"dependents,omitempty"`
+}
+
+// AttributeChange representa a mudança de um atributo entre before e after
+type AttributeChange struct {
+	Before            interface{} `json:"before"`
+	After             interface{} `json:"after"`
+	Unknown           bool        `json:"unknown,omitempty"`            // valor conhecido só após o apply
+	Sensitive         bool        `json:"sensitive,omitempty"`          // valores omitidos
+	ForcesReplacement bool        `json:"forces_replacement,omitempty"` // listado em replace_paths
 }
 
 // RiskWarning representa um aviso de risco
@@ -29,6 +56,9 @@ type RiskWarning struct {
 	Resource string `json:"resource"`
 	Message  string `json:"message"`
 	Action   string `json:"action"`
+
+	// BlastRadius são os recursos afetados pela mudança
+	BlastRadius []string `json:"blast_radius,omitempty"`
 }
 
 // TerraformPlan representa a estrutura de um terraform plan JSON
@@ -45,8 +75,12 @@ type TerraformResourceChange struct {
 	Type    string `json:"type"`
 	Name    string `json:"name"`
 	Change  struct {
-		Actions []string               `json:"actions"`
-		Before  map[string]interface{} `json:"before"`
-		After   map[string]interface{} `json:"after"`
+		Actions         []string               `json:"actions"`
+		Before          map[string]interface{} `json:"before"`
+		After           map[string]interface{} `json:"after"`
+		AfterUnknown    interface{}            `json:"after_unknown"`
+		BeforeSensitive interface{}            `json:"before_sensitive"`
+		AfterSensitive  interface{}            `json:"after_sensitive"`
+		ReplacePaths    [][]interface{}        `json:"replace_paths"`
 	} `json:"change"`
 }
@@ -8,6 +8,9 @@ type ReviewRequest struct {
 	PRNumber       int    `json:"pr_number"`
 	Owner          string `json:"owner"`
 	InstallationID int64  `json:"installation_id,omitempty"`
+
+	// Plan é um terraform plan anexado ao review (opcional)
+	Plan *PlanAnalysisRequest `json:"plan,omitempty"`
 }
 
 // ReviewResponse representa o resultado de um review
@@ -23,6 +26,9 @@ type ReviewResponse struct {
 	Analysis         AnalysisDetails `json:"analysis"`
 	FileReviews      []FileReview    `json:"file_reviews"`
 	Timestamp        time.Time       `json:"timestamp"`
+
+	// Plan é a análise do plan anexado à requisição
+	Plan *PreviewAnalysisResponse `json:"plan,omitempty"`
 }
 
 // FileReview representa o review de um arquivo específico
@@ -0,0 +1,10 @@
+package models
+
+import "encoding/json"
+
+// ScannerReport é o resultado de um scanner executado fora do agente (ex.:
+// no CI), importado e normalizado em findings
+type ScannerReport struct {
+	Tool   string          `json:"tool"`   // checkov, tfsec, trivy, kics, terrascan; vazio detecta pelo formato
+	Output json.RawMessage `json:"output"` // saída JSON do scanner
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// TerraformState representa um state do Terraform normalizado, lido tanto do
+// terraform.tfstate (formato v4) quanto da saída de terraform show -json
+type TerraformState struct {
+	Format           string          `json:"format"` // tfstate, show-json
+	Version          int             `json:"version,omitempty"`
+	TerraformVersion string          `json:"terraform_version,omitempty"`
+	Serial           int64           `json:"serial,omitempty"`
+	Lineage          string          `json:"lineage,omitempty"`
+	Resources        []StateResource `json:"resources"`
+}
+
+// StateResource representa uma instância de recurso registrada no state
+type StateResource struct {
+	Address    string                 `json:"address"`          // ex.: module.network.aws_vpc.main[0]
+	Module     string                 `json:"module,omitempty"` // vazio no módulo raiz
+	Mode       string                 `json:"mode"`             // managed, data
+	Type       string                 `json:"type"`
+	Name       string                 `json:"name"`
+	Index      interface{}            `json:"index,omitempty"`
+	Provider   string                 `json:"provider,omitempty"`
+	Attributes map[string]interface{} `json:"attributes"`
+
+	// SensitivePaths são os caminhos de atributos marcados como sensíveis
+	SensitivePaths []string `json:"sensitive_paths,omitempty"`
+}
+
+// DriftAnalysis compara a configuração com o state aplicado
+type DriftAnalysis struct {
+	StateFormat      string             `json:"state_format"`
+	TerraformVersion string             `json:"terraform_version,omitempty"`
+	Serial           int64              `json:"serial,omitempty"`
+	Drifted          []DriftedResource  `json:"drifted"`   // atributos configurados diferentes do state
+	Orphaned         []OrphanedResource `json:"orphaned"`  // no state, mas não declarados
+	Unapplied        []string           `json:"unapplied"` // declarados, mas ausentes do state
+	Unverified       []string           `json:"unverified,omitempty"`
+	InSync           int                `json:"in_sync"`
+	HasDrift         bool               `json:"has_drift"`
+}
+
+// OrphanedResource identifica um recurso presente no state que não está mais
+// declarado. Os atributos do state não são expostos, pois podem conter
+// segredos.
+type OrphanedResource struct {
+	Address string `json:"address"`
+	Module  string `json:"module,omitempty"`
+	Mode    string `json:"mode"`
+	Type    string `json:"type"`
+}
+
+// DriftedResource representa um recurso com atributos divergentes
+type DriftedResource struct {
+	Address    string           `json:"address"`
+	Type       string           `json:"type"`
+	File       string           `json:"file,omitempty"`
+	Line       int              `json:"line,omitempty"`
+	Attributes []AttributeDrift `json:"attributes"`
+}
+
+// AttributeDrift representa a divergência de um atributo
+type AttributeDrift struct {
+	Path       string      `json:"path"` // ex.: tags.Env, ingress[0].cidr_blocks
+	Configured interface{} `json:"configured"`
+	Actual     interface{} `json:"actual"`
+	Sensitive  bool        `json:"sensitive,omitempty"` // valores omitidos
+}
+
+// DriftRequest representa uma requisição de detecção de drift
+type DriftRequest struct {
+	Path      string `json:"path"`                 // diretório da configuração
+	Content   string `json:"content,omitempty"`    // configuração inline (alternativa a path)
+	Stack     string `json:"stack,omitempty"`      // módulo raiz, quando path tem vários
+	State     string `json:"state,omitempty"`      // state em JSON (tfstate ou show -json)
+	StatePath string `json:"state_path,omitempty"` // arquivo de state relativo a path; padrão <path>/<stack>/terraform.tfstate
+	VarFile   string `json:"var_file,omitempty"`
+}
+
+// DriftResponse representa o resultado de uma detecção de drift
+type DriftResponse struct {
+	ID        string        `json:"id"`
+	Stack     string        `json:"stack,omitempty"`
+	Drift     DriftAnalysis `json:"drift"`
+	Timestamp time.Time     `json:"timestamp"`
+}
@@ -0,0 +1,38 @@
+package models
+
+// Suppression representa a aceitação de um risco conhecido, declarada por um
+// comentário inline (# iac-agent:ignore CKV_AWS_20 reason="..." until=2027-01-01)
+// ou por uma entrada do arquivo de exceções do repositório
+type Suppression struct {
+	CheckID  string `json:"check_id" yaml:"check_id"`           // id ou padrão glob, ex.: CKV_AWS_*
+	Resource string `json:"resource,omitempty" yaml:"resource"` // endereço ou padrão glob; vazio vale para todos
+	File     string `json:"file,omitempty" yaml:"file"`         // arquivo onde a supressão vale
+	Stack    string `json:"stack,omitempty" yaml:"stack"`       // módulo raiz onde a supressão vale; vazio vale para todos
+	Reason   string `json:"reason" yaml:"reason"`               // justificativa obrigatória
+	Until    string `json:"until,omitempty" yaml:"until"`       // data de expiração (YYYY-MM-DD), inclusive
+	Line     int    `json:"line,omitempty" yaml:"-"`            // linha do comentário inline
+	Source   string `json:"source" yaml:"-"`                    // inline, exceptions_file
+	Error    string `json:"error,omitempty" yaml:"-"`           // motivo de a supressão ser inválida
+	Matched  int    `json:"matched" yaml:"-"`                   // findings suprimidos
+}
+
+// ExceptionsFile representa o arquivo de exceções do repositório
+type ExceptionsFile struct {
+	Exceptions []Suppression `json:"exceptions" yaml:"exceptions"`
+}
+
+// SuppressionReport resume as supressões aplicadas em uma análise
+type SuppressionReport struct {
+	Suppressed []SuppressedFinding `json:"suppressed"` // findings que não contam no score
+	Active     []Suppression       `json:"active"`
+	Expired    []Suppression       `json:"expired"` // findings voltaram a contar
+	Invalid    []Suppression       `json:"invalid"` // sem justificativa, data inválida, etc.
+}
+
+// SuppressedFinding é um finding (de segurança ou risco IAM) suprimido
+type SuppressedFinding struct {
+	Finding SecurityFinding `json:"finding"`
+	Reason  string          `json:"reason"`
+	Until   string          `json:"until,omitempty"`
+	Source  string          `json:"source"`
+}
@@ -2,49 +2,93 @@ package models
 
 // TerraformAnalysis contém resultados da análise do código Terraform
 type TerraformAnalysis struct {
-	Valid                bool                `json:"valid"`
-	TotalResources       int                 `json:"total_resources"`
-	TotalModules         int                 `json:"total_modules"`
-	TotalVariables       int                 `json:"total_variables"`
-	TotalOutputs         int                 `json:"total_outputs"`
-	TotalDataSources     int                 `json:"total_data_sources"`
-	Providers            []string            `json:"providers"`
-	Resources            []TerraformResource `json:"resources"`
-	Modules              []TerraformModule   `json:"modules"`
-	Variables            []TerraformVariable `json:"variables"`
-	Outputs              []TerraformOutput   `json:"outputs"`
-	SyntaxErrors         []SyntaxError       `json:"syntax_errors,omitempty"`
-	BestPracticeWarnings []string            `json:"best_practice_warnings,omitempty"`
-	ResourceGraph        *ResourceGraph      `json:"resource_graph,omitempty"`
+	Valid                bool                  `json:"valid"`
+	TotalResources       int                   `json:"total_resources"`
+	TotalModules         int                   `json:"total_modules"`
+	TotalVariables       int                   `json:"total_variables"`
+	TotalOutputs         int                   `json:"total_outputs"`
+	TotalDataSources     int                   `json:"total_data_sources"`
+	Providers            []string              `json:"providers"`
+	Resources            []TerraformResource   `json:"resources"`
+	Modules              []TerraformModule     `json:"modules"`
+	DataSources          []TerraformDataSource `json:"data_sources,omitempty"`
+	Variables            []TerraformVariable   `json:"variables"`
+	Outputs              []TerraformOutput     `json:"outputs"`
+	SyntaxErrors         []SyntaxError         `json:"syntax_errors,omitempty"`
+	BestPracticeWarnings []string              `json:"best_practice_warnings,omitempty"`
+	ResourceGraph        *ResourceGraph        `json:"resource_graph,omitempty"`
+	Settings             *TerraformSettings    `json:"settings,omitempty"`
+
+	// Root é o diretório do módulo raiz relativo ao diretório analisado.
+	// Stacks contém a análise de cada módulo raiz quando um diretório tem
+	// mais de um; nesse caso a análise principal é o agregado deles, com o
+	// stack de cada recurso em Stack. Os recursos de Stacks já estão no
+	// agregado, por isso não são serializados: a resposta expõe um resumo
+	// por stack, com seu grafo (AnalysisResponse.Stacks).
+	Root   string              `json:"root,omitempty"`
+	Stacks []TerraformAnalysis `json:"-"`
 }
 
 // TerraformResource representa um recurso Terraform
 type TerraformResource struct {
-	Type         string                 `json:"type"`
-	Name         string                 `json:"name"`
-	Provider     string                 `json:"provider"`
-	File         string                 `json:"file"`
-	LineStart    int                    `json:"line_start"`
-	LineEnd      int                    `json:"line_end"`
-	Attributes   map[string]interface{} `json:"attributes"`
-	Dependencies []string               `json:"dependencies,omitempty"`
-	Tags         map[string]string      `json:"tags,omitempty"`
+	Address           string                 `json:"address"`          // ex.: module.network.aws_vpc.main
+	Module            string                 `json:"module,omitempty"` // vazio no módulo raiz
+	Type              string                 `json:"type"`
+	Name              string                 `json:"name"`
+	Index             interface{}            `json:"index,omitempty"` // count.index ou each.key
+	Provider          string                 `json:"provider"`
+	File              string                 `json:"file"`
+	LineStart         int                    `json:"line_start"`
+	LineEnd           int                    `json:"line_end"`
+	Attributes        map[string]interface{} `json:"attributes"`
+	Dependencies      []string               `json:"dependencies,omitempty"`
+	UnknownAttributes []string               `json:"unknown_attributes,omitempty"`
+	Tags              map[string]string      `json:"tags,omitempty"`
+	// UnknownCardinality indica que count/for_each só é conhecido após o
+	// apply e o recurso foi analisado como uma única instância
+	UnknownCardinality bool `json:"unknown_cardinality,omitempty"`
+	// Stack é o módulo raiz de origem quando a análise agrega vários stacks;
+	// o mesmo endereço pode existir em stacks diferentes
+	Stack string `json:"stack,omitempty"`
+}
+
+// TerraformDataSource representa um bloco data. Para
+// aws_iam_policy_document o atributo json contém o documento gerado.
+type TerraformDataSource struct {
+	Address           string                 `json:"address"` // ex.: data.aws_iam_policy_document.assume
+	Module            string                 `json:"module,omitempty"`
+	Type              string                 `json:"type"`
+	Name              string                 `json:"name"`
+	Index             interface{}            `json:"index,omitempty"`
+	File              string                 `json:"file"`
+	LineStart         int                    `json:"line_start"`
+	LineEnd           int                    `json:"line_end"`
+	Attributes        map[string]interface{} `json:"attributes"`
+	UnknownAttributes []string               `json:"unknown_attributes,omitempty"`
+	Stack             string                 `json:"stack,omitempty"` // ver TerraformResource.Stack
 }
 
 // TerraformModule representa um módulo Terraform
 type TerraformModule struct {
 	Name      string                 `json:"name"`
+	Module    string                 `json:"module,omitempty"` // módulo chamador, vazio no módulo raiz
 	Source    string                 `json:"source"`
 	Version   string                 `json:"version,omitempty"`
 	File      string                 `json:"file"`
 	LineStart int                    `json:"line_start"`
 	Inputs    map[string]interface{} `json:"inputs"`
+	Outputs   map[string]interface{} `json:"outputs,omitempty"`
 	Verified  bool                   `json:"verified"`
+
+	// SensitiveOutputs são os outputs com sensitive = true, cujos valores
+	// não são incluídos em Outputs
+	SensitiveOutputs []string `json:"sensitive_outputs,omitempty"`
 }
 
 // TerraformVariable representa uma variável Terraform
 type TerraformVariable struct {
 	Name        string      `json:"name"`
+	Module      string      `json:"module,omitempty"`
 	Type        string      `json:"type"`
 	Description string      `json:"description,omitempty"`
 	Default     interface{} `json:"default,omitempty"`
@@ -56,6 +100,7 @@ type TerraformVariable struct {
 // TerraformOutput representa um output Terraform
 type TerraformOutput struct {
 	Name        string `json:"name"`
+	Module      string `json:"module,omitempty"`
 	Description string `json:"description,omitempty"`
 	Value       string `json:"value"`
 	Sensitive   bool   `json:"sensitive"`
@@ -73,21 +118,58 @@ type SyntaxError struct {
 
 // ResourceGraph representa o grafo de dependências entre recursos
 type ResourceGraph struct {
-	Nodes []GraphNode `json:"nodes"`
-	Edges []GraphEdge `json:"edges"`
+	Nodes  []GraphNode `json:"nodes"`
+	Edges  []GraphEdge `json:"edges"`
+	Cycles [][]string  `json:"cycles,omitempty"`
 }
 
 // GraphNode representa um nó no grafo
 type GraphNode struct {
 	ID       string `json:"id"`
-	Type     string `json:"type"`
+	Type     string `json:"type"` // resource, data, module
 	Name     string `json:"name"`
 	Resource string `json:"resource"`
 }
 
-// GraphEdge representa uma aresta no grafo (dependência)
+// GraphEdge representa uma aresta no grafo (From depende de To)
 type GraphEdge struct {
 	From string `json:"from"`
 	To   string `json:"to"`
 	Type string `json:"type"` // explicit, implicit
 }
+
+// TerraformSettings contém o bloco terraform {} (versões, providers e backend)
+// e o lock file .terraform.lock.hcl
+type TerraformSettings struct {
+	Engine            string                `json:"engine"` // terraform, opentofu
+	RequiredVersion   string                `json:"required_version,omitempty"`
+	RequiredProviders []ProviderRequirement `json:"required_providers,omitempty"`
+	Backend           *BackendConfig        `json:"backend,omitempty"`
+	LockFile          string                `json:"lock_file,omitempty"`
+	LockedProviders   []LockedProvider      `json:"locked_providers,omitempty"`
+	Findings          []SecurityFinding     `json:"findings,omitempty"`
+}
+
+// ProviderRequirement representa uma entrada de required_providers
+type ProviderRequirement struct {
+	Name    string `json:"name"`
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+	Module  string `json:"module,omitempty"`
+	File    string `json:"file"`
+}
+
+// BackendConfig representa o backend (ou bloco cloud) do módulo raiz
+type BackendConfig struct {
+	Type   string                 `json:"type"`
+	Config map[string]interface{} `json:"config,omitempty"`
+	File   string                 `json:"file"`
+	Line   int                    `json:"line"`
+}
+
+// LockedProvider representa um provider registrado no lock file
+type LockedProvider struct {
+	Source      string `json:"source"`
+	Version     string `json:"version"`
+	Constraints string `json:"constraints,omitempty"`
+}
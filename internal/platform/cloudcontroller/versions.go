@@ -0,0 +1,151 @@
+package cloudcontroller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/hashicorp/go-version"
+)
+
+// providerAliases mapeia nomes locais de providers para as chaves usadas em
+// SupportedVersions.Providers. Providers sem entrada própria (ex.: azuread,
+// que versiona independente do azurerm) não são verificados.
+var providerAliases = map[string]string{
+	"azurerm": "azure",
+	"google":  "gcp",
+}
+
+// GetSupportedVersions retorna as versões suportadas pela plataforma
+func (kb *KnowledgeBase) GetSupportedVersions() SupportedVersions {
+	return kb.platformContext.SupportedVersions
+}
+
+// CheckSupportedVersions compara as versões exigidas pela configuração
+// (required_version, required_providers e lock file) com as versões
+// suportadas pela plataforma. Uma restrição é considerada suportada quando a
+// menor versão que ela admite satisfaz alguma das versões suportadas.
+func (kb *KnowledgeBase) CheckSupportedVersions(settings *models.TerraformSettings) []models.SecurityFinding {
+	findings := []models.SecurityFinding{}
+	if settings == nil {
+		return findings
+	}
+
+	supported := kb.platformContext.SupportedVersions
+	add := func(checkID, checkName, resource, file, description, guideline string) {
+		findings = append(findings, models.SecurityFinding{
+			ID:          fmt.Sprintf("%s-%d", checkID, len(findings)+1),
+			CheckID:     checkID,
+			CheckName:   checkName,
+			Severity:    "MEDIUM",
+			Resource:    resource,
+			File:        file,
+			Description: description,
+			Guideline:   guideline,
+		})
+	}
+
+	// Versão do Terraform/OpenTofu
+	engine, engineVersions := "Terraform", supported.Terraform
+	if settings.Engine == "opentofu" {
+		engine, engineVersions = "OpenTofu", supported.OpenTofu
+	}
+	if settings.RequiredVersion != "" {
+		if ok, err := allowsSupported(settings.RequiredVersion, engineVersions); err == nil && !ok {
+			add("TF_VERSION_UNSUPPORTED", "Versão do "+engine+" não suportada", "terraform", "",
+				fmt.Sprintf("required_version %q admite versões do %s fora das suportadas (%s)",
+					settings.RequiredVersion, engine, strings.Join(engineVersions, ", ")),
+				fmt.Sprintf("Ajuste required_version para %s", strings.Join(engineVersions, " ou ")))
+		}
+	}
+
+	// Versões dos providers exigidas em required_providers
+	checked := make(map[string]bool)
+	for _, req := range settings.RequiredProviders {
+		constraint, ok := providerConstraint(supported, req.Name)
+		if !ok || req.Version == "" || checked[req.Name+req.Version] {
+			continue
+		}
+		checked[req.Name+req.Version] = true
+
+		if ok, err := allowsSupported(req.Version, []string{constraint}); err == nil && !ok {
+			add("TF_PROVIDER_VERSION_UNSUPPORTED", "Versão de provider não suportada", "provider."+req.Name, req.File,
+				fmt.Sprintf("Provider %s exige %q, fora da versão suportada (%s)", req.Name, req.Version, constraint),
+				fmt.Sprintf("Atualize a restrição do provider %s para %s", req.Name, constraint))
+		}
+	}
+
+	// Versões travadas no lock file
+	for _, locked := range settings.LockedProviders {
+		name := locked.Source[strings.LastIndex(locked.Source, "/")+1:]
+		constraint, ok := providerConstraint(supported, name)
+		if !ok || locked.Version == "" {
+			continue
+		}
+
+		if ok, err := allowsSupported(locked.Version, []string{constraint}); err == nil && !ok {
+			add("TF_PROVIDER_VERSION_UNSUPPORTED", "Versão de provider não suportada", "provider."+name, settings.LockFile,
+				fmt.Sprintf("Lock file trava o provider %s na versão %s, fora da versão suportada (%s)", name, locked.Version, constraint),
+				"Execute terraform init -upgrade após atualizar a restrição do provider")
+		}
+	}
+
+	return findings
+}
+
+// providerConstraint retorna a versão suportada de um provider pelo seu nome local
+func providerConstraint(supported SupportedVersions, name string) (string, bool) {
+	if alias, ok := providerAliases[name]; ok {
+		name = alias
+	}
+	constraint, ok := supported.Providers[name]
+	return constraint, ok
+}
+
+// allowsSupported verifica se a menor versão admitida por uma restrição (ou
+// uma versão exata) satisfaz alguma das restrições suportadas
+func allowsSupported(required string, supported []string) (bool, error) {
+	lower, err := lowerBound(required)
+	if err != nil {
+		return false, err
+	}
+
+	for _, s := range supported {
+		constraints, err := version.NewConstraint(s)
+		if err != nil {
+			continue
+		}
+		if constraints.Check(lower) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// lowerBound calcula a menor versão admitida por uma restrição como
+// ">= 1.5, < 2.0" ou "~> 5.0". Restrições sem limite inferior admitem 0.0.0.
+func lowerBound(constraint string) (*version.Version, error) {
+	lower, _ := version.NewVersion("0.0.0")
+	bounds := []*version.Version{lower}
+
+	for _, part := range strings.Split(constraint, ",") {
+		part = strings.TrimSpace(part)
+		op := strings.TrimRight(part, "0123456789.-+abcdefghijklmnopqrstuvwxyz ")
+		switch strings.TrimSpace(op) {
+		case "", "=", ">=", ">", "~>":
+		default:
+			// "<", "<=" e "!=" não alteram o limite inferior
+			continue
+		}
+
+		v, err := version.NewVersion(strings.TrimSpace(strings.TrimPrefix(part, op)))
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, v)
+	}
+
+	sort.Sort(version.Collection(bounds))
+	return bounds[len(bounds)-1], nil
+}
@@ -33,6 +33,7 @@ func NewWebhookHandler(cfg *config.Config, log *logger.Logger) *WebhookHandler {
 	tfAnalyzer := analyzer.NewTerraformAnalyzer()
 	checkovAnalyzer := analyzer.NewCheckovAnalyzer(log)
 	iamAnalyzer := analyzer.NewIAMAnalyzer(log)
+	iamAnalyzer.SetTrustedAccounts(cfg.Analysis.TrustedAccounts)
 	prScorer := scorer.NewPRScorer()
 	costOptimizer := suggester.NewCostOptimizer(log)
 	securityAdvisor := suggester.NewSecurityAdvisor(log)
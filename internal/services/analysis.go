@@ -1,10 +1,15 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
 	"github.com/govinda777/iac-ai-agent/internal/agent/llm"
 	"github.com/govinda777/iac-ai-agent/internal/models"
 	"github.com/govinda777/iac-ai-agent/internal/platform/cloudcontroller"
@@ -14,16 +19,26 @@ import (
 
 // AnalysisService orquestra análise completa de código IaC
 type AnalysisService struct {
-	tfAnalyzer      TerraformAnalyzerInterface
-	checkovAnalyzer CheckovAnalyzerInterface
-	iamAnalyzer     IAMAnalyzerInterface
-	prScorer        PRScorerInterface
-	costOptimizer   CostOptimizerInterface
-	securityAdvisor SecurityAdvisorInterface
-	llmClient       *llm.Client
-	knowledgeBase   *cloudcontroller.KnowledgeBase
-	logger          *logger.Logger
-	minPassScore    int
+	tfAnalyzer       TerraformAnalyzerInterface
+	checkovAnalyzer  CheckovAnalyzerInterface
+	iamAnalyzer      IAMAnalyzerInterface
+	prScorer         PRScorerInterface
+	costOptimizer    CostOptimizerInterface
+	securityAdvisor  SecurityAdvisorInterface
+	previewAnalyzer  *analyzer.PreviewAnalyzer
+	ruleEngine       *analyzer.RuleEngine
+	regoAnalyzer     *analyzer.RegoAnalyzer
+	knowledgeRules   *analyzer.KnowledgeAnalyzer
+	scannerImporter  *analyzer.ScannerImporter
+	knowledge        *models.AgentKnowledge
+	exceptions       []models.Suppression
+	checkovTimeout   int
+	checkovChecksDir string
+	llmClient        *llm.Client
+	promptBuilder    *llm.PromptBuilder
+	knowledgeBase    *cloudcontroller.KnowledgeBase
+	logger           *logger.Logger
+	minPassScore     int
 }
 
 // NewAnalysisService cria uma nova instância do serviço de análise com injeção de dependência
@@ -44,37 +59,111 @@ func NewAnalysisService(
 	// Inicializa Knowledge Base
 	knowledgeBase := cloudcontroller.NewKnowledgeBase(log)
 
+	// Inicializa análise de plans com a política configurada
+	previewAnalyzer := analyzer.NewPreviewAnalyzer(log)
+	if cfg.Analysis.PlanPolicyFile != "" {
+		policy, err := analyzer.LoadPlanPolicy(cfg.Analysis.PlanPolicyFile)
+		if err != nil {
+			log.Error("Falha ao carregar política de plan", "file", cfg.Analysis.PlanPolicyFile, "error", err)
+		} else {
+			previewAnalyzer.SetPolicy(policy)
+			log.Info("Política de plan carregada", "file", cfg.Analysis.PlanPolicyFile, "rules", len(policy.Rules))
+		}
+	}
+
+	// Inicializa políticas Rego customizadas
+	regoAnalyzer := analyzer.NewRegoAnalyzer(log)
+	if cfg.Analysis.RegoPolicyDir != "" {
+		if err := regoAnalyzer.LoadDirectory(cfg.Analysis.RegoPolicyDir); err != nil {
+			log.Error("Falha ao carregar políticas Rego", "dir", cfg.Analysis.RegoPolicyDir, "error", err)
+		}
+	}
+
+	// Carrega as exceções globais (riscos aceitos)
+	var exceptions []models.Suppression
+	if cfg.Analysis.ExceptionsFile != "" {
+		loaded, err := analyzer.LoadExceptionsFile(cfg.Analysis.ExceptionsFile)
+		if err != nil {
+			log.Error("Falha ao carregar arquivo de exceções", "file", cfg.Analysis.ExceptionsFile, "error", err)
+		} else {
+			exceptions = loaded
+			log.Info("Arquivo de exceções carregado", "file", cfg.Analysis.ExceptionsFile, "exceptions", len(loaded))
+		}
+	}
+
 	return &AnalysisService{
-		tfAnalyzer:      tfAnalyzer,
-		checkovAnalyzer: checkovAnalyzer,
-		iamAnalyzer:     iamAnalyzer,
-		prScorer:        prScorer,
-		costOptimizer:   costOptimizer,
-		securityAdvisor: securityAdvisor,
-		llmClient:       llmClient,
-		knowledgeBase:   knowledgeBase,
-		logger:          log,
-		minPassScore:    minPassScore,
+		tfAnalyzer:       tfAnalyzer,
+		checkovAnalyzer:  checkovAnalyzer,
+		iamAnalyzer:      iamAnalyzer,
+		prScorer:         prScorer,
+		costOptimizer:    costOptimizer,
+		securityAdvisor:  securityAdvisor,
+		previewAnalyzer:  previewAnalyzer,
+		ruleEngine:       analyzer.NewRuleEngine(log),
+		regoAnalyzer:     regoAnalyzer,
+		knowledgeRules:   analyzer.NewKnowledgeAnalyzer(log),
+		scannerImporter:  analyzer.NewScannerImporter(log),
+		exceptions:       exceptions,
+		checkovTimeout:   cfg.Analysis.CheckovTimeout,
+		checkovChecksDir: cfg.Analysis.CheckovExternalChecksDir,
+		llmClient:        llmClient,
+		promptBuilder:    llm.NewPromptBuilder(log),
+		knowledgeBase:    knowledgeBase,
+		logger:           log,
+		minPassScore:     minPassScore,
 	}
 }
 
+// SetKnowledge define o conhecimento do agente (regras customizadas,
+// recursos banidos, tags obrigatórias e módulos preferidos) aplicado às
+// análises que não informam um conhecimento próprio
+func (as *AnalysisService) SetKnowledge(knowledge *models.AgentKnowledge) {
+	as.knowledge = knowledge
+}
+
+// analysisOptions são as opções de uma análise que podem vir da requisição
+type analysisOptions struct {
+	knowledge      *models.AgentKnowledge
+	scannerReports []models.ScannerReport
+}
+
 // Analyze é um wrapper que decide entre AnalyzeContent ou AnalyzeDirectory
 func (as *AnalysisService) Analyze(req *models.AnalysisRequest) (*models.AnalysisResponse, error) {
+	return as.AnalyzeContext(context.Background(), req)
+}
+
+// AnalyzeContext é como Analyze, mas interrompe os scanners externos
+// (Checkov) quando o contexto é cancelado
+func (as *AnalysisService) AnalyzeContext(ctx context.Context, req *models.AnalysisRequest) (*models.AnalysisResponse, error) {
+	opts := analysisOptions{knowledge: as.knowledge, scannerReports: req.ScannerReports}
+	if req.Knowledge != nil {
+		opts.knowledge = req.Knowledge
+	}
+
 	if req.Content != "" {
 		filename := "main.tf"
 		if req.Path != "" {
 			filename = req.Path
 		}
-		return as.AnalyzeContent(req.Content, filename)
+		return as.analyzeContent(ctx, req.Content, filename, opts)
 	}
 	if req.Path != "" {
-		return as.AnalyzeDirectory(req.Path)
+		if req.VarFile != "" {
+			return as.analyzeDirectory(ctx, req.Path, opts, req.VarFile)
+		}
+		return as.analyzeDirectory(ctx, req.Path, opts)
 	}
 	return nil, fmt.Errorf("nenhum conteúdo ou caminho fornecido")
 }
 
 // AnalyzeContent analisa conteúdo Terraform
 func (as *AnalysisService) AnalyzeContent(content string, filename string) (*models.AnalysisResponse, error) {
+	return as.analyzeContent(context.Background(), content, filename, analysisOptions{knowledge: as.knowledge})
+}
+
+// analyzeContent analisa conteúdo Terraform aplicando o conhecimento do
+// agente e os resultados de scanners da requisição
+func (as *AnalysisService) analyzeContent(ctx context.Context, content string, filename string, opts analysisOptions) (*models.AnalysisResponse, error) {
 	as.logger.Info("Iniciando análise de conteúdo", "filename", filename)
 
 	// 1. Análise Terraform
@@ -82,6 +171,7 @@ func (as *AnalysisService) AnalyzeContent(content string, filename string) (*mod
 	if err != nil {
 		return nil, fmt.Errorf("erro na análise Terraform: %w", err)
 	}
+	as.checkSupportedVersions(tfAnalysis)
 
 	// 2. Análise IAM
 	iamAnalysis, err := as.iamAnalyzer.AnalyzeTerraform(tfAnalysis)
@@ -89,16 +179,18 @@ func (as *AnalysisService) AnalyzeContent(content string, filename string) (*mod
 		return nil, fmt.Errorf("erro na análise IAM: %w", err)
 	}
 
-	// 3. Análise de segurança (Checkov é opcional)
-	var securityAnalysis *models.SecurityAnalysis
-	if as.checkovAnalyzer.IsAvailable() {
-		as.logger.Info("Checkov disponível, executando análise de segurança")
-		// Para análise de conteúdo, não executamos Checkov diretamente
-		securityAnalysis = &models.SecurityAnalysis{}
-	} else {
-		as.logger.Warn("Checkov não disponível, pulando análise de segurança")
-		securityAnalysis = &models.SecurityAnalysis{}
+	// 3. Análise de segurança (Checkov em um workspace temporário, com
+	// fallback para as regras nativas)
+	files := map[string]string{filename: content}
+	securityAnalysis, source := as.securityScan(ctx, tfAnalysis, "", files)
+	securityAnalysis, err = as.mergeScannerReports(opts.scannerReports, securityAnalysis, source)
+	if err != nil {
+		return nil, err
 	}
+	as.evaluateRegoPolicies(ctx, tfAnalysis, securityAnalysis, iamAnalysis)
+	analyzer.MergeFindings(securityAnalysis, settingsFindings(tfAnalysis), 0)
+	as.applyKnowledge(opts.knowledge, tfAnalysis, securityAnalysis, files)
+	suppressions := as.applySuppressions("", files, tfAnalysis, securityAnalysis, iamAnalysis)
 
 	// 4. Gera sugestões
 	suggestions := as.generateSuggestions(tfAnalysis, securityAnalysis, iamAnalysis)
@@ -126,22 +218,31 @@ func (as *AnalysisService) AnalyzeContent(content string, filename string) (*mod
 			"score_summary":  as.prScorer.GenerateScoreSummary(score),
 			"recommendation": as.prScorer.GenerateScoreSummary(score),
 		},
-		Timestamp: time.Now(),
+		Timestamp:    time.Now(),
+		Suppressions: suppressions,
 	}
 
 	as.logger.Info("Análise concluída", "score", score.Total, "suggestions", len(suggestions))
 	return response, nil
 }
 
-// AnalyzeDirectory analisa um diretório completo
-func (as *AnalysisService) AnalyzeDirectory(dir string) (*models.AnalysisResponse, error) {
+// AnalyzeDirectory analisa um diretório completo. varFiles são arquivos
+// .tfvars adicionais aplicados sobre os defaults e os tfvars do diretório.
+func (as *AnalysisService) AnalyzeDirectory(dir string, varFiles ...string) (*models.AnalysisResponse, error) {
+	return as.analyzeDirectory(context.Background(), dir, analysisOptions{knowledge: as.knowledge}, varFiles...)
+}
+
+// analyzeDirectory analisa um diretório aplicando o conhecimento do agente
+// e os resultados de scanners da requisição
+func (as *AnalysisService) analyzeDirectory(ctx context.Context, dir string, opts analysisOptions, varFiles ...string) (*models.AnalysisResponse, error) {
 	as.logger.Info("Iniciando análise de diretório", "directory", dir)
 
 	// 1. Análise Terraform
-	tfAnalysis, err := as.tfAnalyzer.AnalyzeDirectory(dir)
+	tfAnalysis, err := as.tfAnalyzer.AnalyzeDirectory(dir, varFiles...)
 	if err != nil {
 		return nil, fmt.Errorf("erro na análise Terraform: %w", err)
 	}
+	as.checkSupportedVersions(tfAnalysis)
 
 	// 2. Análise IAM
 	iamAnalysis, err := as.iamAnalyzer.AnalyzeTerraform(tfAnalysis)
@@ -149,29 +250,20 @@ func (as *AnalysisService) AnalyzeDirectory(dir string) (*models.AnalysisRespons
 		return nil, fmt.Errorf("erro na análise IAM: %w", err)
 	}
 
-	// 3. Análise de segurança (Checkov)
-	var securityAnalysis *models.SecurityAnalysis
-
-	if as.checkovAnalyzer.IsAvailable() {
-		as.logger.Info("Executando análise Checkov")
-		config := &models.CheckovConfig{
-			Directory:     dir,
-			Framework:     "terraform",
-			CompactOutput: true,
-			Quiet:         true,
-		}
-
-		secAnalysis, err := as.checkovAnalyzer.AnalyzeDirectory(dir, config)
-		if err != nil {
-			as.logger.Warn("Erro na análise Checkov", "error", err)
-			securityAnalysis = &models.SecurityAnalysis{}
-		} else {
-			securityAnalysis = secAnalysis
-		}
-	} else {
-		as.logger.Warn("Checkov não disponível")
-		securityAnalysis = &models.SecurityAnalysis{}
+	// 3. Análise de segurança (Checkov, com fallback para as regras nativas)
+	securityAnalysis, source := as.securityScan(ctx, tfAnalysis, dir, nil)
+	securityAnalysis, err = as.mergeScannerReports(opts.scannerReports, securityAnalysis, source)
+	if err != nil {
+		return nil, err
 	}
+	as.evaluateRegoPolicies(ctx, tfAnalysis, securityAnalysis, iamAnalysis)
+	analyzer.MergeFindings(securityAnalysis, settingsFindings(tfAnalysis), 0)
+	files, err := analyzer.ReadTerraformFiles(dir)
+	if err != nil {
+		as.logger.Warn("Erro ao ler arquivos para regras do agente e supressões", "error", err)
+	}
+	as.applyKnowledge(opts.knowledge, tfAnalysis, securityAnalysis, files)
+	suppressions := as.applySuppressions(dir, files, tfAnalysis, securityAnalysis, iamAnalysis)
 
 	// 4. Gera sugestões
 	suggestions := as.generateSuggestions(tfAnalysis, securityAnalysis, iamAnalysis)
@@ -203,17 +295,256 @@ func (as *AnalysisService) AnalyzeDirectory(dir string) (*models.AnalysisRespons
 			"score_summary":  as.prScorer.GenerateScoreSummary(score),
 			"recommendation": as.prScorer.GenerateScoreSummary(score),
 		},
-		Timestamp: time.Now(),
+		Timestamp:    time.Now(),
+		Stacks:       summarizeStacks(tfAnalysis),
+		Suppressions: suppressions,
 	}
 
 	as.logger.Info("Análise de diretório concluída",
 		"score", score.Total,
+		"stacks", len(response.Stacks),
 		"resources", tfAnalysis.TotalResources,
 		"suggestions", len(suggestions))
 
 	return response, nil
 }
 
+// securityScan executa o Checkov no diretório ou, para conteúdo, em um
+// workspace temporário com os arquivos informados. Sem Checkov, ou se ele
+// falhar ou exceder o tempo limite, usa as regras nativas. Retorna também
+// a fonte dos findings (checkov ou native).
+func (as *AnalysisService) securityScan(
+	ctx context.Context,
+	tfAnalysis *models.TerraformAnalysis,
+	dir string,
+	files map[string]string,
+) (*models.SecurityAnalysis, string) {
+	if !as.checkovAnalyzer.IsAvailable() {
+		as.logger.Warn("Checkov não disponível, usando regras nativas")
+		return as.ruleEngine.Analyze(tfAnalysis), "native"
+	}
+
+	as.logger.Info("Executando análise Checkov")
+	config := &models.CheckovConfig{
+		Directory:         dir,
+		Framework:         "terraform",
+		CompactOutput:     true,
+		Quiet:             true,
+		ExternalChecksDir: as.checkovChecksDir,
+		TimeoutSeconds:    as.checkovTimeout,
+	}
+
+	var securityAnalysis *models.SecurityAnalysis
+	var err error
+	if dir != "" {
+		securityAnalysis, err = as.checkovAnalyzer.AnalyzeDirectoryContext(ctx, dir, config)
+	} else {
+		securityAnalysis, err = as.checkovAnalyzer.AnalyzeContent(ctx, files, config)
+	}
+	if err != nil {
+		as.logger.Warn("Erro na análise Checkov, usando regras nativas", "error", err)
+		return as.ruleEngine.Analyze(tfAnalysis), "native"
+	}
+	return securityAnalysis, "checkov"
+}
+
+// mergeScannerReports combina a análise de segurança com os resultados de
+// scanners externos enviados na requisição, unificando os findings
+// duplicados entre as ferramentas
+func (as *AnalysisService) mergeScannerReports(
+	reports []models.ScannerReport,
+	securityAnalysis *models.SecurityAnalysis,
+	source string,
+) (*models.SecurityAnalysis, error) {
+	if len(reports) == 0 {
+		return securityAnalysis, nil
+	}
+
+	imported, err := as.importScannerReports(reports)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzer.TagFindingsSource(securityAnalysis, source)
+	return as.scannerImporter.Merge(append([]*models.SecurityAnalysis{securityAnalysis}, imported...)...), nil
+}
+
+// importScannerReports normaliza os resultados de scanners externos. O
+// Checkov usa o próprio analisador; os demais, o importador de scanners.
+func (as *AnalysisService) importScannerReports(reports []models.ScannerReport) ([]*models.SecurityAnalysis, error) {
+	analyses := make([]*models.SecurityAnalysis, 0, len(reports))
+	for i, report := range reports {
+		if len(report.Output) == 0 {
+			return nil, fmt.Errorf("resultado do scanner %d (%s) está vazio", i, report.Tool)
+		}
+
+		var analysis *models.SecurityAnalysis
+		var err error
+		if strings.EqualFold(report.Tool, "checkov") {
+			analysis, err = as.checkovAnalyzer.ValidateAndParseResult(report.Output)
+			analyzer.TagFindingsSource(analysis, "checkov")
+		} else {
+			analysis, err = as.scannerImporter.Import(report.Tool, report.Output)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("erro ao importar resultado do scanner %d (%s): %w", i, report.Tool, err)
+		}
+		analyses = append(analyses, analysis)
+	}
+	return analyses, nil
+}
+
+// evaluateRegoPolicies avalia as políticas Rego, no contexto da requisição,
+// contra a análise e adiciona os resultados deny/warn e as falhas de
+// avaliação aos findings de segurança
+func (as *AnalysisService) evaluateRegoPolicies(
+	ctx context.Context,
+	tfAnalysis *models.TerraformAnalysis,
+	securityAnalysis *models.SecurityAnalysis,
+	iamAnalysis *models.IAMAnalysis,
+) {
+	if as.regoAnalyzer.PolicyCount() == 0 {
+		return
+	}
+
+	findings, passed, err := as.regoAnalyzer.EvaluateContext(ctx, &models.AnalysisDetails{
+		Terraform: *tfAnalysis,
+		Security:  *securityAnalysis,
+		IAM:       *iamAnalysis,
+	})
+	if err != nil {
+		// Sem avaliação as políticas não podem contar como aprovadas
+		as.logger.Warn("Erro ao avaliar políticas Rego", "error", err)
+		findings = []models.SecurityFinding{{
+			ID:          "REGO_EVALUATION_ERROR-1",
+			CheckID:     "REGO_EVALUATION_ERROR",
+			CheckName:   "Falha ao avaliar políticas Rego",
+			Severity:    "HIGH",
+			Description: fmt.Sprintf("As políticas Rego não puderam ser avaliadas: %v", err),
+		}}
+		passed = 0
+	}
+	analyzer.MergeFindings(securityAnalysis, findings, passed)
+}
+
+// applyKnowledge aplica as regras do agente: os findings entram na análise
+// de segurança e as dicas de módulos preferidos nas best practices, e ambos
+// são refletidos nas sugestões e no score
+func (as *AnalysisService) applyKnowledge(
+	knowledge *models.AgentKnowledge,
+	tfAnalysis *models.TerraformAnalysis,
+	securityAnalysis *models.SecurityAnalysis,
+	files map[string]string,
+) {
+	if knowledge == nil {
+		return
+	}
+
+	result, hints := as.knowledgeRules.Analyze(knowledge, tfAnalysis, files)
+	analyzer.MergeFindings(securityAnalysis, result.Findings, result.ChecksPassed)
+	tfAnalysis.BestPracticeWarnings = append(tfAnalysis.BestPracticeWarnings, hints...)
+}
+
+// applySuppressions aplica as exceções globais, o arquivo de exceções do
+// diretório e os comentários iac-agent:ignore. Findings suprimidos saem da
+// análise (e portanto das sugestões e do score) e vão para o relatório.
+func (as *AnalysisService) applySuppressions(
+	dir string,
+	files map[string]string,
+	tfAnalysis *models.TerraformAnalysis,
+	securityAnalysis *models.SecurityAnalysis,
+	iamAnalysis *models.IAMAnalysis,
+) *models.SuppressionReport {
+	suppressions := append([]models.Suppression{}, as.exceptions...)
+
+	if dir != "" {
+		exceptionsPath := filepath.Join(dir, analyzer.ExceptionsFileName)
+		if _, err := os.Stat(exceptionsPath); err == nil {
+			loaded, err := analyzer.LoadExceptionsFile(exceptionsPath)
+			if err != nil {
+				as.logger.Warn("Erro ao carregar exceções do diretório", "file", exceptionsPath, "error", err)
+			} else {
+				suppressions = append(suppressions, loaded...)
+			}
+		}
+	}
+	suppressions = append(suppressions, analyzer.ParseInlineSuppressions(files, tfAnalysis)...)
+
+	if len(suppressions) == 0 {
+		return nil
+	}
+
+	report := analyzer.ApplySuppressions(suppressions, securityAnalysis, iamAnalysis, time.Now())
+	as.logger.Info("Supressões aplicadas",
+		"suppressed", len(report.Suppressed),
+		"expired", len(report.Expired),
+		"invalid", len(report.Invalid))
+	return report
+}
+
+// checkSupportedVersions adiciona às configurações do Terraform (da análise e
+// de cada stack) os findings de versões fora das suportadas pela plataforma
+func (as *AnalysisService) checkSupportedVersions(tfAnalysis *models.TerraformAnalysis) {
+	for i := range tfAnalysis.Stacks {
+		as.checkSupportedVersions(&tfAnalysis.Stacks[i])
+	}
+	if tfAnalysis.Settings == nil {
+		return
+	}
+	tfAnalysis.Settings.Findings = append(tfAnalysis.Settings.Findings,
+		as.knowledgeBase.CheckSupportedVersions(tfAnalysis.Settings)...)
+}
+
+// settingsFindings retorna os findings das configurações do Terraform da
+// análise e de cada stack, identificando o stack de origem no recurso. Eles
+// entram na análise de segurança antes das supressões e do score.
+func settingsFindings(tfAnalysis *models.TerraformAnalysis) []models.SecurityFinding {
+	findings := []models.SecurityFinding{}
+	if tfAnalysis.Settings != nil {
+		findings = append(findings, tfAnalysis.Settings.Findings...)
+	}
+	for _, stack := range tfAnalysis.Stacks {
+		for _, finding := range settingsFindings(&stack) {
+			finding.Resource = stack.Root + ":" + finding.Resource
+			findings = append(findings, finding)
+		}
+	}
+	return findings
+}
+
+// summarizeStacks monta o resumo de cada stack de uma análise de diretório
+func summarizeStacks(tfAnalysis *models.TerraformAnalysis) []models.StackSummary {
+	stacks := []*models.TerraformAnalysis{}
+	for i := range tfAnalysis.Stacks {
+		stacks = append(stacks, &tfAnalysis.Stacks[i])
+	}
+	if len(stacks) == 0 && tfAnalysis.Root != "" {
+		stacks = append(stacks, tfAnalysis)
+	}
+
+	summaries := []models.StackSummary{}
+	for _, stack := range stacks {
+		summary := models.StackSummary{
+			Root:           stack.Root,
+			Valid:          stack.Valid,
+			TotalResources: stack.TotalResources,
+			TotalModules:   stack.TotalModules,
+			Providers:      stack.Providers,
+			Errors:         len(stack.SyntaxErrors),
+			Warnings:       len(stack.BestPracticeWarnings),
+			ResourceGraph:  stack.ResourceGraph,
+		}
+		if stack.Settings != nil {
+			summary.Findings = len(stack.Settings.Findings)
+			if stack.Settings.Backend != nil {
+				summary.Backend = stack.Settings.Backend.Type
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
 // generateSuggestions gera sugestões baseadas nas análises
 func (as *AnalysisService) generateSuggestions(
 	tfAnalysis *models.TerraformAnalysis,
@@ -252,8 +583,36 @@ func (as *AnalysisService) generateRuleBasedSuggestions(
 		})
 	}
 
+	// Sugestões de versões, providers, lock file e backend. Os findings das
+	// configurações que restaram após as supressões estão na análise de
+	// segurança; os demais vão para o security advisor.
+	settings := make(map[string]bool)
+	for _, finding := range settingsFindings(tfAnalysis) {
+		settings[finding.ID+"|"+finding.Resource] = true
+	}
+	scanned := *securityAnalysis
+	scanned.Findings = []models.SecurityFinding{}
+	for _, finding := range securityAnalysis.Findings {
+		if !settings[finding.ID+"|"+finding.Resource] {
+			scanned.Findings = append(scanned.Findings, finding)
+			continue
+		}
+		suggestionType := "best_practice"
+		if finding.CheckID == "TF_BACKEND_UNENCRYPTED" {
+			suggestionType = "security"
+		}
+		suggestions = append(suggestions, models.Suggestion{
+			Type:           suggestionType,
+			Severity:       strings.ToLower(finding.Severity),
+			Message:        finding.Description,
+			Recommendation: finding.Guideline,
+			File:           finding.File,
+			Resource:       finding.Resource,
+		})
+	}
+
 	// Sugestões de segurança
-	secSuggestions := as.securityAdvisor.GenerateSuggestions(securityAnalysis, iamAnalysis)
+	secSuggestions := as.securityAdvisor.GenerateSuggestions(&scanned, iamAnalysis)
 	suggestions = append(suggestions, secSuggestions...)
 
 	// Sugestões de custo
@@ -452,6 +811,33 @@ func (as *AnalysisService) ValidatePreExistingResults(
 		securityAnalysis = &models.SecurityAnalysis{}
 	}
 
+	return as.validateResults(securityAnalysis, tfAnalysis, "pre_existing_results")
+}
+
+// ValidateScannerResults valida resultados de scanners executados
+// externamente (Checkov, tfsec, Trivy, KICS, Terrascan), combinando os
+// findings das várias ferramentas sem executar nenhuma delas
+func (as *AnalysisService) ValidateScannerResults(
+	reports []models.ScannerReport,
+	tfAnalysis *models.TerraformAnalysis,
+) (*models.AnalysisResponse, error) {
+	as.logger.Info("Validando resultados de scanners (sem execução)", "reports", len(reports))
+
+	imported, err := as.importScannerReports(reports)
+	if err != nil {
+		return nil, err
+	}
+
+	return as.validateResults(as.scannerImporter.Merge(imported...), tfAnalysis, "scanner_reports")
+}
+
+// validateResults monta a resposta de validação a partir da análise de
+// segurança já importada
+func (as *AnalysisService) validateResults(
+	securityAnalysis *models.SecurityAnalysis,
+	tfAnalysis *models.TerraformAnalysis,
+	mode string,
+) (*models.AnalysisResponse, error) {
 	// 2. Valida análise Terraform se fornecida
 	if tfAnalysis == nil {
 		as.logger.Warn("Nenhuma análise Terraform fornecida")
@@ -497,7 +883,7 @@ func (as *AnalysisService) ValidatePreExistingResults(
 			"is_approved":     as.prScorer.ShouldApprove(score, as.minPassScore),
 			"score_level":     as.prScorer.GetScoreLevel(score.Total),
 			"score_summary":   as.prScorer.GenerateScoreSummary(score),
-			"validation_mode": "pre_existing_results",
+			"validation_mode": mode,
 		},
 		Timestamp: time.Now(),
 	}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+// DriftService compara a configuração Terraform com o state aplicado
+type DriftService struct {
+	tfAnalyzer    TerraformAnalyzerInterface
+	stateAnalyzer StateAnalyzerInterface
+	logger        *logger.Logger
+}
+
+// NewDriftService cria uma nova instância do serviço de drift
+func NewDriftService(
+	tfAnalyzer TerraformAnalyzerInterface,
+	stateAnalyzer StateAnalyzerInterface,
+	log *logger.Logger,
+) *DriftService {
+	return &DriftService{
+		tfAnalyzer:    tfAnalyzer,
+		stateAnalyzer: stateAnalyzer,
+		logger:        log,
+	}
+}
+
+// DetectDrift analisa a configuração (path ou content), lê o state (state,
+// <path>/<state_path> ou <path>/<stack>/terraform.tfstate) e compara os dois
+func (ds *DriftService) DetectDrift(req *models.DriftRequest) (*models.DriftResponse, error) {
+	ds.logger.Info("Iniciando detecção de drift", "path", req.Path, "stack", req.Stack)
+
+	// 1. Configuração
+	var config *models.TerraformAnalysis
+	var err error
+	switch {
+	case req.Content != "":
+		config, err = ds.tfAnalyzer.AnalyzeContent(req.Content, "main.tf")
+	case req.Path != "":
+		varFiles := []string{}
+		if req.VarFile != "" {
+			varFiles = append(varFiles, req.VarFile)
+		}
+		config, err = ds.tfAnalyzer.AnalyzeDirectory(req.Path, varFiles...)
+	default:
+		return nil, fmt.Errorf("nenhum conteúdo ou caminho fornecido")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro na análise Terraform: %w", err)
+	}
+
+	// 2. Stack comparado com o state
+	config, err = selectStack(config, req.Stack)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. State
+	data, err := ds.readState(req, config.Root)
+	if err != nil {
+		return nil, err
+	}
+	state, err := ds.stateAnalyzer.ParseState(data)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler state: %w", err)
+	}
+
+	// 4. Comparação
+	response := &models.DriftResponse{
+		ID:        uuid.New().String(),
+		Stack:     config.Root,
+		Drift:     *ds.stateAnalyzer.DetectDrift(config, state),
+		Timestamp: time.Now(),
+	}
+
+	ds.logger.Info("Detecção de drift concluída",
+		"stack", response.Stack,
+		"has_drift", response.Drift.HasDrift)
+
+	return response, nil
+}
+
+// selectStack escolhe o stack informado quando a análise tem vários
+func selectStack(config *models.TerraformAnalysis, stack string) (*models.TerraformAnalysis, error) {
+	if len(config.Stacks) == 0 {
+		if stack != "" && stack != config.Root {
+			return nil, fmt.Errorf("stack %q não encontrado", stack)
+		}
+		return config, nil
+	}
+
+	roots := []string{}
+	for i := range config.Stacks {
+		if config.Stacks[i].Root == stack {
+			return &config.Stacks[i], nil
+		}
+		roots = append(roots, config.Stacks[i].Root)
+	}
+	if stack == "" {
+		return nil, fmt.Errorf("o diretório contém %d stacks; informe stack (%s)", len(roots), strings.Join(roots, ", "))
+	}
+	return nil, fmt.Errorf("stack %q não encontrado (%s)", stack, strings.Join(roots, ", "))
+}
+
+// readState retorna o conteúdo do state informado na requisição. O arquivo
+// de state só é lido dentro do diretório analisado: state_path é relativo a
+// path e não pode sair dele.
+func (ds *DriftService) readState(req *models.DriftRequest, root string) ([]byte, error) {
+	if req.State != "" {
+		return []byte(req.State), nil
+	}
+	if req.Path == "" {
+		return nil, fmt.Errorf("nenhum state fornecido")
+	}
+
+	path := filepath.Join(req.Path, filepath.FromSlash(root), "terraform.tfstate")
+	if req.StatePath != "" {
+		var err error
+		path, err = stateFilePath(req.Path, req.StatePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler state: %w", err)
+	}
+	return data, nil
+}
+
+// stateFilePath resolve state_path dentro do diretório analisado, seguindo
+// links simbólicos, e rejeita caminhos absolutos ou que saiam dele
+func stateFilePath(dir, statePath string) (string, error) {
+	if filepath.IsAbs(statePath) {
+		return "", fmt.Errorf("state_path deve ser relativo ao diretório analisado")
+	}
+
+	base, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return "", fmt.Errorf("erro ao resolver diretório: %w", err)
+	}
+	path, err := filepath.EvalSymlinks(filepath.Join(base, filepath.FromSlash(statePath)))
+	if err != nil {
+		return "", fmt.Errorf("erro ao ler state: %w", err)
+	}
+
+	rel, err := filepath.Rel(base, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("state_path %q está fora do diretório analisado", statePath)
+	}
+	return path, nil
+}
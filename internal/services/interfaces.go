@@ -1,17 +1,28 @@
 package services
 
-import "github.com/govinda777/iac-ai-agent/internal/models"
+import (
+	"context"
+
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
 
 // TerraformAnalyzerInterface defines the interface for a Terraform analyzer.
 type TerraformAnalyzerInterface interface {
-	AnalyzeDirectory(dir string) (*models.TerraformAnalysis, error)
+	AnalyzeDirectory(dir string, varFiles ...string) (*models.TerraformAnalysis, error)
 	AnalyzeContent(content string, filename string) (*models.TerraformAnalysis, error)
 }
 
+// StateAnalyzerInterface defines the interface for a Terraform state analyzer.
+type StateAnalyzerInterface interface {
+	ParseState(data []byte) (*models.TerraformState, error)
+	DetectDrift(config *models.TerraformAnalysis, state *models.TerraformState) *models.DriftAnalysis
+}
+
 // CheckovAnalyzerInterface defines the interface for a Checkov analyzer.
 type CheckovAnalyzerInterface interface {
 	IsAvailable() bool
-	AnalyzeDirectory(dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error)
+	AnalyzeDirectoryContext(ctx context.Context, dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error)
+	AnalyzeContent(ctx context.Context, files map[string]string, config *models.CheckovConfig) (*models.SecurityAnalysis, error)
 	ValidateAndParseResult(jsonResult []byte) (*models.SecurityAnalysis, error)
 }
 
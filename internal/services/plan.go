@@ -0,0 +1,395 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+// planRenderTimeout é o tempo máximo de terraform show -json para plans binários
+const planRenderTimeout = 2 * time.Minute
+
+// riskOrder ordena os níveis de risco do menor para o maior
+var riskOrder = map[string]int{"low": 0, "medium": 1, "high": 2, "critical": 3}
+
+// AnalyzePlan analisa um terraform plan (JSON ou binário), avaliando a
+// política de plan configurada e, se pedido, a revisão do LLM. O contexto
+// limita a conversão de plans binários.
+func (as *AnalysisService) AnalyzePlan(ctx context.Context, req *models.PlanAnalysisRequest) (*models.PreviewAnalysisResponse, error) {
+	as.logger.Info("Iniciando análise de plan", "plan_path", req.PlanPath, "workspace", req.Workspace)
+
+	// 1. Plan em JSON
+	planJSON, err := as.loadPlan(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Análise do plan
+	preview, err := as.previewAnalyzer.AnalyzePreviewWithOptions(planJSON, analyzer.PreviewOptions{Workspace: req.Workspace})
+	if err != nil {
+		return nil, fmt.Errorf("erro na análise do plan: %w", err)
+	}
+
+	// 3. Monta resposta
+	response := buildPreviewResponse(preview, req.Workspace)
+
+	// 4. Revisão do LLM (opcional)
+	if req.LLMReview {
+		if err := as.reviewPlanWithLLM(preview, response); err != nil {
+			as.logger.Warn("Erro na revisão LLM do plan, usando apenas a análise local", "error", err)
+		}
+	}
+
+	as.logger.Info("Análise de plan concluída",
+		"resources_affected", preview.ResourcesAffected,
+		"risk_level", preview.RiskLevel,
+		"recommendation", response.ExecutiveSummary.Recommendation)
+
+	return response, nil
+}
+
+// loadPlan retorna o JSON do plan informado na requisição. Plans binários
+// são convertidos com terraform show -json no diretório da configuração.
+func (as *AnalysisService) loadPlan(ctx context.Context, req *models.PlanAnalysisRequest) ([]byte, error) {
+	if len(req.Plan) > 0 && string(req.Plan) != "null" {
+		// O plan pode vir como objeto JSON ou como string contendo o JSON
+		var text string
+		if err := json.Unmarshal(req.Plan, &text); err == nil {
+			return []byte(text), nil
+		}
+		return req.Plan, nil
+	}
+
+	if req.PlanPath == "" {
+		return nil, fmt.Errorf("nenhum plan fornecido")
+	}
+
+	data, err := os.ReadFile(req.PlanPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler plan: %w", err)
+	}
+	if json.Valid(data) {
+		return data, nil
+	}
+
+	as.logger.Info("Convertendo plan binário", "plan_path", req.PlanPath, "directory", req.Path)
+	return renderBinaryPlan(ctx, req.PlanPath, req.Path)
+}
+
+// renderBinaryPlan executa terraform show -json para um plan binário. O
+// diretório precisa ser o da configuração já inicializada (terraform init).
+func renderBinaryPlan(ctx context.Context, planPath, dir string) ([]byte, error) {
+	absPlan, dir, err := planWorkDir(planPath, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	terraform, err := exec.LookPath("terraform")
+	if err != nil {
+		return nil, fmt.Errorf("plan binário requer o terraform no PATH: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, planRenderTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, terraform, "show", "-json", absPlan)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1", "TF_INPUT=0")
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("erro ao executar terraform show: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return output, nil
+}
+
+// planWorkDir valida o plan binário e o diretório onde terraform show é
+// executado: o diretório precisa ser uma configuração Terraform inicializada
+// e conter o arquivo de plan, de forma que o terraform só carregue providers
+// e módulos da própria configuração. Retorna os caminhos resolvidos.
+func planWorkDir(planPath, dir string) (string, string, error) {
+	absPlan, err := filepath.Abs(planPath)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao resolver caminho do plan: %w", err)
+	}
+	absPlan, err = filepath.EvalSymlinks(absPlan)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao resolver caminho do plan: %w", err)
+	}
+	if info, err := os.Stat(absPlan); err != nil || !info.Mode().IsRegular() {
+		return "", "", fmt.Errorf("plan %s não é um arquivo", planPath)
+	}
+
+	if dir == "" {
+		dir = filepath.Dir(absPlan)
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao resolver diretório do plan: %w", err)
+	}
+	absDir, err = filepath.EvalSymlinks(absDir)
+	if err != nil {
+		return "", "", fmt.Errorf("erro ao resolver diretório do plan: %w", err)
+	}
+	if info, err := os.Stat(absDir); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("%s não é um diretório", dir)
+	}
+
+	rel, err := filepath.Rel(absDir, absPlan)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", "", fmt.Errorf("o plan %s precisa estar dentro do diretório da configuração %s", planPath, dir)
+	}
+
+	configs, _ := filepath.Glob(filepath.Join(absDir, "*.tf"))
+	jsonConfigs, _ := filepath.Glob(filepath.Join(absDir, "*.tf.json"))
+	if len(configs)+len(jsonConfigs) == 0 {
+		return "", "", fmt.Errorf("%s não contém configuração Terraform", dir)
+	}
+	if info, err := os.Stat(filepath.Join(absDir, ".terraform")); err != nil || !info.IsDir() {
+		return "", "", fmt.Errorf("%s não foi inicializado (terraform init)", dir)
+	}
+
+	return absPlan, absDir, nil
+}
+
+// buildPreviewResponse monta a resposta de preview a partir da análise local
+func buildPreviewResponse(preview *models.PreviewAnalysis, workspace string) *models.PreviewAnalysisResponse {
+	response := &models.PreviewAnalysisResponse{
+		LLMStructuredResponse: models.LLMStructuredResponse{
+			ID:              uuid.New().String(),
+			Timestamp:       time.Now(),
+			AnalysisType:    "preview",
+			CriticalIssues:  []models.EnrichedIssue{},
+			Improvements:    []models.EnrichedImprovement{},
+			BestPractices:   []models.BestPracticeCheck{},
+			PriorityActions: []models.PriorityAction{},
+			QuickWins:       []models.QuickWin{},
+		},
+		PreviewDetails: models.PreviewDetails{
+			PlannedChanges:      preview.PlannedChanges,
+			ResourcesAffected:   preview.ResourcesAffected,
+			CreateCount:         preview.CreateCount,
+			UpdateCount:         preview.UpdateCount,
+			DestroyCount:        preview.DestroyCount,
+			ReplaceCount:        preview.ReplaceCount,
+			RiskLevel:           preview.RiskLevel,
+			DangerousOperations: []models.DangerousOperation{},
+			Workspace:           workspace,
+			Policy:              preview.Policy,
+		},
+	}
+
+	// Operações perigosas e principais preocupações
+	concerns := []string{}
+	for _, warning := range preview.RiskWarnings {
+		if warning.Severity != "critical" && warning.Severity != "high" {
+			continue
+		}
+		response.PreviewDetails.DangerousOperations = append(response.PreviewDetails.DangerousOperations, models.DangerousOperation{
+			Resource:    warning.Resource,
+			Action:      warning.Action,
+			Severity:    warning.Severity,
+			Description: warning.Message,
+			Mitigation:  planMitigation(warning),
+		})
+		if len(concerns) < 5 {
+			concerns = append(concerns, fmt.Sprintf("%s: %s", warning.Resource, warning.Message))
+		}
+	}
+
+	// Regras deny da política viram issues críticas e exigem aprovação
+	blocked := preview.Policy != nil && preview.Policy.Blocked
+	if preview.Policy != nil {
+		for _, result := range preview.Policy.Results {
+			if result.Status != "deny" {
+				continue
+			}
+			response.PreviewDetails.RecommendedApprovals = append(response.PreviewDetails.RecommendedApprovals, "policy:"+result.RuleID)
+			for _, resource := range result.Resources {
+				response.CriticalIssues = append(response.CriticalIssues, models.EnrichedIssue{
+					ID:          fmt.Sprintf("%s-%d", result.RuleID, len(response.CriticalIssues)+1),
+					Title:       result.Description,
+					Category:    "policy",
+					Severity:    "critical",
+					Resource:    resource,
+					Description: result.Message,
+				})
+			}
+		}
+	}
+
+	recommendation := "approve"
+	switch {
+	case blocked || preview.RiskLevel == "critical":
+		recommendation = "request_changes"
+	case preview.RiskLevel == "high" || preview.RiskLevel == "medium":
+		recommendation = "needs_review"
+	}
+
+	summary := fmt.Sprintf("Plan com %d recurso(s) afetado(s): %d create, %d update, %d replace, %d destroy. Risco %s.",
+		preview.ResourcesAffected, preview.CreateCount, preview.UpdateCount,
+		preview.ReplaceCount, preview.DestroyCount, preview.RiskLevel)
+	if blocked {
+		summary += " Bloqueado pela política de plan."
+	}
+
+	score, level := planScore(preview.RiskLevel)
+	response.ExecutiveSummary = models.ExecutiveSummary{
+		OverallScore:       score,
+		ScoreLevel:         level,
+		Summary:            summary,
+		MainConcerns:       concerns,
+		KeyStrengths:       []string{},
+		Recommendation:     recommendation,
+		EstimatedRiskLevel: preview.RiskLevel,
+	}
+	response.Metadata = models.ResponseMetadata{
+		RulesApplied: policyRuleCount(preview.Policy),
+	}
+
+	return response
+}
+
+// planMitigation sugere uma mitigação para uma mudança arriscada
+func planMitigation(warning models.RiskWarning) string {
+	if len(warning.BlastRadius) > 0 {
+		return fmt.Sprintf("Valide o impacto nos %d recurso(s) dependente(s) antes do apply", len(warning.BlastRadius))
+	}
+	if strings.Contains(warning.Action, "delete") && strings.Contains(warning.Action, "create") {
+		return "Avalie create_before_destroy ou terraform state mv para evitar a substituição"
+	}
+	if strings.Contains(warning.Action, "delete") {
+		return "Confirme backups e considere lifecycle { prevent_destroy = true }"
+	}
+	return "Aplique em janela de manutenção com plano de rollback"
+}
+
+// planScore converte o nível de risco do plan em score e nível de score
+func planScore(riskLevel string) (int, string) {
+	switch riskLevel {
+	case "critical":
+		return 10, "critical"
+	case "high":
+		return 40, "poor"
+	case "medium":
+		return 70, "fair"
+	default:
+		return 90, "good"
+	}
+}
+
+// policyRuleCount retorna o número de regras avaliadas (as ignoradas pelo
+// workspace não contam)
+func policyRuleCount(policy *models.PlanPolicyEvaluation) int {
+	if policy == nil {
+		return 0
+	}
+	count := 0
+	for _, result := range policy.Results {
+		if result.Status != "skipped" {
+			count++
+		}
+	}
+	return count
+}
+
+// reviewPlanWithLLM pede ao LLM a revisão do plan e incorpora riscos,
+// recomendações e best practices à resposta
+func (as *AnalysisService) reviewPlanWithLLM(preview *models.PreviewAnalysis, response *models.PreviewAnalysisResponse) error {
+	if as.llmClient == nil {
+		return fmt.Errorf("cliente LLM não configurado")
+	}
+
+	llmReq := &models.LLMRequest{
+		SystemPrompt: "Você é um especialista em Infrastructure as Code. Avalie o terraform plan fornecido quanto a riscos, impacto e estratégia de aplicação.",
+		Prompt:       as.promptBuilder.BuildPreviewAnalysisPrompt(preview),
+		MaxTokens:    2000,
+		Temperature:  0.2,
+	}
+
+	var review models.PreviewLLMReview
+	if err := as.llmClient.GenerateStructured(llmReq, &review); err != nil {
+		return fmt.Errorf("erro ao gerar revisão LLM do plan: %w", err)
+	}
+
+	applyPreviewLLMReview(response, &review)
+	return nil
+}
+
+// applyPreviewLLMReview incorpora a revisão do LLM à resposta. O risco
+// estimado só é elevado, nunca reduzido, pela opinião do LLM.
+func applyPreviewLLMReview(response *models.PreviewAnalysisResponse, review *models.PreviewLLMReview) {
+	summary := &response.ExecutiveSummary
+	if risk, ok := riskOrder[review.RiskAssessment.OverallRisk]; ok && risk > riskOrder[summary.EstimatedRiskLevel] {
+		summary.EstimatedRiskLevel = review.RiskAssessment.OverallRisk
+	}
+	if review.RiskAssessment.BlastRadius != "" {
+		summary.Summary += " " + review.RiskAssessment.BlastRadius
+	}
+
+	for i, change := range review.RiskAssessment.HighRiskChanges {
+		issue := models.EnrichedIssue{
+			ID:          fmt.Sprintf("LLM-PLAN-%d", i+1),
+			Title:       fmt.Sprintf("%s de %s", change.Action, change.Resource),
+			Category:    "preview",
+			Severity:    "high",
+			Resource:    change.Resource,
+			Description: change.RiskDetails,
+		}
+		if change.Mitigation != "" {
+			issue.HowToFix.Steps = []string{change.Mitigation}
+		}
+		response.CriticalIssues = append(response.CriticalIssues, issue)
+	}
+
+	actions := []struct{ action, reason string }{
+		{review.Recommendations.ApplyStrategy, "Estratégia de aplicação"},
+		{review.Recommendations.TestingStrategy, "Estratégia de testes"},
+		{review.Recommendations.RollbackPlan, "Plano de rollback"},
+	}
+	for _, a := range actions {
+		if a.action == "" {
+			continue
+		}
+		response.PriorityActions = append(response.PriorityActions, models.PriorityAction{
+			Order:  len(response.PriorityActions) + 1,
+			Action: a.action,
+			Reason: a.reason,
+		})
+	}
+
+	for i, bp := range review.BestPractices {
+		response.BestPractices = append(response.BestPractices, models.BestPracticeCheck{
+			ID:             fmt.Sprintf("PLAN-BP-%d", i+1),
+			Title:          bp.Issue,
+			Category:       "plan",
+			Status:         "warning",
+			Message:        bp.Issue,
+			Recommendation: bp.Recommendation,
+			Priority:       "medium",
+		})
+	}
+
+	response.Metadata.LLMCallCount++
+	if response.Metadata.CustomData == nil {
+		response.Metadata.CustomData = map[string]interface{}{}
+	}
+	if downtime := review.ImpactAnalysis.EstimatedDowntime; downtime != "" {
+		response.Metadata.CustomData["estimated_downtime"] = downtime
+	}
+	if len(review.ImpactAnalysis.AffectedDependencies) > 0 {
+		response.Metadata.CustomData["affected_dependencies"] = review.ImpactAnalysis.AffectedDependencies
+	}
+}
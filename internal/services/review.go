@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -25,6 +26,12 @@ func NewReviewService(analysisService *AnalysisService, log *logger.Logger) *Rev
 
 // ReviewPR realiza review completo de um PR
 func (rs *ReviewService) ReviewPR(request *models.ReviewRequest) (*models.ReviewResponse, error) {
+	return rs.ReviewPRWithContext(context.Background(), request)
+}
+
+// ReviewPRWithContext realiza o review de um PR; o contexto limita a análise
+// do plan anexado
+func (rs *ReviewService) ReviewPRWithContext(ctx context.Context, request *models.ReviewRequest) (*models.ReviewResponse, error) {
 	rs.logger.Info("Iniciando review de PR",
 		"repository", request.Repository,
 		"pr_number", request.PRNumber)
@@ -45,6 +52,14 @@ func (rs *ReviewService) ReviewPR(request *models.ReviewRequest) (*models.Review
 
 	// Simula análise básica
 	// Em produção, buscaria os arquivos alterados do GitHub
+
+	// Plan anexado ao review
+	if request.Plan != nil {
+		if err := rs.attachPlan(ctx, review, request.Plan); err != nil {
+			return nil, err
+		}
+	}
+
 	rs.logger.Info("Review de PR concluído",
 		"pr_number", request.PRNumber,
 		"status", review.Status)
@@ -52,6 +67,45 @@ func (rs *ReviewService) ReviewPR(request *models.ReviewRequest) (*models.Review
 	return review, nil
 }
 
+// attachPlan analisa o plan anexado ao review. Um plan bloqueado pela
+// política ou com risco crítico pede mudanças no PR.
+func (rs *ReviewService) attachPlan(ctx context.Context, review *models.ReviewResponse, plan *models.PlanAnalysisRequest) error {
+	planResponse, err := rs.analysisService.AnalyzePlan(ctx, plan)
+	if err != nil {
+		return fmt.Errorf("erro ao analisar plan do review: %w", err)
+	}
+
+	review.Plan = planResponse
+	review.Summary = planResponse.ExecutiveSummary.Summary
+	switch planResponse.ExecutiveSummary.Recommendation {
+	case "request_changes":
+		review.Status = "changes_requested"
+	case "needs_review":
+		review.Status = "commented"
+	}
+
+	// Operações perigosas viram comentários do review do plan
+	comments := []models.Comment{}
+	for _, op := range planResponse.PreviewDetails.DangerousOperations {
+		comments = append(comments, models.Comment{
+			Path: "plan",
+			Body: fmt.Sprintf("**[%s]** `%s` (%s): %s\n\n%s",
+				op.Severity, op.Resource, op.Action, op.Description, op.Mitigation),
+		})
+	}
+	if len(comments) > 0 {
+		review.FileReviews = append(review.FileReviews, models.FileReview{
+			Filename:    "plan",
+			Status:      "plan",
+			Suggestions: []models.Suggestion{},
+			Comments:    comments,
+		})
+		review.TotalSuggestions += len(comments)
+	}
+
+	return nil
+}
+
 // ReviewFiles realiza review de arquivos específicos
 func (rs *ReviewService) ReviewFiles(files []string) (*models.ReviewResponse, error) {
 	rs.logger.Info("Iniciando review de arquivos", "file_count", len(files))
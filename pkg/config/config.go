@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -51,6 +52,26 @@ type AnalysisConfig struct {
 	CheckovEnabled          bool `yaml:"checkov_enabled"`
 	IAMAnalysisEnabled      bool `yaml:"iam_analysis_enabled"`
 	CostOptimizationEnabled bool `yaml:"cost_optimization_enabled"`
+
+	// CheckovTimeout é o tempo máximo de execução do Checkov, em segundos
+	CheckovTimeout int `yaml:"checkov_timeout"`
+
+	// CheckovExternalChecksDir é um diretório de checks customizados do Checkov
+	CheckovExternalChecksDir string `yaml:"checkov_external_checks_dir"`
+
+	// PlanPolicyFile é o arquivo de política avaliado contra terraform plans
+	PlanPolicyFile string `yaml:"plan_policy_file"`
+
+	// RegoPolicyDir é o diretório de políticas Rego avaliadas em cada análise
+	RegoPolicyDir string `yaml:"rego_policy_dir"`
+
+	// ExceptionsFile é o arquivo de exceções (riscos aceitos) aplicado a todas
+	// as análises, além do .iac-agent-exceptions.yaml de cada diretório
+	ExceptionsFile string `yaml:"exceptions_file"`
+
+	// TrustedAccounts são as contas AWS da organização aceitas como
+	// principais em trust policies sem serem tratadas como acesso externo
+	TrustedAccounts []string `yaml:"trusted_accounts"`
 }
 
 // ScoringConfig configurações de scoring
@@ -141,6 +162,7 @@ func Load(path string) (*Config, error) {
 		},
 		Analysis: AnalysisConfig{
 			CheckovEnabled:          true,
+			CheckovTimeout:          300,
 			IAMAnalysisEnabled:      true,
 			CostOptimizationEnabled: true,
 		},
@@ -224,6 +246,26 @@ func (c *Config) loadFromEnv() {
 	if cost := os.Getenv("COST_OPTIMIZATION_ENABLED"); cost == "false" {
 		c.Analysis.CostOptimizationEnabled = false
 	}
+	if timeout := os.Getenv("CHECKOV_TIMEOUT"); timeout != "" {
+		if seconds, err := strconv.Atoi(timeout); err == nil && seconds > 0 {
+			c.Analysis.CheckovTimeout = seconds
+		}
+	}
+	if checksDir := os.Getenv("CHECKOV_EXTERNAL_CHECKS_DIR"); checksDir != "" {
+		c.Analysis.CheckovExternalChecksDir = checksDir
+	}
+	if policy := os.Getenv("PLAN_POLICY_FILE"); policy != "" {
+		c.Analysis.PlanPolicyFile = policy
+	}
+	if dir := os.Getenv("REGO_POLICY_DIR"); dir != "" {
+		c.Analysis.RegoPolicyDir = dir
+	}
+	if exceptions := os.Getenv("EXCEPTIONS_FILE"); exceptions != "" {
+		c.Analysis.ExceptionsFile = exceptions
+	}
+	if accounts := os.Getenv("IAM_TRUSTED_ACCOUNTS"); accounts != "" {
+		c.Analysis.TrustedAccounts = strings.Split(accounts, ",")
+	}
 
 	// Logging
 	if level := os.Getenv("LOG_LEVEL"); level != "" {
@@ -1,8 +1,10 @@
 package integration_test
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -10,6 +12,7 @@ import (
 	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
 	"github.com/govinda777/iac-ai-agent/internal/agent/scorer"
 	"github.com/govinda777/iac-ai-agent/internal/agent/suggester"
+	"github.com/govinda777/iac-ai-agent/internal/models"
 	"github.com/govinda777/iac-ai-agent/internal/services"
 	"github.com/govinda777/iac-ai-agent/pkg/config"
 	"github.com/govinda777/iac-ai-agent/pkg/logger"
@@ -18,9 +21,10 @@ import (
 
 var _ = Describe("AnalysisService Integration", func() {
 	var (
-		analysisService *services.AnalysisService
-		log             *logger.Logger
-		tempDir         string
+		analysisService     *services.AnalysisService
+		mockCheckovAnalyzer *mocks.MockCheckovAnalyzer
+		log                 *logger.Logger
+		tempDir             string
 	)
 
 	BeforeEach(func() {
@@ -37,7 +41,7 @@ var _ = Describe("AnalysisService Integration", func() {
 
 		// Instantiate concrete analyzers and the mock
 		tfAnalyzer := analyzer.NewTerraformAnalyzer()
-		mockCheckovAnalyzer := &mocks.MockCheckovAnalyzer{}
+		mockCheckovAnalyzer = &mocks.MockCheckovAnalyzer{}
 		iamAnalyzer := analyzer.NewIAMAnalyzer(log)
 		prScorer := scorer.NewPRScorer()
 		costOptimizer := suggester.NewCostOptimizer(log)
@@ -204,6 +208,180 @@ resource "aws_db_instance" "public_db" {
 			})
 		})
 
+		Context("quando analisa conteúdo com recursos inseguros", func() {
+			It("deve retornar findings das regras de segurança nativas", func() {
+				content := `
+resource "aws_db_instance" "public_db" {
+  identifier          = "mydb"
+  allocated_storage   = 20
+  engine              = "postgres"
+  instance_class      = "db.t3.micro"
+  publicly_accessible = true
+}
+`
+				response, err := analysisService.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				security := response.Analysis.Security
+				Expect(security.Critical).To(Equal(1))
+				Expect(security.High).To(Equal(1))
+
+				checkIDs := []string{}
+				for _, finding := range security.Findings {
+					checkIDs = append(checkIDs, finding.CheckID)
+				}
+				Expect(checkIDs).To(ConsistOf("AWS_RDS_PUBLIC", "AWS_RDS_ENCRYPTION"))
+			})
+		})
+
+		Context("quando o Checkov analisa o conteúdo", func() {
+			It("deve usar os findings do Checkov e expor a correção automática", func() {
+				var received map[string]string
+				mockCheckovAnalyzer.AnalyzeContentFunc = func(files map[string]string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
+					received = files
+					return &models.SecurityAnalysis{
+						High:         1,
+						TotalIssues:  1,
+						ChecksFailed: 1,
+						Findings: []models.SecurityFinding{{
+							CheckID:   "CKV_AWS_20",
+							CheckName: "S3 Bucket has an ACL defined which allows public READ access",
+							Severity:  "HIGH",
+							Resource:  "aws_s3_bucket.site",
+							File:      "s3.tf",
+							Line:      2,
+							FixedCode: "acl = \"private\"",
+						}},
+					}, nil
+				}
+				content := `
+resource "aws_s3_bucket" "site" {
+  bucket = "site"
+  acl    = "public-read"
+}
+`
+				response, err := analysisService.AnalyzeContent(content, "s3.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(received).To(HaveKeyWithValue("s3.tf", content))
+				Expect(response.Analysis.Security.Findings).To(HaveLen(1))
+				Expect(response.Analysis.Security.Findings[0].CheckID).To(Equal("CKV_AWS_20"))
+
+				var fix *models.Suggestion
+				for i := range response.Suggestions {
+					if response.Suggestions[i].AutoFixAvailable {
+						fix = &response.Suggestions[i]
+					}
+				}
+				Expect(fix).NotTo(BeNil())
+				Expect(fix.Metadata).To(HaveKeyWithValue("fixed_code", `acl = "private"`))
+			})
+		})
+
+		Context("quando há políticas Rego configuradas", func() {
+			It("deve incluir os resultados das políticas nos findings e no score", func() {
+				content := `
+resource "aws_instance" "web" {
+  ami           = "ami-12345678"
+  instance_type = "t3.micro"
+
+  metadata_options {
+    http_tokens = "required"
+  }
+
+  root_block_device {
+    encrypted = true
+  }
+}
+`
+				baseline, err := analysisService.AnalyzeContent(content, "main.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				cfg := &config.Config{
+					Analysis: config.AnalysisConfig{RegoPolicyDir: "../../configs/policies"},
+				}
+				mockCheckovAnalyzer := &mocks.MockCheckovAnalyzer{}
+				regoService := services.NewAnalysisService(
+					log,
+					70,
+					analyzer.NewTerraformAnalyzer(),
+					mockCheckovAnalyzer,
+					analyzer.NewIAMAnalyzer(log),
+					scorer.NewPRScorer(),
+					suggester.NewCostOptimizer(log),
+					suggester.NewSecurityAdvisor(log),
+					cfg,
+				)
+
+				response, err := regoService.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				security := response.Analysis.Security
+				Expect(security.Findings).To(HaveLen(1))
+				Expect(security.Findings[0].CheckID).To(Equal("ORG_REQUIRED_TAGS"))
+				Expect(security.Findings[0].Resource).To(Equal("aws_instance.web"))
+				Expect(security.Findings[0].Line).To(Equal(2))
+				Expect(security.Medium).To(Equal(1))
+				Expect(response.Score).To(BeNumerically("<", baseline.Score))
+			})
+		})
+
+		Context("quando o agente define regras próprias", func() {
+			It("deve aplicar regras regex, recursos banidos e tags obrigatórias", func() {
+				content := `
+resource "aws_instance" "web" {
+  ami           = "ami-12345678"
+  instance_type = "t3.micro"
+
+  metadata_options {
+    http_tokens = "required"
+  }
+
+  root_block_device {
+    encrypted = true
+  }
+
+  tags = {
+    Environment = "prod"
+  }
+}
+
+resource "aws_iam_user" "deploy" {
+  name = "deploy"
+}
+`
+				baseline, err := analysisService.AnalyzeContent(content, "main.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := analysisService.Analyze(&models.AnalysisRequest{
+					Content: content,
+					Knowledge: &models.AgentKnowledge{
+						CustomRules: []models.CustomRule{
+							{ID: "NO_T3_MICRO", Name: "Tipo de instância", Pattern: `"t3\.micro"`, Severity: "low", Message: "t3.micro não é permitido", Enabled: true},
+						},
+						BannedResources: []string{"aws_iam_user"},
+						RequiredTags:    []string{"Environment", "Owner"},
+					},
+				})
+
+				Expect(err).NotTo(HaveOccurred())
+				checkIDs := map[string]int{}
+				for _, finding := range response.Analysis.Security.Findings {
+					checkIDs[finding.CheckID] = finding.Line
+				}
+				Expect(checkIDs).To(HaveKeyWithValue("NO_T3_MICRO", 4))
+				Expect(checkIDs).To(HaveKeyWithValue("AGENT_BANNED_RESOURCE", 19))
+				Expect(checkIDs).To(HaveKeyWithValue("AGENT_REQUIRED_TAGS", 2))
+				Expect(response.Score).To(BeNumerically("<", baseline.Score))
+
+				messages := []string{}
+				for _, suggestion := range response.Suggestions {
+					messages = append(messages, suggestion.Message)
+				}
+				Expect(messages).To(ContainElement("Tipo de instância"))
+			})
+		})
+
 		Context("quando analisa código com problemas de best practices", func() {
 			It("deve gerar warnings e reduzir score", func() {
 				content := `
@@ -400,6 +578,201 @@ resource "aws_instance" "web" {
 			})
 		})
 
+		Context("quando analisa um monorepo com vários stacks", func() {
+			BeforeEach(func() {
+				stacks := map[string]string{
+					"envs/prod/main.tf": `
+terraform {
+  required_version = ">= 1.6.0"
+
+  backend "s3" {
+    bucket = "state"
+    key    = "prod.tfstate"
+  }
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`,
+					"envs/dev/main.tf": `
+resource "aws_vpc" "main" {
+  cidr_block = "10.1.0.0/16"
+}
+
+resource "aws_subnet" "a" {
+  vpc_id     = aws_vpc.main.id
+  cidr_block = "10.1.1.0/24"
+}
+`,
+				}
+				for name, content := range stacks {
+					path := filepath.Join(tempDir, name)
+					Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+					Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+				}
+			})
+
+			It("deve retornar um resumo por stack e o agregado", func() {
+				response, err := analysisService.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.Analysis.Terraform.TotalResources).To(Equal(3))
+				Expect(response.Stacks).To(ConsistOf(
+					And(HaveField("Root", "envs/dev"), HaveField("TotalResources", 2), HaveField("Backend", "")),
+					And(HaveField("Root", "envs/prod"), HaveField("TotalResources", 1), HaveField("Backend", "s3")),
+				))
+				for _, stack := range response.Stacks {
+					if stack.Root == "envs/dev" {
+						Expect(stack.ResourceGraph).NotTo(BeNil())
+						Expect(stack.ResourceGraph.Edges).To(ContainElement(
+							And(HaveField("From", "aws_subnet.a"), HaveField("To", "aws_vpc.main"))))
+					}
+				}
+				Expect(response.Analysis.Terraform.Resources).To(ContainElement(
+					And(HaveField("Address", "aws_vpc.main"), HaveField("Stack", "envs/prod"))))
+
+				unencrypted := false
+				for _, sugg := range response.Suggestions {
+					if sugg.Resource == "envs/prod:backend.s3" {
+						unencrypted = true
+					}
+				}
+				Expect(unencrypted).To(BeTrue())
+			})
+
+			It("deve serializar cada recurso uma única vez", func() {
+				response, err := analysisService.AnalyzeDirectory(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				data, err := json.Marshal(response)
+				Expect(err).NotTo(HaveOccurred())
+
+				var decoded struct {
+					Analysis struct {
+						Terraform map[string]json.RawMessage `json:"terraform"`
+					} `json:"analysis"`
+					Stacks []map[string]interface{} `json:"stacks"`
+				}
+				Expect(json.Unmarshal(data, &decoded)).To(Succeed())
+				Expect(decoded.Analysis.Terraform).NotTo(HaveKey("stacks"))
+				Expect(strings.Count(string(data), `"address":"aws_subnet.a"`)).To(Equal(1))
+				Expect(decoded.Stacks).To(HaveLen(2))
+				Expect(decoded.Stacks[0]).To(HaveKeyWithValue("errors", BeNumerically("==", 0)))
+			})
+		})
+
+		Context("quando stacks diferentes declaram os mesmos endereços", func() {
+			BeforeEach(func() {
+				stacks := map[string]string{
+					"envs/dev/main.tf": `
+resource "aws_iam_role" "app" {
+  name               = "app-dev"
+  assume_role_policy = "{}"
+}
+
+resource "aws_iam_role_policy_attachment" "admin" {
+  role       = aws_iam_role.app.name
+  policy_arn = "arn:aws:iam::aws:policy/AdministratorAccess"
+}
+`,
+					"envs/prod/main.tf": `
+# iac-agent:ignore IAM_ADMIN_ACCESS reason="Role de break-glass"
+resource "aws_iam_role" "app" {
+  name               = "app-prod"
+  assume_role_policy = "{}"
+}
+`,
+				}
+				for name, content := range stacks {
+					path := filepath.Join(tempDir, name)
+					Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+					Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+				}
+			})
+
+			It("deve analisar IAM e supressões por stack", func() {
+				response, err := analysisService.AnalyzeDirectory(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				iam := response.Analysis.IAM
+				Expect(iam.PrincipalRisks).To(ContainElement(And(
+					HaveField("CheckID", "IAM_ADMIN_ACCESS"),
+					HaveField("Principal", "envs/dev:aws_iam_role.app"),
+				)))
+				Expect(iam.EffectivePermissions).To(ConsistOf(And(
+					HaveField("Principal", "envs/dev:aws_iam_role.app"),
+					HaveField("Policies", ConsistOf("arn:aws:iam::aws:policy/AdministratorAccess")),
+				)))
+
+				// A supressão do stack prod não cobre a role homônima de dev
+				Expect(response.Suppressions).NotTo(BeNil())
+				Expect(response.Suppressions.Suppressed).To(BeEmpty())
+				Expect(response.Suppressions.Active).To(ContainElement(HaveField("Stack", "envs/prod")))
+			})
+		})
+
+		Context("quando o repositório aceita riscos conhecidos", func() {
+			It("deve suprimir findings e relatar exceções expiradas", func() {
+				mainTf := `
+terraform {
+  required_version = ">= 1.6.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+# iac-agent:ignore CKV_AWS_18 reason="Bucket de logs não registra a si mesmo"
+resource "aws_s3_bucket" "example" {
+  bucket = "logs"
+}
+`
+				exceptions := `
+exceptions:
+  - check_id: CKV_AWS_117
+    resource: aws_s3_bucket.example
+    reason: Criptografia gerenciada fora do Terraform
+    until: 2020-01-01
+  - check_id: TF_BACKEND_LOCAL
+    reason: State local apenas no ambiente de testes
+`
+				lock := `
+provider "registry.terraform.io/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.0"
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, ".terraform.lock.hcl"), []byte(lock), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, ".iac-agent-exceptions.yaml"), []byte(exceptions), 0644)).To(Succeed())
+
+				response, err := analysisService.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				security := response.Analysis.Security
+				Expect(security.Findings).To(HaveLen(1))
+				Expect(security.Findings[0].CheckID).To(Equal("CKV_AWS_117"))
+				Expect(security.Medium).To(Equal(0))
+				Expect(security.ChecksFailed).To(Equal(1))
+
+				Expect(response.Suppressions).NotTo(BeNil())
+				Expect(response.Suppressions.Suppressed).To(ConsistOf(
+					HaveField("Finding.CheckID", "CKV_AWS_18"),
+					HaveField("Finding.CheckID", "TF_BACKEND_LOCAL"),
+				))
+				Expect(response.Suppressions.Expired).To(HaveLen(1))
+				Expect(response.Suppressions.Expired[0].Source).To(Equal("exceptions_file"))
+
+				for _, suggestion := range response.Suggestions {
+					Expect(suggestion.Message).NotTo(ContainSubstring("access logging"))
+				}
+			})
+		})
+
 		Context("quando diretório está vazio", func() {
 			It("deve retornar análise sem recursos", func() {
 				response, err := analysisService.AnalyzeDirectory(tempDir)
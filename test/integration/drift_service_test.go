@@ -0,0 +1,130 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/internal/services"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+var _ = Describe("DriftService Integration", func() {
+	var (
+		driftService *services.DriftService
+		tempDir      string
+	)
+
+	BeforeEach(func() {
+		log := logger.New("debug", "text")
+		driftService = services.NewDriftService(
+			analyzer.NewTerraformAnalyzer(),
+			analyzer.NewStateAnalyzer(log),
+			log,
+		)
+
+		var err error
+		tempDir, err = os.MkdirTemp("", "drift-integration-*")
+		Expect(err).NotTo(HaveOccurred())
+
+		files := map[string]string{
+			"envs/prod/main.tf": `
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs-prod"
+}
+`,
+			"envs/prod/terraform.tfstate": `{
+  "version": 4,
+  "terraform_version": "1.7.5",
+  "serial": 7,
+  "resources": [
+    { "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "instances": [ { "attributes": { "bucket": "logs-prod-old" } } ] }
+  ]
+}`,
+			"envs/dev/main.tf": `
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs-dev"
+}
+`,
+		}
+		for name, content := range files {
+			path := filepath.Join(tempDir, name)
+			Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+			Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+		}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Context("quando o diretório tem vários stacks", func() {
+		It("deve exigir o stack a comparar", func() {
+			_, err := driftService.DetectDrift(&models.DriftRequest{Path: tempDir})
+
+			Expect(err).To(MatchError(ContainSubstring("informe stack")))
+		})
+
+		It("deve ler o terraform.tfstate do stack e reportar o drift", func() {
+			response, err := driftService.DetectDrift(&models.DriftRequest{Path: tempDir, Stack: "envs/prod"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Stack).To(Equal("envs/prod"))
+			Expect(response.Drift.StateFormat).To(Equal("tfstate"))
+			Expect(response.Drift.Serial).To(Equal(int64(7)))
+			Expect(response.Drift.HasDrift).To(BeTrue())
+			Expect(response.Drift.Drifted).To(ConsistOf(HaveField("Attributes", ConsistOf(models.AttributeDrift{
+				Path:       "bucket",
+				Configured: "logs-prod",
+				Actual:     "logs-prod-old",
+			}))))
+		})
+	})
+
+	Context("quando state_path é informado", func() {
+		It("deve ler o state relativo ao diretório analisado", func() {
+			response, err := driftService.DetectDrift(&models.DriftRequest{
+				Path:      tempDir,
+				Stack:     "envs/dev",
+				StatePath: "envs/prod/terraform.tfstate",
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Drift.Serial).To(Equal(int64(7)))
+		})
+
+		It("deve rejeitar caminhos fora do diretório analisado", func() {
+			outside, err := os.CreateTemp("", "outside-*.tfstate")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(outside.Name())
+			outside.Close()
+
+			for _, statePath := range []string{outside.Name(), filepath.Join("..", filepath.Base(outside.Name()))} {
+				_, err := driftService.DetectDrift(&models.DriftRequest{
+					Path:      tempDir,
+					Stack:     "envs/prod",
+					StatePath: statePath,
+				})
+				Expect(err).To(MatchError(ContainSubstring("diretório analisado")))
+			}
+		})
+	})
+
+	Context("quando o state é enviado na requisição", func() {
+		It("deve comparar com a configuração inline", func() {
+			response, err := driftService.DetectDrift(&models.DriftRequest{
+				Content: `resource "aws_s3_bucket" "logs" { bucket = "logs" }`,
+				State:   `{"format_version": "1.0"}`,
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Drift.StateFormat).To(Equal("show-json"))
+			Expect(response.Drift.Unapplied).To(Equal([]string{"aws_s3_bucket.logs"}))
+		})
+	})
+})
@@ -0,0 +1,224 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/agent/scorer"
+	"github.com/govinda777/iac-ai-agent/internal/agent/suggester"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/internal/services"
+	"github.com/govinda777/iac-ai-agent/pkg/config"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+	"github.com/govinda777/iac-ai-agent/test/mocks"
+)
+
+const planServiceFixture = `{
+  "format_version": "1.2",
+  "terraform_version": "1.6.0",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.logs",
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": {"bucket": "logs"}
+      }
+    },
+    {
+      "address": "aws_db_instance.main",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": {"engine_version": "13.4"},
+        "after": {"engine_version": "15.2"},
+        "replace_paths": [["engine_version"]]
+      }
+    }
+  ]
+}`
+
+const planServicePolicy = `
+rules:
+  - id: db-replace-approval
+    description: Substituições de banco exigem aprovação
+    effect: deny
+    actions: [replace]
+    resource_types: [aws_db_instance]
+`
+
+var _ = Describe("AnalysisService AnalyzePlan", func() {
+	var (
+		log        *logger.Logger
+		policyFile string
+	)
+
+	newServices := func(policyPath string) (*services.AnalysisService, *services.ReviewService) {
+		cfg := &config.Config{
+			LLM: config.LLMConfig{
+				Provider: "openai",
+				Model:    "gpt-4",
+				APIKey:   "test-key",
+			},
+			Analysis: config.AnalysisConfig{
+				PlanPolicyFile: policyPath,
+			},
+		}
+
+		mockCheckovAnalyzer := &mocks.MockCheckovAnalyzer{}
+		mockCheckovAnalyzer.IsAvailableFunc = func() bool {
+			return true
+		}
+
+		analysisService := services.NewAnalysisService(
+			log,
+			70,
+			analyzer.NewTerraformAnalyzer(),
+			mockCheckovAnalyzer,
+			analyzer.NewIAMAnalyzer(log),
+			scorer.NewPRScorer(),
+			suggester.NewCostOptimizer(log),
+			suggester.NewSecurityAdvisor(log),
+			cfg,
+		)
+		return analysisService, services.NewReviewService(analysisService, log)
+	}
+
+	BeforeEach(func() {
+		log = logger.New("debug", "text")
+
+		dir := GinkgoT().TempDir()
+		policyFile = filepath.Join(dir, "plan-policy.yaml")
+		Expect(os.WriteFile(policyFile, []byte(planServicePolicy), 0644)).To(Succeed())
+	})
+
+	Context("sem política de plan", func() {
+		It("deve analisar o plan informado como objeto JSON", func() {
+			analysisService, _ := newServices("")
+
+			response, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{
+				Plan: json.RawMessage(planServiceFixture),
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.AnalysisType).To(Equal("preview"))
+			Expect(response.PreviewDetails.CreateCount).To(Equal(1))
+			Expect(response.PreviewDetails.ReplaceCount).To(Equal(1))
+			Expect(response.PreviewDetails.Policy).To(BeNil())
+			Expect(response.ExecutiveSummary.Summary).To(ContainSubstring("2 recurso(s) afetado(s)"))
+		})
+
+		It("deve aceitar o plan como string JSON", func() {
+			analysisService, _ := newServices("")
+			encoded, err := json.Marshal(planServiceFixture)
+			Expect(err).NotTo(HaveOccurred())
+
+			response, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{
+				Plan: encoded,
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.PreviewDetails.ResourcesAffected).To(Equal(2))
+		})
+
+		It("deve ler o plan JSON de plan_path", func() {
+			analysisService, _ := newServices("")
+			planPath := filepath.Join(GinkgoT().TempDir(), "plan.json")
+			Expect(os.WriteFile(planPath, []byte(planServiceFixture), 0644)).To(Succeed())
+
+			response, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{
+				PlanPath: planPath,
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.PreviewDetails.ResourcesAffected).To(Equal(2))
+		})
+
+		It("deve recusar plan binário fora do diretório da configuração", func() {
+			analysisService, _ := newServices("")
+			configDir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(configDir, "main.tf"), []byte(`resource "aws_s3_bucket" "logs" {}`), 0644)).To(Succeed())
+			Expect(os.Mkdir(filepath.Join(configDir, ".terraform"), 0755)).To(Succeed())
+			planPath := filepath.Join(GinkgoT().TempDir(), "tfplan")
+			Expect(os.WriteFile(planPath, []byte("PK\x03\x04"), 0644)).To(Succeed())
+
+			_, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{
+				PlanPath: planPath,
+				Path:     configDir,
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("precisa estar dentro do diretório da configuração")))
+		})
+
+		It("deve recusar plan binário em diretório sem configuração inicializada", func() {
+			analysisService, _ := newServices("")
+			planPath := filepath.Join(GinkgoT().TempDir(), "tfplan")
+			Expect(os.WriteFile(planPath, []byte("PK\x03\x04"), 0644)).To(Succeed())
+
+			_, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{
+				PlanPath: planPath,
+			})
+
+			Expect(err).To(MatchError(ContainSubstring("não contém configuração Terraform")))
+		})
+
+		It("deve retornar erro quando nenhum plan é informado", func() {
+			analysisService, _ := newServices("")
+
+			_, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{})
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("com política de plan configurada", func() {
+		It("deve bloquear o plan que viola uma regra deny", func() {
+			analysisService, _ := newServices(policyFile)
+
+			response, err := analysisService.AnalyzePlan(context.Background(), &models.PlanAnalysisRequest{
+				Plan:      json.RawMessage(planServiceFixture),
+				Workspace: "prod",
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.PreviewDetails.Workspace).To(Equal("prod"))
+			Expect(response.PreviewDetails.Policy).NotTo(BeNil())
+			Expect(response.PreviewDetails.Policy.Blocked).To(BeTrue())
+			Expect(response.PreviewDetails.RecommendedApprovals).To(ContainElement("policy:db-replace-approval"))
+			Expect(response.CriticalIssues).NotTo(BeEmpty())
+			Expect(response.CriticalIssues[0].Resource).To(Equal("aws_db_instance.main"))
+			Expect(response.ExecutiveSummary.Recommendation).To(Equal("request_changes"))
+		})
+
+		It("deve pedir mudanças no review do PR com plan bloqueado", func() {
+			_, reviewService := newServices(policyFile)
+
+			review, err := reviewService.ReviewPR(&models.ReviewRequest{
+				Repository: "test-org/test-repo",
+				PRNumber:   42,
+				Plan: &models.PlanAnalysisRequest{
+					Plan: json.RawMessage(planServiceFixture),
+				},
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(review.Plan).NotTo(BeNil())
+			Expect(review.Status).To(Equal("changes_requested"))
+			Expect(review.Summary).To(ContainSubstring("Bloqueado pela política de plan"))
+			Expect(review.FileReviews).To(HaveLen(1))
+			Expect(review.FileReviews[0].Filename).To(Equal("plan"))
+			Expect(review.TotalSuggestions).To(Equal(len(review.FileReviews[0].Comments)))
+		})
+	})
+})
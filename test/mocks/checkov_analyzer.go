@@ -1,6 +1,9 @@
 package mocks
 
 import (
+	"context"
+	"errors"
+
 	"github.com/govinda777/iac-ai-agent/internal/models"
 )
 
@@ -8,6 +11,7 @@ import (
 type MockCheckovAnalyzer struct {
 	IsAvailableFunc            func() bool
 	AnalyzeDirectoryFunc       func(dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error)
+	AnalyzeContentFunc         func(files map[string]string, config *models.CheckovConfig) (*models.SecurityAnalysis, error)
 	ValidateAndParseResultFunc func(jsonResult []byte) (*models.SecurityAnalysis, error)
 }
 
@@ -19,8 +23,8 @@ func (m *MockCheckovAnalyzer) IsAvailable() bool {
 	return true // Default to true for tests
 }
 
-// AnalyzeDirectory mocks the AnalyzeDirectory method.
-func (m *MockCheckovAnalyzer) AnalyzeDirectory(dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
+// AnalyzeDirectoryContext mocks the AnalyzeDirectoryContext method.
+func (m *MockCheckovAnalyzer) AnalyzeDirectoryContext(ctx context.Context, dir string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
 	if m.AnalyzeDirectoryFunc != nil {
 		return m.AnalyzeDirectoryFunc(dir, config)
 	}
@@ -50,6 +54,16 @@ func (m *MockCheckovAnalyzer) AnalyzeDirectory(dir string, config *models.Checko
 	}, nil
 }
 
+// AnalyzeContent mocks the AnalyzeContent method.
+func (m *MockCheckovAnalyzer) AnalyzeContent(ctx context.Context, files map[string]string, config *models.CheckovConfig) (*models.SecurityAnalysis, error) {
+	if m.AnalyzeContentFunc != nil {
+		return m.AnalyzeContentFunc(files, config)
+	}
+	// Default mock behavior: simulates a Checkov failure so content analysis
+	// falls back to the native rules
+	return nil, errors.New("checkov content analysis not mocked")
+}
+
 // ValidateAndParseResult mocks the ValidateAndParseResult method.
 func (m *MockCheckovAnalyzer) ValidateAndParseResult(jsonResult []byte) (*models.SecurityAnalysis, error) {
 	if m.ValidateAndParseResultFunc != nil {
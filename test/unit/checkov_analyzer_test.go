@@ -1,7 +1,11 @@
 package unit_test
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -214,6 +218,132 @@ var _ = Describe("CheckovAnalyzer", func() {
 			})
 		})
 	})
+
+	Describe("Executando o Checkov", func() {
+		var binDir string
+
+		// fakeCheckov instala no PATH um executável checkov com o script informado
+		fakeCheckov := func(script string) *analyzer.CheckovAnalyzer {
+			Expect(os.WriteFile(filepath.Join(binDir, "checkov"), []byte("#!/bin/sh\n"+script), 0755)).To(Succeed())
+			return analyzer.NewCheckovAnalyzer(log)
+		}
+
+		BeforeEach(func() {
+			binDir = GinkgoT().TempDir()
+			GinkgoT().Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		})
+
+		Context("quando analisa conteúdo em memória", func() {
+			It("deve usar um workspace temporário e mapear os arquivos originais", func() {
+				argsFile := filepath.Join(binDir, "args")
+				checkov := fakeCheckov(`echo "$@" > ` + argsFile + `
+cat <<'JSON'
+{"summary": {"passed": 1, "failed": 1}, "results": {"failed_checks": [
+  {"check_id": "CKV_AWS_20", "check_name": "S3 Bucket has an ACL defined which allows public READ access", "resource": "aws_s3_bucket.site",
+   "file_path": "/s3.tf", "file_line_range": [2, 5], "fixed_code": "resource \"aws_s3_bucket\" \"site\" {\n  acl = \"private\"\n}"}
+]}}
+JSON
+exit 1
+`)
+
+				analysis, err := checkov.AnalyzeContent(context.Background(), map[string]string{
+					"infra/s3.tf": `resource "aws_s3_bucket" "site" { acl = "public-read" }`,
+				}, &models.CheckovConfig{Framework: "terraform", ExternalChecksDir: "/checks"})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Findings).To(HaveLen(1))
+				Expect(analysis.Findings[0].File).To(Equal("infra/s3.tf"))
+				Expect(analysis.Findings[0].Line).To(Equal(2))
+				Expect(analysis.Findings[0].FixedCode).To(ContainSubstring(`acl = "private"`))
+
+				args, err := os.ReadFile(argsFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(args)).To(ContainSubstring("--external-checks-dir /checks"))
+				Expect(string(args)).To(ContainSubstring("--framework terraform"))
+
+				suggestions := checkov.GetRecommendations(analysis)
+				Expect(suggestions[0].AutoFixAvailable).To(BeTrue())
+				Expect(suggestions[0].Metadata).To(HaveKey("fixed_code"))
+			})
+		})
+
+		Context("quando arquivos de diretórios diferentes têm o mesmo nome", func() {
+			It("deve manter os diretórios no workspace e atribuir cada finding ao seu arquivo", func() {
+				checkov := fakeCheckov(`dir="$2"
+test -f "$dir/dev/main.tf" && test -f "$dir/prod/main.tf" || exit 3
+cat <<'JSON'
+{"summary": {"passed": 0, "failed": 2}, "results": {"failed_checks": [
+  {"check_id": "CKV_AWS_20", "check_name": "public read", "resource": "aws_s3_bucket.site", "file_path": "/prod/main.tf", "file_line_range": [1, 1]},
+  {"check_id": "CKV_AWS_20", "check_name": "public read", "resource": "aws_s3_bucket.site", "file_path": "/dev/main.tf", "file_line_range": [1, 1]}
+]}}
+JSON
+exit 1
+`)
+
+				analysis, err := checkov.AnalyzeContent(context.Background(), map[string]string{
+					"repo/stacks/dev/main.tf":  `resource "aws_s3_bucket" "site" { acl = "public-read" }`,
+					"repo/stacks/prod/main.tf": `resource "aws_s3_bucket" "site" { acl = "public-read" }`,
+				}, nil)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Findings).To(HaveLen(2))
+				Expect(analysis.Findings[0].File).To(Equal("repo/stacks/prod/main.tf"))
+				Expect(analysis.Findings[1].File).To(Equal("repo/stacks/dev/main.tf"))
+			})
+		})
+
+		Context("quando a saída tem resultados de vários frameworks", func() {
+			It("deve combinar os resultados", func() {
+				checkov := fakeCheckov(`cat <<'JSON'
+[{"check_type": "terraform", "summary": {"passed": 2, "failed": 1}, "results": {"failed_checks": [{"check_id": "CKV_AWS_1", "check_name": "a"}]}},
+ {"check_type": "secrets", "summary": {"passed": 0, "failed": 1}, "results": {"failed_checks": [{"check_id": "CKV_SECRET_2", "check_name": "b"}]}}]
+JSON
+`)
+
+				analysis, err := checkov.AnalyzeDirectory(GinkgoT().TempDir(), nil)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Findings).To(HaveLen(2))
+				Expect(analysis.ChecksPassed).To(Equal(2))
+				Expect(analysis.ChecksFailed).To(Equal(2))
+			})
+		})
+
+		Context("quando o Checkov não responde", func() {
+			It("deve interromper a execução após o timeout", func() {
+				checkov := fakeCheckov("exec sleep 10\n")
+
+				start := time.Now()
+				_, err := checkov.AnalyzeDirectory(GinkgoT().TempDir(), &models.CheckovConfig{TimeoutSeconds: 1})
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("tempo limite"))
+				Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			})
+
+			It("deve respeitar o cancelamento do contexto", func() {
+				checkov := fakeCheckov("exec sleep 10\n")
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				_, err := checkov.AnalyzeDirectoryContext(ctx, GinkgoT().TempDir(), nil)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cancelada"))
+			})
+		})
+
+		Context("quando o Checkov falha sem produzir resultado", func() {
+			It("deve retornar o stderr como erro", func() {
+				checkov := fakeCheckov("echo 'invalid framework' >&2\nexit 2\n")
+
+				_, err := checkov.AnalyzeDirectory(GinkgoT().TempDir(), nil)
+
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid framework"))
+			})
+		})
+	})
 })
 
 // Helper function para testar determinação de severidade
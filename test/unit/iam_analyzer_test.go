@@ -547,4 +547,819 @@ var _ = Describe("IAMAnalyzer", func() {
 			})
 		})
 	})
+	Describe("Avaliando permissões efetivas", func() {
+		analyze := func(content string) *models.IAMAnalysis {
+			tfAnalysis, err := analyzer.NewTerraformAnalyzer().AnalyzeContent(content, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+			analysis, err := iamAnalyzer.AnalyzeTerraform(tfAnalysis)
+			Expect(err).NotTo(HaveOccurred())
+			return analysis
+		}
+
+		risksOf := func(analysis *models.IAMAnalysis, checkID string) []models.PrincipalRisk {
+			risks := []models.PrincipalRisk{}
+			for _, risk := range analysis.PrincipalRisks {
+				if risk.CheckID == checkID {
+					risks = append(risks, risk)
+				}
+			}
+			return risks
+		}
+
+		Context("quando a role combina políticas inline e anexadas", func() {
+			It("deve detectar iam:PassRole + lambda:CreateFunction", func() {
+				analysis := analyze(`
+resource "aws_iam_role" "deployer" {
+  name               = "deployer"
+  assume_role_policy = "{}"
+}
+
+resource "aws_iam_role_policy" "pass" {
+  role = aws_iam_role.deployer.id
+  policy = jsonencode({
+    Version   = "2012-10-17"
+    Statement = [{ Effect = "Allow", Action = "iam:PassRole", Resource = "*" }]
+  })
+}
+
+resource "aws_iam_policy" "lambda" {
+  name = "lambda"
+  policy = jsonencode({
+    Version   = "2012-10-17"
+    Statement = [{ Effect = "Allow", Action = "lambda:Create*", Resource = "*" }]
+  })
+}
+
+resource "aws_iam_role_policy_attachment" "lambda" {
+  role       = aws_iam_role.deployer.name
+  policy_arn = aws_iam_policy.lambda.arn
+}
+`)
+
+				risks := risksOf(analysis, "IAM_PRIVILEGE_ESCALATION")
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].Resource).To(Equal("aws_iam_role.deployer"))
+				Expect(risks[0].Type).To(Equal("role"))
+				Expect(risks[0].RiskLevel).To(Equal("high"))
+				Expect(risks[0].Permissions).To(Equal([]string{"iam:PassRole", "lambda:CreateFunction"}))
+				Expect(risks[0].Reason).To(ContainSubstring("aws_iam_policy.lambda"))
+				Expect(risks[0].Reason).To(ContainSubstring("aws_iam_role_policy.pass"))
+
+				Expect(analysis.EffectivePermissions).To(HaveLen(1))
+				Expect(analysis.EffectivePermissions[0].Principal).To(Equal("aws_iam_role.deployer"))
+				Expect(analysis.EffectivePermissions[0].Actions).To(ContainElements(
+					"iam:PassRole", "lambda:CreateFunction", "lambda:CreateEventSourceMapping"))
+				Expect(analysis.Recommendations).To(ContainElement(ContainSubstring("escalada de privilégio")))
+			})
+		})
+
+		Context("quando um usuário herda permissões do grupo", func() {
+			It("deve detectar iam:CreatePolicyVersion expandido de wildcard", func() {
+				analysis := analyze(`
+resource "aws_iam_user" "ci" {
+  name = "ci"
+}
+
+resource "aws_iam_group" "ops" {
+  name = "ops"
+}
+
+resource "aws_iam_group_policy" "ops" {
+  group  = "ops"
+  policy = jsonencode({ Statement = { Effect = "Allow", Action = "iam:Create*", Resource = "*" } })
+}
+
+resource "aws_iam_user_group_membership" "ci" {
+  user   = aws_iam_user.ci.name
+  groups = [aws_iam_group.ops.name]
+}
+`)
+
+				Expect(analysis.EffectivePermissions).To(HaveLen(2))
+				userRisks := []string{}
+				for _, risk := range risksOf(analysis, "IAM_PRIVILEGE_ESCALATION") {
+					if risk.Resource == "aws_iam_user.ci" {
+						userRisks = append(userRisks, risk.Permissions...)
+					}
+				}
+				Expect(userRisks).To(ConsistOf("iam:CreatePolicyVersion", "iam:CreateAccessKey", "iam:CreateLoginProfile"))
+			})
+		})
+
+		Context("quando um Deny explícito remove permissões", func() {
+			It("deve aplicar a precedência do Deny", func() {
+				analysis := analyze(`
+resource "aws_iam_policy" "guarded" {
+  policy = jsonencode({
+    Statement = [
+      { Effect = "Allow", Action = "*", Resource = "*" },
+      { Effect = "Deny", Action = ["iam:*", "sts:*"], Resource = "*" },
+    ]
+  })
+}
+`)
+
+				Expect(analysis.AdminAccessDetected).To(BeFalse())
+				Expect(risksOf(analysis, "IAM_ADMIN_ACCESS")).To(BeEmpty())
+				permissions := []string{}
+				for _, risk := range risksOf(analysis, "IAM_PRIVILEGE_ESCALATION") {
+					permissions = append(permissions, risk.Permissions...)
+				}
+				Expect(permissions).NotTo(ContainElement(HavePrefix("iam:")))
+				Expect(permissions).To(ContainElement("lambda:UpdateFunctionCode"))
+			})
+
+			It("não deve considerar Deny com condição nem Deny em recursos específicos", func() {
+				analysis := analyze(`
+resource "aws_iam_policy" "partial" {
+  policy = jsonencode({
+    Statement = [
+      { Effect = "Allow", Action = "iam:AttachRolePolicy", Resource = "*" },
+      { Effect = "Deny", Action = "iam:AttachRolePolicy", Resource = "*",
+        Condition = { StringEquals = { "aws:RequestedRegion" = "us-east-1" } } },
+      { Effect = "Deny", Action = "iam:AttachRolePolicy", Resource = "arn:aws:iam::*:role/admin" },
+    ]
+  })
+}
+`)
+
+				risks := risksOf(analysis, "IAM_PRIVILEGE_ESCALATION")
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].RiskLevel).To(Equal("critical"))
+			})
+		})
+
+		Context("quando a política usa NotAction", func() {
+			It("deve conceder o complemento das ações listadas", func() {
+				analysis := analyze(`
+resource "aws_iam_policy" "power_user" {
+  policy = jsonencode({
+    Statement = [{ Effect = "Allow", NotAction = ["iam:*", "organizations:*"], Resource = "*" }]
+  })
+}
+`)
+
+				Expect(analysis.OverlyPermissive).To(BeTrue())
+				Expect(analysis.WildcardActions).To(ContainElement(ContainSubstring("NotAction iam:*")))
+				Expect(analysis.EffectivePermissions[0].Actions).To(ContainElement("ec2:RunInstances"))
+				Expect(analysis.EffectivePermissions[0].Actions).NotTo(ContainElement("iam:PassRole"))
+				Expect(risksOf(analysis, "IAM_PRIVILEGE_ESCALATION")).NotTo(BeEmpty())
+			})
+		})
+
+		Context("quando a permissão é condicionada ou restrita a recursos", func() {
+			It("deve reduzir o nível de risco", func() {
+				analysis := analyze(`
+resource "aws_iam_policy" "scoped" {
+  policy = jsonencode({
+    Statement = [
+      { Effect = "Allow", Action = "iam:PutRolePolicy", Resource = "arn:aws:iam::123456789012:role/app" },
+      { Effect = "Allow", Action = "iam:CreateAccessKey", Resource = "*",
+        Condition = { Bool = { "aws:MultiFactorAuthPresent" = "true" } } },
+    ]
+  })
+}
+`)
+
+				risks := risksOf(analysis, "IAM_PRIVILEGE_ESCALATION")
+				Expect(risks).To(HaveLen(2))
+				for _, risk := range risks {
+					Expect(risk.Type).To(Equal("policy"))
+					Expect(risk.Reason).To(ContainSubstring("limitado por"))
+				}
+				Expect(risks[0].RiskLevel).To(Equal("high"))
+				Expect(risks[1].RiskLevel).To(Equal("medium"))
+			})
+		})
+
+		Context("quando a política concede acesso total", func() {
+			It("deve reportar acesso administrativo em vez de cada caminho", func() {
+				analysis := analyze(`
+resource "aws_iam_user" "admin" {
+  name = "admin"
+}
+
+resource "aws_iam_policy" "admin" {
+  policy = jsonencode({ Statement = [{ Effect = "Allow", Action = "*", Resource = "*" }] })
+}
+
+resource "aws_iam_policy_attachment" "admin" {
+  name       = "admin"
+  users      = [aws_iam_user.admin.name]
+  policy_arn = aws_iam_policy.admin.arn
+}
+`)
+
+				Expect(analysis.AdminAccessDetected).To(BeTrue())
+				Expect(analysis.PrincipalRisks).To(HaveLen(1))
+				Expect(analysis.PrincipalRisks[0].CheckID).To(Equal("IAM_ADMIN_ACCESS"))
+				Expect(analysis.PrincipalRisks[0].Resource).To(Equal("aws_iam_user.admin"))
+				Expect(analysis.EffectivePermissions).To(HaveLen(1))
+				Expect(analysis.EffectivePermissions[0].FullAccess).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("Analisando documentos de política do Terraform", func() {
+		analyze := func(content string) (*models.TerraformAnalysis, *models.IAMAnalysis) {
+			tfAnalysis, err := analyzer.NewTerraformAnalyzer().AnalyzeContent(content, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+			analysis, err := iamAnalyzer.AnalyzeTerraform(tfAnalysis)
+			Expect(err).NotTo(HaveOccurred())
+			return tfAnalysis, analysis
+		}
+
+		Context("quando a política usa data aws_iam_policy_document", func() {
+			It("deve resolver o .json e aplicar as verificações de statement", func() {
+				tfAnalysis, analysis := analyze(`
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+data "aws_iam_policy_document" "base" {
+  statement {
+    sid       = "Read"
+    actions   = ["s3:GetObject"]
+    resources = ["${aws_s3_bucket.logs.arn}/*"]
+  }
+}
+
+data "aws_iam_policy_document" "app" {
+  source_policy_documents = [data.aws_iam_policy_document.base.json]
+
+  statement {
+    sid       = "Pass"
+    actions   = ["iam:PassRole", "lambda:*"]
+    resources = ["*"]
+  }
+}
+
+resource "aws_iam_policy" "app" {
+  name   = "app"
+  policy = data.aws_iam_policy_document.app.json
+}
+`)
+
+				Expect(tfAnalysis.DataSources).To(HaveLen(2))
+				Expect(tfAnalysis.DataSources[1].Address).To(Equal("data.aws_iam_policy_document.app"))
+				Expect(tfAnalysis.DataSources[1].Attributes["json"]).To(ContainSubstring(`"Sid":"Read"`))
+
+				policy, ok := tfAnalysis.Resources[1].Attributes["policy"].(string)
+				Expect(ok).To(BeTrue())
+				Expect(policy).To(ContainSubstring("${aws_s3_bucket.logs.arn}/*"))
+
+				Expect(analysis.TotalPolicies).To(Equal(1))
+				Expect(analysis.OverlyPermissive).To(BeTrue())
+				Expect(analysis.WildcardActions).To(ContainElement("app: lambda:*"))
+				Expect(analysis.EffectivePermissions).To(HaveLen(1))
+				Expect(analysis.EffectivePermissions[0].Actions).To(ContainElements("s3:GetObject", "lambda:CreateFunction"))
+
+				escalation := false
+				for _, risk := range analysis.PrincipalRisks {
+					if risk.CheckID == "IAM_PRIVILEGE_ESCALATION" && risk.Resource == "aws_iam_policy.app" {
+						escalation = true
+					}
+				}
+				Expect(escalation).To(BeTrue())
+			})
+
+			It("deve substituir statements pelo Sid nos documentos de override", func() {
+				tfAnalysis, analysis := analyze(`
+data "aws_iam_policy_document" "restrict" {
+  statement {
+    sid       = "All"
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::bucket/*"]
+  }
+}
+
+data "aws_iam_policy_document" "broad" {
+  override_policy_documents = [data.aws_iam_policy_document.restrict.json]
+
+  statement {
+    sid       = "All"
+    actions   = ["*"]
+    resources = ["*"]
+  }
+}
+
+resource "aws_iam_policy" "broad" {
+  policy = data.aws_iam_policy_document.broad.json
+}
+`)
+
+				Expect(tfAnalysis.Resources[0].Attributes["policy"]).NotTo(ContainSubstring(`"Action":"*"`))
+				Expect(analysis.OverlyPermissive).To(BeFalse())
+				Expect(analysis.AdminAccessDetected).To(BeFalse())
+			})
+
+			It("deve converter principals e conditions na trust policy", func() {
+				tfAnalysis, _ := analyze(`
+data "aws_iam_policy_document" "trust" {
+  statement {
+    actions = ["sts:AssumeRole"]
+
+    principals {
+      type        = "Service"
+      identifiers = ["lambda.amazonaws.com"]
+    }
+
+    condition {
+      test     = "StringEquals"
+      variable = "aws:SourceAccount"
+      values   = ["123456789012"]
+    }
+  }
+}
+
+resource "aws_iam_role" "app" {
+  name               = "app"
+  assume_role_policy = data.aws_iam_policy_document.trust.json
+}
+`)
+
+				trust, ok := tfAnalysis.Resources[0].Attributes["assume_role_policy"].(string)
+				Expect(ok).To(BeTrue())
+				Expect(trust).To(ContainSubstring(`"Principal":{"Service":"lambda.amazonaws.com"}`))
+				Expect(trust).To(ContainSubstring(`"Condition":{"StringEquals":{"aws:SourceAccount":"123456789012"}}`))
+				Expect(trust).To(ContainSubstring(`"Effect":"Allow"`))
+			})
+		})
+
+		Context("quando a role define blocos inline_policy", func() {
+			It("deve analisar as políticas inline da role", func() {
+				_, analysis := analyze(`
+resource "aws_iam_role" "worker" {
+  name               = "worker"
+  assume_role_policy = "{}"
+
+  inline_policy {
+    name = "attach"
+    policy = jsonencode({
+      Statement = [{ Effect = "Allow", Action = "iam:Attach*", Resource = "*" }]
+    })
+  }
+}
+`)
+
+				Expect(analysis.TotalRoles).To(Equal(1))
+				Expect(analysis.TotalPolicies).To(Equal(1))
+				Expect(analysis.WildcardActions).To(ContainElement("worker: iam:Attach*"))
+
+				Expect(analysis.EffectivePermissions).To(HaveLen(1))
+				Expect(analysis.EffectivePermissions[0].Policies).To(ConsistOf(`aws_iam_role.worker.inline_policy["attach"]`))
+				checks := []string{}
+				for _, risk := range analysis.PrincipalRisks {
+					checks = append(checks, risk.CheckID)
+				}
+				Expect(checks).To(ContainElement("IAM_PRIVILEGE_ESCALATION"))
+			})
+		})
+
+		Context("quando a role recebe políticas gerenciadas pela AWS", func() {
+			It("deve detectar AdministratorAccess anexada", func() {
+				_, analysis := analyze(`
+resource "aws_iam_role" "ops" {
+  name               = "ops"
+  assume_role_policy = "{}"
+}
+
+resource "aws_iam_role_policy_attachment" "admin" {
+  role       = aws_iam_role.ops.name
+  policy_arn = "arn:aws:iam::aws:policy/AdministratorAccess"
+}
+`)
+
+				Expect(analysis.AdminAccessDetected).To(BeTrue())
+				Expect(analysis.WildcardActions).To(ContainElement("admin: *"))
+				Expect(analysis.PrincipalRisks).To(HaveLen(1))
+				Expect(analysis.PrincipalRisks[0].CheckID).To(Equal("IAM_ADMIN_ACCESS"))
+				Expect(analysis.PrincipalRisks[0].Resource).To(Equal("aws_iam_role.ops"))
+			})
+
+			It("deve considerar managed_policy_arns e ignorar políticas fora do catálogo", func() {
+				_, analysis := analyze(`
+resource "aws_iam_role" "fn" {
+  name                = "fn"
+  assume_role_policy  = "{}"
+  managed_policy_arns = [
+    "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+    "arn:aws:iam::123456789012:policy/custom",
+  ]
+}
+`)
+
+				Expect(analysis.AdminAccessDetected).To(BeFalse())
+				Expect(analysis.WildcardActions).To(BeEmpty())
+				Expect(analysis.EffectivePermissions).To(HaveLen(1))
+				Expect(analysis.EffectivePermissions[0].Actions).To(ConsistOf(
+					"logs:CreateLogGroup", "logs:CreateLogStream", "logs:PutLogEvents"))
+			})
+		})
+	})
+
+	Describe("Analisando trust policies", func() {
+		trustRisks := func(trustPolicy string) []models.PrincipalRisk {
+			tfAnalysis, err := analyzer.NewTerraformAnalyzer().AnalyzeContent(`
+resource "aws_iam_openid_connect_provider" "github" {
+  url            = "https://token.actions.githubusercontent.com"
+  client_id_list = ["sts.amazonaws.com"]
+}
+
+`+trustPolicy, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+			analysis, err := iamAnalyzer.AnalyzeTerraform(tfAnalysis)
+			Expect(err).NotTo(HaveOccurred())
+			return analysis.PrincipalRisks
+		}
+
+		Context("quando a role confia em outra conta AWS", func() {
+			role := `
+resource "aws_iam_role" "vendor" {
+  name = "vendor"
+  assume_role_policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRole"
+      Principal = { AWS = ["arn:aws:iam::111111111111:root", "222222222222"] }
+    }]
+  })
+}
+`
+
+			It("deve exigir sts:ExternalId de contas fora da lista de contas confiáveis", func() {
+				iamAnalyzer.SetTrustedAccounts([]string{"222222222222"})
+				risks := trustRisks(role)
+
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].CheckID).To(Equal("IAM_TRUST_MISSING_EXTERNAL_ID"))
+				Expect(risks[0].Resource).To(Equal("aws_iam_role.vendor"))
+				Expect(risks[0].Principal).To(Equal("arn:aws:iam::111111111111:root"))
+				Expect(risks[0].Type).To(Equal("cross-account"))
+				Expect(risks[0].RiskLevel).To(Equal("high"))
+				Expect(risks[0].Permissions).To(Equal([]string{"sts:AssumeRole"}))
+			})
+
+			It("deve reduzir o risco quando há sts:ExternalId", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "vendor" {
+  name = "vendor"
+  assume_role_policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRole"
+      Principal = { AWS = "arn:aws:iam::111111111111:role/integration" }
+      Condition = { StringEquals = { "sts:ExternalId" = "a1b2c3" } }
+    }]
+  })
+}
+`)
+
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].CheckID).To(Equal("IAM_TRUST_CROSS_ACCOUNT"))
+				Expect(risks[0].RiskLevel).To(Equal("medium"))
+			})
+
+			It("não deve reportar principais referenciados no próprio código", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "ci" {
+  name = "ci"
+}
+
+data "aws_iam_policy_document" "trust" {
+  statement {
+    actions = ["sts:AssumeRole"]
+    principals {
+      type        = "AWS"
+      identifiers = [aws_iam_role.ci.arn]
+    }
+  }
+}
+
+resource "aws_iam_role" "deploy" {
+  name               = "deploy"
+  assume_role_policy = data.aws_iam_policy_document.trust.json
+}
+`)
+
+				Expect(risks).To(BeEmpty())
+			})
+		})
+
+		Context("quando a role confia no GitHub Actions via OIDC", func() {
+			It("deve reportar a ausência de condição em sub", func() {
+				risks := trustRisks(`
+data "aws_iam_policy_document" "github" {
+  statement {
+    actions = ["sts:AssumeRoleWithWebIdentity"]
+    principals {
+      type        = "Federated"
+      identifiers = [aws_iam_openid_connect_provider.github.arn]
+    }
+    condition {
+      test     = "StringEquals"
+      variable = "token.actions.githubusercontent.com:aud"
+      values   = ["sts.amazonaws.com"]
+    }
+  }
+}
+
+resource "aws_iam_role" "github" {
+  name               = "github"
+  assume_role_policy = data.aws_iam_policy_document.github.json
+}
+`)
+
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].CheckID).To(Equal("IAM_TRUST_OIDC_NO_SUBJECT"))
+				Expect(risks[0].Type).To(Equal("federated"))
+				Expect(risks[0].RiskLevel).To(Equal("critical"))
+				Expect(risks[0].Principal).To(Equal("${aws_iam_openid_connect_provider.github.arn}"))
+				Expect(risks[0].Reason).To(ContainSubstring("GitHub Actions"))
+			})
+
+			It("deve reportar sub que não fixa a organização", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "github" {
+  name = "github"
+  assume_role_policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRoleWithWebIdentity"
+      Principal = { Federated = "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com" }
+      Condition = { StringLike = { "token.actions.githubusercontent.com:sub" = "repo:*" } }
+    }]
+  })
+}
+`)
+
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].CheckID).To(Equal("IAM_TRUST_OIDC_BROAD_SUBJECT"))
+				Expect(risks[0].RiskLevel).To(Equal("high"))
+			})
+
+			It("não deve reportar sub restrito a um repositório", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "github" {
+  name = "github"
+  assume_role_policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRoleWithWebIdentity"
+      Principal = { Federated = "arn:aws:iam::123456789012:oidc-provider/token.actions.githubusercontent.com" }
+      Condition = { StringLike = { "token.actions.githubusercontent.com:sub" = "repo:acme/infra:ref:refs/heads/main" } }
+    }]
+  })
+}
+`)
+
+				Expect(risks).To(BeEmpty())
+			})
+		})
+
+		Context("quando a role confia em qualquer principal", func() {
+			It("deve reportar principal * sem condições como crítico", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "open" {
+  name = "open"
+  assume_role_policy = jsonencode({
+    Statement = [{ Effect = "Allow", Action = "sts:AssumeRole", Principal = "*" }]
+  })
+}
+`)
+
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].CheckID).To(Equal("IAM_TRUST_PUBLIC"))
+				Expect(risks[0].RiskLevel).To(Equal("critical"))
+			})
+
+			It("deve reportar principal * protegido apenas por condições fracas", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "weak" {
+  name = "weak"
+  assume_role_policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRole"
+      Principal = { AWS = "*" }
+      Condition = {
+        IpAddress            = { "aws:SourceIp" = "10.0.0.0/8" }
+        StringEqualsIfExists = { "aws:PrincipalOrgID" = "o-abc123" }
+      }
+    }]
+  })
+}
+`)
+
+				Expect(risks).To(HaveLen(1))
+				Expect(risks[0].CheckID).To(Equal("IAM_TRUST_WILDCARD_WEAK_CONDITION"))
+				Expect(risks[0].RiskLevel).To(Equal("high"))
+				Expect(risks[0].Reason).To(ContainSubstring("aws:SourceIp"))
+			})
+
+			It("não deve reportar principal * restrito à organização", func() {
+				risks := trustRisks(`
+resource "aws_iam_role" "org" {
+  name = "org"
+  assume_role_policy = jsonencode({
+    Statement = [{
+      Effect    = "Allow"
+      Action    = "sts:AssumeRole"
+      Principal = { AWS = "*" }
+      Condition = { StringEquals = { "aws:PrincipalOrgID" = "o-abc123" } }
+    }]
+  })
+}
+`)
+
+				Expect(risks).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Analisando Azure RBAC e GCP IAM", func() {
+		analyze := func(content string) *models.IAMAnalysis {
+			tfAnalysis, err := analyzer.NewTerraformAnalyzer().AnalyzeContent(content, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+			analysis, err := iamAnalyzer.AnalyzeTerraform(tfAnalysis)
+			Expect(err).NotTo(HaveOccurred())
+			return analysis
+		}
+
+		Context("quando papéis do Azure são atribuídos", func() {
+			It("deve reportar Owner e Contributor no escopo de subscription", func() {
+				analysis := analyze(`
+data "azurerm_subscription" "primary" {}
+
+resource "azurerm_user_assigned_identity" "app" {
+  name = "app"
+}
+
+resource "azurerm_role_assignment" "owner" {
+  scope                = data.azurerm_subscription.primary.id
+  role_definition_name = "Owner"
+  principal_id         = azurerm_user_assigned_identity.app.principal_id
+}
+
+resource "azurerm_role_assignment" "contributor" {
+  scope              = "/subscriptions/00000000-0000-0000-0000-000000000000"
+  role_definition_id = "/subscriptions/00000000-0000-0000-0000-000000000000/providers/Microsoft.Authorization/roleDefinitions/b24988ac-6180-42a0-ab88-20f7382dd24c"
+  principal_id       = "11111111-1111-1111-1111-111111111111"
+  principal_type     = "ServicePrincipal"
+}
+
+resource "azurerm_role_assignment" "rg_owner" {
+  scope                = "/subscriptions/00000000-0000-0000-0000-000000000000/resourceGroups/app"
+  role_definition_name = "Owner"
+  principal_id         = "11111111-1111-1111-1111-111111111111"
+}
+`)
+
+				Expect(analysis.TotalRoles).To(Equal(3))
+				Expect(analysis.AdminAccessDetected).To(BeTrue())
+				Expect(analysis.PrincipalRisks).To(HaveLen(2))
+
+				owner := analysis.PrincipalRisks[0]
+				Expect(owner.CheckID).To(Equal("AZURE_PRIVILEGED_ROLE_ASSIGNMENT"))
+				Expect(owner.Resource).To(Equal("azurerm_role_assignment.owner"))
+				Expect(owner.Principal).To(Equal("azurerm_user_assigned_identity.app"))
+				Expect(owner.RiskLevel).To(Equal("critical"))
+				Expect(owner.Reason).To(ContainSubstring("subscription"))
+
+				contributor := analysis.PrincipalRisks[1]
+				Expect(contributor.Permissions).To(Equal([]string{"Contributor"}))
+				Expect(contributor.Type).To(Equal("serviceprincipal"))
+				Expect(contributor.RiskLevel).To(Equal("high"))
+				Expect(analysis.Recommendations).To(ContainElement(ContainSubstring("Azure")))
+			})
+		})
+
+		Context("quando há papéis customizados no Azure", func() {
+			It("deve diferenciar * com e sem gerenciamento de acesso", func() {
+				analysis := analyze(`
+resource "azurerm_role_definition" "god" {
+  name  = "god"
+  scope = "/subscriptions/00000000-0000-0000-0000-000000000000"
+  permissions {
+    actions = ["*"]
+  }
+}
+
+resource "azurerm_role_definition" "almost" {
+  name  = "almost"
+  scope = "/subscriptions/00000000-0000-0000-0000-000000000000"
+  permissions {
+    actions     = ["*"]
+    not_actions = ["Microsoft.Authorization/*/Write"]
+  }
+}
+
+resource "azurerm_role_definition" "reader" {
+  name  = "reader"
+  scope = "/subscriptions/00000000-0000-0000-0000-000000000000"
+  permissions {
+    actions = ["Microsoft.Storage/storageAccounts/read"]
+  }
+}
+`)
+
+				Expect(analysis.TotalPolicies).To(Equal(3))
+				Expect(analysis.WildcardActions).To(ConsistOf("god: *", "almost: *"))
+				Expect(analysis.PrincipalRisks).To(HaveLen(2))
+				Expect(analysis.PrincipalRisks[0].CheckID).To(Equal("AZURE_CUSTOM_ROLE_WILDCARD"))
+				Expect(analysis.PrincipalRisks[0].RiskLevel).To(Equal("critical"))
+				Expect(analysis.PrincipalRisks[1].Principal).To(Equal("almost"))
+				Expect(analysis.PrincipalRisks[1].RiskLevel).To(Equal("high"))
+			})
+		})
+
+		Context("quando bindings do GCP concedem acesso amplo", func() {
+			It("deve reportar papéis básicos e membros públicos", func() {
+				analysis := analyze(`
+resource "google_project_iam_binding" "owners" {
+  project = "acme"
+  role    = "roles/owner"
+  members = ["user:admin@acme.com", "group:devs@acme.com"]
+}
+
+resource "google_project_iam_member" "editor" {
+  project = "acme"
+  role    = "roles/editor"
+  member  = "serviceAccount:ci@acme.iam.gserviceaccount.com"
+}
+
+resource "google_storage_bucket_iam_member" "public" {
+  bucket = "assets"
+  role   = "roles/storage.objectViewer"
+  member = "allUsers"
+}
+
+resource "google_cloud_run_service_iam_binding" "invokers" {
+  service = "api"
+  role    = "roles/run.invoker"
+  members = ["allAuthenticatedUsers"]
+}
+`)
+
+				Expect(analysis.TotalRoles).To(Equal(2))
+				Expect(analysis.AdminAccessDetected).To(BeTrue())
+
+				levels := map[string]string{}
+				for _, risk := range analysis.PrincipalRisks {
+					levels[risk.CheckID+" "+risk.Principal] = risk.RiskLevel
+				}
+				Expect(levels).To(Equal(map[string]string{
+					"GCP_PRIMITIVE_ROLE user:admin@acme.com":                            "critical",
+					"GCP_PRIMITIVE_ROLE group:devs@acme.com":                            "critical",
+					"GCP_PRIMITIVE_ROLE serviceAccount:ci@acme.iam.gserviceaccount.com": "high",
+					"GCP_PUBLIC_MEMBER allUsers":                                        "critical",
+					"GCP_PUBLIC_MEMBER allAuthenticatedUsers":                           "high",
+				}))
+				Expect(analysis.PublicAccess).To(HaveLen(2))
+				Expect(analysis.Recommendations).To(ContainElement(ContainSubstring("GCP")))
+			})
+		})
+
+		Context("quando chaves de service account podem ser criadas", func() {
+			It("deve reportar google_service_account_key, o papel de admin de chaves e papéis customizados", func() {
+				analysis := analyze(`
+resource "google_service_account" "ci" {
+  account_id = "ci"
+}
+
+resource "google_service_account_key" "ci" {
+  service_account_id = google_service_account.ci.name
+}
+
+resource "google_project_iam_member" "keys" {
+  project = "acme"
+  role    = "roles/iam.serviceAccountKeyAdmin"
+  member  = "user:ops@acme.com"
+}
+
+resource "google_project_iam_custom_role" "deployer" {
+  role_id     = "deployer"
+  title       = "Deployer"
+  permissions = ["iam.serviceAccountKeys.create", "resourcemanager.projects.setIamPolicy", "storage.buckets.get"]
+}
+`)
+
+				Expect(analysis.TotalPolicies).To(Equal(1))
+				checks := map[string]models.PrincipalRisk{}
+				for _, risk := range analysis.PrincipalRisks {
+					checks[risk.Resource+" "+risk.CheckID] = risk
+				}
+				Expect(checks).To(HaveLen(4))
+
+				key := checks["google_service_account_key.ci GCP_SERVICE_ACCOUNT_KEY"]
+				Expect(key.Principal).To(Equal("google_service_account.ci"))
+				Expect(key.RiskLevel).To(Equal("high"))
+				Expect(checks).To(HaveKey("google_project_iam_member.keys GCP_SERVICE_ACCOUNT_KEY_CREATION"))
+				Expect(checks["google_project_iam_custom_role.deployer GCP_SERVICE_ACCOUNT_KEY_CREATION"].Permissions).To(
+					Equal([]string{"iam.serviceAccountKeys.create"}))
+				Expect(checks["google_project_iam_custom_role.deployer GCP_CUSTOM_ROLE_SET_IAM_POLICY"].Permissions).To(
+					Equal([]string{"resourcemanager.projects.setIamPolicy"}))
+			})
+		})
+	})
 })
@@ -0,0 +1,200 @@
+package unit_test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+const knowledgeContent = `resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "m5.24xlarge"
+
+  tags = {
+    Environment = "prod"
+  }
+}
+
+resource "aws_iam_user" "deploy" {
+  name = "deploy"
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+module "network" {
+  source = "someone/vpc/aws"
+}
+`
+
+var _ = Describe("KnowledgeAnalyzer", func() {
+	var (
+		knowledgeAnalyzer *analyzer.KnowledgeAnalyzer
+		tfAnalysis        *models.TerraformAnalysis
+		files             map[string]string
+	)
+
+	BeforeEach(func() {
+		log := logger.New("info", "json")
+		knowledgeAnalyzer = analyzer.NewKnowledgeAnalyzer(log)
+
+		var err error
+		tfAnalysis, err = analyzer.NewTerraformAnalyzer().AnalyzeContent(knowledgeContent, "main.tf")
+		Expect(err).NotTo(HaveOccurred())
+		files = map[string]string{"main.tf": knowledgeContent}
+	})
+
+	findingsFor := func(analysis *models.SecurityAnalysis, checkID string) []models.SecurityFinding {
+		findings := []models.SecurityFinding{}
+		for _, finding := range analysis.Findings {
+			if finding.CheckID == checkID {
+				findings = append(findings, finding)
+			}
+		}
+		return findings
+	}
+
+	Context("quando não há conhecimento configurado", func() {
+		It("não deve retornar findings nem dicas", func() {
+			analysis, hints := knowledgeAnalyzer.Analyze(nil, tfAnalysis, files)
+
+			Expect(analysis.Findings).To(BeEmpty())
+			Expect(hints).To(BeEmpty())
+		})
+	})
+
+	Context("quando avalia regras customizadas", func() {
+		It("deve reportar cada match com linha e recurso", func() {
+			knowledge := &models.AgentKnowledge{
+				CustomRules: []models.CustomRule{
+					{
+						ID:         "NO_LARGE_INSTANCES",
+						Name:       "Instâncias grandes",
+						Severity:   "high",
+						Pattern:    `instance_type\s*=\s*"\w+\.\d+xlarge"`,
+						Message:    "Instâncias 24xlarge exigem aprovação",
+						Suggestion: "Use um tipo menor",
+						Enabled:    true,
+					},
+					{ID: "DISABLED", Pattern: "ami", Enabled: false},
+					{ID: "INVALID", Pattern: "(", Enabled: true},
+				},
+			}
+
+			analysis, _ := knowledgeAnalyzer.Analyze(knowledge, tfAnalysis, files)
+
+			Expect(analysis.Findings).To(HaveLen(1))
+			finding := analysis.Findings[0]
+			Expect(finding.CheckID).To(Equal("NO_LARGE_INSTANCES"))
+			Expect(finding.CheckName).To(Equal("Instâncias grandes"))
+			Expect(finding.Severity).To(Equal("HIGH"))
+			Expect(finding.Description).To(Equal("Instâncias 24xlarge exigem aprovação"))
+			Expect(finding.Guideline).To(Equal("Use um tipo menor"))
+			Expect(finding.File).To(Equal("main.tf"))
+			Expect(finding.Line).To(Equal(3))
+			Expect(finding.Resource).To(Equal("aws_instance.web"))
+			Expect(analysis.High).To(Equal(1))
+		})
+
+		It("deve contar regras sem match como aprovadas", func() {
+			knowledge := &models.AgentKnowledge{
+				CustomRules: []models.CustomRule{
+					{ID: "NO_HARDCODED_KEYS", Pattern: "AKIA[0-9A-Z]{16}", Severity: "critical", Enabled: true},
+				},
+			}
+
+			analysis, _ := knowledgeAnalyzer.Analyze(knowledge, tfAnalysis, files)
+
+			Expect(analysis.Findings).To(BeEmpty())
+			Expect(analysis.ChecksPassed).To(Equal(1))
+		})
+	})
+
+	Context("quando há recursos banidos", func() {
+		It("deve reportar os tipos que casam com os padrões", func() {
+			knowledge := &models.AgentKnowledge{BannedResources: []string{"aws_iam_*"}}
+
+			analysis, _ := knowledgeAnalyzer.Analyze(knowledge, tfAnalysis, files)
+
+			banned := findingsFor(analysis, "AGENT_BANNED_RESOURCE")
+			Expect(banned).To(HaveLen(1))
+			Expect(banned[0].Resource).To(Equal("aws_iam_user.deploy"))
+			Expect(banned[0].Line).To(Equal(10))
+			Expect(banned[0].Severity).To(Equal("HIGH"))
+		})
+	})
+
+	Context("quando há tags obrigatórias", func() {
+		It("deve reportar as tags ausentes nos recursos que aceitam tags", func() {
+			knowledge := &models.AgentKnowledge{RequiredTags: []string{"Environment", "Owner"}}
+
+			analysis, _ := knowledgeAnalyzer.Analyze(knowledge, tfAnalysis, files)
+
+			missing := findingsFor(analysis, "AGENT_REQUIRED_TAGS")
+			resources := []string{}
+			for _, finding := range missing {
+				resources = append(resources, finding.Resource)
+			}
+			Expect(resources).To(ConsistOf("aws_instance.web", "aws_vpc.main"))
+			Expect(missing[0].Description).To(ContainSubstring("Owner"))
+			Expect(missing[0].Description).NotTo(ContainSubstring("Environment"))
+			Expect(analysis.Medium).To(Equal(2))
+		})
+	})
+
+	Context("quando um banco RDS não tem tags", func() {
+		It("deve exigir as tags em aws_db_instance", func() {
+			content := `resource "aws_db_instance" "main" {
+  engine = "postgres"
+}
+`
+			dbAnalysis, err := analyzer.NewTerraformAnalyzer().AnalyzeContent(content, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+			knowledge := &models.AgentKnowledge{RequiredTags: []string{"Owner"}}
+
+			analysis, _ := knowledgeAnalyzer.Analyze(knowledge, dbAnalysis, map[string]string{"main.tf": content})
+
+			Expect(findingsFor(analysis, "AGENT_REQUIRED_TAGS")).To(ConsistOf(
+				HaveField("Resource", "aws_db_instance.main")))
+		})
+	})
+
+	Context("quando há módulos preferidos", func() {
+		It("deve sugerir o módulo para recursos e módulos equivalentes", func() {
+			knowledge := &models.AgentKnowledge{PreferredModules: []string{"terraform-aws-modules/vpc/aws"}}
+
+			analysis, hints := knowledgeAnalyzer.Analyze(knowledge, tfAnalysis, files)
+
+			Expect(analysis.Findings).To(BeEmpty())
+			Expect(hints).To(HaveLen(2))
+			Expect(hints[0]).To(ContainSubstring("aws_vpc.main"))
+			Expect(hints[1]).To(ContainSubstring("someone/vpc/aws"))
+		})
+	})
+
+	Context("quando lê os arquivos de um diretório", func() {
+		It("deve ignorar arquivos que não são .tf ou .tf.json e diretórios ignorados", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "main.tf"), []byte(knowledgeContent), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "extra.tf.json"), []byte(`{"resource": {}}`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "terraform.tfvars.json"), []byte(`{}`), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "README.md"), []byte("# docs"), 0644)).To(Succeed())
+			Expect(os.MkdirAll(filepath.Join(dir, ".terraform"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, ".terraform", "cached.tf"), []byte(""), 0644)).To(Succeed())
+
+			files, err := analyzer.ReadTerraformFiles(dir)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(files).To(HaveLen(2))
+			Expect(files).To(HaveKey(filepath.Join(dir, "main.tf")))
+			Expect(files).To(HaveKey(filepath.Join(dir, "extra.tf.json")))
+		})
+	})
+})
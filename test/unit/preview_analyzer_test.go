@@ -0,0 +1,368 @@
+package unit_test
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+var _ = Describe("PreviewAnalyzer", func() {
+	var previewAnalyzer *analyzer.PreviewAnalyzer
+
+	BeforeEach(func() {
+		previewAnalyzer = analyzer.NewPreviewAnalyzer(logger.New("info", "json"))
+	})
+
+	Describe("Comparando before e after", func() {
+		Context("quando um recurso é atualizado", func() {
+			It("deve reportar cada caminho alterado em mapas e listas", func() {
+				analysis, err := previewAnalyzer.AnalyzePreview([]byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_security_group.web",
+      "mode": "managed",
+      "type": "aws_security_group",
+      "name": "web",
+      "change": {
+        "actions": ["update"],
+        "before": {
+          "name": "web",
+          "tags": { "Env": "prod", "Team": "core" },
+          "ingress": [
+            { "from_port": 443, "cidr_blocks": ["10.0.0.0/8"] }
+          ]
+        },
+        "after": {
+          "name": "web",
+          "tags": { "Env": "prod", "Owner": "platform" },
+          "ingress": [
+            { "from_port": 443, "cidr_blocks": ["0.0.0.0/0"] },
+            { "from_port": 80, "cidr_blocks": ["10.0.0.0/8"] }
+          ]
+        },
+        "after_unknown": { "arn": true, "ingress": [{}, {}], "tags": {} },
+        "before_sensitive": {},
+        "after_sensitive": {}
+      }
+    }
+  ]
+}`))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.PlannedChanges).To(HaveLen(1))
+
+				changes := analysis.PlannedChanges[0].Changes
+				Expect(changes).To(Equal(map[string]models.AttributeChange{
+					"arn":                       {Unknown: true},
+					"tags.Team":                 {Before: "core", After: nil},
+					"tags.Owner":                {Before: nil, After: "platform"},
+					"ingress[0].cidr_blocks[0]": {Before: "10.0.0.0/8", After: "0.0.0.0/0"},
+					"ingress[1].from_port":      {Before: nil, After: float64(80)},
+					"ingress[1].cidr_blocks[0]": {Before: nil, After: "10.0.0.0/8"},
+				}))
+			})
+		})
+
+		Context("quando há valores sensíveis e substituição", func() {
+			It("deve omitir valores sensíveis e marcar os caminhos de replace_paths", func() {
+				analysis, err := previewAnalyzer.AnalyzePreview([]byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_db_instance.main",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": { "identifier": "main", "password": "old", "engine_version": "14", "id": "db-1", "token": "a" },
+        "after": { "identifier": "main-v2", "password": "new", "engine_version": "14", "token": "a" },
+        "after_unknown": { "id": true },
+        "before_sensitive": { "password": true, "token": true },
+        "after_sensitive": { "password": true, "token": true },
+        "replace_paths": [["identifier"]]
+      }
+    }
+  ]
+}`))
+				Expect(err).NotTo(HaveOccurred())
+
+				changes := analysis.PlannedChanges[0].Changes
+				Expect(changes).To(Equal(map[string]models.AttributeChange{
+					"identifier": {Before: "main", After: "main-v2", ForcesReplacement: true},
+					"password":   {Sensitive: true},
+					"id":         {Before: "db-1", Unknown: true},
+				}))
+			})
+		})
+
+		Context("quando um recurso é criado", func() {
+			It("deve listar os atributos novos e os conhecidos só após o apply", func() {
+				analysis, err := previewAnalyzer.AnalyzePreview([]byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.logs",
+      "mode": "managed",
+      "type": "aws_s3_bucket",
+      "name": "logs",
+      "change": {
+        "actions": ["create"],
+        "before": null,
+        "after": { "bucket": "logs", "force_destroy": false, "tags": null },
+        "after_unknown": { "id": true, "arn": true }
+      }
+    }
+  ]
+}`))
+				Expect(err).NotTo(HaveOccurred())
+
+				changes := analysis.PlannedChanges[0].Changes
+				Expect(changes).To(HaveLen(4))
+				Expect(changes).To(HaveKeyWithValue("bucket", models.AttributeChange{After: "logs"}))
+				Expect(changes).To(HaveKeyWithValue("force_destroy", models.AttributeChange{After: false}))
+				Expect(changes).To(HaveKeyWithValue("id", models.AttributeChange{Unknown: true}))
+				Expect(changes).To(HaveKeyWithValue("arn", models.AttributeChange{Unknown: true}))
+			})
+		})
+	})
+
+	Describe("Avaliando política de plan", func() {
+		policyYAML := `
+rules:
+  - id: protected-no-destroy
+    description: Recursos protegidos não podem ser destruídos
+    effect: deny
+    actions: [destroy, replace]
+    tags:
+      protected: "true"
+  - id: no-iam-in-prod
+    description: Sem IAM em prod
+    effect: deny
+    resource_types: [aws_iam_*]
+    workspaces: [prod]
+  - id: sg-ingress
+    description: Ingress alterado
+    effect: warn
+    resource_types: [aws_security_group]
+    attributes: [ingress]
+    message: Revise as regras de ingress
+`
+
+		plan := []byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_s3_bucket.state", "mode": "managed", "type": "aws_s3_bucket", "name": "state",
+      "change": {
+        "actions": ["delete"],
+        "before": { "bucket": "state", "tags": { "protected": "true" } },
+        "after": null
+      }
+    },
+    {
+      "address": "aws_iam_role.app", "mode": "managed", "type": "aws_iam_role", "name": "app",
+      "change": { "actions": ["update"], "before": { "name": "app" }, "after": { "name": "app-v2" } }
+    },
+    {
+      "address": "aws_security_group.web", "mode": "managed", "type": "aws_security_group", "name": "web",
+      "change": {
+        "actions": ["update"],
+        "before": { "ingress": [{ "from_port": 443 }] },
+        "after": { "ingress": [{ "from_port": 80 }] }
+      }
+    }
+  ]
+}`)
+
+		It("deve bloquear o plan quando uma regra deny é violada", func() {
+			policy, err := analyzer.ParsePlanPolicy([]byte(policyYAML))
+			Expect(err).NotTo(HaveOccurred())
+			previewAnalyzer.SetPolicy(policy)
+
+			analysis, err := previewAnalyzer.AnalyzePreviewWithOptions(plan, analyzer.PreviewOptions{Workspace: "prod"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(analysis.Policy).NotTo(BeNil())
+			Expect(analysis.Policy.Decision).To(Equal("deny"))
+			Expect(analysis.Policy.Blocked).To(BeTrue())
+			Expect(analysis.Policy.Results).To(Equal([]models.PlanPolicyResult{
+				{RuleID: "protected-no-destroy", Description: "Recursos protegidos não podem ser destruídos", Status: "deny",
+					Message: "Recursos protegidos não podem ser destruídos", Resources: []string{"aws_s3_bucket.state"}},
+				{RuleID: "no-iam-in-prod", Description: "Sem IAM em prod", Status: "deny",
+					Message: "Sem IAM em prod", Resources: []string{"aws_iam_role.app"}},
+				{RuleID: "sg-ingress", Description: "Ingress alterado", Status: "warn",
+					Message: "Revise as regras de ingress", Resources: []string{"aws_security_group.web"}},
+			}))
+			Expect(analysis.Errors).To(HaveLen(2))
+			Expect(analysis.RiskWarnings).To(ContainElement(HaveField("Message", ContainSubstring("protected-no-destroy"))))
+		})
+
+		It("deve ignorar regras restritas a outros workspaces", func() {
+			policy, err := analyzer.ParsePlanPolicy([]byte(policyYAML))
+			Expect(err).NotTo(HaveOccurred())
+			previewAnalyzer.SetPolicy(policy)
+
+			analysis, err := previewAnalyzer.AnalyzePreviewWithOptions(plan, analyzer.PreviewOptions{Workspace: "dev"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(analysis.Policy.Results[1].Status).To(Equal("skipped"))
+			Expect(analysis.Policy.Results[1].Message).To(ContainSubstring("workspace dev"))
+			Expect(analysis.Policy.Results[1].Resources).To(BeEmpty())
+		})
+
+		It("deve avaliar as regras deny de workspace quando o plan não informa workspace", func() {
+			policy, err := analyzer.ParsePlanPolicy([]byte(policyYAML + `
+  - id: sg-ingress-prod
+    description: Ingress alterado em prod
+    effect: warn
+    resource_types: [aws_security_group]
+    workspaces: [prod]
+`))
+			Expect(err).NotTo(HaveOccurred())
+			previewAnalyzer.SetPolicy(policy)
+
+			analysis, err := previewAnalyzer.AnalyzePreviewWithOptions(plan, analyzer.PreviewOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(analysis.Policy.Results[1].Status).To(Equal("deny"))
+			Expect(analysis.Policy.Results[1].Resources).To(Equal([]string{"aws_iam_role.app"}))
+			Expect(analysis.Policy.Results[1].Message).To(ContainSubstring("nenhum workspace foi informado"))
+			Expect(analysis.Policy.Results[3].Status).To(Equal("skipped"))
+			Expect(analysis.Policy.Results[3].Message).To(ContainSubstring("nenhum workspace informado"))
+			Expect(analysis.Policy.Decision).To(Equal("deny"))
+			Expect(analysis.Policy.Blocked).To(BeTrue())
+		})
+
+		It("deve rejeitar políticas inválidas", func() {
+			_, err := analyzer.ParsePlanPolicy([]byte("rules:\n  - id: x\n    effect: block\n"))
+			Expect(err).To(MatchError(ContainSubstring("effect")))
+
+			_, err = analyzer.ParsePlanPolicy([]byte("rules:\n  - id: x\n    effect: warn\n    actions: [recreate]\n"))
+			Expect(err).To(MatchError(ContainSubstring("ação")))
+
+			_, err = analyzer.ParsePlanPolicy([]byte("rules:\n  - id: x\n    effect: warn\n    resource_types: [\"aws_[\"]\n"))
+			Expect(err).To(MatchError(ContainSubstring("padrão")))
+		})
+	})
+
+	Describe("Calculando blast radius", func() {
+		plan := []byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main",
+      "change": {
+        "actions": ["delete", "create"],
+        "before": { "cidr_block": "10.0.0.0/16" },
+        "after": { "cidr_block": "10.1.0.0/16" },
+        "replace_paths": [["cidr_block"]]
+      }
+    },
+    {
+      "address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "change": { "actions": ["delete"], "before": { "bucket": "logs" }, "after": null }
+    }
+  ],
+  "configuration": {
+    "root_module": {
+      "resources": [
+        { "address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main",
+          "expressions": { "cidr_block": { "constant_value": "10.1.0.0/16" } } },
+        { "address": "aws_subnet.a", "mode": "managed", "type": "aws_subnet", "name": "a",
+          "expressions": { "vpc_id": { "references": ["aws_vpc.main.id", "aws_vpc.main"] } } },
+        { "address": "aws_security_group.web", "mode": "managed", "type": "aws_security_group", "name": "web",
+          "expressions": {
+            "vpc_id": { "references": ["aws_vpc.main.id", "aws_vpc.main"] },
+            "ingress": [ { "cidr_blocks": { "constant_value": ["10.0.0.0/8"] } } ]
+          } },
+        { "address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+          "expressions": { "bucket": { "constant_value": "logs" } } }
+      ],
+      "module_calls": {
+        "app": {
+          "source": "./modules/app",
+          "expressions": { "subnet_id": { "references": ["aws_subnet.a.id", "aws_subnet.a"] } },
+          "module": {
+            "resources": [
+              { "address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+                "expressions": { "subnet_id": { "references": ["var.subnet_id"] } } }
+            ],
+            "outputs": {
+              "ip": { "expression": { "references": ["aws_instance.web.private_ip", "aws_instance.web"] } }
+            }
+          }
+        }
+      }
+    }
+  },
+  "prior_state": {
+    "format_version": "1.0",
+    "values": {
+      "root_module": {
+        "resources": [
+          { "address": "aws_route53_record.www", "mode": "managed", "type": "aws_route53_record", "name": "www",
+            "values": {}, "depends_on": ["module.app.aws_instance.web"] }
+        ]
+      }
+    }
+  }
+}`)
+
+		It("deve listar os dependentes transitivos de um replace e elevar o risco", func() {
+			analysis, err := previewAnalyzer.AnalyzePreview(plan)
+			Expect(err).NotTo(HaveOccurred())
+
+			vpc := analysis.PlannedChanges[0]
+			Expect(vpc.Dependents).To(Equal([]string{
+				"aws_route53_record.www",
+				"aws_security_group.web",
+				"aws_subnet.a",
+				"module.app.aws_instance.web",
+			}))
+			Expect(analysis.PlannedChanges[1].Dependents).To(BeEmpty())
+
+			Expect(analysis.RiskWarnings).To(ContainElement(And(
+				HaveField("Resource", "aws_vpc.main"),
+				HaveField("Message", ContainSubstring("4 dependent resource(s)")),
+				HaveField("BlastRadius", vpc.Dependents),
+			)))
+			for _, warning := range analysis.RiskWarnings {
+				if warning.Resource == "aws_vpc.main" {
+					Expect(warning.BlastRadius).To(Equal(vpc.Dependents))
+				}
+			}
+		})
+
+		It("deve considerar o blast radius no nível de risco", func() {
+			vpcReplace := `{ "address": "aws_vpc.main", "mode": "managed", "type": "aws_vpc", "name": "main",
+      "change": { "actions": ["delete", "create"], "before": {}, "after": {} } }`
+
+			isolated, err := previewAnalyzer.AnalyzePreview([]byte(`{"resource_changes": [` + vpcReplace + `]}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(isolated.RiskLevel).To(Equal("low"))
+
+			subnets := []string{}
+			for i := 0; i < 10; i++ {
+				subnets = append(subnets, fmt.Sprintf(`{ "address": "aws_subnet.s%d",
+          "expressions": { "vpc_id": { "references": ["aws_vpc.main.id", "aws_vpc.main"] } } }`, i))
+			}
+			withDependents, err := previewAnalyzer.AnalyzePreview([]byte(`{"resource_changes": [` + vpcReplace + `],
+  "configuration": { "root_module": { "resources": [` + strings.Join(subnets, ",") + `] } } }`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(withDependents.PlannedChanges[0].Dependents).To(HaveLen(10))
+			Expect(withDependents.RiskLevel).To(Equal("high"))
+			Expect(withDependents.RiskWarnings).To(ContainElement(And(
+				HaveField("Severity", "critical"),
+				HaveField("Message", ContainSubstring("10 dependent resource(s)")),
+			)))
+		})
+	})
+})
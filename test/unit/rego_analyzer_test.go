@@ -0,0 +1,234 @@
+package unit_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+const regoPublicBucketPolicy = `# METADATA
+# title: Buckets sem ACL pública
+# related_resources:
+#   - ref: https://example.com/policies/s3
+# custom:
+#   id: ORG_S3_PUBLIC
+#   severity: critical
+#   remediation: Use acl = "private"
+package iac.s3_public
+
+deny contains result if {
+	some resource in input.terraform.resources
+	resource.type == "aws_s3_bucket"
+	resource.attributes.acl == "public-read"
+	result := {"msg": sprintf("%s é público", [resource.address]), "resource": resource.address}
+}
+`
+
+const regoOwnerTagPolicy = `package iac.owner_tag
+
+warn contains sprintf("%s sem tag Owner", [resource.address]) if {
+	some resource in input.terraform.resources
+	not resource.tags.Owner
+}
+`
+
+var _ = Describe("RegoAnalyzer", func() {
+	var (
+		regoAnalyzer *analyzer.RegoAnalyzer
+		details      *models.AnalysisDetails
+	)
+
+	BeforeEach(func() {
+		log := logger.New("info", "json")
+		regoAnalyzer = analyzer.NewRegoAnalyzer(log)
+		details = &models.AnalysisDetails{
+			Terraform: models.TerraformAnalysis{
+				Resources: []models.TerraformResource{
+					{
+						Address:    "aws_s3_bucket.public",
+						Type:       "aws_s3_bucket",
+						Name:       "public",
+						File:       "s3.tf",
+						LineStart:  7,
+						Attributes: map[string]interface{}{"acl": "public-read"},
+					},
+					{
+						Address:    "aws_s3_bucket.private",
+						Type:       "aws_s3_bucket",
+						Name:       "private",
+						File:       "s3.tf",
+						LineStart:  20,
+						Attributes: map[string]interface{}{"acl": "private"},
+						Tags:       map[string]string{"Owner": "platform"},
+					},
+				},
+			},
+		}
+	})
+
+	Context("quando não há políticas carregadas", func() {
+		It("não deve retornar findings", func() {
+			findings, passed, err := regoAnalyzer.Evaluate(details)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings).To(BeEmpty())
+			Expect(passed).To(Equal(0))
+		})
+	})
+
+	Context("quando avalia políticas deny e warn", func() {
+		BeforeEach(func() {
+			Expect(regoAnalyzer.LoadModules(map[string]string{
+				"s3_public.rego": regoPublicBucketPolicy,
+				"owner_tag.rego": regoOwnerTagPolicy,
+			})).To(Succeed())
+		})
+
+		It("deve converter deny em finding com os metadados da política", func() {
+			findings, _, err := regoAnalyzer.Evaluate(details)
+			Expect(err).NotTo(HaveOccurred())
+
+			var deny *models.SecurityFinding
+			for i := range findings {
+				if findings[i].CheckID == "ORG_S3_PUBLIC" {
+					deny = &findings[i]
+				}
+			}
+			Expect(deny).NotTo(BeNil())
+			Expect(deny.CheckName).To(Equal("Buckets sem ACL pública"))
+			Expect(deny.Severity).To(Equal("CRITICAL"))
+			Expect(deny.Description).To(Equal("aws_s3_bucket.public é público"))
+			Expect(deny.Guideline).To(Equal(`Use acl = "private"`))
+			Expect(deny.References).To(ConsistOf("https://example.com/policies/s3"))
+			Expect(deny.Resource).To(Equal("aws_s3_bucket.public"))
+			Expect(deny.File).To(Equal("s3.tf"))
+			Expect(deny.Line).To(Equal(7))
+		})
+
+		It("deve converter warn com severidade padrão e id do pacote", func() {
+			findings, _, err := regoAnalyzer.Evaluate(details)
+			Expect(err).NotTo(HaveOccurred())
+
+			warnings := []models.SecurityFinding{}
+			for _, finding := range findings {
+				if finding.CheckID == "iac.owner_tag" {
+					warnings = append(warnings, finding)
+				}
+			}
+			Expect(warnings).To(HaveLen(1))
+			Expect(warnings[0].Severity).To(Equal("LOW"))
+			Expect(warnings[0].Description).To(Equal("aws_s3_bucket.public sem tag Owner"))
+		})
+
+		It("deve contar políticas sem violações como aprovadas", func() {
+			details.Terraform.Resources = details.Terraform.Resources[1:]
+
+			findings, passed, err := regoAnalyzer.Evaluate(details)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings).To(BeEmpty())
+			Expect(passed).To(Equal(2))
+		})
+
+		It("deve mesclar os findings na análise de segurança", func() {
+			findings, passed, err := regoAnalyzer.Evaluate(details)
+			Expect(err).NotTo(HaveOccurred())
+
+			security := &models.SecurityAnalysis{
+				High:         1,
+				TotalIssues:  1,
+				ChecksFailed: 1,
+				Findings:     []models.SecurityFinding{{CheckID: "AWS_S3_ENCRYPTION", Severity: "HIGH"}},
+			}
+			analyzer.MergeFindings(security, findings, passed)
+
+			Expect(security.TotalIssues).To(Equal(3))
+			Expect(security.Critical).To(Equal(1))
+			Expect(security.High).To(Equal(1))
+			Expect(security.Low).To(Equal(1))
+			Expect(security.ChecksFailed).To(Equal(3))
+		})
+	})
+
+	Context("quando a avaliação de uma política falha", func() {
+		It("deve reportar a falha como finding em vez de aprovar a política", func() {
+			Expect(regoAnalyzer.LoadModules(map[string]string{
+				// Regra completa com valores conflitantes: erro em tempo de avaliação
+				"broken.rego": `package iac.broken
+
+public if {
+	some resource in input.terraform.resources
+	resource.attributes.acl == "public-read"
+}
+
+exposure := "public" if public
+
+exposure := "private" if {
+	some resource in input.terraform.resources
+	resource.attributes.acl == "private"
+}
+
+deny contains exposure
+`,
+			})).To(Succeed())
+
+			findings, passed, err := regoAnalyzer.Evaluate(details)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passed).To(BeZero())
+			Expect(findings).To(ConsistOf(And(
+				HaveField("CheckID", "iac.broken"),
+				HaveField("Severity", "HIGH"),
+				HaveField("Description", ContainSubstring("Falha ao avaliar a política iac.broken")),
+			)))
+		})
+
+		It("deve respeitar o cancelamento do contexto da requisição", func() {
+			Expect(regoAnalyzer.LoadModules(map[string]string{"s3_public.rego": regoPublicBucketPolicy})).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			findings, passed, err := regoAnalyzer.EvaluateContext(ctx, details)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(passed).To(BeZero())
+			Expect(findings).To(ConsistOf(HaveField("Description", ContainSubstring("Falha ao avaliar"))))
+		})
+	})
+
+	Context("quando carrega políticas de um diretório", func() {
+		It("deve ignorar testes Rego e compilar as políticas", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "s3_public.rego"), []byte(regoPublicBucketPolicy), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "s3_public_test.rego"), []byte("package iac.s3_public_test\n\ntest_x if true\n"), 0644)).To(Succeed())
+
+			Expect(regoAnalyzer.LoadDirectory(dir)).To(Succeed())
+			Expect(regoAnalyzer.PolicyCount()).To(Equal(1))
+		})
+
+		It("deve retornar erro para políticas inválidas", func() {
+			dir := GinkgoT().TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, "broken.rego"), []byte("package iac.broken\n\ndeny contains x if {\n"), 0644)).To(Succeed())
+
+			Expect(regoAnalyzer.LoadDirectory(dir)).To(HaveOccurred())
+		})
+
+		It("deve carregar as políticas de exemplo do repositório", func() {
+			Expect(regoAnalyzer.LoadDirectory("../../configs/policies")).To(Succeed())
+			Expect(regoAnalyzer.PolicyCount()).To(Equal(2))
+
+			findings, _, err := regoAnalyzer.Evaluate(details)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(findings).To(HaveLen(2))
+			Expect(findings[0].CheckID).To(Equal("ORG_REQUIRED_TAGS"))
+			Expect(findings[0].Severity).To(Equal("MEDIUM"))
+		})
+	})
+})
@@ -0,0 +1,293 @@
+package unit_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+const tfsecOutput = `{"results": [
+  {"rule_id": "AVD-AWS-0088", "long_id": "aws-s3-enable-bucket-encryption", "rule_description": "Unencrypted S3 bucket.",
+   "description": "Bucket does not have encryption enabled", "resolution": "Configure bucket encryption",
+   "links": ["https://aquasecurity.github.io/tfsec/latest/checks/aws/s3/enable-bucket-encryption/"],
+   "severity": "HIGH", "status": 0, "resource": "aws_s3_bucket.logs",
+   "location": {"filename": "/work/infra/main.tf", "start_line": 1, "end_line": 3}},
+  {"rule_id": "AVD-AWS-0090", "long_id": "aws-s3-enable-versioning", "severity": "MEDIUM", "status": 1,
+   "resource": "aws_s3_bucket.logs", "location": {"filename": "/work/infra/main.tf", "start_line": 1}},
+  {"rule_id": "AVD-AWS-0089", "long_id": "aws-s3-enable-bucket-logging", "severity": "LOW", "status": 2,
+   "resource": "aws_s3_bucket.logs", "location": {"filename": "/work/infra/main.tf", "start_line": 1}}
+]}`
+
+const trivyOutput = `{"SchemaVersion": 2, "Results": [
+  {"Target": "main.tf", "Class": "config", "Type": "terraform",
+   "MisconfSummary": {"Successes": 7, "Failures": 2},
+   "Misconfigurations": [
+     {"ID": "AWS-0088", "AVDID": "AVD-AWS-0088", "Title": "Unencrypted S3 bucket.", "Message": "Bucket does not have encryption enabled",
+      "Resolution": "Configure bucket encryption", "Severity": "CRITICAL", "PrimaryURL": "https://avd.aquasec.com/misconfig/avd-aws-0088",
+      "Status": "FAIL", "CauseMetadata": {"Resource": "aws_s3_bucket.logs", "StartLine": 1, "EndLine": 3}},
+     {"AVDID": "AVD-AWS-0107", "Title": "An ingress security group rule allows traffic from /0.", "Severity": "UNKNOWN",
+      "Status": "FAIL", "CauseMetadata": {"Resource": "aws_security_group.web", "StartLine": 12}}
+   ]}
+]}`
+
+const kicsOutput = `{"kics_version": "v1.7.0", "queries": [
+  {"query_name": "S3 Bucket ACL Allows Read Or Write to All Users", "query_id": "38c5ee0d-7f22-4260-ab72-5073048df100",
+   "query_url": "https://docs.kics.io/", "severity": "CRITICAL", "description": "S3 Buckets should not be readable and writable to all users",
+   "files": [
+     {"file_name": "main.tf", "line": 5, "search_key": "aws_s3_bucket[site].acl", "remediation": "private"},
+     {"file_name": "modules/web/main.tf", "line": 2, "search_key": "aws_s3_bucket[assets]",
+      "resource_type": "aws_s3_bucket", "resource_name": "company-assets"}
+   ]},
+  {"query_name": "Passwords And Secrets", "query_id": "487f4be7-3fd9-4506-a07a-eae252180c08", "severity": "TRACE",
+   "description": "Hardcoded secret", "files": [{"file_name": "vars.tf", "line": 9, "search_key": "variable.password",
+     "resource_type": "aws_db_instance", "resource_name": "main-db"}]}
+]}`
+
+const terrascanOutput = `{"results": {
+  "violations": [
+    {"rule_name": "s3Versioning", "description": "Enable S3 versioning", "rule_id": "AC_AWS_0214", "severity": "MEDIUM",
+     "resource_name": "logs", "resource_type": "aws_s3_bucket", "module_name": "root", "file": "main.tf", "line": 1},
+    {"rule_name": "rdsPublic", "description": "RDS instance is publicly accessible", "rule_id": "AC_AWS_0054", "severity": "HIGH",
+     "resource_name": "this", "resource_type": "aws_db_instance", "module_name": "database", "file": "modules/db/main.tf", "line": 4}
+  ],
+  "scan_summary": {"policies_validated": 140, "violated_policies": 2}
+}}`
+
+var _ = Describe("ScannerImporter", func() {
+	var importer *analyzer.ScannerImporter
+
+	BeforeEach(func() {
+		importer = analyzer.NewScannerImporter(logger.New("error", "text"))
+	})
+
+	Describe("Normalização", func() {
+		It("deve importar a saída do tfsec ignorando checks aprovados e ignorados", func() {
+			analysis, err := importer.Import("tfsec", []byte(tfsecOutput))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(analysis.Findings).To(HaveLen(1))
+			Expect(analysis.ChecksPassed).To(Equal(1))
+			Expect(analysis.ChecksFailed).To(Equal(1))
+			Expect(analysis.High).To(Equal(1))
+
+			finding := analysis.Findings[0]
+			Expect(finding.CheckID).To(Equal("AVD-AWS-0088"))
+			Expect(finding.CheckName).To(Equal("Unencrypted S3 bucket."))
+			Expect(finding.Resource).To(Equal("aws_s3_bucket.logs"))
+			Expect(finding.File).To(Equal("/work/infra/main.tf"))
+			Expect(finding.Line).To(Equal(1))
+			Expect(finding.Guideline).To(Equal("Configure bucket encryption"))
+			Expect(finding.Sources).To(Equal([]string{"tfsec"}))
+		})
+
+		It("deve importar as misconfigurations do Trivy", func() {
+			analysis, err := importer.Import("trivy", []byte(trivyOutput))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(analysis.Findings).To(HaveLen(2))
+			Expect(analysis.ChecksPassed).To(Equal(7))
+			Expect(analysis.Critical).To(Equal(1))
+			Expect(analysis.Info).To(Equal(1))
+			Expect(analysis.Findings[0].CheckID).To(Equal("AVD-AWS-0088"))
+			Expect(analysis.Findings[0].File).To(Equal("main.tf"))
+			Expect(analysis.Findings[0].References).To(ContainElement("https://avd.aquasec.com/misconfig/avd-aws-0088"))
+			Expect(analysis.Findings[1].Resource).To(Equal("aws_security_group.web"))
+		})
+
+		It("deve importar o KICS extraindo o recurso da search_key", func() {
+			analysis, err := importer.Import("kics", []byte(kicsOutput))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(analysis.Findings).To(HaveLen(3))
+			Expect(analysis.Findings[0].Resource).To(Equal("aws_s3_bucket.site"))
+			Expect(analysis.Findings[0].Line).To(Equal(5))
+			Expect(analysis.Findings[0].FixedCode).To(Equal("private"))
+			Expect(analysis.Findings[1].Resource).To(Equal("aws_s3_bucket.assets"))
+			Expect(analysis.Findings[2].Resource).To(BeEmpty())
+			Expect(analysis.Findings[2].Severity).To(Equal("INFO"))
+			Expect(analysis.Critical).To(Equal(2))
+		})
+
+		It("deve importar o Terrascan com o endereço do módulo", func() {
+			analysis, err := importer.Import("terrascan", []byte(terrascanOutput))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(analysis.Findings).To(HaveLen(2))
+			Expect(analysis.Findings[0].Resource).To(Equal("aws_s3_bucket.logs"))
+			Expect(analysis.Findings[1].Resource).To(Equal("module.database.aws_db_instance.this"))
+			Expect(analysis.Findings[1].CheckName).To(Equal("RDS instance is publicly accessible"))
+		})
+
+		It("deve detectar o scanner pelo formato da saída", func() {
+			for tool, output := range map[string]string{
+				"tfsec":     tfsecOutput,
+				"trivy":     trivyOutput,
+				"kics":      kicsOutput,
+				"terrascan": terrascanOutput,
+				"checkov":   `{"check_type": "terraform", "summary": {"passed": 1, "failed": 0}, "results": {"failed_checks": []}}`,
+			} {
+				analysis, err := importer.Import("", []byte(output))
+				Expect(err).NotTo(HaveOccurred(), tool)
+				for _, finding := range analysis.Findings {
+					Expect(finding.Sources).To(Equal([]string{tool}))
+				}
+			}
+		})
+
+		It("deve rejeitar scanners e formatos desconhecidos", func() {
+			_, err := importer.Import("snyk", []byte(`{}`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("não suportado"))
+
+			_, err = importer.Import("", []byte(`{"foo": 1}`))
+			Expect(err).To(HaveOccurred())
+
+			_, err = importer.Import("tfsec", []byte(`{"results": [`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Deduplicação", func() {
+		It("deve unificar findings da mesma regra no mesmo recurso entre scanners", func() {
+			checkov := &models.SecurityAnalysis{
+				ChecksPassed: 10,
+				ChecksFailed: 2,
+				Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_19", CheckName: "Ensure S3 bucket encryption", Severity: "MEDIUM",
+						Resource: "aws_s3_bucket.logs", File: "/main.tf", Line: 1, Sources: []string{"checkov"}},
+					{CheckID: "CKV_AWS_18", Severity: "LOW", Resource: "aws_s3_bucket.logs", File: "/main.tf", Sources: []string{"checkov"}},
+				},
+			}
+			tfsec, err := importer.Import("tfsec", []byte(tfsecOutput))
+			Expect(err).NotTo(HaveOccurred())
+			trivy, err := importer.Import("trivy", []byte(trivyOutput))
+			Expect(err).NotTo(HaveOccurred())
+
+			merged := importer.Merge(checkov, tfsec, trivy)
+
+			Expect(merged.Findings).To(HaveLen(3))
+			encryption := merged.Findings[0]
+			Expect(encryption.CheckID).To(Equal("CKV_AWS_19"))
+			Expect(encryption.Severity).To(Equal("CRITICAL"))
+			Expect(encryption.Sources).To(Equal([]string{"checkov", "tfsec", "trivy"}))
+			Expect(encryption.Guideline).To(Equal("Configure bucket encryption"))
+
+			Expect(merged.ChecksPassed).To(Equal(18))
+			Expect(merged.ChecksFailed).To(Equal(3))
+			Expect(merged.TotalIssues).To(Equal(3))
+			Expect(merged.Critical).To(Equal(1))
+			Expect(merged.Low).To(Equal(1))
+
+			// a análise original não é alterada
+			Expect(checkov.Findings[0].Sources).To(Equal([]string{"checkov"}))
+		})
+
+		It("deve manter findings do mesmo recurso em arquivos diferentes", func() {
+			merged := importer.Merge(
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "AWS_S3_VERSIONING", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "stacks/dev/main.tf"},
+				}},
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_21", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "stacks/prod/main.tf"},
+					{CheckID: "CKV_AWS_21", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "/repo/stacks/dev/main.tf"},
+				}},
+			)
+
+			Expect(merged.Findings).To(HaveLen(2))
+			Expect(merged.Findings[1].File).To(Equal("stacks/prod/main.tf"))
+		})
+
+		It("deve unificar findings apenas dentro do mesmo stack", func() {
+			merged := importer.Merge(
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "AWS_S3_VERSIONING", Severity: "MEDIUM", Resource: "envs/dev:aws_s3_bucket.logs", Sources: []string{"native"}},
+					{CheckID: "AWS_S3_VERSIONING", Severity: "MEDIUM", Resource: "envs/prod:aws_s3_bucket.logs", Sources: []string{"native"}},
+				}},
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_21", Severity: "HIGH", Resource: "aws_s3_bucket.logs", File: "/envs/prod/main.tf", Sources: []string{"checkov"}},
+					{CheckID: "CKV_AWS_21", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "/envs/staging/main.tf", Sources: []string{"checkov"}},
+				}},
+			)
+
+			Expect(merged.Findings).To(HaveLen(3))
+			Expect(merged.Findings[0].Sources).To(Equal([]string{"native"}))
+			Expect(merged.Findings[1].Resource).To(Equal("envs/prod:aws_s3_bucket.logs"))
+			Expect(merged.Findings[1].Sources).To(Equal([]string{"native", "checkov"}))
+			Expect(merged.Findings[1].Severity).To(Equal("HIGH"))
+			Expect(merged.Findings[2].File).To(Equal("/envs/staging/main.tf"))
+		})
+
+		It("deve manter instâncias diferentes do mesmo recurso", func() {
+			merged := importer.Merge(
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_19", Severity: "MEDIUM", Resource: "aws_s3_bucket.b[0]", File: "main.tf", Sources: []string{"checkov"}},
+					{CheckID: "CKV_AWS_19", Severity: "MEDIUM", Resource: "aws_s3_bucket.b[1]", File: "main.tf", Sources: []string{"checkov"}},
+				}},
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_19", Severity: "HIGH", Resource: "aws_s3_bucket.b[1]", File: "main.tf", Sources: []string{"trivy"}},
+				}},
+			)
+
+			Expect(merged.Findings).To(HaveLen(2))
+			Expect(merged.Findings[0].Resource).To(Equal("aws_s3_bucket.b[0]"))
+			Expect(merged.Findings[0].Sources).To(Equal([]string{"checkov"}))
+			Expect(merged.Findings[1].Resource).To(Equal("aws_s3_bucket.b[1]"))
+			Expect(merged.Findings[1].Severity).To(Equal("HIGH"))
+			Expect(merged.Findings[1].Sources).To(Equal([]string{"checkov", "trivy"}))
+		})
+
+		It("deve usar o endereço sem índice apenas quando o scanner não informa a instância", func() {
+			merged := importer.Merge(
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_19", Severity: "MEDIUM", Resource: "aws_s3_bucket.b[0]", File: "main.tf", Sources: []string{"checkov"}},
+					{CheckID: "CKV_AWS_19", Severity: "MEDIUM", Resource: "aws_s3_bucket.b[1]", File: "main.tf", Sources: []string{"checkov"}},
+				}},
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_19", Severity: "MEDIUM", Resource: "aws_s3_bucket.b", File: "main.tf", Sources: []string{"tfsec"}},
+				}},
+			)
+
+			Expect(merged.Findings).To(HaveLen(2))
+			Expect(merged.Findings[0].Sources).To(Equal([]string{"checkov", "tfsec"}))
+			Expect(merged.Findings[1].Sources).To(Equal([]string{"checkov"}))
+		})
+
+		It("deve unificar KICS e Terrascan com os mapeamentos padrão", func() {
+			terrascan, err := importer.Import("terrascan", []byte(terrascanOutput))
+			Expect(err).NotTo(HaveOccurred())
+			kics, err := importer.Import("kics", []byte(kicsOutput))
+			Expect(err).NotTo(HaveOccurred())
+
+			merged := importer.Merge(&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+				{CheckID: "AWS_S3_VERSIONING", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "main.tf", Sources: []string{"native"}},
+				{CheckID: "AWS_S3_PUBLIC_ACL", Severity: "HIGH", Resource: "aws_s3_bucket.site", File: "main.tf", Sources: []string{"native"}},
+			}}, terrascan, kics)
+
+			Expect(merged.Findings).To(HaveLen(5))
+			Expect(merged.Findings[0].Sources).To(Equal([]string{"native", "terrascan"}))
+			Expect(merged.Findings[1].Sources).To(Equal([]string{"native", "kics"}))
+			Expect(merged.Findings[1].Severity).To(Equal("CRITICAL"))
+			Expect(importer.CanonicalRule("ac_aws_0214")).To(Equal("AWS_S3_VERSIONING"))
+		})
+
+		It("deve aceitar mapeamentos de regras adicionais", func() {
+			importer.MapRules("AWS_S3_VERSIONING", "CUSTOM_S3_VERSIONING")
+
+			merged := importer.Merge(
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "AWS_S3_VERSIONING", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "main.tf", Sources: []string{"native"}},
+				}},
+				&models.SecurityAnalysis{Findings: []models.SecurityFinding{
+					{CheckID: "CUSTOM_S3_VERSIONING", Severity: "MEDIUM", Resource: "aws_s3_bucket.logs", File: "main.tf", Sources: []string{"custom"}},
+				}},
+			)
+
+			Expect(merged.Findings).To(HaveLen(1))
+			Expect(merged.Findings[0].Sources).To(Equal([]string{"native", "custom"}))
+			Expect(importer.CanonicalRule("custom_s3_versioning")).To(Equal("AWS_S3_VERSIONING"))
+		})
+	})
+})
@@ -0,0 +1,279 @@
+package unit_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+var _ = Describe("RuleEngine", func() {
+	var (
+		engine     *analyzer.RuleEngine
+		tfAnalyzer *analyzer.TerraformAnalyzer
+	)
+
+	BeforeEach(func() {
+		log := logger.New("info", "json")
+		engine = analyzer.NewRuleEngine(log)
+		tfAnalyzer = analyzer.NewTerraformAnalyzer()
+	})
+
+	analyze := func(content string) *models.SecurityAnalysis {
+		tfAnalysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+		Expect(err).NotTo(HaveOccurred())
+		return engine.Analyze(tfAnalysis)
+	}
+
+	checkIDs := func(analysis *models.SecurityAnalysis) []string {
+		ids := []string{}
+		for _, finding := range analysis.Findings {
+			ids = append(ids, finding.CheckID)
+		}
+		return ids
+	}
+
+	Describe("Carregando regras", func() {
+		It("deve carregar regras AWS, Azure e GCP", func() {
+			providers := map[string]bool{}
+			for _, rule := range engine.Rules() {
+				Expect(rule.ID).NotTo(BeEmpty())
+				Expect(rule.Check).NotTo(BeNil())
+				Expect(rule.Severity).To(BeElementOf("CRITICAL", "HIGH", "MEDIUM", "LOW"))
+				providers[rule.Provider] = true
+			}
+			Expect(providers).To(HaveKey("aws"))
+			Expect(providers).To(HaveKey("azure"))
+			Expect(providers).To(HaveKey("gcp"))
+		})
+
+		It("deve rejeitar regras duplicadas", func() {
+			rule := engine.Rules()[0]
+			Expect(engine.AddRule(rule)).To(HaveOccurred())
+		})
+
+		It("deve avaliar regras customizadas", func() {
+			err := engine.AddRule(analyzer.SecurityRule{
+				ID:            "CUSTOM_INSTANCE_TYPE",
+				Severity:      "low",
+				ResourceTypes: []string{"aws_instance"},
+				Check: func(r *models.TerraformResource, _ *analyzer.RuleContext) bool {
+					return r.Attributes["instance_type"] != "t2.micro"
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			analysis := analyze(`
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t2.micro"
+  metadata_options {
+    http_tokens = "required"
+  }
+  root_block_device {
+    encrypted = true
+  }
+}
+`)
+			Expect(checkIDs(analysis)).To(ConsistOf("CUSTOM_INSTANCE_TYPE"))
+			Expect(analysis.Findings[0].Severity).To(Equal("LOW"))
+		})
+	})
+
+	Describe("Avaliando recursos AWS", func() {
+		Context("quando o bucket S3 não tem controles", func() {
+			It("deve reportar criptografia, versionamento, logging e acesso público", func() {
+				analysis := analyze(`
+resource "aws_s3_bucket" "data" {
+  bucket = "my-data"
+  acl    = "public-read"
+}
+`)
+				Expect(checkIDs(analysis)).To(ConsistOf(
+					"AWS_S3_ENCRYPTION",
+					"AWS_S3_VERSIONING",
+					"AWS_S3_LOGGING",
+					"AWS_S3_PUBLIC_ACL",
+					"AWS_S3_PUBLIC_ACCESS_BLOCK",
+				))
+				Expect(analysis.High).To(Equal(2))
+				Expect(analysis.TotalIssues).To(Equal(5))
+				Expect(analysis.ChecksFailed).To(Equal(5))
+
+				finding := analysis.Findings[0]
+				Expect(finding.Resource).To(Equal("aws_s3_bucket.data"))
+				Expect(finding.File).To(Equal("main.tf"))
+				Expect(finding.Line).To(Equal(2))
+				Expect(finding.Guideline).NotTo(BeEmpty())
+			})
+		})
+
+		Context("quando o bucket S3 é configurado por recursos separados", func() {
+			It("deve considerar os recursos que referenciam o bucket", func() {
+				analysis := analyze(`
+resource "aws_s3_bucket" "data" {
+  bucket = "my-data"
+}
+
+resource "aws_s3_bucket_server_side_encryption_configuration" "data" {
+  bucket = aws_s3_bucket.data.id
+  rule {
+    apply_server_side_encryption_by_default {
+      sse_algorithm = "aws:kms"
+    }
+  }
+}
+
+resource "aws_s3_bucket_versioning" "data" {
+  bucket = aws_s3_bucket.data.id
+  versioning_configuration {
+    status = "Enabled"
+  }
+}
+
+resource "aws_s3_bucket_logging" "data" {
+  bucket        = "my-data"
+  target_bucket = "my-logs"
+  target_prefix = "data/"
+}
+
+resource "aws_s3_bucket_public_access_block" "data" {
+  bucket                  = aws_s3_bucket.data.id
+  block_public_acls       = true
+  block_public_policy     = true
+  ignore_public_acls      = true
+  restrict_public_buckets = true
+}
+`)
+				Expect(analysis.Findings).To(BeEmpty())
+				Expect(analysis.ChecksPassed).To(Equal(5))
+			})
+		})
+
+		Context("quando a instância EC2 permite IMDSv1", func() {
+			It("deve reportar AWS_EC2_IMDSV2", func() {
+				analysis := analyze(`
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+  root_block_device {
+    encrypted = true
+  }
+}
+`)
+				Expect(checkIDs(analysis)).To(ConsistOf("AWS_EC2_IMDSV2"))
+			})
+		})
+
+		Context("quando o security group abre SSH para a internet", func() {
+			It("deve reportar finding crítico apenas para portas de administração", func() {
+				analysis := analyze(`
+resource "aws_security_group" "ssh" {
+  name = "ssh"
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+
+resource "aws_security_group" "https" {
+  name = "https"
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+}
+
+resource "aws_security_group_rule" "rdp" {
+  type              = "ingress"
+  from_port         = 3000
+  to_port           = 4000
+  protocol          = "tcp"
+  cidr_blocks       = ["0.0.0.0/0"]
+  security_group_id = aws_security_group.https.id
+}
+`)
+				Expect(checkIDs(analysis)).To(ConsistOf("AWS_SG_OPEN_ADMIN_PORTS", "AWS_SG_OPEN_ADMIN_PORTS"))
+				Expect(analysis.Critical).To(Equal(2))
+				Expect(analysis.ChecksPassed).To(Equal(1))
+			})
+		})
+
+		Context("quando o atributo só é conhecido após o apply", func() {
+			It("não deve avaliar a regra", func() {
+				analysis := analyze(`
+variable "encrypted" {
+  type = bool
+}
+
+resource "aws_ebs_volume" "data" {
+  availability_zone = "us-east-1a"
+  size              = 10
+  encrypted         = var.encrypted
+}
+`)
+				Expect(analysis.Findings).To(BeEmpty())
+				Expect(analysis.ChecksPassed).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Avaliando recursos Azure e GCP", func() {
+		It("deve reportar NSG, storage account e firewall abertos", func() {
+			analysis := analyze(`
+resource "azurerm_network_security_rule" "rdp" {
+  name                        = "rdp"
+  priority                    = 100
+  direction                   = "Inbound"
+  access                      = "Allow"
+  protocol                    = "Tcp"
+  source_port_range           = "*"
+  destination_port_range      = "3389"
+  source_address_prefix       = "Internet"
+  destination_address_prefix  = "*"
+  resource_group_name         = "rg"
+  network_security_group_name = "nsg"
+}
+
+resource "azurerm_storage_account" "data" {
+  name                            = "data"
+  resource_group_name             = "rg"
+  location                        = "eastus"
+  account_tier                    = "Standard"
+  account_replication_type        = "LRS"
+  min_tls_version                 = "TLS1_0"
+  allow_nested_items_to_be_public = false
+}
+
+resource "google_compute_firewall" "ssh" {
+  name          = "ssh"
+  network       = "default"
+  source_ranges = ["0.0.0.0/0"]
+  allow {
+    protocol = "tcp"
+    ports    = ["22"]
+  }
+}
+
+resource "google_storage_bucket_iam_member" "public" {
+  bucket = "data"
+  role   = "roles/storage.objectViewer"
+  member = "allUsers"
+}
+`)
+			Expect(checkIDs(analysis)).To(ConsistOf(
+				"AZURE_NSG_OPEN_ADMIN_PORTS",
+				"AZURE_STORAGE_MIN_TLS",
+				"GCP_FIREWALL_OPEN_ADMIN_PORTS",
+				"GCP_STORAGE_PUBLIC",
+			))
+			Expect(analysis.Critical).To(Equal(3))
+		})
+	})
+})
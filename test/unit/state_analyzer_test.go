@@ -0,0 +1,319 @@
+package unit_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
+)
+
+var _ = Describe("StateAnalyzer", func() {
+	var (
+		stateAnalyzer *analyzer.StateAnalyzer
+		tfAnalyzer    *analyzer.TerraformAnalyzer
+	)
+
+	config := `
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs-prod"
+  tags = {
+    Env = "prod"
+  }
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+
+  ingress {
+    from_port   = 80
+    to_port     = 80
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+}
+
+resource "aws_db_instance" "main" {
+  identifier = "main"
+  password   = "configured-secret"
+}
+
+resource "aws_instance" "web" {
+  count         = 2
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+}
+
+module "remote" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`
+
+	BeforeEach(func() {
+		stateAnalyzer = analyzer.NewStateAnalyzer(logger.New("info", "json"))
+		tfAnalyzer = analyzer.NewTerraformAnalyzer()
+	})
+
+	Describe("Lendo states", func() {
+		Context("quando o state é um terraform.tfstate v4", func() {
+			It("deve normalizar endereços, índices e atributos sensíveis", func() {
+				state, err := stateAnalyzer.ParseState([]byte(`{
+  "version": 4,
+  "terraform_version": "1.7.5",
+  "serial": 12,
+  "lineage": "abc",
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        { "index_key": 0, "attributes": { "id": "i-0", "ami": "ami-123" } },
+        { "index_key": 1, "attributes": { "id": "i-1", "ami": "ami-123" } }
+      ]
+    },
+    {
+      "module": "module.app[\"api\"]",
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "instances": [
+        {
+          "attributes": { "password": "secret" },
+          "sensitive_attributes": [[{ "type": "get_attr", "value": "password" }]]
+        }
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "instances": [ { "attributes": { "id": "ami-123" } } ]
+    }
+  ]
+}`))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Format).To(Equal("tfstate"))
+				Expect(state.Serial).To(Equal(int64(12)))
+				Expect(state.Resources).To(HaveLen(4))
+				Expect(state.Resources[0].Address).To(Equal("aws_instance.web[0]"))
+				Expect(state.Resources[1].Address).To(Equal("aws_instance.web[1]"))
+				Expect(state.Resources[2].Address).To(Equal(`module.app["api"].aws_db_instance.main`))
+				Expect(state.Resources[2].SensitivePaths).To(Equal([]string{"password"}))
+				Expect(state.Resources[3].Address).To(Equal("data.aws_ami.ubuntu"))
+			})
+		})
+
+		Context("quando o state vem de terraform show -json", func() {
+			It("deve percorrer os módulos filhos", func() {
+				state, err := stateAnalyzer.ParseState([]byte(`{
+  "format_version": "1.0",
+  "terraform_version": "1.7.5",
+  "values": {
+    "root_module": {
+      "resources": [
+        { "address": "aws_s3_bucket.logs", "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+          "values": { "bucket": "logs-prod" }, "sensitive_values": {} }
+      ],
+      "child_modules": [
+        {
+          "address": "module.db",
+          "resources": [
+            { "address": "module.db.aws_db_instance.main", "mode": "managed", "type": "aws_db_instance", "name": "main",
+              "values": { "password": "x" }, "sensitive_values": { "password": true } }
+          ]
+        }
+      ]
+    }
+  }
+}`))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(state.Format).To(Equal("show-json"))
+				Expect(state.Resources).To(HaveLen(2))
+				Expect(state.Resources[1].Module).To(Equal("module.db"))
+				Expect(state.Resources[1].SensitivePaths).To(Equal([]string{"password"}))
+			})
+		})
+
+		Context("quando o JSON não é um state suportado", func() {
+			It("deve retornar erro para plans e versões antigas", func() {
+				_, err := stateAnalyzer.ParseState([]byte(`{"format_version": "1.2", "planned_values": {}}`))
+				Expect(err).To(MatchError(ContainSubstring("plan")))
+
+				_, err = stateAnalyzer.ParseState([]byte(`{"version": 3, "modules": []}`))
+				Expect(err).To(MatchError(ContainSubstring("versão de state não suportada")))
+			})
+		})
+	})
+
+	Describe("Detectando drift", func() {
+		It("deve reportar atributos divergentes, órfãos e recursos não aplicados", func() {
+			tfAnalysis, err := tfAnalyzer.AnalyzeContent(config, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := stateAnalyzer.ParseState([]byte(`{
+  "version": 4,
+  "terraform_version": "1.7.5",
+  "serial": 3,
+  "resources": [
+    {
+      "mode": "managed", "type": "aws_s3_bucket", "name": "logs",
+      "instances": [ { "attributes": {
+        "bucket": "logs-prod",
+        "arn": "arn:aws:s3:::logs-prod",
+        "tags": { "Env": "staging", "Owner": "manual" }
+      } } ]
+    },
+    {
+      "mode": "managed", "type": "aws_security_group", "name": "web",
+      "instances": [ { "attributes": {
+        "name": "web",
+        "ingress": [
+          { "from_port": 80, "to_port": 80, "protocol": "tcp", "cidr_blocks": ["10.0.0.0/8"], "self": false },
+          { "from_port": 443, "to_port": 443, "protocol": "tcp", "cidr_blocks": ["10.0.0.0/8"], "self": false }
+        ]
+      } } ]
+    },
+    {
+      "mode": "managed", "type": "aws_db_instance", "name": "main",
+      "instances": [ {
+        "attributes": { "identifier": "main", "password": "rotated-secret" },
+        "sensitive_attributes": [[{ "type": "get_attr", "value": "password" }]]
+      } ]
+    },
+    {
+      "mode": "managed", "type": "aws_instance", "name": "web",
+      "instances": [ { "index_key": 0, "attributes": { "ami": "ami-123", "instance_type": "t3.micro" } } ]
+    },
+    {
+      "mode": "managed", "type": "aws_iam_user", "name": "legacy",
+      "instances": [ { "attributes": { "name": "legacy" } } ]
+    },
+    {
+      "module": "module.remote", "mode": "managed", "type": "aws_vpc", "name": "this",
+      "instances": [ { "attributes": { "cidr_block": "10.0.0.0/16" } } ]
+    }
+  ]
+}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			drift := stateAnalyzer.DetectDrift(tfAnalysis, state)
+
+			Expect(drift.HasDrift).To(BeTrue())
+			Expect(drift.InSync).To(Equal(2)) // aws_security_group.web e aws_instance.web[0]
+			Expect(drift.Unapplied).To(Equal([]string{"aws_instance.web[1]"}))
+			Expect(drift.Orphaned).To(ConsistOf(HaveField("Address", "aws_iam_user.legacy")))
+			Expect(drift.Unverified).To(Equal([]string{"module.remote.aws_vpc.this"}))
+
+			Expect(drift.Drifted).To(HaveLen(2))
+			Expect(drift.Drifted[0].Address).To(Equal("aws_s3_bucket.logs"))
+			Expect(drift.Drifted[0].Attributes).To(Equal([]models.AttributeDrift{
+				{Path: "tags.Env", Configured: "prod", Actual: "staging"},
+				{Path: "tags.Owner", Configured: nil, Actual: "manual"},
+			}))
+
+			Expect(drift.Drifted[1].Address).To(Equal("aws_db_instance.main"))
+			Expect(drift.Drifted[1].Attributes).To(Equal([]models.AttributeDrift{
+				{Path: "password", Sensitive: true},
+			}))
+		})
+
+		It("deve mascarar atributos sensíveis do schema e valores de variáveis sensíveis", func() {
+			tfAnalysis, err := tfAnalyzer.AnalyzeContent(`
+variable "api_key" {
+  type      = string
+  default   = "configured-key"
+  sensitive = true
+}
+
+resource "aws_db_instance" "legacy" {
+  identifier = "legacy"
+  password   = "configured-secret"
+}
+
+resource "aws_ssm_parameter" "key" {
+  name  = "/app/key"
+  value = "configured-value"
+}
+
+resource "aws_lambda_function" "api" {
+  function_name = "api"
+  environment {
+    variables = {
+      API_KEY = var.api_key
+    }
+  }
+}
+`, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			// State antigo, sem sensitive_attributes
+			state, err := stateAnalyzer.ParseState([]byte(`{
+  "version": 4,
+  "resources": [
+    { "mode": "managed", "type": "aws_db_instance", "name": "legacy",
+      "instances": [ { "attributes": { "identifier": "legacy", "password": "rotated-secret" } } ] },
+    { "mode": "managed", "type": "aws_ssm_parameter", "name": "key",
+      "instances": [ { "attributes": { "name": "/app/key", "value": "rotated-value" } } ] },
+    { "mode": "managed", "type": "aws_lambda_function", "name": "api",
+      "instances": [ { "attributes": { "function_name": "api",
+        "environment": [ { "variables": { "API_KEY": "state-key" } } ] } } ] }
+  ]
+}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			drift := stateAnalyzer.DetectDrift(tfAnalysis, state)
+
+			Expect(drift.Drifted).To(HaveLen(3))
+			Expect(drift.Drifted[0].Attributes).To(Equal([]models.AttributeDrift{
+				{Path: "password", Sensitive: true},
+			}))
+			Expect(drift.Drifted[1].Attributes).To(Equal([]models.AttributeDrift{
+				{Path: "value", Sensitive: true},
+			}))
+			Expect(drift.Drifted[2].Attributes).To(Equal([]models.AttributeDrift{
+				{Path: "environment[0].variables.API_KEY", Sensitive: true},
+			}))
+		})
+
+		It("deve aceitar qualquer instância quando count é desconhecido", func() {
+			tfAnalysis, err := tfAnalyzer.AnalyzeContent(`
+variable "replicas" {
+  type = number
+}
+
+resource "aws_instance" "web" {
+  count = var.replicas
+  ami   = "ami-123"
+}
+`, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			state, err := stateAnalyzer.ParseState([]byte(`{
+  "version": 4,
+  "resources": [
+    { "mode": "managed", "type": "aws_instance", "name": "web",
+      "instances": [ { "index_key": 0, "attributes": {} }, { "index_key": 1, "attributes": {} } ] }
+  ]
+}`))
+			Expect(err).NotTo(HaveOccurred())
+
+			drift := stateAnalyzer.DetectDrift(tfAnalysis, state)
+
+			Expect(drift.HasDrift).To(BeFalse())
+			Expect(drift.Orphaned).To(BeEmpty())
+			Expect(drift.Unapplied).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,233 @@
+package unit_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+)
+
+const suppressedContent = `# iac-agent:ignore AWS_S3_LOGGING,AWS_S3_VERSIONING reason="Bucket de logs" until=2027-01-01
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+resource "aws_s3_bucket" "data" {
+  bucket = "data" # iac-agent:ignore AWS_S3_ENCRYPTION reason="Dados públicos" until=2020-01-01
+}
+
+resource "aws_s3_bucket" "tmp" {
+  # iac-agent:ignore AWS_S3_LOGGING
+  bucket = "tmp"
+}
+`
+
+var _ = Describe("Suppressions", func() {
+	var (
+		tfAnalysis *models.TerraformAnalysis
+		files      map[string]string
+		now        time.Time
+	)
+
+	BeforeEach(func() {
+		var err error
+		tfAnalysis, err = analyzer.NewTerraformAnalyzer().AnalyzeContent(suppressedContent, "main.tf")
+		Expect(err).NotTo(HaveOccurred())
+		files = map[string]string{"main.tf": suppressedContent}
+		now = time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	finding := func(checkID, resource, severity string) models.SecurityFinding {
+		return models.SecurityFinding{CheckID: checkID, Resource: resource, Severity: severity, File: "main.tf"}
+	}
+
+	Describe("Comentários inline", func() {
+		It("deve associar cada comentário ao recurso correspondente", func() {
+			suppressions := analyzer.ParseInlineSuppressions(files, tfAnalysis)
+
+			Expect(suppressions).To(HaveLen(4))
+			Expect(suppressions[0].CheckID).To(Equal("AWS_S3_LOGGING"))
+			Expect(suppressions[0].Resource).To(Equal("aws_s3_bucket.logs"))
+			Expect(suppressions[0].Reason).To(Equal("Bucket de logs"))
+			Expect(suppressions[0].Until).To(Equal("2027-01-01"))
+			Expect(suppressions[0].Line).To(Equal(1))
+			Expect(suppressions[0].Source).To(Equal("inline"))
+			Expect(suppressions[1].CheckID).To(Equal("AWS_S3_VERSIONING"))
+			Expect(suppressions[2].Resource).To(Equal("aws_s3_bucket.data"))
+			Expect(suppressions[3].Resource).To(Equal("aws_s3_bucket.tmp"))
+		})
+
+		It("deve suprimir findings ativos e reativar os expirados", func() {
+			security := &models.SecurityAnalysis{
+				ChecksFailed: 4,
+				Findings: []models.SecurityFinding{
+					finding("AWS_S3_LOGGING", "aws_s3_bucket.logs", "LOW"),
+					finding("AWS_S3_LOGGING", "aws_s3_bucket.data", "LOW"),
+					finding("AWS_S3_ENCRYPTION", "aws_s3_bucket.data", "HIGH"),
+					finding("AWS_S3_LOGGING", "aws_s3_bucket.tmp", "LOW"),
+				},
+			}
+
+			report := analyzer.ApplySuppressions(analyzer.ParseInlineSuppressions(files, tfAnalysis), security, nil, now)
+
+			Expect(report.Suppressed).To(HaveLen(1))
+			Expect(report.Suppressed[0].Finding.Resource).To(Equal("aws_s3_bucket.logs"))
+			Expect(report.Suppressed[0].Reason).To(Equal("Bucket de logs"))
+			Expect(report.Expired).To(HaveLen(1))
+			Expect(report.Expired[0].CheckID).To(Equal("AWS_S3_ENCRYPTION"))
+			Expect(report.Invalid).To(HaveLen(1))
+			Expect(report.Invalid[0].Error).To(ContainSubstring("justificativa"))
+
+			Expect(security.Findings).To(HaveLen(3))
+			Expect(security.ChecksFailed).To(Equal(3))
+			Expect(security.High).To(Equal(1))
+			Expect(security.Low).To(Equal(2))
+		})
+
+		It("deve exigir o id exato do check nos comentários inline", func() {
+			content := `# iac-agent:ignore * reason="Tudo aceito"
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs" # iac-agent:ignore AWS_S3_* reason="Bucket de logs"
+}
+`
+			inline := map[string]string{"main.tf": content}
+			analysis, err := analyzer.NewTerraformAnalyzer().AnalyzeContent(content, "main.tf")
+			Expect(err).NotTo(HaveOccurred())
+
+			security := &models.SecurityAnalysis{
+				ChecksFailed: 1,
+				Findings:     []models.SecurityFinding{finding("AWS_S3_LOGGING", "aws_s3_bucket.logs", "LOW")},
+			}
+			report := analyzer.ApplySuppressions(analyzer.ParseInlineSuppressions(inline, analysis), security, nil, now)
+
+			Expect(report.Suppressed).To(BeEmpty())
+			Expect(report.Invalid).To(HaveLen(2))
+			Expect(report.Invalid[0].Error).To(ContainSubstring("arquivo de exceções"))
+			Expect(security.Findings).To(HaveLen(1))
+		})
+	})
+
+	Describe("Arquivo de exceções", func() {
+		It("deve aplicar padrões glob de check e recurso", func() {
+			suppressions, err := analyzer.ParseExceptionsFile([]byte(`
+exceptions:
+  - check_id: CKV_AWS_*
+    resource: module.logs.*
+    reason: Módulo de logs aprovado pela segurança
+  - check_id: IAM_SERVICE_TRUST
+    resource: aws_iam_role.lambda
+    reason: Role de execução
+    until: 2026-12-31
+  - check_id: CKV_AWS_18
+    reason: Sem data válida
+    until: 31/12/2026
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			security := &models.SecurityAnalysis{
+				ChecksFailed: 2,
+				Findings: []models.SecurityFinding{
+					finding("CKV_AWS_18", "module.logs.aws_s3_bucket.this[0]", "MEDIUM"),
+					finding("CKV_AWS_18", "aws_s3_bucket.data", "MEDIUM"),
+				},
+			}
+			iam := &models.IAMAnalysis{
+				PrincipalRisks: []models.PrincipalRisk{
+					{CheckID: "IAM_SERVICE_TRUST", Resource: "aws_iam_role.lambda", RiskLevel: "medium"},
+					{CheckID: "IAM_SERVICE_TRUST", Resource: "aws_iam_role.ec2", RiskLevel: "medium"},
+				},
+			}
+
+			report := analyzer.ApplySuppressions(suppressions, security, iam, now)
+
+			Expect(report.Suppressed).To(HaveLen(2))
+			Expect(report.Suppressed[0].Source).To(Equal("exceptions_file"))
+			Expect(report.Active).To(HaveLen(2))
+			Expect(report.Active[0].Matched).To(Equal(1))
+			Expect(report.Invalid).To(HaveLen(1))
+			Expect(report.Invalid[0].Error).To(ContainSubstring("inválida"))
+
+			Expect(security.Findings).To(HaveLen(1))
+			Expect(security.Findings[0].Resource).To(Equal("aws_s3_bucket.data"))
+			Expect(security.Medium).To(Equal(1))
+			Expect(iam.PrincipalRisks).To(HaveLen(1))
+			Expect(iam.PrincipalRisks[0].Resource).To(Equal("aws_iam_role.ec2"))
+		})
+
+		It("deve limitar exceções ao stack informado", func() {
+			suppressions, err := analyzer.ParseExceptionsFile([]byte(`
+exceptions:
+  - check_id: IAM_ADMIN_ACCESS
+    resource: aws_iam_role.app
+    stack: envs/dev
+    reason: Role administrativa do ambiente de desenvolvimento
+  - check_id: CKV_AWS_18
+    resource: envs/dev:aws_s3_bucket.logs
+    reason: Bucket de logs do ambiente de desenvolvimento
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			security := &models.SecurityAnalysis{
+				ChecksFailed: 3,
+				Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_18", Resource: "aws_s3_bucket.logs", File: "/envs/dev/main.tf", Severity: "MEDIUM"},
+					{CheckID: "CKV_AWS_18", Resource: "aws_s3_bucket.logs", File: "/envs/prod/main.tf", Severity: "MEDIUM"},
+					{CheckID: "CKV_AWS_18", Resource: "envs/prod:aws_s3_bucket.logs", Severity: "MEDIUM"},
+				},
+			}
+			iam := &models.IAMAnalysis{
+				PrincipalRisks: []models.PrincipalRisk{
+					{CheckID: "IAM_ADMIN_ACCESS", Resource: "envs/dev:aws_iam_role.app", RiskLevel: "critical"},
+					{CheckID: "IAM_ADMIN_ACCESS", Resource: "envs/prod:aws_iam_role.app", RiskLevel: "critical"},
+				},
+			}
+
+			report := analyzer.ApplySuppressions(suppressions, security, iam, now)
+
+			Expect(report.Suppressed).To(HaveLen(2))
+			Expect(security.Findings).To(HaveLen(2))
+			Expect(security.Findings[0].File).To(Equal("/envs/prod/main.tf"))
+			Expect(iam.PrincipalRisks).To(ConsistOf(HaveField("Resource", "envs/prod:aws_iam_role.app")))
+		})
+
+		It("não deve aplicar exceções de módulo ao recurso homônimo do módulo raiz", func() {
+			suppressions, err := analyzer.ParseExceptionsFile([]byte(`
+exceptions:
+  - check_id: CKV_AWS_18
+    resource: module.logs.aws_s3_bucket.this
+    reason: Bucket de logs do módulo
+  - check_id: CKV_AWS_21
+    resource: module.logs.aws_s3_bucket.this
+    file: modules/logs/main.tf
+    reason: Bucket de logs do módulo
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			security := &models.SecurityAnalysis{
+				ChecksFailed: 4,
+				Findings: []models.SecurityFinding{
+					{CheckID: "CKV_AWS_18", Resource: "aws_s3_bucket.this", File: "/storage.tf", Severity: "MEDIUM"},
+					{CheckID: "CKV_AWS_18", Resource: "module.logs.aws_s3_bucket.this", File: "/modules/logs/main.tf", Severity: "MEDIUM"},
+					{CheckID: "CKV_AWS_21", Resource: "aws_s3_bucket.this", File: "/storage.tf", Severity: "MEDIUM"},
+					{CheckID: "CKV_AWS_21", Resource: "aws_s3_bucket.this", File: "/modules/logs/main.tf", Severity: "MEDIUM"},
+				},
+			}
+
+			report := analyzer.ApplySuppressions(suppressions, security, nil, now)
+
+			Expect(report.Suppressed).To(HaveLen(2))
+			Expect(security.Findings).To(ConsistOf(
+				And(HaveField("CheckID", "CKV_AWS_18"), HaveField("File", "/storage.tf")),
+				And(HaveField("CheckID", "CKV_AWS_21"), HaveField("File", "/storage.tf")),
+			))
+		})
+
+		It("deve retornar erro para YAML inválido", func() {
+			_, err := analyzer.ParseExceptionsFile([]byte("exceptions: ["))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,131 @@
+package unit_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+)
+
+// writeSyntheticTree cria um monorepo sintético com stacks stacks, cada uma
+// com filesPerStack arquivos de recursos e um módulo local compartilhado
+func writeSyntheticTree(b *testing.B, stacks, filesPerStack int) string {
+	b.Helper()
+	root := b.TempDir()
+
+	module := `
+variable "name" {
+  type = string
+}
+
+resource "aws_s3_bucket" "this" {
+  bucket = var.name
+  tags = {
+    Name = var.name
+  }
+}
+
+output "arn" {
+  value = aws_s3_bucket.this.arn
+}
+`
+	write(b, filepath.Join(root, "modules", "bucket", "main.tf"), module)
+
+	for s := 0; s < stacks; s++ {
+		dir := filepath.Join(root, "stacks", fmt.Sprintf("stack-%03d", s))
+		write(b, filepath.Join(dir, "main.tf"), fmt.Sprintf(`
+locals {
+  env = "stack-%03d"
+}
+
+module "logs" {
+  source = "../../modules/bucket"
+  name   = "${local.env}-logs"
+}
+`, s))
+
+		for f := 0; f < filesPerStack; f++ {
+			write(b, filepath.Join(dir, fmt.Sprintf("resources_%02d.tf", f)), fmt.Sprintf(`
+resource "aws_instance" "web_%[1]d" {
+  count         = 2
+  ami           = "ami-123456"
+  instance_type = "t3.micro"
+
+  root_block_device {
+    volume_size = 20
+    encrypted   = true
+  }
+
+  tags = {
+    Name = "${local.env}-web-%[1]d-${count.index}"
+    Env  = local.env
+  }
+}
+
+resource "aws_security_group" "web_%[1]d" {
+  name = "${local.env}-web-%[1]d"
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+}
+`, f))
+		}
+
+		// Diretórios que devem ser ignorados na varredura
+		write(b, filepath.Join(dir, ".terraform", "modules", "logs", "main.tf"), module)
+	}
+
+	return root
+}
+
+func write(b *testing.B, path, content string) {
+	b.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkAnalyzeDirectoryCold mede a análise sem cache (novo analisador a
+// cada iteração) com diferentes níveis de concorrência
+func BenchmarkAnalyzeDirectoryCold(b *testing.B) {
+	root := writeSyntheticTree(b, 100, 20)
+
+	for _, workers := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				tfAnalyzer := analyzer.NewTerraformAnalyzer()
+				tfAnalyzer.SetConcurrency(workers)
+				if _, err := tfAnalyzer.AnalyzeDirectory(root); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkAnalyzeDirectoryCached mede análises repetidas do mesmo
+// repositório, em que todos os arquivos vêm do cache de parsing
+func BenchmarkAnalyzeDirectoryCached(b *testing.B) {
+	root := writeSyntheticTree(b, 100, 20)
+
+	tfAnalyzer := analyzer.NewTerraformAnalyzer()
+	if _, err := tfAnalyzer.AnalyzeDirectory(root); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := tfAnalyzer.AnalyzeDirectory(root); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
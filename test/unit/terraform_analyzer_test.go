@@ -1,6 +1,8 @@
 package unit_test
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -8,6 +10,9 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/govinda777/iac-ai-agent/internal/agent/analyzer"
+	"github.com/govinda777/iac-ai-agent/internal/models"
+	"github.com/govinda777/iac-ai-agent/internal/platform/cloudcontroller"
+	"github.com/govinda777/iac-ai-agent/pkg/logger"
 )
 
 var _ = Describe("TerraformAnalyzer", func() {
@@ -301,14 +306,1580 @@ resource "aws_instance" "web" {
 			})
 		})
 
+		Context("quando o diretório contém .terraform, .git e vendor", func() {
+			BeforeEach(func() {
+				resource := `
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(resource), 0644)).To(Succeed())
+				for _, dir := range []string{".terraform/modules/vpc", ".git/hooks", "vendor/modules"} {
+					Expect(os.MkdirAll(filepath.Join(tempDir, dir), 0755)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(tempDir, dir, "main.tf"), []byte(resource), 0644)).To(Succeed())
+				}
+			})
+
+			It("deve ignorar esses diretórios", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.TotalResources).To(Equal(1))
+				Expect(analysis.Resources[0].File).To(Equal(filepath.Join(tempDir, "main.tf")))
+			})
+		})
+
+		Context("quando o mesmo diretório é analisado várias vezes", func() {
+			BeforeEach(func() {
+				for i, name := range []string{"a.tf", "b.tf", "c.tf"} {
+					content := fmt.Sprintf("resource \"aws_vpc\" \"vpc%d\" {\n  cidr_block = \"10.%d.0.0/16\"\n}\n", i, i)
+					Expect(os.WriteFile(filepath.Join(tempDir, name), []byte(content), 0644)).To(Succeed())
+				}
+			})
+
+			It("deve reutilizar o parsing de arquivos inalterados", func() {
+				tfAnalyzer.SetConcurrency(2)
+
+				first, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tfAnalyzer.ParseCacheStats()).To(Equal(analyzer.ParseCacheStats{Entries: 3, Hits: 0, Misses: 3}))
+
+				changed := "resource \"aws_vpc\" \"vpc1\" {\n  cidr_block = \"10.9.0.0/16\"\n}\n"
+				Expect(os.WriteFile(filepath.Join(tempDir, "b.tf"), []byte(changed), 0644)).To(Succeed())
+
+				second, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tfAnalyzer.ParseCacheStats()).To(Equal(analyzer.ParseCacheStats{Entries: 4, Hits: 2, Misses: 4}))
+
+				Expect(first.Resources).To(HaveLen(3))
+				Expect(second.Resources).To(HaveLen(3))
+				Expect(first.Resources[1].Attributes).To(HaveKeyWithValue("cidr_block", "10.1.0.0/16"))
+				Expect(second.Resources[1].Attributes).To(HaveKeyWithValue("cidr_block", "10.9.0.0/16"))
+				Expect(second.Resources[0].Attributes).To(Equal(first.Resources[0].Attributes))
+			})
+		})
+
+		Context("quando o diretório é um monorepo com vários stacks", func() {
+			BeforeEach(func() {
+				files := map[string]string{
+					"modules/vpc/main.tf": `
+variable "cidr" {
+  type        = string
+  description = "CIDR da VPC"
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+  tags       = { Name = "vpc" }
+}
+`,
+					"envs/prod/main.tf": `
+terraform {
+  backend "s3" {
+    bucket  = "state"
+    key     = "prod.tfstate"
+    encrypt = true
+  }
+}
+
+module "vpc" {
+  source = "../../modules/vpc"
+  cidr   = "10.0.0.0/16"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "prod-logs"
+}
+`,
+					"envs/dev/main.tf": `
+module "vpc" {
+  source = "../../modules/vpc"
+  cidr   = "10.1.0.0/16"
+}
+`,
+					// Chamado por envs/dev, mas declara provider: também é um stack
+					"bootstrap/main.tf": `
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_dynamodb_table" "locks" {
+  name     = "locks"
+  hash_key = "LockID"
+  tags     = { Name = "locks" }
+}
+`,
+				}
+				for name, content := range files {
+					path := filepath.Join(tempDir, name)
+					Expect(os.MkdirAll(filepath.Dir(path), 0755)).To(Succeed())
+					Expect(os.WriteFile(path, []byte(content), 0644)).To(Succeed())
+				}
+
+				devCallsBootstrap := `
+module "bootstrap" {
+  source = "../../bootstrap"
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "envs/dev/bootstrap.tf"), []byte(devCallsBootstrap), 0644)).To(Succeed())
+			})
+
+			It("deve descobrir os módulos raiz e analisar cada um separadamente", func() {
+				stacks, err := tfAnalyzer.AnalyzeStacks(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stacks).To(HaveLen(3))
+
+				byRoot := make(map[string]*models.TerraformAnalysis)
+				for _, stack := range stacks {
+					byRoot[stack.Root] = stack
+				}
+				Expect(byRoot).To(HaveKey("bootstrap"))
+				Expect(byRoot).To(HaveKey("envs/dev"))
+				Expect(byRoot).To(HaveKey("envs/prod"))
+				Expect(byRoot).NotTo(HaveKey("modules/vpc"))
+
+				prod := byRoot["envs/prod"]
+				Expect(prod.TotalResources).To(Equal(2))
+				Expect(prod.Settings.Backend.Type).To(Equal("s3"))
+				Expect(prod.Resources).To(ContainElement(And(
+					HaveField("Address", "module.vpc.aws_vpc.this"),
+					HaveField("Attributes", HaveKeyWithValue("cidr_block", "10.0.0.0/16")),
+				)))
+
+				dev := byRoot["envs/dev"]
+				Expect(dev.TotalResources).To(Equal(2))
+				Expect(dev.Resources).To(ContainElement(HaveField("Address", "module.bootstrap.aws_dynamodb_table.locks")))
+				Expect(dev.Resources).To(ContainElement(And(
+					HaveField("Address", "module.vpc.aws_vpc.this"),
+					HaveField("Attributes", HaveKeyWithValue("cidr_block", "10.1.0.0/16")),
+				)))
+
+				Expect(byRoot["bootstrap"].TotalResources).To(Equal(1))
+			})
+
+			It("deve usar o diretório do stack como raiz da avaliação", func() {
+				policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+				Expect(os.WriteFile(filepath.Join(tempDir, "envs/prod/pol.json"), []byte(policy), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "envs/prod/iam.tf"), []byte(`
+resource "aws_iam_policy" "app" {
+  policy      = file("pol.json")
+  description = "${path.root}|${path.module}|${file("${path.module}/pol.json") == file("pol.json")}"
+}
+`), 0644)).To(Succeed())
+
+				stacks, err := tfAnalyzer.AnalyzeStacks(tempDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				var prod *models.TerraformAnalysis
+				for _, stack := range stacks {
+					if stack.Root == "envs/prod" {
+						prod = stack
+					}
+				}
+				Expect(prod).NotTo(BeNil())
+				Expect(prod.Resources).To(ContainElement(And(
+					HaveField("Address", "aws_iam_policy.app"),
+					HaveField("Attributes", HaveKeyWithValue("policy", policy)),
+					HaveField("Attributes", HaveKeyWithValue("description", ".|.|true")),
+				)))
+			})
+
+			It("deve agregar os stacks na análise do diretório", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Stacks).To(HaveLen(3))
+				Expect(analysis.TotalResources).To(Equal(5))
+				Expect(analysis.TotalModules).To(Equal(3))
+				Expect(analysis.Providers).To(ConsistOf("aws"))
+				Expect(analysis.BestPracticeWarnings).To(ContainElement(
+					"[envs/prod] Recurso aws_s3_bucket.logs não possui tags"))
+			})
+
+			It("deve retornar a análise do stack quando há um único módulo raiz", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(filepath.Join(tempDir, "envs", "prod"))
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Root).To(Equal("."))
+				Expect(analysis.Stacks).To(BeEmpty())
+				Expect(analysis.TotalResources).To(Equal(1))
+			})
+		})
+
 		Context("quando o diretório está vazio", func() {
 			It("deve retornar análise vazia mas válida", func() {
 				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
 
 				Expect(err).NotTo(HaveOccurred())
 				Expect(analysis.Valid).To(BeTrue())
-				Expect(analysis.TotalResources).To(Equal(0))
-				Expect(analysis.TotalModules).To(Equal(0))
+				Expect(analysis.TotalResources).To(Equal(0))
+				Expect(analysis.TotalModules).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("Avaliando variáveis, locals e tfvars", func() {
+		Context("quando atributos referenciam var.* e local.*", func() {
+			It("deve resolver os valores usando defaults e locals", func() {
+				content := `
+variable "environment" {
+  type        = string
+  description = "Environment name"
+  default     = "dev"
+}
+
+variable "instance_type" {
+  type    = string
+  default = "t3.micro"
+}
+
+locals {
+  name_prefix = "app-${var.environment}"
+  bucket_name = "${local.name_prefix}-logs"
+}
+
+resource "aws_instance" "web" {
+  instance_type = var.instance_type
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = local.bucket_name
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				Expect(analysis.Resources[0].Attributes["instance_type"]).To(Equal("t3.micro"))
+				Expect(analysis.Resources[1].Attributes["bucket"]).To(Equal("app-dev-logs"))
+				Expect(analysis.Variables[0].Default).To(Equal("dev"))
+				Expect(analysis.Variables[0].Required).To(BeFalse())
+				Expect(analysis.Variables[0].Type).To(Equal("string"))
+			})
+		})
+
+		Context("quando o valor não pode ser determinado estaticamente", func() {
+			It("deve marcar o atributo como desconhecido em vez de descartá-lo", func() {
+				content := `
+variable "ami_id" {
+  type = string
+}
+
+resource "aws_instance" "web" {
+  ami       = var.ami_id
+  subnet_id = aws_subnet.private.id
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				Expect(analysis.Resources[0].Attributes).NotTo(HaveKey("ami"))
+				Expect(analysis.Resources[0].UnknownAttributes).To(ConsistOf("ami", "subnet_id"))
+			})
+		})
+
+		Context("quando o diretório contém arquivos tfvars", func() {
+			BeforeEach(func() {
+				mainTf := `
+variable "instance_type" {
+  type    = string
+  default = "t3.micro"
+}
+
+variable "acl" {
+  type    = string
+  default = "private"
+}
+
+variable "retention" {
+  type = number
+}
+
+resource "aws_instance" "web" {
+  instance_type = var.instance_type
+}
+
+resource "aws_s3_bucket" "data" {
+  acl = var.acl
+}
+
+resource "aws_cloudwatch_log_group" "app" {
+  retention_in_days = var.retention
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "terraform.tfvars"),
+					[]byte("instance_type = \"m5.large\"\nretention = 30\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "public.auto.tfvars.json"),
+					[]byte(`{"acl": "public-read"}`), 0644)).To(Succeed())
+			})
+
+			It("deve aplicar terraform.tfvars e *.auto.tfvars sobre os defaults", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				Expect(analysis.Resources[0].Attributes["instance_type"]).To(Equal("m5.large"))
+				Expect(analysis.Resources[1].Attributes["acl"]).To(Equal("public-read"))
+				Expect(analysis.Resources[2].Attributes["retention_in_days"]).To(Equal(30.0))
+			})
+
+			It("deve dar precedência ao arquivo de variáveis informado pelo usuário", func() {
+				varFile := filepath.Join(tempDir, "prod.tfvars")
+				Expect(os.WriteFile(varFile, []byte("instance_type = \"c5.xlarge\"\n"), 0644)).To(Succeed())
+
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir, varFile)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].Attributes["instance_type"]).To(Equal("c5.xlarge"))
+			})
+		})
+
+		Context("quando variáveis são sensíveis", func() {
+			BeforeEach(func() {
+				mainTf := `
+variable "db_password" {
+  type      = string
+  default   = "s3cr3t-value"
+  sensitive = true
+}
+
+variable "api_token" {
+  type      = string
+  sensitive = true
+}
+
+locals {
+  connection = "postgres://admin:${var.db_password}@db"
+}
+
+resource "aws_db_instance" "d" {
+  password = var.db_password
+  username = "admin"
+}
+
+resource "aws_ssm_parameter" "conn" {
+  value = local.connection
+  tags  = { token = upper(var.api_token) }
+}
+
+resource "aws_ssm_parameter" "explicit" {
+  value = sensitive("plain")
+}
+
+module "app" {
+  source   = "./modules/app"
+  password = var.db_password
+}
+`
+				Expect(os.MkdirAll(filepath.Join(tempDir, "modules", "app"), 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "terraform.tfvars"),
+					[]byte("api_token = \"tok-from-tfvars\"\n"), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "modules", "app", "main.tf"), []byte(`
+variable "password" {
+  type = string
+}
+
+resource "aws_secretsmanager_secret_version" "this" {
+  secret_string = var.password
+}
+
+output "password" {
+  value = var.password
+}
+`), 0644)).To(Succeed())
+			})
+
+			It("não deve expor valores derivados de variáveis sensíveis", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+
+				byAddress := map[string]models.TerraformResource{}
+				for _, r := range analysis.Resources {
+					byAddress[r.Address] = r
+				}
+				Expect(byAddress["aws_db_instance.d"].Attributes).To(HaveKeyWithValue("password", "(sensitive value)"))
+				Expect(byAddress["aws_db_instance.d"].Attributes).To(HaveKeyWithValue("username", "admin"))
+				Expect(byAddress["aws_ssm_parameter.conn"].Attributes).To(HaveKeyWithValue("value", "(sensitive value)"))
+				Expect(byAddress["aws_ssm_parameter.conn"].Tags).To(HaveKeyWithValue("token", "(sensitive value)"))
+				Expect(byAddress["aws_ssm_parameter.explicit"].Attributes).To(HaveKeyWithValue("value", "(sensitive value)"))
+				Expect(byAddress["module.app.aws_secretsmanager_secret_version.this"].Attributes).To(
+					HaveKeyWithValue("secret_string", "(sensitive value)"))
+
+				Expect(analysis.Modules[0].Inputs).To(HaveKeyWithValue("password", "(sensitive value)"))
+				Expect(analysis.Modules[0].Outputs).To(HaveKeyWithValue("password", "(sensitive value)"))
+
+				for _, v := range analysis.Variables {
+					if v.Name == "db_password" {
+						Expect(v.Sensitive).To(BeTrue())
+						Expect(v.Required).To(BeFalse())
+						Expect(v.Default).To(BeNil())
+					}
+				}
+
+				data, err := json.Marshal(analysis)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(data)).NotTo(ContainSubstring("s3cr3t-value"))
+				Expect(string(data)).NotTo(ContainSubstring("tok-from-tfvars"))
+				Expect(string(data)).NotTo(ContainSubstring("TOK-FROM-TFVARS"))
+			})
+
+			It("deve rejeitar for_each derivado de valores sensíveis", func() {
+				content := `
+variable "names" {
+  type      = set(string)
+  default   = ["a", "b"]
+  sensitive = true
+}
+
+resource "aws_s3_bucket" "b" {
+  for_each = var.names
+  bucket   = each.key
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeFalse())
+				Expect(analysis.Resources).To(BeEmpty())
+				Expect(analysis.SyntaxErrors).To(ContainElement(
+					HaveField("Snippet", "for_each não pode derivar de valores sensíveis")))
+			})
+		})
+	})
+
+	Describe("Avaliando funções do Terraform", func() {
+		Context("quando atributos usam funções da biblioteca padrão", func() {
+			It("deve avaliar funções de string, coleção, codificação e rede", func() {
+				content := `
+locals {
+  tags   = { team = "platform" }
+  merged = merge(local.tags, { env = "prod" })
+  policy = jsondecode("{\"Version\": \"2012-10-17\"}")
+}
+
+resource "aws_subnet" "private" {
+  cidr_block = cidrsubnet("10.0.0.0/16", 8, 2)
+  gateway    = cidrhost("10.0.2.0/24", 1)
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket  = format("%s-%s-logs", local.merged["team"], lookup(local.merged, "env", "dev"))
+  policy  = jsonencode(local.policy)
+  count_  = length(concat(["a"], ["b", "c"]))
+  encoded = base64encode(upper("abc"))
+  name    = join("-", tolist(["x", "y"]))
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				subnet := analysis.Resources[0]
+				Expect(subnet.Attributes["cidr_block"]).To(Equal("10.0.2.0/24"))
+				Expect(subnet.Attributes["gateway"]).To(Equal("10.0.2.1"))
+
+				bucket := analysis.Resources[1]
+				Expect(bucket.Attributes["bucket"]).To(Equal("platform-prod-logs"))
+				Expect(bucket.Attributes["policy"]).To(Equal(`{"Version":"2012-10-17"}`))
+				Expect(bucket.Attributes["count_"]).To(Equal(3.0))
+				Expect(bucket.Attributes["encoded"]).To(Equal("QUJD"))
+				Expect(bucket.Attributes["name"]).To(Equal("x-y"))
+				Expect(bucket.UnknownAttributes).To(BeEmpty())
+			})
+
+			It("deve tratar funções impuras como valores desconhecidos", func() {
+				content := `
+resource "aws_instance" "web" {
+  user_data = "created-${timestamp()}"
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].UnknownAttributes).To(ContainElement("user_data"))
+			})
+
+			It("não deve permitir leitura de arquivos na análise de conteúdo", func() {
+				content := `
+resource "aws_iam_policy" "p" {
+  policy = file("/etc/hostname")
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].Attributes).NotTo(HaveKey("policy"))
+				Expect(analysis.Resources[0].UnknownAttributes).To(ContainElement("policy"))
+			})
+		})
+
+		Context("quando funções de arquivo são usadas em um diretório", func() {
+			BeforeEach(func() {
+				mainTf := `
+resource "aws_iam_policy" "from_file" {
+  policy = file("${path.module}/policy.json")
+}
+
+resource "aws_instance" "web" {
+  user_data = templatefile("${path.module}/init.sh.tpl", { env = "prod" })
+}
+
+resource "aws_iam_policy" "escape" {
+  policy = file("../outside.json")
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "policy.json"), []byte(`{"Statement": []}`), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "init.sh.tpl"), []byte("echo ${upper(env)}"), 0644)).To(Succeed())
+			})
+
+			It("deve ler arquivos dentro do diretório analisado e bloquear os de fora", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].Attributes["policy"]).To(Equal(`{"Statement": []}`))
+				Expect(analysis.Resources[1].Attributes["user_data"]).To(Equal("echo PROD"))
+				Expect(analysis.Resources[2].Attributes).NotTo(HaveKey("policy"))
+				Expect(analysis.Resources[2].UnknownAttributes).To(ContainElement("policy"))
+			})
+
+			It("não deve expor o diretório do servidor em path.cwd", func() {
+				Expect(os.WriteFile(filepath.Join(tempDir, "paths.tf"), []byte(`
+resource "aws_s3_object" "paths" {
+  key    = "${path.cwd}/${path.root}/${path.module}"
+  source = "${path.cwd}/policy.json"
+}
+`), 0644)).To(Succeed())
+
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources).To(ContainElement(And(
+					HaveField("Address", "aws_s3_object.paths"),
+					HaveField("Attributes", And(
+						HaveKeyWithValue("key", "././."),
+						HaveKeyWithValue("source", "./policy.json"),
+					)),
+				)))
+			})
+		})
+	})
+
+	Describe("Extraindo blocos aninhados e atributos complexos", func() {
+		Context("quando o recurso possui blocos aninhados, listas e mapas", func() {
+			var content string
+
+			BeforeEach(func() {
+				content = `
+variable "env" {
+  default = "prod"
+}
+
+resource "aws_security_group" "web" {
+  name   = "web-sg"
+  vpc_id = aws_vpc.main.id
+
+  ingress {
+    from_port   = 443
+    to_port     = 443
+    protocol    = "tcp"
+    cidr_blocks = ["0.0.0.0/0"]
+  }
+
+  ingress {
+    from_port   = 22
+    to_port     = 22
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8", aws_vpc.main.cidr_block]
+  }
+
+  tags = {
+    Name        = "web"
+    Environment = var.env
+    Owner       = aws_iam_user.owner.name
+  }
+
+  lifecycle {
+    create_before_destroy = true
+    ignore_changes        = [tags]
+  }
+
+  depends_on = [aws_vpc.main]
+}
+`
+			})
+
+			It("deve converter blocos repetidos em listas de mapas", func() {
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "sg.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				sg := analysis.Resources[0]
+				ingress, ok := sg.Attributes["ingress"].([]interface{})
+				Expect(ok).To(BeTrue())
+				Expect(ingress).To(HaveLen(2))
+				Expect(ingress[0]).To(HaveKeyWithValue("from_port", 443.0))
+				Expect(ingress[0]).To(HaveKeyWithValue("cidr_blocks", []interface{}{"0.0.0.0/0"}))
+				Expect(sg.Attributes["lifecycle"]).To(Equal([]interface{}{
+					map[string]interface{}{
+						"create_before_destroy": true,
+						"ignore_changes":        []string{"tags"},
+					},
+				}))
+				Expect(sg.Attributes["depends_on"]).To(Equal([]string{"aws_vpc.main"}))
+			})
+
+			It("deve registrar o caminho das folhas desconhecidas", func() {
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "sg.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				sg := analysis.Resources[0]
+				Expect(sg.UnknownAttributes).To(Equal([]string{
+					"ingress[1].cidr_blocks[1]",
+					"tags.Owner",
+					"vpc_id",
+				}))
+			})
+
+			It("deve preencher as tags e não gerar warning de falta de tags", func() {
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "sg.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].Tags).To(Equal(map[string]string{
+					"Name":        "web",
+					"Environment": "prod",
+				}))
+				Expect(analysis.BestPracticeWarnings).NotTo(ContainElement(
+					ContainSubstring("aws_security_group.web não possui tags")))
+			})
+
+			It("deve permitir que o IAMAnalyzer detecte ingress público", func() {
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "sg.tf")
+				Expect(err).NotTo(HaveOccurred())
+
+				iamAnalysis, err := analyzer.NewIAMAnalyzer(logger.New("info", "json")).AnalyzeTerraform(analysis)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(iamAnalysis.PublicAccess).To(ContainElement(
+					ContainSubstring("aws_security_group.web")))
+			})
+		})
+
+		Context("quando o recurso usa labels (GCP)", func() {
+			It("deve preencher as tags a partir de labels", func() {
+				content := `
+resource "google_storage_bucket" "data" {
+  name = "data"
+
+  labels = {
+    team = "platform"
+  }
+
+  versioning {
+    enabled = true
+  }
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "gcs.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				bucket := analysis.Resources[0]
+				Expect(bucket.Tags).To(HaveKeyWithValue("team", "platform"))
+				Expect(bucket.Attributes["versioning"]).To(Equal([]interface{}{
+					map[string]interface{}{"enabled": true},
+				}))
+			})
+		})
+	})
+
+	Describe("Montando o grafo de dependências", func() {
+		Context("quando recursos se referenciam", func() {
+			It("deve criar arestas implícitas, explícitas, de módulos e data sources", func() {
+				content := `
+data "aws_ami" "ubuntu" {
+  most_recent = true
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+
+locals {
+  vpc_id = aws_vpc.main.id
+}
+
+resource "aws_subnet" "public" {
+  vpc_id     = local.vpc_id
+  cidr_block = cidrsubnet(aws_vpc.main.cidr_block, 8, 0)
+}
+
+module "database" {
+  source    = "./modules/db"
+  subnet_id = aws_subnet.public.id
+}
+
+resource "aws_instance" "web" {
+  ami       = data.aws_ami.ubuntu.id
+  subnet_id = aws_subnet.public.id
+  user_data = module.database.endpoint
+
+  depends_on = [aws_vpc.main]
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				graph := analysis.ResourceGraph
+				Expect(graph).NotTo(BeNil())
+				Expect(graph.Nodes).To(HaveLen(5))
+				Expect(graph.Nodes).To(ContainElement(models.GraphNode{
+					ID: "data.aws_ami.ubuntu", Type: "data", Name: "ubuntu", Resource: "aws_ami",
+				}))
+				Expect(graph.Edges).To(ContainElements(
+					models.GraphEdge{From: "aws_subnet.public", To: "aws_vpc.main", Type: "implicit"},
+					models.GraphEdge{From: "module.database", To: "aws_subnet.public", Type: "implicit"},
+					models.GraphEdge{From: "aws_instance.web", To: "data.aws_ami.ubuntu", Type: "implicit"},
+					models.GraphEdge{From: "aws_instance.web", To: "module.database", Type: "implicit"},
+					models.GraphEdge{From: "aws_instance.web", To: "aws_vpc.main", Type: "explicit"},
+				))
+				Expect(graph.Cycles).To(BeEmpty())
+
+				var web models.TerraformResource
+				for _, r := range analysis.Resources {
+					if r.Name == "web" {
+						web = r
+					}
+				}
+				Expect(web.Dependencies).To(Equal([]string{
+					"aws_subnet.public", "aws_vpc.main", "data.aws_ami.ubuntu", "module.database",
+				}))
+			})
+		})
+
+		Context("quando há dependência circular", func() {
+			It("deve detectar o ciclo e gerar warning", func() {
+				content := `
+resource "aws_security_group" "a" {
+  description = aws_security_group.b.id
+}
+
+resource "aws_security_group" "b" {
+  description = aws_security_group.a.id
+}
+
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "cycle.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.ResourceGraph.Cycles).To(Equal([][]string{
+					{"aws_security_group.a", "aws_security_group.b"},
+				}))
+				Expect(analyzer.DetectCycles(analysis.ResourceGraph)).To(HaveLen(1))
+				Expect(analysis.BestPracticeWarnings).To(ContainElement(
+					ContainSubstring("Ciclo de dependências detectado")))
+			})
+		})
+	})
+
+	Describe("Analisando módulos locais", func() {
+		Context("quando o módulo raiz chama um módulo local", func() {
+			BeforeEach(func() {
+				mainTf := `
+locals {
+  env = "prod"
+}
+
+module "network" {
+  source = "./modules/network"
+
+  cidr = "10.20.0.0/16"
+  name = "${local.env}-vpc"
+}
+
+module "registry" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+
+resource "aws_security_group" "web" {
+  description = "CIDR ${module.network.cidr}"
+  vpc_id      = module.network.vpc_id
+  tags        = { Name = "web" }
+}
+
+output "vpc_cidr" {
+  value = module.network.cidr
+}
+`
+				networkTf := `
+variable "cidr" {
+  description = "CIDR da VPC"
+  type        = string
+}
+
+variable "name" {
+  description = "Nome da VPC"
+  type        = string
+}
+
+variable "enable_dns" {
+  type    = bool
+  default = true
+}
+
+resource "aws_vpc" "this" {
+  cidr_block           = var.cidr
+  enable_dns_hostnames = var.enable_dns
+  tags                 = { Name = var.name }
+}
+
+output "vpc_id" {
+  value = aws_vpc.this.id
+}
+
+output "cidr" {
+  value = var.cidr
+}
+`
+				moduleDir := filepath.Join(tempDir, "modules", "network")
+				Expect(os.MkdirAll(moduleDir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte(networkTf), 0644)).To(Succeed())
+			})
+
+			It("deve analisar os recursos do módulo com o endereço module.<nome>", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources).To(HaveLen(2))
+
+				vpc := analysis.Resources[0]
+				Expect(vpc.Address).To(Equal("module.network.aws_vpc.this"))
+				Expect(vpc.Module).To(Equal("module.network"))
+				Expect(vpc.Attributes).To(HaveKeyWithValue("cidr_block", "10.20.0.0/16"))
+				Expect(vpc.Attributes).To(HaveKeyWithValue("enable_dns_hostnames", true))
+				Expect(vpc.Tags).To(HaveKeyWithValue("Name", "prod-vpc"))
+
+				Expect(analysis.Resources[1].Address).To(Equal("aws_security_group.web"))
+			})
+
+			It("deve registrar source, version, inputs e outputs dos módulos", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Modules).To(HaveLen(2))
+
+				network := analysis.Modules[0]
+				Expect(network.Source).To(Equal("./modules/network"))
+				Expect(network.Inputs).To(Equal(map[string]interface{}{
+					"cidr": "10.20.0.0/16",
+					"name": "prod-vpc",
+				}))
+				Expect(network.Outputs).To(Equal(map[string]interface{}{
+					"cidr": "10.20.0.0/16",
+				}))
+
+				registry := analysis.Modules[1]
+				Expect(registry.Source).To(Equal("terraform-aws-modules/vpc/aws"))
+				Expect(registry.Version).To(Equal("5.0.0"))
+				Expect(registry.Outputs).To(BeEmpty())
+			})
+
+			It("não deve expor os valores de outputs sensíveis do módulo", func() {
+				Expect(os.WriteFile(filepath.Join(tempDir, "modules", "network", "outputs.tf"), []byte(`
+output "admin_password" {
+  value     = "s3cr3t-${var.name}"
+  sensitive = true
+}
+`), 0644)).To(Succeed())
+
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				network := analysis.Modules[0]
+				Expect(network.Outputs).To(Equal(map[string]interface{}{
+					"cidr": "10.20.0.0/16",
+				}))
+				Expect(network.SensitiveOutputs).To(Equal([]string{"admin_password"}))
+				for _, output := range analysis.Outputs {
+					Expect(output.Value).NotTo(ContainSubstring("s3cr3t"))
+				}
+			})
+
+			It("deve disponibilizar os outputs do módulo para o chamador", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				sg := analysis.Resources[1]
+				Expect(sg.Attributes).To(HaveKeyWithValue("description", "CIDR 10.20.0.0/16"))
+				Expect(sg.UnknownAttributes).To(ContainElement("vpc_id"))
+				Expect(sg.Dependencies).To(ContainElement("module.network"))
+
+				Expect(analysis.ResourceGraph.Nodes).To(ContainElement(
+					HaveField("ID", "module.network.aws_vpc.this")))
+				Expect(analysis.Outputs).To(ContainElement(models.TerraformOutput{
+					Name: "vpc_cidr", Value: "10.20.0.0/16", File: filepath.Join(tempDir, "main.tf"),
+				}))
+			})
+
+			It("não deve analisar o diretório do módulo como um módulo raiz", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				for _, r := range analysis.Resources {
+					Expect(r.Address).NotTo(Equal("aws_vpc.this"))
+				}
+				Expect(analysis.Variables).To(HaveLen(3))
+				Expect(analysis.Variables[0].Module).To(Equal("module.network"))
+				Expect(analysis.BestPracticeWarnings).To(ContainElement(
+					"Variável module.network.var.enable_dns não possui descrição"))
+			})
+		})
+
+		Context("quando o conteúdo é analisado sem diretório", func() {
+			It("não deve seguir sources locais", func() {
+				content := `
+module "network" {
+  source = "./modules/network"
+  cidr   = "10.0.0.0/16"
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources).To(BeEmpty())
+				Expect(analysis.Modules[0].Inputs).To(HaveKeyWithValue("cidr", "10.0.0.0/16"))
+			})
+		})
+	})
+
+	Describe("Expandindo count, for_each e blocos dynamic", func() {
+		Context("quando count e for_each são conhecidos", func() {
+			It("deve criar uma instância endereçável para cada elemento", func() {
+				content := `
+variable "instances" {
+  default = 2
+}
+
+variable "buckets" {
+  default = {
+    logs   = "private"
+    assets = "public-read"
+  }
+}
+
+resource "aws_instance" "web" {
+  count         = var.instances
+  instance_type = "t3.micro"
+  tags          = { Name = "web-${count.index}" }
+}
+
+resource "aws_s3_bucket" "b" {
+  for_each = var.buckets
+  bucket   = "acme-${each.key}"
+  acl      = each.value
+}
+
+resource "aws_iam_user" "u" {
+  for_each = toset(["alice", "bob"])
+  name     = each.value
+}
+
+resource "aws_eip" "disabled" {
+  count = 0
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.TotalResources).To(Equal(6))
+
+				addresses := []string{}
+				for _, r := range analysis.Resources {
+					addresses = append(addresses, r.Address)
+				}
+				Expect(addresses).To(Equal([]string{
+					"aws_instance.web[0]",
+					"aws_instance.web[1]",
+					`aws_s3_bucket.b["assets"]`,
+					`aws_s3_bucket.b["logs"]`,
+					`aws_iam_user.u["alice"]`,
+					`aws_iam_user.u["bob"]`,
+				}))
+
+				web1 := analysis.Resources[1]
+				Expect(web1.Index).To(Equal(1.0))
+				Expect(web1.Tags).To(HaveKeyWithValue("Name", "web-1"))
+				Expect(web1.Attributes).NotTo(HaveKey("count"))
+
+				assets := analysis.Resources[2]
+				Expect(assets.Index).To(Equal("assets"))
+				Expect(assets.Attributes).To(HaveKeyWithValue("bucket", "acme-assets"))
+				Expect(assets.Attributes).To(HaveKeyWithValue("acl", "public-read"))
+				Expect(analysis.Resources[4].Attributes).To(HaveKeyWithValue("name", "alice"))
+			})
+		})
+
+		Context("quando a cardinalidade depende de valores desconhecidos", func() {
+			It("deve manter uma instância marcada como de cardinalidade desconhecida", func() {
+				content := `
+resource "aws_instance" "web" {
+  count         = length(data.aws_availability_zones.all.names)
+  instance_type = "t3.micro"
+  subnet_id     = "subnet-${count.index}"
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources).To(HaveLen(1))
+				web := analysis.Resources[0]
+				Expect(web.Address).To(Equal("aws_instance.web"))
+				Expect(web.UnknownCardinality).To(BeTrue())
+				Expect(web.Attributes).To(HaveKeyWithValue("instance_type", "t3.micro"))
+				Expect(web.UnknownAttributes).To(ContainElement("subnet_id"))
+			})
+		})
+
+		Context("quando count é inválido ou grande demais", func() {
+			It("deve rejeitar count negativo com um diagnóstico", func() {
+				content := `
+resource "aws_instance" "web" {
+  count = -1
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeFalse())
+				Expect(analysis.Resources).To(BeEmpty())
+				Expect(analysis.SyntaxErrors).To(ContainElement(And(
+					HaveField("Message", "Valor inválido para count"),
+					HaveField("Line", 3),
+				)))
+			})
+
+			It("deve rejeitar count fracionário com um diagnóstico", func() {
+				content := `
+resource "aws_instance" "web" {
+  count = 1.5
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeFalse())
+				Expect(analysis.Resources).To(BeEmpty())
+				Expect(analysis.SyntaxErrors).To(ContainElement(And(
+					HaveField("Message", "Valor inválido para count"),
+					HaveField("Snippet", "count deve ser um número inteiro (1.5)"),
+				)))
+			})
+
+			It("deve rejeitar for_each que não é mapa nem set de strings", func() {
+				content := `
+resource "aws_s3_bucket" "list" {
+  for_each = ["logs", "data"]
+  bucket   = each.key
+}
+
+resource "aws_s3_bucket" "numbers" {
+  for_each = toset([1, 2])
+  bucket   = each.key
+}
+
+resource "aws_s3_bucket" "set" {
+  for_each = toset(["logs", "data"])
+  bucket   = each.key
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeFalse())
+				Expect(analysis.Resources).To(ConsistOf(
+					HaveField("Address", `aws_s3_bucket.set["data"]`),
+					HaveField("Address", `aws_s3_bucket.set["logs"]`),
+				))
+				Expect(analysis.SyntaxErrors).To(ContainElements(
+					And(HaveField("Message", "Valor inválido para for_each"), HaveField("Line", 3)),
+					And(HaveField("Message", "Valor inválido para for_each"), HaveField("Line", 8)),
+				))
+			})
+
+			It("deve tratar count acima do limite como cardinalidade desconhecida", func() {
+				content := `
+resource "aws_instance" "web" {
+  count = 1e9
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				Expect(analysis.Resources).To(HaveLen(1))
+				Expect(analysis.Resources[0].Address).To(Equal("aws_instance.web"))
+				Expect(analysis.Resources[0].UnknownCardinality).To(BeTrue())
+			})
+		})
+
+		Context("quando o recurso usa blocos dynamic", func() {
+			It("deve expandir o conteúdo com o iterador ligado", func() {
+				content := `
+variable "ports" {
+  default = [22, 443]
+}
+
+resource "aws_security_group" "web" {
+  name = "web"
+
+  ingress {
+    from_port   = 80
+    to_port     = 80
+    protocol    = "tcp"
+    cidr_blocks = ["10.0.0.0/8"]
+  }
+
+  dynamic "ingress" {
+    for_each = var.ports
+    iterator = port
+    content {
+      from_port   = port.value
+      to_port     = port.value
+      protocol    = "tcp"
+      cidr_blocks = ["0.0.0.0/0"]
+    }
+  }
+
+  dynamic "egress" {
+    for_each = data.aws_prefix_list.s3.ids
+    content {
+      prefix_list_ids = [egress.value]
+    }
+  }
+
+  tags = { Name = "web" }
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "sg.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				sg := analysis.Resources[0]
+				ingress := sg.Attributes["ingress"].([]interface{})
+				Expect(ingress).To(HaveLen(3))
+				Expect(ingress[1]).To(HaveKeyWithValue("from_port", 22.0))
+				Expect(ingress[2]).To(HaveKeyWithValue("to_port", 443.0))
+				Expect(sg.Attributes).NotTo(HaveKey("egress"))
+				Expect(sg.UnknownAttributes).To(ContainElement("egress"))
+
+				iamAnalysis, err := analyzer.NewIAMAnalyzer(logger.New("info", "json")).AnalyzeTerraform(analysis)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(iamAnalysis.PublicAccess).To(ContainElement(
+					ContainSubstring("aws_security_group.web")))
+			})
+		})
+
+		Context("quando um módulo local usa count", func() {
+			It("deve analisar uma instância do módulo para cada índice", func() {
+				mainTf := `
+module "bucket" {
+  count  = 2
+  source = "./modules/bucket"
+  name   = "data-${count.index}"
+}
+
+output "names" {
+  value = module.bucket[1].name
+}
+`
+				bucketTf := `
+variable "name" {
+  description = "Nome do bucket"
+}
+
+resource "aws_s3_bucket" "this" {
+  bucket = var.name
+  tags   = { Name = var.name }
+}
+
+output "name" {
+  value = var.name
+}
+`
+				moduleDir := filepath.Join(tempDir, "modules", "bucket")
+				Expect(os.MkdirAll(moduleDir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(moduleDir, "main.tf"), []byte(bucketTf), 0644)).To(Succeed())
+
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources).To(HaveLen(2))
+				Expect(analysis.Resources[0].Address).To(Equal("module.bucket[0].aws_s3_bucket.this"))
+				Expect(analysis.Resources[1].Address).To(Equal("module.bucket[1].aws_s3_bucket.this"))
+				Expect(analysis.Resources[1].Attributes).To(HaveKeyWithValue("bucket", "data-1"))
+				Expect(analysis.Modules[0].Inputs).NotTo(HaveKey("name"))
+
+				Expect(analysis.Outputs).To(ContainElement(And(
+					HaveField("Name", "names"),
+					HaveField("Value", "data-1"),
+				)))
+			})
+		})
+	})
+
+	Describe("Analisando sintaxe JSON e arquivos de override", func() {
+		Context("quando o conteúdo está em sintaxe JSON", func() {
+			It("deve interpretar recursos, variáveis e blocos aninhados", func() {
+				content := `{
+  "variable": {
+    "env": { "default": "prod", "description": "Ambiente" }
+  },
+  "resource": {
+    "aws_security_group": {
+      "web": {
+        "name": "web-${var.env}",
+        "ingress": [
+          { "from_port": 22, "to_port": 22, "protocol": "tcp", "cidr_blocks": ["0.0.0.0/0"] }
+        ],
+        "tags": { "Env": "${var.env}" },
+        "depends_on": ["aws_vpc.main"]
+      }
+    },
+    "aws_vpc": {
+      "main": { "cidr_block": "10.0.0.0/16" }
+    }
+  }
+}`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf.json")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				Expect(analysis.Resources).To(HaveLen(2))
+				Expect(analysis.Variables[0].Default).To(Equal("prod"))
+
+				sg := analysis.Resources[0]
+				Expect(sg.Attributes).To(HaveKeyWithValue("name", "web-prod"))
+				Expect(sg.Tags).To(HaveKeyWithValue("Env", "prod"))
+				Expect(sg.Attributes["ingress"]).To(ContainElement(
+					HaveKeyWithValue("cidr_blocks", []interface{}{"0.0.0.0/0"})))
+				Expect(sg.Dependencies).To(Equal([]string{"aws_vpc.main"}))
+			})
+		})
+
+		Context("quando o diretório contém arquivos .tf.json e overrides", func() {
+			BeforeEach(func() {
+				mainTf := `
+locals {
+  env  = "dev"
+  team = "platform"
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-123"
+  instance_type = "t3.micro"
+
+  ebs_block_device {
+    device_name = "/dev/sdb"
+    volume_size = 10
+  }
+
+  ebs_block_device {
+    device_name = "/dev/sdc"
+    volume_size = 20
+  }
+
+  lifecycle {
+    create_before_destroy = true
+    prevent_destroy       = false
+  }
+
+  tags = { Env = local.env, Team = local.team }
+}
+`
+				generatedTfJSON := `{
+  "resource": {
+    "aws_s3_bucket": {
+      "generated": { "bucket": "generated-bucket", "tags": { "Owner": "codegen" } }
+    }
+  }
+}`
+				overrideTf := `
+locals {
+  env = "prod"
+}
+
+resource "aws_instance" "web" {
+  instance_type = "m5.large"
+
+  ebs_block_device {
+    device_name = "/dev/sdd"
+    volume_size = 100
+  }
+
+  lifecycle {
+    prevent_destroy = true
+  }
+}
+`
+				bucketOverrideJSON := `{
+  "resource": {
+    "aws_s3_bucket": {
+      "generated": { "acl": "private" }
+    }
+  }
+}`
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "generated.tf.json"), []byte(generatedTfJSON), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "override.tf"), []byte(overrideTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, "bucket_override.tf.json"), []byte(bucketOverrideJSON), 0644)).To(Succeed())
+			})
+
+			It("deve aplicar a semântica de override do Terraform", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeTrue())
+				Expect(analysis.Resources).To(HaveLen(2))
+
+				web := analysis.Resources[1]
+				Expect(web.Address).To(Equal("aws_instance.web"))
+				Expect(web.Attributes).To(HaveKeyWithValue("ami", "ami-123"))
+				Expect(web.Attributes).To(HaveKeyWithValue("instance_type", "m5.large"))
+				Expect(web.Attributes["ebs_block_device"]).To(Equal([]interface{}{
+					map[string]interface{}{"device_name": "/dev/sdd", "volume_size": 100.0},
+				}))
+				Expect(web.Attributes["lifecycle"]).To(Equal([]interface{}{
+					map[string]interface{}{"create_before_destroy": true, "prevent_destroy": true},
+				}))
+				Expect(web.Tags).To(Equal(map[string]string{"Env": "prod", "Team": "platform"}))
+			})
+
+			It("deve combinar overrides JSON com arquivos JSON", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				bucket := analysis.Resources[0]
+				Expect(bucket.Address).To(Equal("aws_s3_bucket.generated"))
+				Expect(bucket.Attributes).To(HaveKeyWithValue("bucket", "generated-bucket"))
+				Expect(bucket.Attributes).To(HaveKeyWithValue("acl", "private"))
+				Expect(bucket.Tags).To(HaveKeyWithValue("Owner", "codegen"))
+			})
+
+			It("deve reportar override sem definição original", func() {
+				orphan := `
+resource "aws_instance" "missing" {
+  instance_type = "t3.micro"
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "orphan_override.tf"), []byte(orphan), 0644)).To(Succeed())
+
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Valid).To(BeFalse())
+				Expect(analysis.SyntaxErrors).To(ContainElement(
+					HaveField("Message", ContainSubstring("resource.aws_instance.missing"))))
+				Expect(analysis.Resources).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("Analisando required_providers, backend e lock file", func() {
+		checkIDs := func(findings []models.SecurityFinding) []string {
+			ids := []string{}
+			for _, f := range findings {
+				ids = append(ids, f.CheckID)
+			}
+			return ids
+		}
+
+		Context("quando o bloco terraform fixa versões e usa backend remoto", func() {
+			It("deve extrair as configurações sem findings", func() {
+				content := `
+terraform {
+  required_version = ">= 1.6.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.40"
+    }
+    random = "~> 3.6"
+  }
+
+  backend "s3" {
+    bucket  = "state"
+    key     = "prod/terraform.tfstate"
+    region  = "us-east-1"
+    encrypt = true
+  }
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  tags   = { Env = "prod" }
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Settings).NotTo(BeNil())
+				Expect(analysis.Settings.Engine).To(Equal("terraform"))
+				Expect(analysis.Settings.RequiredVersion).To(Equal(">= 1.6.0"))
+				Expect(analysis.Settings.RequiredProviders).To(ConsistOf(
+					And(HaveField("Name", "aws"), HaveField("Source", "registry.terraform.io/hashicorp/aws"), HaveField("Version", "~> 5.40")),
+					And(HaveField("Name", "random"), HaveField("Source", "registry.terraform.io/hashicorp/random"), HaveField("Version", "~> 3.6")),
+				))
+				Expect(analysis.Settings.Backend.Type).To(Equal("s3"))
+				Expect(analysis.Settings.Backend.Config).To(HaveKeyWithValue("encrypt", true))
+				Expect(analysis.Settings.Findings).To(BeEmpty())
+			})
+		})
+
+		Context("quando providers não estão fixados e o backend não é criptografado", func() {
+			It("deve gerar findings", func() {
+				content := `
+terraform {
+  backend "s3" {
+    bucket = "state"
+    key    = "terraform.tfstate"
+  }
+}
+
+provider "google" {}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "main.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkIDs(analysis.Settings.Findings)).To(ConsistOf(
+					"TF_VERSION_REQUIRED",
+					"TF_PROVIDER_UNPINNED",
+					"TF_PROVIDER_UNPINNED",
+					"TF_BACKEND_UNENCRYPTED",
+				))
+				Expect(analysis.Settings.Findings).To(ContainElement(And(
+					HaveField("CheckID", "TF_BACKEND_UNENCRYPTED"),
+					HaveField("Severity", "HIGH"),
+				)))
+			})
+		})
+
+		Context("quando o diretório tem lock file", func() {
+			BeforeEach(func() {
+				mainTf := `
+terraform {
+  required_version = ">= 1.6.0"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.40"
+    }
+    random = {
+      source  = "hashicorp/random"
+      version = "~> 3.6"
+    }
+  }
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+`
+				lockFile := `
+provider "registry.opentofu.org/hashicorp/aws" {
+  version     = "5.31.0"
+  constraints = "~> 5.40"
+  hashes = [
+    "h1:abc=",
+  ]
+}
+`
+				Expect(os.WriteFile(filepath.Join(tempDir, "main.tf"), []byte(mainTf), 0644)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(tempDir, ".terraform.lock.hcl"), []byte(lockFile), 0644)).To(Succeed())
+			})
+
+			It("deve comparar o lock file com required_providers", func() {
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Settings.Engine).To(Equal("opentofu"))
+				Expect(analysis.Settings.LockFile).To(HaveSuffix(".terraform.lock.hcl"))
+				Expect(analysis.Settings.LockedProviders).To(ConsistOf(models.LockedProvider{
+					Source:      "registry.opentofu.org/hashicorp/aws",
+					Version:     "5.31.0",
+					Constraints: "~> 5.40",
+				}))
+				Expect(checkIDs(analysis.Settings.Findings)).To(ConsistOf(
+					"TF_LOCK_CONSTRAINT_MISMATCH",
+					"TF_LOCK_ENTRY_MISSING",
+					"TF_BACKEND_LOCAL",
+				))
+			})
+
+			It("deve reportar lock file ausente", func() {
+				Expect(os.Remove(filepath.Join(tempDir, ".terraform.lock.hcl"))).To(Succeed())
+
+				analysis, err := tfAnalyzer.AnalyzeDirectory(tempDir)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Settings.Engine).To(Equal("terraform"))
+				Expect(checkIDs(analysis.Settings.Findings)).To(ContainElement("TF_LOCK_MISSING"))
+			})
+		})
+
+		Context("quando as versões são comparadas com a Knowledge Base", func() {
+			It("deve reportar versões abaixo das suportadas", func() {
+				kb := cloudcontroller.NewKnowledgeBase(logger.New("info", "json"))
+				settings := &models.TerraformSettings{
+					Engine:          "terraform",
+					RequiredVersion: ">= 1.3.0, < 2.0.0",
+					RequiredProviders: []models.ProviderRequirement{
+						{Name: "aws", Version: "~> 4.67"},
+						{Name: "azurerm", Version: "~> 3.90"},
+						{Name: "google", Version: ">= 3.0"},
+						// azuread versiona independente do azurerm
+						{Name: "azuread", Version: "~> 2.47"},
+					},
+					LockedProviders: []models.LockedProvider{
+						{Source: "registry.terraform.io/hashicorp/azurerm", Version: "2.99.0"},
+					},
+				}
+
+				findings := kb.CheckSupportedVersions(settings)
+
+				Expect(findings).To(ConsistOf(
+					And(HaveField("CheckID", "TF_VERSION_UNSUPPORTED"), HaveField("Resource", "terraform")),
+					And(HaveField("CheckID", "TF_PROVIDER_VERSION_UNSUPPORTED"), HaveField("Resource", "provider.aws")),
+					And(HaveField("CheckID", "TF_PROVIDER_VERSION_UNSUPPORTED"), HaveField("Resource", "provider.google")),
+					And(HaveField("CheckID", "TF_PROVIDER_VERSION_UNSUPPORTED"), HaveField("Resource", "provider.azurerm")),
+				))
+			})
+
+			It("deve usar as versões do OpenTofu quando o engine é opentofu", func() {
+				kb := cloudcontroller.NewKnowledgeBase(logger.New("info", "json"))
+
+				Expect(kb.CheckSupportedVersions(&models.TerraformSettings{
+					Engine:          "opentofu",
+					RequiredVersion: ">= 1.5.0",
+				})).To(HaveLen(1))
+				Expect(kb.CheckSupportedVersions(&models.TerraformSettings{
+					Engine:          "terraform",
+					RequiredVersion: ">= 1.5.0",
+				})).To(BeEmpty())
 			})
 		})
 	})
@@ -357,4 +1928,165 @@ resource "random_string" "suffix" {
 			})
 		})
 	})
+
+	Describe("Analisando data sources", func() {
+		Context("quando há data sources aws_iam_policy_document", func() {
+			It("deve registrar os data sources e resolver o json em locals", func() {
+				content := `
+variable "bucket" {
+  default = "assets"
+}
+
+data "aws_caller_identity" "current" {}
+
+data "aws_iam_policy_document" "read" {
+  statement {
+    actions   = ["s3:GetObject"]
+    resources = ["arn:aws:s3:::${var.bucket}/*"]
+  }
+}
+
+data "aws_iam_policy_document" "per_env" {
+  count = 2
+  statement {
+    actions = ["s3:ListBucket"]
+  }
+}
+
+locals {
+  read_policy = data.aws_iam_policy_document.read.json
+}
+
+resource "aws_iam_policy" "read" {
+  policy = local.read_policy
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "iam.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.TotalDataSources).To(Equal(3))
+				Expect(analysis.DataSources).To(HaveLen(4))
+
+				addresses := []string{}
+				for _, ds := range analysis.DataSources {
+					addresses = append(addresses, ds.Address)
+				}
+				Expect(addresses).To(ConsistOf(
+					"data.aws_caller_identity.current",
+					"data.aws_iam_policy_document.read",
+					"data.aws_iam_policy_document.per_env[0]",
+					"data.aws_iam_policy_document.per_env[1]",
+				))
+
+				Expect(analysis.Resources).To(HaveLen(1))
+				Expect(analysis.Resources[0].Attributes["policy"]).To(Equal(
+					`{"Statement":[{"Action":"s3:GetObject","Effect":"Allow","Resource":"arn:aws:s3:::assets/*"}],"Version":"2012-10-17"}`))
+			})
+
+			It("deve gerar o documento de arquivos .tf.json", func() {
+				content := `{
+  "data": {
+    "aws_iam_policy_document": {
+      "admin": {
+        "statement": [
+          {"actions": ["*"], "resources": ["*"]},
+          {
+            "effect": "Deny",
+            "actions": ["s3:DeleteBucket"],
+            "resources": ["*"],
+            "principals": {"type": "AWS", "identifiers": ["arn:aws:iam::111122223333:root"]}
+          }
+        ]
+      }
+    }
+  },
+  "resource": {
+    "aws_iam_policy": {
+      "admin": {"policy": "${data.aws_iam_policy_document.admin.json}"}
+    }
+  }
+}`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "iam.tf.json")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources).To(HaveLen(1))
+				Expect(analysis.Resources[0].Attributes["policy"]).To(Equal(
+					`{"Statement":[{"Action":"*","Effect":"Allow","Resource":"*"},` +
+						`{"Action":"s3:DeleteBucket","Effect":"Deny","Principal":{"AWS":"arn:aws:iam::111122223333:root"},"Resource":"*"}],` +
+						`"Version":"2012-10-17"}`))
+			})
+
+			It("deve expandir statements e principals dynamic", func() {
+				content := `
+variable "grants" {
+  default = {
+    read  = ["s3:GetObject"]
+    admin = ["*"]
+  }
+}
+
+data "aws_iam_policy_document" "grants" {
+  dynamic "statement" {
+    for_each = var.grants
+    content {
+      sid       = statement.key
+      actions   = statement.value
+      resources = ["*"]
+
+      dynamic "principals" {
+        for_each = ["arn:aws:iam::111122223333:root"]
+        iterator = p
+        content {
+          type        = "AWS"
+          identifiers = [p.value]
+        }
+      }
+    }
+  }
+}
+
+resource "aws_iam_policy" "grants" {
+  policy = data.aws_iam_policy_document.grants.json
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "iam.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].Attributes["policy"]).To(Equal(
+					`{"Statement":[` +
+						`{"Action":"*","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111122223333:root"},"Resource":"*","Sid":"admin"},` +
+						`{"Action":"s3:GetObject","Effect":"Allow","Principal":{"AWS":"arn:aws:iam::111122223333:root"},"Resource":"*","Sid":"read"}],` +
+						`"Version":"2012-10-17"}`))
+			})
+
+			It("deve tratar o documento como desconhecido quando um statement não pode ser expandido", func() {
+				content := `
+variable "grants" {
+  type = list(string)
+}
+
+data "aws_iam_policy_document" "grants" {
+  dynamic "statement" {
+    for_each = var.grants
+    content {
+      actions   = [statement.value]
+      resources = ["*"]
+    }
+  }
+}
+
+resource "aws_iam_policy" "grants" {
+  policy = data.aws_iam_policy_document.grants.json
+}
+`
+				analysis, err := tfAnalyzer.AnalyzeContent(content, "iam.tf")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(analysis.Resources[0].Attributes).NotTo(HaveKey("policy"))
+				Expect(analysis.Resources[0].UnknownAttributes).To(ContainElement("policy"))
+				Expect(analysis.DataSources[0].Attributes).NotTo(HaveKey("json"))
+				Expect(analysis.DataSources[0].UnknownAttributes).To(ContainElement("json"))
+			})
+		})
+	})
 })
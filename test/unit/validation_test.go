@@ -242,4 +242,44 @@ var _ = ginkgo.Describe("Validação de Resultados Pré-existentes", func() {
 			})
 		})
 	})
+
+	ginkgo.Describe("ValidateScannerResults do AnalysisService", func() {
+		tfAnalysis := func() *models.TerraformAnalysis {
+			return &models.TerraformAnalysis{
+				TotalResources: 1,
+				Resources:      []models.TerraformResource{{Type: "aws_s3_bucket", Name: "logs"}},
+			}
+		}
+
+		ginkgo.Context("quando fornecidos resultados de vários scanners", func() {
+			ginkgo.It("deve combinar os findings sem duplicar os equivalentes", func() {
+				reports := []models.ScannerReport{
+					{Tool: "tfsec", Output: json.RawMessage(`{"results": [{"rule_id": "AVD-AWS-0088", "severity": "HIGH",
+						"resource": "aws_s3_bucket.logs", "location": {"filename": "main.tf", "start_line": 1}}]}`)},
+					{Output: json.RawMessage(`{"Results": [{"Target": "main.tf", "Misconfigurations": [
+						{"AVDID": "AVD-AWS-0088", "Severity": "HIGH", "Status": "FAIL", "CauseMetadata": {"Resource": "aws_s3_bucket.logs"}},
+						{"AVDID": "AVD-AWS-0090", "Severity": "MEDIUM", "Status": "FAIL", "CauseMetadata": {"Resource": "aws_s3_bucket.logs"}}]}]}`)},
+				}
+
+				response, err := analysisService.ValidateScannerResults(reports, tfAnalysis())
+
+				gomega.Expect(err).ToNot(gomega.HaveOccurred())
+				gomega.Expect(response.Analysis.Security.TotalIssues).To(gomega.Equal(2))
+				gomega.Expect(response.Analysis.Security.High).To(gomega.Equal(1))
+				gomega.Expect(response.Analysis.Security.Findings[0].Sources).To(gomega.Equal([]string{"tfsec", "trivy"}))
+				gomega.Expect(response.Metadata["validation_mode"]).To(gomega.Equal("scanner_reports"))
+			})
+		})
+
+		ginkgo.Context("quando um resultado é inválido", func() {
+			ginkgo.It("deve retornar erro indicando o scanner", func() {
+				reports := []models.ScannerReport{{Tool: "kics", Output: json.RawMessage(`{"queries": {}}`)}}
+
+				_, err := analysisService.ValidateScannerResults(reports, tfAnalysis())
+
+				gomega.Expect(err).To(gomega.HaveOccurred())
+				gomega.Expect(err.Error()).To(gomega.ContainSubstring("kics"))
+			})
+		})
+	})
 })